@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+)
+
+var cacheCommand = &command{
+	name:        "cache",
+	summary:     "Manage the commit stats cached between runs",
+	description: "Manage the commit stats cached between runs.",
+	examples: []string{
+		"git-wrapped cache clear",
+	},
+	subcommands: []*command{
+		{
+			name:        "clear",
+			summary:     "Remove the cached commit stats of repositories",
+			description: "Remove the commit stats cached for the repositories, so the next run computes them again.",
+			args:        "[path...]",
+			examples: []string{
+				"git-wrapped cache clear",
+				"git-wrapped cache clear --cache-dir /tmp/git-wrapped ~/work/api ~/work/web",
+			},
+			setup: setupCacheClear,
+		},
+	},
+}
+
+func setupCacheClear(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	var paths stringsFlag
+	fs.Var(&paths, "path", "The path to a repository whose cache is cleared, can be repeated. Default=the current directory")
+	flags := &analysisFlags{cacheDir: fs.String("cache-dir", "", "The directory used to cache commit stats between runs. Default=<user cache dir>/git-wrapped")}
+
+	return func(ctx context.Context, args []string) error {
+		return clearCaches(repoPaths(paths, args), flags)
+	}
+}
+
+// clearCaches removes the cached stats of every repository.
+func clearCaches(paths []string, flags *analysisFlags) error {
+	cacheDir, err := flags.resolveCacheDir()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		dir, err := wrapped.ClearStatsCache(cacheDir, path)
+		if err != nil {
+			return fmt.Errorf("unable to clear the cache of %s: %w", path, err)
+		}
+		fmt.Printf("Cleared the cache at %s\n", dir)
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// copyToClipboard copies the text to the clipboard with pbcopy on macOS, and
+// elsewhere with wl-copy on Wayland or xclip or xsel on X11.
+func copyToClipboard(text string) error {
+	command := clipboardCommand()
+	if command == nil {
+		return errors.New("no clipboard, install wl-copy, xclip or xsel and run it from a graphical session")
+	}
+
+	command.Stdin = strings.NewReader(text)
+	output, err := command.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %s %s", command.Args[0], strings.TrimSpace(string(output)), err.Error())
+	}
+
+	return nil
+}
+
+// clipboardCommand returns the command writing its stdin to the clipboard,
+// nil when the system has none or there's no display to own the clipboard.
+func clipboardCommand() *exec.Cmd {
+	candidates := [][]string{{"pbcopy"}}
+	if runtime.GOOS != "darwin" {
+		candidates = nil
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			candidates = append(candidates, []string{"wl-copy"})
+		}
+		if os.Getenv("DISPLAY") != "" {
+			candidates = append(candidates, []string{"xclip", "-selection", "clipboard"}, []string{"xsel", "--clipboard", "--input"})
+		}
+	}
+
+	for _, candidate := range candidates {
+		if _, err := exec.LookPath(candidate[0]); err == nil {
+			return exec.Command(candidate[0], candidate[1:]...)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// The clipboard API of user32 and the global memory its data lives in.
+var (
+	user32           = syscall.NewLazyDLL("user32.dll")
+	openClipboard    = user32.NewProc("OpenClipboard")
+	closeClipboard   = user32.NewProc("CloseClipboard")
+	emptyClipboard   = user32.NewProc("EmptyClipboard")
+	setClipboardData = user32.NewProc("SetClipboardData")
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	globalAlloc      = kernel32.NewProc("GlobalAlloc")
+	globalFree       = kernel32.NewProc("GlobalFree")
+	globalLock       = kernel32.NewProc("GlobalLock")
+	globalUnlock     = kernel32.NewProc("GlobalUnlock")
+	moveMemory       = kernel32.NewProc("RtlMoveMemory")
+)
+
+const (
+	cfUnicodeText = 13
+	gmemMoveable  = 0x0002
+)
+
+// copyToClipboard copies the text to the clipboard of Windows as UTF-16, the
+// way it keeps Unicode text.
+func copyToClipboard(text string) error {
+	data, err := syscall.UTF16FromString(text)
+	if err != nil {
+		return err
+	}
+
+	// The clipboard is opened by the thread calling OpenClipboard.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if opened, _, err := openClipboard.Call(0); opened == 0 {
+		return fmt.Errorf("unable to open the clipboard. [err=%s]", err.Error())
+	}
+	defer closeClipboard.Call()
+	if emptied, _, err := emptyClipboard.Call(); emptied == 0 {
+		return fmt.Errorf("unable to empty the clipboard. [err=%s]", err.Error())
+	}
+
+	size := uintptr(len(data) * 2)
+	memory, _, err := globalAlloc.Call(gmemMoveable, size)
+	if memory == 0 {
+		return fmt.Errorf("unable to allocate the clipboard data. [err=%s]", err.Error())
+	}
+	locked, _, err := globalLock.Call(memory)
+	if locked == 0 {
+		globalFree.Call(memory)
+		return fmt.Errorf("unable to lock the clipboard data. [err=%s]", err.Error())
+	}
+	moveMemory.Call(locked, uintptr(unsafe.Pointer(&data[0])), size)
+	globalUnlock.Call(memory)
+
+	// The clipboard owns the memory once it's set, it's only freed otherwise.
+	if set, _, err := setClipboardData.Call(cfUnicodeText, memory); set == 0 {
+		globalFree.Call(memory)
+		return fmt.Errorf("unable to set the clipboard data. [err=%s]", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// syncClones clones every repository into dir, or fetches the clone kept
+// from an earlier run, with at most jobs at once. The paths of the clones are
+// returned in the order of repos. Repositories that can't be cloned, like the
+// ones the token lacks access to, are skipped with a warning.
+func syncClones(ctx context.Context, dir string, repos []githubRepo, token string, jobs int, logger wrapped.Logger) []string {
+	paths := make([]string, len(repos))
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+	for i := 0; i < jobs && i < len(repos); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				repo := repos[index]
+				clone, err := syncClone(ctx, dir, repo, token, logger)
+				if err != nil {
+					if ctx.Err() == nil {
+						fmt.Fprintf(os.Stderr, "Warning: skipping %s. [err=%s]\n", repo.Name, err.Error())
+					}
+					continue
+				}
+				paths[index] = clone
+			}
+		}()
+	}
+	for index := range repos {
+		if ctx.Err() != nil {
+			break
+		}
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	synced := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if path != "" {
+			synced = append(synced, path)
+		}
+	}
+
+	return synced
+}
+
+// syncClone fetches the bare clone of the repository, cloning it first when
+// there's none yet, and returns its path, empty for an empty repository.
+// Clones keep the whole history, go-git can't cut it off at a date and a fixed
+// depth could cut the window short, but only the first run pays for it. A
+// failed clone is removed rather than left half written.
+func syncClone(ctx context.Context, dir string, repo githubRepo, token string, logger wrapped.Logger) (string, error) {
+	path := filepath.Join(dir, repo.Name+".git")
+	var auth transport.AuthMethod
+	if token != "" {
+		auth = &githttp.BasicAuth{Username: "x-access-token", Password: token}
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		clone, err := git.PlainOpen(path)
+		if err != nil {
+			return "", fmt.Errorf("unable to open the clone at %s. [err=%s]", path, err.Error())
+		}
+		err = clone.FetchContext(ctx, &git.FetchOptions{RemoteName: git.DefaultRemoteName, Auth: auth, Tags: git.AllTags, Force: true})
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return "", fmt.Errorf("unable to fetch %s. [err=%s]", repo.CloneURL, err.Error())
+		}
+		logger.Logf(wrapped.LevelVerbose, "Fetched %s into %s", repo.Name, path)
+		return path, nil
+	}
+
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return "", fmt.Errorf("unable to create the clone directory %s. [err=%s]", dir, err.Error())
+	}
+	temp, err := os.MkdirTemp(dir, ".tmp-"+repo.Name+"-*")
+	if err != nil {
+		return "", fmt.Errorf("unable to create the clone directory %s. [err=%s]", dir, err.Error())
+	}
+	_, err = git.PlainCloneContext(ctx, temp, true, &git.CloneOptions{URL: repo.CloneURL, Auth: auth, Tags: git.AllTags})
+	if err == nil {
+		err = os.Rename(temp, path)
+	}
+	if err != nil {
+		os.RemoveAll(temp)
+		// An empty repository has nothing to analyze, but isn't worth a warning.
+		if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+			logger.Logf(wrapped.LevelVerbose, "Skipping the empty %s", repo.Name)
+			return "", nil
+		}
+		return "", fmt.Errorf("unable to clone %s. [err=%s]", repo.CloneURL, err.Error())
+	}
+	logger.Logf(wrapped.LevelVerbose, "Cloned %s into %s", repo.Name, path)
+
+	return path, nil
+}
@@ -0,0 +1,313 @@
+// Package cmd wires the git-wrapped subcommands, their flags and exit codes
+// to the analysis in the wrapped package.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// Exit codes, so scripts can tell why a run failed.
+const (
+	exitOK = 0
+	// exitUsage is used for missing or invalid flags.
+	exitUsage = 1
+	// exitRepoOpen is used when the repository can't be opened.
+	exitRepoOpen = 2
+	// exitNoCommits is used when no commits matched the year and emails, or
+	// the repository has none yet.
+	exitNoCommits = 3
+	// exitAnalysis is used when the analysis itself fails.
+	exitAnalysis = 4
+	// exitDelivery is used when the report couldn't be posted to --post-url.
+	exitDelivery = 5
+	// exitCompliance is used when the commits miss a requirement like
+	// --require-signoff.
+	exitCompliance = 6
+	// exitTimeout is used when --timeout cancels the run, matching timeout(1).
+	exitTimeout = 124
+	// exitInterrupted is used when the run is cancelled by SIGINT or SIGTERM.
+	exitInterrupted = 130
+)
+
+// command is a single git-wrapped subcommand. Commands either run themselves
+// or, like cache, only group further subcommands.
+type command struct {
+	name        string
+	summary     string
+	description string
+	// args describes the positional arguments in the usage.
+	args     string
+	examples []string
+	// setup registers the command's flags and returns the function running
+	// the command once they're parsed.
+	setup       func(fs *flag.FlagSet) func(ctx context.Context, args []string) error
+	subcommands []*command
+}
+
+// commands are the top level subcommands. The first one runs when no
+// subcommand is given.
+var commands []*command
+
+func init() {
+	// Set in init since the completion command walks the commands and would
+	// otherwise make their initialization depend on itself.
+	commands = []*command{
+		generateCommand,
+		leaderboardCommand,
+		compareCommand,
+		orgCommand,
+		cacheCommand,
+		sendEmailCommand,
+		serveCommand,
+		versionCommand,
+		completionCommand,
+	}
+}
+
+var rootExamples = []string{
+	"git-wrapped --emails me@example.com",
+	"git-wrapped generate --emails me@example.com --year 2022 --format json ~/src/project",
+	"git-wrapped leaderboard",
+	"git-wrapped compare --emails me@example.com --year 2023 --against 2022",
+	"git-wrapped org --github-org mycompany --emails me@mycompany.com",
+	"git-wrapped cache clear",
+	"git-wrapped serve --repos /srv/git",
+}
+
+// usageError is returned for invalid flags, it's reported together with the
+// command's usage.
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+// Execute runs the subcommand named by the first argument, or generate when
+// the arguments start with a flag or a path, and returns the exit code for
+// the run.
+func Execute(args []string) int {
+	if len(args) > 0 && isHelp(args[0]) {
+		printRootUsage()
+		return exitOK
+	}
+	if len(args) > 0 && isVersion(args[0]) {
+		fmt.Println(buildInfo())
+		return exitOK
+	}
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return run(commands[0], "git-wrapped", args)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return run(cmd, "git-wrapped "+cmd.name, args[1:])
+		}
+	}
+	// A path to a repository runs generate, like a path after the flags.
+	if _, err := os.Stat(args[0]); err == nil {
+		return run(commands[0], "git-wrapped", args)
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n", args[0])
+	printRootUsage()
+	return exitUsage
+}
+
+func isHelp(arg string) bool {
+	return arg == "help" || arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// run parses the flags of the command and runs it, or dispatches to one of
+// its subcommands.
+func run(cmd *command, path string, args []string) int {
+	if len(cmd.subcommands) > 0 {
+		if len(args) == 0 || isHelp(args[0]) {
+			printGroupUsage(cmd, path)
+			if len(args) == 0 {
+				return exitUsage
+			}
+			return exitOK
+		}
+		for _, sub := range cmd.subcommands {
+			if sub.name == args[0] {
+				return run(sub, path+" "+sub.name, args[1:])
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n", path+" "+args[0])
+		printGroupUsage(cmd, path)
+		return exitUsage
+	}
+
+	fs, runCommand, noEnv := newFlagSet(cmd, path)
+	err := fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+	if !*noEnv {
+		err = applyEnv(fs, os.LookupEnv)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			fs.Usage()
+			return exitUsage
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = runCommand(ctx, fs.Args())
+	usageErr := &usageError{}
+	if errors.As(err, &usageErr) {
+		fmt.Fprintln(os.Stderr, usageErr.message)
+		fs.Usage()
+		return exitUsage
+	}
+
+	return exitCode(err)
+}
+
+// newFlagSet registers the flags of the command, including the ones every
+// command has, and returns the function running it once they're parsed.
+func newFlagSet(cmd *command, path string) (*flag.FlagSet, func(ctx context.Context, args []string) error, *bool) {
+	fs := flag.NewFlagSet(path, flag.ContinueOnError)
+	fs.Usage = func() {
+		printCommandUsage(fs, cmd, path)
+	}
+	runCommand := cmd.setup(fs)
+	noEnv := fs.Bool("no-env", false, "Ignore the "+envPrefix+"* environment variables")
+
+	return fs, runCommand, noEnv
+}
+
+func printRootUsage() {
+	out := os.Stderr
+	fmt.Fprintln(out, "git-wrapped generates a yearly wrap-up of an author's git activity.")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Usage:")
+	fmt.Fprintln(out, "  git-wrapped [command] [flags]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Commands:")
+	for _, cmd := range commands {
+		printCommandList(cmd, "")
+	}
+	printExamples(rootExamples)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Without a command generate runs. Run \"git-wrapped <command> --help\" for the flags of a command.")
+	fmt.Fprintln(out, "Flags can also be set with "+envPrefix+"* environment variables, pass --no-env to ignore them.")
+}
+
+func printCommandList(cmd *command, parent string) {
+	if len(cmd.subcommands) > 0 {
+		for _, sub := range cmd.subcommands {
+			printCommandList(sub, parent+cmd.name+" ")
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "  %-13s %s\n", parent+cmd.name, cmd.summary)
+}
+
+func printGroupUsage(cmd *command, path string) {
+	out := os.Stderr
+	fmt.Fprintln(out, cmd.description)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Usage:")
+	fmt.Fprintf(out, "  %s <command> [flags]\n", path)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Commands:")
+	for _, sub := range cmd.subcommands {
+		fmt.Fprintf(out, "  %-13s %s\n", sub.name, sub.summary)
+	}
+	printExamples(cmd.examples)
+}
+
+func printCommandUsage(fs *flag.FlagSet, cmd *command, path string) {
+	out := os.Stderr
+	fmt.Fprintln(out, cmd.description)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Usage:")
+	fmt.Fprintf(out, "  %s [flags] %s\n", path, cmd.args)
+	printExamples(cmd.examples)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Flags:")
+	fs.SetOutput(out)
+	fs.PrintDefaults()
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Environment:")
+	fmt.Fprintln(out, "  Every flag can be set with an environment variable, flags take precedence:")
+	fs.VisitAll(func(f *flag.Flag) {
+		if hasEnv(f) {
+			fmt.Fprintf(out, "  %s=%s\n", envName(f.Name), envPlaceholder(f))
+		}
+	})
+}
+
+func printExamples(examples []string) {
+	if len(examples) == 0 {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Examples:")
+	for _, example := range examples {
+		fmt.Fprintf(os.Stderr, "  %s\n", example)
+	}
+}
+
+// exitCode reports a failed run on stderr and returns the exit code for it.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	interrupted := &wrapped.InterruptedError{}
+	if errors.As(err, &interrupted) {
+		fmt.Fprintf(os.Stderr, "Interrupted after %s/%s commits\n", wrapped.FormatCount(interrupted.Processed), wrapped.FormatCount(interrupted.Found))
+		if errors.Is(interrupted.Cause, context.DeadlineExceeded) {
+			return exitTimeout
+		}
+		return exitInterrupted
+	}
+
+	emptyErr := &wrapped.EmptyRepoError{}
+	if errors.As(err, &emptyErr) {
+		fmt.Fprintf(os.Stderr, "Nothing to wrap up: %s\n", emptyErr.Error())
+		return exitNoCommits
+	}
+
+	fmt.Fprintf(os.Stderr, "Error generating your wrapped. [err=%s]\n", err.Error())
+
+	openErr := &wrapped.RepoOpenError{}
+	noCommitsErr := &noCommitsError{}
+	deliveryErr := &deliveryError{}
+	complianceErr := &complianceError{}
+	switch {
+	case errors.As(err, &openErr):
+		return exitRepoOpen
+	case errors.As(err, &noCommitsErr):
+		fmt.Fprint(os.Stderr, noCommitsErr.suggestions)
+		return exitNoCommits
+	case errors.As(err, &deliveryErr):
+		return exitDelivery
+	case errors.As(err, &complianceErr):
+		return exitCompliance
+	default:
+		return exitAnalysis
+	}
+}
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// execEnv runs Execute in the test binary when set, so the tests can run the
+// commands as a process of their own, with their own streams and exit code.
+const execEnv = "GIT_WRAPPED_TEST_EXEC"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(execEnv) != "" {
+		os.Exit(Execute(os.Args[1:]))
+	}
+
+	os.Exit(m.Run())
+}
+
+// newTestRepo returns a repository with a commit by dev@example.com at every
+// time.
+func newTestRepo(t *testing.T, times ...time.Time) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, when := range times {
+		if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte(fmt.Sprintf("line %d\n", i)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worktree.Add("file.txt"); err != nil {
+			t.Fatal(err)
+		}
+		signature := &object.Signature{Name: "Dev", Email: "dev@example.com", When: when}
+		if _, err := worktree.Commit(fmt.Sprintf("change %d", i), &git.CommitOptions{Author: signature, Committer: signature}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+// execResult is the outcome of running git-wrapped as a process.
+type execResult struct {
+	code   int
+	stdout string
+	stderr string
+}
+
+// execute runs git-wrapped with the arguments, ignoring the GIT_WRAPPED_*
+// environment of the test.
+func execute(t *testing.T, args ...string) execResult {
+	t.Helper()
+
+	command := exec.Command(os.Args[0], args...)
+	command.Env = append(os.Environ(), execEnv+"=1", "HOME="+t.TempDir(), "XDG_CONFIG_HOME="+t.TempDir())
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	command.Stdout, command.Stderr = stdout, stderr
+	err := command.Run()
+	exitErr := &exec.ExitError{}
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatal(err)
+	}
+
+	return execResult{code: command.ProcessState.ExitCode(), stdout: stdout.String(), stderr: stderr.String()}
+}
+
+func TestExecuteExitCodes(t *testing.T) {
+	repo := newTestRepo(t, time.Date(2023, time.March, 14, 10, 0, 0, 0, time.UTC), time.Date(2023, time.March, 15, 10, 0, 0, 0, time.UTC))
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "rejected", http.StatusBadRequest)
+	}))
+	defer rejecting.Close()
+
+	common := []string{"--no-env", "--quiet", "--no-cache", "--tz", "UTC", "--year", "2023", "--path", repo}
+	tests := []struct {
+		name string
+		args []string
+		code int
+		// stdout is expected on stdout, which stays empty when it is.
+		stdout string
+		stderr string
+	}{
+		{name: "report", args: []string{"--emails", "dev@example.com"}, code: exitOK, stdout: "Total commit count: 2"},
+		{name: "usage", args: []string{"--emails", "dev@example.com", "--format", "bogus"}, code: exitUsage, stderr: `Unknown --format "bogus"`},
+		{name: "no commits", args: []string{"--emails", "nobody@example.com"}, code: exitNoCommits, stderr: "nobody@example.com"},
+		{name: "analysis", args: []string{"--emails", "dev@example.com", "--branch", "missing"}, code: exitAnalysis, stderr: "Error generating your wrapped"},
+		{name: "delivery", args: []string{"--emails", "dev@example.com", "--format", "json", "--post-url", rejecting.URL}, code: exitDelivery, stdout: `"total_commits"`, stderr: "400"},
+		{name: "timeout", args: []string{"--emails", "dev@example.com", "--timeout", "1ns"}, code: exitTimeout, stderr: "Interrupted after"},
+		{name: "compliance", args: []string{"--emails", "dev@example.com", "--require-signoff", "100"}, code: exitCompliance, stdout: "Total commit count: 2", stderr: "signed off"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := execute(t, append(append([]string{}, common...), tt.args...)...)
+			if result.code != tt.code {
+				t.Fatalf("exited with %d, want %d\nstdout:\n%s\nstderr:\n%s", result.code, tt.code, result.stdout, result.stderr)
+			}
+			if tt.stdout == "" && result.stdout != "" {
+				t.Errorf("got stdout:\n%s\nwant it empty", result.stdout)
+			}
+			if !strings.Contains(result.stdout, tt.stdout) {
+				t.Errorf("got stdout:\n%s\nwant it to contain %q", result.stdout, tt.stdout)
+			}
+			if tt.stderr == "" && result.stderr != "" {
+				t.Errorf("got stderr:\n%s\nwant it empty", result.stderr)
+			}
+			if !strings.Contains(result.stderr, tt.stderr) {
+				t.Errorf("got stderr:\n%s\nwant it to contain %q", result.stderr, tt.stderr)
+			}
+		})
+	}
+
+	t.Run("empty repository", func(t *testing.T) {
+		result := execute(t, "--no-env", "--quiet", "--no-cache", "--emails", "dev@example.com", "--path", newTestRepo(t))
+		if result.code != exitNoCommits || result.stdout != "" || !strings.Contains(result.stderr, "has no commits yet") {
+			t.Errorf("got %d, stdout %q and stderr %q, want %d telling the repository has no commits yet", result.code, result.stdout, result.stderr, exitNoCommits)
+		}
+	})
+	t.Run("single commit", func(t *testing.T) {
+		single := newTestRepo(t, time.Date(2023, time.March, 1, 10, 0, 0, 0, time.UTC))
+		result := execute(t, "--no-env", "--quiet", "--no-cache", "--tz", "UTC", "--year", "2023", "--emails", "dev@example.com", "--path", single)
+		if result.code != exitOK || !strings.Contains(result.stdout, "Total commit count: 1") || result.stderr != "" {
+			t.Errorf("got %d, stdout %q and stderr %q, want the report of the commit", result.code, result.stdout, result.stderr)
+		}
+	})
+	t.Run("unreachable commit", func(t *testing.T) {
+		amended := newTestRepo(t, time.Date(2023, time.March, 1, 10, 0, 0, 0, time.UTC), time.Date(2023, time.March, 2, 10, 0, 0, 0, time.UTC))
+		dropLastCommit(t, amended)
+		result := execute(t, "--no-env", "--quiet", "--tz", "UTC", "--year", "2023", "--emails", "dev@example.com", "--path", amended)
+		if result.code != exitOK || !strings.Contains(result.stdout, "Total commit count: 1") || !strings.Contains(result.stderr, "Skipped 1 unreachable commit, pass --include-unreachable") {
+			t.Errorf("got %d, stdout %q and stderr %q, want the report of the reachable commit and the unreachable one counted", result.code, result.stdout, result.stderr)
+		}
+	})
+	t.Run("repository open", func(t *testing.T) {
+		result := execute(t, "--no-env", "--quiet", "--no-cache", "--emails", "dev@example.com", "--path", t.TempDir())
+		if result.code != exitRepoOpen || result.stdout != "" || !strings.Contains(result.stderr, "Error generating your wrapped") {
+			t.Errorf("got %d, stdout %q and stderr %q, want %d with the error on stderr only", result.code, result.stdout, result.stderr, exitRepoOpen)
+		}
+	})
+}
+
+// dropLastCommit points the branch of HEAD at the parent of its commit,
+// leaving the commit unreachable like an amended one.
+func dropLastCommit(t *testing.T, dir string) {
+	t.Helper()
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(head.Name(), commit.ParentHashes[0])); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// captureStderr returns what fn wrote to os.Stderr.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = writer
+	defer func() { os.Stderr = stderr }()
+
+	fn()
+	writer.Close()
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(output)
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		code   int
+		stderr string
+	}{
+		{name: "ok", err: nil, code: exitOK},
+		{name: "repository open", err: fmt.Errorf("opening: %w", &wrapped.RepoOpenError{}), code: exitRepoOpen, stderr: "Error generating your wrapped"},
+		{name: "no commits", err: &noCommitsError{window: wrapped.NewYearWindow(2023, time.UTC), emails: []string{"dev@example.com"}, suggestions: "Did you mean other@example.com?\n"}, code: exitNoCommits, stderr: "Did you mean other@example.com?"},
+		{name: "empty repository", err: &wrapped.EmptyRepoError{}, code: exitNoCommits, stderr: "Nothing to wrap up"},
+		{name: "analysis", err: errors.New("broken"), code: exitAnalysis, stderr: "[err=broken]"},
+		{name: "delivery", err: &deliveryError{action: "post the report", status: "400 Bad Request"}, code: exitDelivery, stderr: "post the report"},
+		{name: "timeout", err: &wrapped.InterruptedError{Processed: 3200, Found: 8400, Cause: context.DeadlineExceeded}, code: exitTimeout, stderr: "Interrupted after 3,200/8,400 commits"},
+		{name: "interrupted", err: &wrapped.InterruptedError{Processed: 1, Found: 2, Cause: context.Canceled}, code: exitInterrupted, stderr: "Interrupted after 1/2 commits"},
+		{name: "compliance", err: &complianceError{reason: "too few sign-offs"}, code: exitCompliance, stderr: "too few sign-offs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := 0
+			stderr := captureStderr(t, func() {
+				code = exitCode(tt.err)
+			})
+			if code != tt.code {
+				t.Errorf("got exit code %d, want %d", code, tt.code)
+			}
+			if tt.stderr == "" && stderr != "" {
+				t.Errorf("got stderr %q, want it empty", stderr)
+			}
+			if !strings.Contains(stderr, tt.stderr) {
+				t.Errorf("got stderr %q, want it to contain %q", stderr, tt.stderr)
+			}
+		})
+	}
+}
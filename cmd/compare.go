@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"strings"
+	"text/tabwriter"
+)
+
+var compareCommand = &command{
+	name:        "compare",
+	summary:     "Compare an author's year with another year, or two authors",
+	description: "Compare the stats of an author's year against another year, by default the year before. With --emails-a and --emails-b, compare the headline stats of two authors in the same year instead.",
+	args:        "[path...]",
+	examples: []string{
+		"git-wrapped compare --emails me@example.com",
+		"git-wrapped compare --emails me@example.com --year 2023 --against 2020",
+		"git-wrapped compare --emails-a alice@example.com --emails-b bob@example.com --format markdown",
+	},
+	setup: setupCompare,
+}
+
+func setupCompare(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	selectionFlags := addSelectionFlags(fs, true)
+	analysisFlags := addAnalysisFlags(fs)
+	againstFlag := fs.Int("against", 0, "The year compared against. Default=the year before --year")
+	emailsAFlag := fs.String("emails-a", "", "A comma separated list of emails identifying the first of two authors to compare, instead of comparing years")
+	emailsBFlag := fs.String("emails-b", "", "A comma separated list of emails identifying the second of two authors to compare")
+	formatFlag := fs.String("format", "text", "The format of the comparison of two authors: "+strings.Join(wrapped.RivalryFormats(), ", "))
+	configFlags := addConfigFlags(fs)
+	logFlags := addLogFlags(fs)
+
+	return func(ctx context.Context, args []string) error {
+		config, err := configFlags.load(fs)
+		if err != nil {
+			return err
+		}
+		if *configFlags.printConfig {
+			return config.print(fs)
+		}
+
+		paths := repoPaths(selectionFlags.paths, args)
+		rivalry := *emailsAFlag != "" || *emailsBFlag != ""
+		if !rivalry && *formatFlag != "text" {
+			return usagef("--format only applies to comparing two authors with --emails-a and --emails-b")
+		}
+		if rivalry && !isRivalryFormat(*formatFlag) {
+			return usagef("Unknown --format %q, expected one of %s", *formatFlag, strings.Join(wrapped.RivalryFormats(), ", "))
+		}
+
+		var selection wrapped.Selection
+		var contenders []wrapped.Selection
+		if rivalry {
+			contenders, err = contenderSelections(selectionFlags, *emailsAFlag, *emailsBFlag)
+			if err == nil {
+				selection = contenders[0]
+			}
+		} else {
+			selection, err = selectionFlags.selection()
+		}
+		if err != nil {
+			return err
+		}
+		opts, err := analysisFlags.options()
+		if err != nil {
+			return err
+		}
+		opts.Overrides = config.overrides(paths, opts.Filter)
+		if rivalry {
+			clearAuthorOverrides(opts.Overrides)
+		}
+		logFlags.apply(&opts)
+		logConfiguration(opts.Logger, paths, selection, opts)
+
+		ctx, cancel := analysisFlags.withTimeout(ctx)
+		defer cancel()
+
+		if rivalry {
+			return analysisFlags.runProfiled(opts, func() error {
+				summaries := make([]wrapped.Contender, 0, len(contenders))
+				for i, contender := range contenders {
+					summary, err := analyzeSelection(ctx, paths, contender, opts)
+					if err != nil {
+						return err
+					}
+					summary.Generator = generator()
+					label := contenderLabel([]string{*emailsAFlag, *emailsBFlag}[i])
+					summaries = append(summaries, wrapped.Contender{Label: label, Summary: summary})
+				}
+
+				output, err := wrapped.RenderRivalry(*formatFlag, summaries[0], summaries[1])
+				if err != nil {
+					return err
+				}
+				fmt.Println(output)
+				return nil
+			})
+		}
+
+		against := selection
+		againstYear := *againstFlag
+		if againstYear == 0 {
+			againstYear = *selectionFlags.year - 1
+		}
+		against.Window = wrapped.NewYearWindow(againstYear, selection.Window.Location)
+
+		return analysisFlags.runProfiled(opts, func() error {
+			before, err := analyzeSelection(ctx, paths, against, opts)
+			if err != nil {
+				return err
+			}
+			after, err := analyzeSelection(ctx, paths, selection, opts)
+			if err != nil {
+				return err
+			}
+
+			fmt.Print(buildComparison(againstYear, before, *selectionFlags.year, after))
+			return nil
+		})
+	}
+}
+
+// buildComparison lays the stats of both years out in columns, with the
+// change from the earlier to the later one.
+func buildComparison(beforeYear int, before *wrapped.Summary, afterYear int, after *wrapped.Summary) string {
+	builder := strings.Builder{}
+	writer := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(writer, "\t%d\t%d\tchange\n", beforeYear, afterYear)
+	row := func(label string, format string, beforeValue float64, afterValue float64) {
+		fmt.Fprintf(writer, "%s\t"+format+"\t"+format+"\t%+"+format[1:]+"\n", label, beforeValue, afterValue, afterValue-beforeValue)
+	}
+	row("🧮 Commits", "%.0f", float64(before.TotalCommits), float64(after.TotalCommits))
+	row("📅 Active days", "%.0f", float64(before.ActiveDays()), float64(after.ActiveDays()))
+	if after.HasLineStats() {
+		row("🟢 Average additions", "%.1f", before.AverageAdditions, after.AverageAdditions)
+		row("🔴 Average deletions", "%.1f", before.AverageDeletions, after.AverageDeletions)
+	}
+	row("🔀 Merge commits", "%.0f", float64(before.MergeCommits), float64(after.MergeCommits))
+	writer.Flush()
+
+	return builder.String()
+}
+
+// contenderSelections returns the selections of the year of both authors
+// compared. --emails is ignored.
+func contenderSelections(selectionFlags *selectionFlags, emailsA string, emailsB string) ([]wrapped.Selection, error) {
+	if emailsA == "" || emailsB == "" {
+		return nil, usagef("Forgot to set both --emails-a and --emails-b to compare two authors")
+	}
+
+	selections := make([]wrapped.Selection, 0, 2)
+	for _, emails := range []string{emailsA, emailsB} {
+		authors := emailSet(strings.Split(emails, ","))
+		if len(authors) == 0 {
+			return nil, usagef("Forgot to specify a valid email address in %q", emails)
+		}
+		selection, err := selectionFlags.selectionOf(authors)
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, selection)
+	}
+
+	return selections, nil
+}
+
+// isRivalryFormat reports whether two authors can be compared in the format.
+func isRivalryFormat(format string) bool {
+	for _, known := range wrapped.RivalryFormats() {
+		if format == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// contenderLabel names an author by their emails, in the order given.
+func contenderLabel(emails string) string {
+	labels := make([]string, 0)
+	for _, email := range strings.Split(emails, ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			labels = append(labels, email)
+		}
+	}
+
+	return strings.Join(labels, ", ")
+}
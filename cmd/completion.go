@@ -0,0 +1,486 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+var completionCommand = &command{
+	name:        "completion",
+	summary:     "Print a shell completion script",
+	description: "Print the completion script of git-wrapped for bash, zsh or fish.",
+	args:        strings.Join(completionShells, "|"),
+	examples: []string{
+		"source <(git-wrapped completion bash)",
+		"git-wrapped completion zsh > \"${fpath[1]}/_git-wrapped\"",
+		"git-wrapped completion fish | source",
+	},
+	setup: setupCompletion,
+}
+
+func setupCompletion(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	return func(ctx context.Context, args []string) error {
+		// The scripts call back into "completion values <flag>" for the values
+		// that can't be known when the script is generated.
+		if len(args) == 2 && args[0] == "values" {
+			for _, value := range flagValues(args[1]) {
+				fmt.Println(value)
+			}
+			return nil
+		}
+		if len(args) != 1 {
+			return usagef("Expected the shell to print the completion for, one of %s", strings.Join(completionShells, ", "))
+		}
+
+		specs := completionSpecs()
+		switch args[0] {
+		case "bash":
+			fmt.Print(bashCompletion(specs))
+		case "zsh":
+			fmt.Print(zshCompletion(specs))
+		case "fish":
+			fmt.Print(fishCompletion(specs))
+		default:
+			return usagef("Unknown shell %q, expected one of %s", args[0], strings.Join(completionShells, ", "))
+		}
+
+		return nil
+	}
+}
+
+// Flags whose values are completed with files or directories, or with the
+// candidates printed by flagValues. Every other flag takes a free value.
+var (
+	dirFlags  = map[string]bool{"path": true, "cache-dir": true, "repos": true, "clone-dir": true}
+	fileFlags = map[string]bool{"config": true, "identities-file": true, "profile": true, "profile-mem": true}
+	// valueFlags map to the candidates of their value.
+	valueFlags = map[string]func() []string{
+		"year":        recentYears,
+		"format":      wrapped.Formats,
+		"tz":          timeZones,
+		"merge-stats": func() []string { return []string{wrapped.MergeStatsNone, wrapped.MergeStatsFirstParent} },
+		"enable":      statNames,
+		"period":      wrapped.Periods,
+		"disable":     statNames,
+	}
+)
+
+func flagValues(name string) []string {
+	values, ok := valueFlags[name]
+	if !ok {
+		return nil
+	}
+
+	return values()
+}
+
+// recentYears returns this year and the four before it.
+func recentYears() []string {
+	years := make([]string, 0, 5)
+	for year := time.Now().Year(); len(years) < cap(years); year-- {
+		years = append(years, strconv.Itoa(year))
+	}
+
+	return years
+}
+
+// timeZones returns the names of the time zones in the system's zoneinfo.
+func timeZones() []string {
+	seen := map[string]bool{"Local": true, "UTC": true}
+	for _, dir := range []string{os.Getenv("ZONEINFO"), "/usr/share/zoneinfo", "/usr/share/lib/zoneinfo", "/usr/lib/locale/TZ"} {
+		if dir == "" {
+			continue
+		}
+		_ = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			name, _ := filepath.Rel(dir, path)
+			if entry.IsDir() {
+				if name == "posix" || name == "right" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if name[0] >= 'A' && name[0] <= 'Z' && !strings.Contains(name, ".") {
+				seen[filepath.ToSlash(name)] = true
+			}
+			return nil
+		})
+	}
+
+	zones := make([]string, 0, len(seen))
+	for zone := range seen {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+
+	return zones
+}
+
+// completionSpec is a command as the completion scripts see it.
+type completionSpec struct {
+	// words are the command's name and the names of its parent groups.
+	words   []string
+	summary string
+	flags   []*flag.Flag
+	// args are the candidates of the positional arguments, paths is set
+	// instead when they're repositories.
+	args  []string
+	paths bool
+	// subcommands is set for groups of commands, which take no flags.
+	subcommands []*completionSpec
+}
+
+func (s *completionSpec) name() string {
+	return strings.Join(s.words, " ")
+}
+
+// completionSpecs describes every command.
+func completionSpecs() []*completionSpec {
+	var describe func(cmd *command, parents []string) *completionSpec
+	describe = func(cmd *command, parents []string) *completionSpec {
+		spec := &completionSpec{
+			words:   append(append([]string{}, parents...), cmd.name),
+			summary: cmd.summary,
+		}
+		if len(cmd.subcommands) > 0 {
+			for _, sub := range cmd.subcommands {
+				spec.subcommands = append(spec.subcommands, describe(sub, spec.words))
+			}
+			return spec
+		}
+
+		fs, _, _ := newFlagSet(cmd, "git-wrapped "+spec.name())
+		fs.VisitAll(func(f *flag.Flag) {
+			spec.flags = append(spec.flags, f)
+		})
+		// Arguments are either repositories or one of a list like a|b|c.
+		switch {
+		case cmd.args == "[path...]":
+			spec.paths = true
+		case strings.Contains(cmd.args, "|"):
+			spec.args = strings.Split(cmd.args, "|")
+		}
+		return spec
+	}
+
+	specs := make([]*completionSpec, 0, len(commands))
+	for _, cmd := range commands {
+		specs = append(specs, describe(cmd, nil))
+	}
+
+	return specs
+}
+
+// runnableSpecs returns the commands that can run, flattening the groups.
+func runnableSpecs(specs []*completionSpec) []*completionSpec {
+	runnable := make([]*completionSpec, 0, len(specs))
+	for _, spec := range specs {
+		if len(spec.subcommands) > 0 {
+			runnable = append(runnable, runnableSpecs(spec.subcommands)...)
+			continue
+		}
+		runnable = append(runnable, spec)
+	}
+
+	return runnable
+}
+
+// flagNames groups the value taking flags of every command by how their
+// values are completed.
+func flagNames(specs []*completionSpec) (dirs, files, values, free []string) {
+	seen := make(map[string]bool)
+	for _, spec := range runnableSpecs(specs) {
+		for _, f := range spec.flags {
+			if seen[f.Name] || isBoolFlag(f) {
+				continue
+			}
+			seen[f.Name] = true
+			switch {
+			case dirFlags[f.Name]:
+				dirs = append(dirs, f.Name)
+			case fileFlags[f.Name]:
+				files = append(files, f.Name)
+			case valueFlags[f.Name] != nil:
+				values = append(values, f.Name)
+			default:
+				free = append(free, f.Name)
+			}
+		}
+	}
+
+	return dirs, files, values, free
+}
+
+func specNames(specs []*completionSpec) []string {
+	names := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		names = append(names, spec.words[len(spec.words)-1])
+	}
+
+	return names
+}
+
+// shellCase renders the detection of the command being completed, shared
+// by bash and zsh which index the words differently. unit is one level of
+// indentation.
+func shellCase(specs []*completionSpec, word func(int) string, after func(int) string, unit string) string {
+	builder := strings.Builder{}
+	fmt.Fprintf(&builder, "%scase %s in\n", unit, word(1))
+	for _, spec := range specs {
+		fmt.Fprintf(&builder, "%s(%s) %s && cmd=%q", unit+unit, spec.words[0], after(1), spec.name())
+		if len(spec.subcommands) > 0 {
+			fmt.Fprintf(&builder, "\n%scase %s in\n", unit+unit+unit, word(2))
+			for _, sub := range spec.subcommands {
+				fmt.Fprintf(&builder, "%s(%s) %s && cmd=%q ;;\n", unit+unit+unit+unit, sub.words[1], after(2), sub.name())
+			}
+			fmt.Fprintf(&builder, "%sesac", unit+unit+unit)
+		}
+		builder.WriteString(" ;;\n")
+	}
+	fmt.Fprintf(&builder, "%sesac\n", unit)
+
+	return builder.String()
+}
+
+func bashCompletion(specs []*completionSpec) string {
+	dirs, files, values, free := flagNames(specs)
+	builder := strings.Builder{}
+
+	builder.WriteString(`# bash completion for git-wrapped. Load it with
+#   source <(git-wrapped completion bash)
+_git_wrapped() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}" cmd="" flag words=""
+`)
+	builder.WriteString(shellCase(specs,
+		func(i int) string { return fmt.Sprintf(`"${COMP_WORDS[%d]}"`, i) },
+		func(i int) string { return fmt.Sprintf("(( COMP_CWORD > %d ))", i) },
+		"    "))
+	fmt.Fprintf(&builder, `    [[ -z "$cmd" && COMP_CWORD -gt 1 ]] && cmd=%q
+
+    if [[ "$prev" == -* ]]; then
+        flag="${prev#-}"
+        flag="${flag#-}"
+        case "$flag" in
+            %s) COMPREPLY=($(compgen -d -- "$cur")); return ;;
+            %s) COMPREPLY=($(compgen -f -- "$cur")); return ;;
+            %s) COMPREPLY=($(compgen -W "$(git-wrapped completion values "$flag" 2>/dev/null)" -- "$cur")); return ;;
+            %s) return ;;
+        esac
+    fi
+
+    if [[ "$cur" == -* ]]; then
+        case "$cmd" in
+`, specs[0].name(), strings.Join(dirs, "|"), strings.Join(files, "|"), strings.Join(values, "|"), strings.Join(free, "|"))
+	for _, spec := range runnableSpecs(specs) {
+		flags := make([]string, 0, len(spec.flags))
+		for _, f := range spec.flags {
+			flags = append(flags, "--"+f.Name)
+		}
+		fmt.Fprintf(&builder, "            %q) words=%q ;;\n", spec.name(), strings.Join(flags, " "))
+	}
+	fmt.Fprintf(&builder, `        esac
+        COMPREPLY=($(compgen -W "$words" -- "$cur"))
+        return
+    fi
+
+    case "$cmd" in
+        "") COMPREPLY=($(compgen -W %q -- "$cur") $(compgen -d -- "$cur")) ;;
+`, strings.Join(specNames(specs), " "))
+	for _, spec := range specs {
+		if len(spec.subcommands) > 0 {
+			fmt.Fprintf(&builder, "        %q) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", spec.name(), strings.Join(specNames(spec.subcommands), " "))
+		}
+	}
+	for _, spec := range runnableSpecs(specs) {
+		switch {
+		case spec.paths:
+			fmt.Fprintf(&builder, "        %q) COMPREPLY=($(compgen -d -- \"$cur\")) ;;\n", spec.name())
+		case len(spec.args) > 0:
+			fmt.Fprintf(&builder, "        %q) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", spec.name(), strings.Join(spec.args, " "))
+		}
+	}
+	builder.WriteString(`    esac
+}
+complete -F _git_wrapped git-wrapped
+`)
+
+	return builder.String()
+}
+
+// zshQuote single quotes a string for zsh.
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func zshDescribed(names []string, descriptions []string) string {
+	items := make([]string, 0, len(names))
+	for i, name := range names {
+		items = append(items, zshQuote(strings.ReplaceAll(name, ":", `\:`)+":"+descriptions[i]))
+	}
+
+	return strings.Join(items, " ")
+}
+
+func zshCompletion(specs []*completionSpec) string {
+	dirs, files, values, free := flagNames(specs)
+	builder := strings.Builder{}
+
+	builder.WriteString(`#compdef git-wrapped
+# zsh completion for git-wrapped. Load it with
+#   source <(git-wrapped completion zsh)
+# or save it as _git-wrapped in a directory of your $fpath.
+_git_wrapped() {
+  local cur=${words[CURRENT]} prev=${words[CURRENT-1]} cmd="" flag
+  local -a candidates
+`)
+	builder.WriteString(shellCase(specs,
+		func(i int) string { return fmt.Sprintf("${words[%d]}", i+1) },
+		func(i int) string { return fmt.Sprintf("(( CURRENT > %d ))", i+1) },
+		"  "))
+	fmt.Fprintf(&builder, `  [[ -z $cmd && CURRENT -gt 2 ]] && cmd=%q
+
+  if [[ $prev == -* ]]; then
+    flag=${prev#-}
+    flag=${flag#-}
+    case $flag in
+      (%s) _directories; return ;;
+      (%s) _files; return ;;
+      (%s) candidates=(${(f)"$(git-wrapped completion values $flag 2>/dev/null)"}); compadd -a candidates; return ;;
+      (%s) return ;;
+    esac
+  fi
+
+  if [[ $cur == -* ]]; then
+    case $cmd in
+`, specs[0].name(), strings.Join(dirs, "|"), strings.Join(files, "|"), strings.Join(values, "|"), strings.Join(free, "|"))
+	for _, spec := range runnableSpecs(specs) {
+		names := make([]string, 0, len(spec.flags))
+		descriptions := make([]string, 0, len(spec.flags))
+		for _, f := range spec.flags {
+			names = append(names, "--"+f.Name)
+			descriptions = append(descriptions, f.Usage)
+		}
+		fmt.Fprintf(&builder, "      (%s) candidates=(%s) ;;\n", zshQuote(spec.name()), zshDescribed(names, descriptions))
+	}
+	builder.WriteString(`    esac
+    _describe -t flags flag candidates
+    return
+  fi
+
+  case $cmd in
+`)
+	describeCommands := func(specs []*completionSpec) string {
+		summaries := make([]string, 0, len(specs))
+		for _, spec := range specs {
+			summary := spec.summary
+			if summary == "" && len(spec.subcommands) > 0 {
+				summary = strings.Join(specNames(spec.subcommands), ", ")
+			}
+			summaries = append(summaries, summary)
+		}
+		return fmt.Sprintf("candidates=(%s); _describe -t commands command candidates", zshDescribed(specNames(specs), summaries))
+	}
+	fmt.Fprintf(&builder, "    ('') %s; _directories ;;\n", describeCommands(specs))
+	for _, spec := range specs {
+		if len(spec.subcommands) > 0 {
+			fmt.Fprintf(&builder, "    (%s) %s ;;\n", zshQuote(spec.name()), describeCommands(spec.subcommands))
+		}
+	}
+	for _, spec := range runnableSpecs(specs) {
+		switch {
+		case spec.paths:
+			fmt.Fprintf(&builder, "    (%s) _directories ;;\n", zshQuote(spec.name()))
+		case len(spec.args) > 0:
+			fmt.Fprintf(&builder, "    (%s) compadd %s ;;\n", zshQuote(spec.name()), strings.Join(spec.args, " "))
+		}
+	}
+	builder.WriteString(`  esac
+}
+
+if [[ $zsh_eval_context[-1] == loadautofunc ]]; then
+  _git_wrapped "$@"
+else
+  compdef _git_wrapped git-wrapped
+fi
+`)
+
+	return builder.String()
+}
+
+// fishQuote single quotes a string for fish.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+func fishCompletion(specs []*completionSpec) string {
+	builder := strings.Builder{}
+	builder.WriteString(`# fish completion for git-wrapped. Load it with
+#   git-wrapped completion fish | source
+complete -c git-wrapped -f
+`)
+
+	topNames := strings.Join(specNames(specs), " ")
+	for _, spec := range specs {
+		summary := spec.summary
+		if summary == "" && len(spec.subcommands) > 0 {
+			summary = strings.Join(specNames(spec.subcommands), ", ")
+		}
+		fmt.Fprintf(&builder, "complete -c git-wrapped -n %s -a %s -d %s\n",
+			fishQuote("not __fish_seen_subcommand_from "+topNames), spec.words[0], fishQuote(summary))
+		for _, sub := range spec.subcommands {
+			subNames := strings.Join(specNames(spec.subcommands), " ")
+			fmt.Fprintf(&builder, "complete -c git-wrapped -n %s -a %s -d %s\n",
+				fishQuote("__fish_seen_subcommand_from "+spec.words[0]+"; and not __fish_seen_subcommand_from "+subNames), sub.words[1], fishQuote(sub.summary))
+		}
+	}
+
+	for i, spec := range runnableSpecs(specs) {
+		condition := "__fish_seen_subcommand_from " + spec.words[len(spec.words)-1]
+		if len(spec.words) > 1 {
+			condition = "__fish_seen_subcommand_from " + spec.words[0] + "; and " + condition
+		}
+		if i == 0 {
+			// The first command also runs without naming it.
+			others := specNames(specs)[1:]
+			condition = "not __fish_seen_subcommand_from " + strings.Join(others, " ")
+		}
+
+		for _, f := range spec.flags {
+			fmt.Fprintf(&builder, "complete -c git-wrapped -n %s -l %s -d %s", fishQuote(condition), f.Name, fishQuote(f.Usage))
+			switch {
+			case isBoolFlag(f):
+			case dirFlags[f.Name]:
+				builder.WriteString(" -x -a '(__fish_complete_directories)'")
+			case fileFlags[f.Name]:
+				builder.WriteString(" -r -F")
+			case valueFlags[f.Name] != nil:
+				fmt.Fprintf(&builder, " -x -a %s", fishQuote("(git-wrapped completion values "+f.Name+")"))
+			default:
+				builder.WriteString(" -x")
+			}
+			builder.WriteString("\n")
+		}
+
+		switch {
+		case spec.paths:
+			fmt.Fprintf(&builder, "complete -c git-wrapped -n %s -a '(__fish_complete_directories)'\n", fishQuote(condition))
+		case len(spec.args) > 0:
+			fmt.Fprintf(&builder, "complete -c git-wrapped -n %s -a %s\n", fishQuote(condition), fishQuote(strings.Join(spec.args, " ")))
+		}
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,378 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// repoConfigName is the name of the config file read from the root of the
+// repository being analyzed, see repoConfigPath.
+const repoConfigName = ".git-wrapped.yaml"
+
+// fileConfig is the configuration read from a config file. Every setting is
+// optional and only fills in flags that weren't passed.
+type fileConfig struct {
+	Emails       []string `yaml:"emails,omitempty"`
+	ExcludePaths []string `yaml:"exclude_paths,omitempty"`
+	Format       string   `yaml:"format,omitempty"`
+	TZ           string   `yaml:"tz,omitempty"`
+	// Repos are analyzed when no --path is passed, and override the emails
+	// and excluded paths for the repository at Path.
+	Repos []repoConfig `yaml:"repos,omitempty"`
+}
+
+// repoConfig overrides the settings of a single repository.
+type repoConfig struct {
+	Path         string   `yaml:"path"`
+	Emails       []string `yaml:"emails,omitempty"`
+	ExcludePaths []string `yaml:"exclude_paths,omitempty"`
+}
+
+// configFlags are the flags choosing and printing the configuration.
+type configFlags struct {
+	config      *string
+	printConfig *bool
+}
+
+func addConfigFlags(fs *flag.FlagSet) *configFlags {
+	return &configFlags{
+		config:      fs.String("config", "", "The config file to read instead of ~/.config/git-wrapped/config.yaml. The "+repoConfigName+" at the root of the repository is read on top of it"),
+		printConfig: fs.Bool("print-config", false, "Print the configuration merged from the config files and flags, and exit"),
+	}
+}
+
+// load reads the user and the repo-local config and fills in every flag that
+// wasn't passed from them. Flags take precedence over the repo-local config,
+// which takes precedence over the user config.
+func (f *configFlags) load(fs *flag.FlagSet) (*fileConfig, error) {
+	userPath, required := *f.config, true
+	if userPath == "" {
+		userPath, required = defaultConfigPath(), false
+	}
+
+	config := &fileConfig{}
+	for _, layer := range []struct {
+		path     string
+		required bool
+	}{{userPath, required}, {repoConfigPath(fs), false}} {
+		if layer.path == "" {
+			continue
+		}
+		layerConfig, err := readConfig(layer.path, layer.required)
+		if err != nil {
+			return nil, err
+		}
+		config.merge(layerConfig)
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	// Positional arguments are paths just like --path.
+	if fs.NArg() > 0 {
+		set["path"] = true
+	}
+	// Any of the identity flags replaces the emails of the config.
+	if set["email"] || set["identities-file"] {
+		set["emails"] = true
+	}
+	setDefault := func(name string, values ...string) error {
+		if set[name] || fs.Lookup(name) == nil {
+			return nil
+		}
+		for _, value := range values {
+			if value == "" {
+				continue
+			}
+			err := fs.Set(name, value)
+			if err != nil {
+				return fmt.Errorf("invalid %s in the config: %w", name, err)
+			}
+		}
+		return nil
+	}
+
+	repoPaths := make([]string, 0, len(config.Repos))
+	for _, repo := range config.Repos {
+		repoPaths = append(repoPaths, repo.Path)
+	}
+	err := errors.Join(
+		setDefault("emails", strings.Join(config.Emails, ",")),
+		setDefault("exclude-path", config.ExcludePaths...),
+		setDefault("path", repoPaths...),
+		setDefault("format", config.Format),
+		setDefault("tz", config.TZ),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Overrides only apply to what wasn't set on the command line.
+	for i := range config.Repos {
+		if set["emails"] {
+			config.Repos[i].Emails = nil
+		}
+		if set["exclude-path"] {
+			config.Repos[i].ExcludePaths = nil
+		}
+	}
+
+	return config, nil
+}
+
+// repoConfigPath returns the path of the repo-local config, at the root of
+// the first repository passed with --path or as an argument, or of the one
+// containing the working directory. It's read from the working directory
+// when none of them is in a repository, like for the commands analyzing a
+// directory of repositories.
+func repoConfigPath(fs *flag.FlagSet) string {
+	paths, _ := lookupStrings(fs, "path")
+	for _, path := range repoPaths(paths, fs.Args()) {
+		if root, err := wrapped.FindRepoRoot(path); err == nil {
+			return filepath.Join(root, repoConfigName)
+		}
+	}
+
+	return repoConfigName
+}
+
+// defaultConfigPath returns the path of the user config, honoring
+// XDG_CONFIG_HOME, or an empty string when there's no home directory.
+func defaultConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "git-wrapped", "config.yaml")
+}
+
+// readConfig reads a config file. A missing file is an empty config unless
+// it's required. Unknown keys are warned about and ignored, so configs keep
+// working with older versions.
+func readConfig(path string, required bool) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return &fileConfig{}, nil
+	}
+	if err != nil && required {
+		return nil, usagef("Unable to read the --config. [err=%s]", err.Error())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the config: %w", err)
+	}
+
+	document := yaml.Node{}
+	err = yaml.Unmarshal(data, &document)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the config %s: %w", path, err)
+	}
+	if len(document.Content) == 0 {
+		return &fileConfig{}, nil
+	}
+
+	root := document.Content[0]
+	warnUnknownKeys(path, root, reflect.TypeOf(fileConfig{}))
+	config := &fileConfig{}
+	err = root.Decode(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the config %s: %w", path, err)
+	}
+
+	// Repository paths are relative to the config file.
+	for i, repo := range config.Repos {
+		config.Repos[i].Path = resolveConfigPath(filepath.Dir(path), repo.Path)
+	}
+
+	return config, nil
+}
+
+// warnUnknownKeys warns on stderr about the keys of the mapping that don't
+// match a field of the struct, descending into lists of structs.
+func warnUnknownKeys(path string, node *yaml.Node, structType reflect.Type) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		fields[strings.Split(field.Tag.Get("yaml"), ",")[0]] = field.Type
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		fieldType, ok := fields[key.Value]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring unknown key %q in %s:%d\n", key.Value, path, key.Line)
+			continue
+		}
+		if fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Struct && value.Kind == yaml.SequenceNode {
+			for _, item := range value.Content {
+				warnUnknownKeys(path, item, fieldType.Elem())
+			}
+		}
+	}
+}
+
+// resolveConfigPath expands a leading ~ and makes the path absolute relative
+// to dir.
+func resolveConfigPath(dir string, path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	return filepath.Clean(path)
+}
+
+// merge applies the settings of other on top of the config. Repositories are
+// merged by their path.
+func (c *fileConfig) merge(other *fileConfig) {
+	if other.Emails != nil {
+		c.Emails = other.Emails
+	}
+	if other.ExcludePaths != nil {
+		c.ExcludePaths = other.ExcludePaths
+	}
+	if other.Format != "" {
+		c.Format = other.Format
+	}
+	if other.TZ != "" {
+		c.TZ = other.TZ
+	}
+
+	for _, repo := range other.Repos {
+		existing := c.repo(repo.Path)
+		if existing == nil {
+			c.Repos = append(c.Repos, repo)
+			continue
+		}
+		if repo.Emails != nil {
+			existing.Emails = repo.Emails
+		}
+		if repo.ExcludePaths != nil {
+			existing.ExcludePaths = repo.ExcludePaths
+		}
+	}
+}
+
+// repo returns the settings of the repository at path, or nil.
+func (c *fileConfig) repo(path string) *repoConfig {
+	path = resolveConfigPath(".", path)
+	for i := range c.Repos {
+		if c.Repos[i].Path == path {
+			return &c.Repos[i]
+		}
+	}
+
+	return nil
+}
+
+// overrides returns the per repository settings of the analyzed paths.
+func (c *fileConfig) overrides(paths []string, filter wrapped.PathFilter) map[string]wrapped.RepoOverride {
+	overrides := make(map[string]wrapped.RepoOverride)
+	for _, path := range paths {
+		repo := c.repo(path)
+		if repo == nil {
+			continue
+		}
+
+		override := wrapped.RepoOverride{}
+		if len(repo.Emails) > 0 {
+			override.Authors = emailSet(repo.Emails)
+		}
+		if repo.ExcludePaths != nil {
+			repoFilter := filter
+			repoFilter.Excluded = repo.ExcludePaths
+			override.Filter = &repoFilter
+		}
+		overrides[path] = override
+	}
+
+	return overrides
+}
+
+// print prints the effective configuration as a config file, with the
+// values of the flags merged in.
+func (c *fileConfig) print(fs *flag.FlagSet) error {
+	effective := fileConfig{}
+	if emails := fs.Lookup("emails"); emails != nil && emails.Value.String() != "" {
+		for _, email := range strings.Split(emails.Value.String(), ",") {
+			effective.Emails = append(effective.Emails, strings.TrimSpace(email))
+		}
+	}
+	if emails, ok := lookupStrings(fs, "email"); ok {
+		effective.Emails = append(effective.Emails, emails...)
+	}
+	if excluded, ok := lookupStrings(fs, "exclude-path"); ok {
+		effective.ExcludePaths = excluded
+	}
+	if format := fs.Lookup("format"); format != nil {
+		effective.Format = format.Value.String()
+	}
+	if tz := fs.Lookup("tz"); tz != nil {
+		effective.TZ = tz.Value.String()
+	}
+	paths, _ := lookupStrings(fs, "path")
+	for _, path := range repoPaths(paths, fs.Args()) {
+		repo := repoConfig{Path: path}
+		if override := c.repo(path); override != nil {
+			repo.Emails = override.Emails
+			repo.ExcludePaths = override.ExcludePaths
+		}
+		effective.Repos = append(effective.Repos, repo)
+	}
+
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	err := encoder.Encode(effective)
+	if err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+func lookupStrings(fs *flag.FlagSet, name string) ([]string, bool) {
+	f := fs.Lookup(name)
+	if f == nil {
+		return nil, false
+	}
+	values, ok := f.Value.(*stringsFlag)
+	if !ok {
+		return nil, false
+	}
+
+	return *values, true
+}
+
+// clearAuthorOverrides drops the emails the config sets for repositories, for
+// commands choosing the authors themselves.
+func clearAuthorOverrides(overrides map[string]wrapped.RepoOverride) {
+	for path, override := range overrides {
+		override.Authors = nil
+		overrides[path] = override
+	}
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRepoConfigPath(t *testing.T) {
+	repo := newTestRepo(t, time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC))
+	nested := filepath.Join(repo, "src", "pkg")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	root, err := filepath.EvalSymlinks(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(root, repoConfigName)
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "path", args: []string{"--path", nested}},
+		{name: "argument", args: []string{nested}},
+		{name: "first repository", args: []string{"--path", t.TempDir(), "--path", repo}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			paths := stringsFlag{}
+			fs.Var(&paths, "path", "")
+			if err := fs.Parse(test.args); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := filepath.EvalSymlinks(filepath.Dir(repoConfigPath(fs)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got = filepath.Join(got, repoConfigName); got != want {
+				t.Errorf("repoConfigPath() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestRepoConfigReadFromRepoRoot(t *testing.T) {
+	repo := newTestRepo(t, time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC))
+	if err := os.WriteFile(filepath.Join(repo, repoConfigName), []byte("tz: Asia/Tokyo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(repo, "src")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	result := execute(t, "--print-config", "--path", nested)
+	if result.code != 0 {
+		t.Fatalf("exit code = %d, want 0, stderr %q", result.code, result.stderr)
+	}
+	if !strings.Contains(result.stdout, "tz: Asia/Tokyo") {
+		t.Errorf("printed config %q, want the tz of the repository's config", result.stdout)
+	}
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvFlags are the flags exporting the listed commits as CSV.
+type csvFlags struct {
+	commits *string
+	files   *string
+}
+
+func addCSVFlags(fs *flag.FlagSet) *csvFlags {
+	flags := &csvFlags{}
+	flags.commits = fs.String("csv-commits", "", "Also write a row per analyzed commit with its line stats to this CSV file")
+	flags.files = fs.String("csv-files", "", "Also write a row per file changed by an analyzed commit, with its line stats and how it was changed, to this CSV file")
+
+	return flags
+}
+
+// enabled reports whether any CSV was asked for, they need the commits
+// collected.
+func (f *csvFlags) enabled() bool {
+	return *f.commits != "" || *f.files != ""
+}
+
+// csvCommitsHeader and csvFilesHeader are the columns of --csv-commits and
+// --csv-files. Line stats are empty when they weren't computed.
+var (
+	csvCommitsHeader = []string{"hash", "repo", "date", "author_name", "author_email", "subject", "is_merge", "additions", "deletions", "files_changed"}
+	csvFilesHeader   = []string{"hash", "repo", "path", "old_path", "change", "additions", "deletions"}
+)
+
+func (f *csvFlags) validate(fast bool) error {
+	if *f.files != "" && fast {
+		return usagef("Unable to combine --csv-files with --fast, the changed files come with the line stats")
+	}
+
+	return nil
+}
+
+// write writes the CSV files that were asked for, both in a single pass over
+// the listed commits and the file stats the analysis kept with them.
+func (f *csvFlags) write(summary *wrapped.Summary) error {
+	if !f.enabled() {
+		return nil
+	}
+
+	commits, closeCommits, err := createCSV(*f.commits, csvCommitsHeader)
+	if err != nil {
+		return err
+	}
+	files, closeFiles, err := createCSV(*f.files, csvFilesHeader)
+	if err != nil {
+		closeCommits()
+		return err
+	}
+
+	rows, err := writeCSVRows(summary, commits, files)
+	err = errors.Join(err, closeCommits(), closeFiles())
+	if err != nil {
+		return fmt.Errorf("unable to export the CSV. [err=%s]", err.Error())
+	}
+
+	if commits != nil {
+		fmt.Fprintf(os.Stderr, "Exported %s commits to %s\n", wrapped.FormatCount(len(summary.Commits)), *f.commits)
+	}
+	if files != nil {
+		fmt.Fprintf(os.Stderr, "Exported %s changed files to %s\n", wrapped.FormatCount(rows), *f.files)
+	}
+	return nil
+}
+
+// createCSV creates the CSV file at path with its header, the RFC 4180 way
+// with CRLF line endings. Nothing is created without a path, rows written to
+// the nil writer are dropped.
+func createCSV(path string, header []string) (*csv.Writer, func() error, error) {
+	if path == "" {
+		return nil, func() error { return nil }, nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to export to %s. [err=%s]", path, err.Error())
+	}
+	writer := csv.NewWriter(file)
+	writer.UseCRLF = true
+	err = writer.Write(header)
+	closeFile := func() error {
+		writer.Flush()
+		return errors.Join(writer.Error(), file.Close())
+	}
+	if err != nil {
+		closeFile()
+		return nil, nil, fmt.Errorf("unable to export to %s. [err=%s]", path, err.Error())
+	}
+
+	return writer, closeFile, nil
+}
+
+// writeCSVRows writes the row of every listed commit to commits and the rows
+// of the files it changed to files, skipping the nil writer. It returns the
+// number of file rows.
+func writeCSVRows(summary *wrapped.Summary, commits *csv.Writer, files *csv.Writer) (int, error) {
+	rows := 0
+	for i := range summary.Commits {
+		commit := &summary.Commits[i]
+		repo, err := repoPath(summary, commit.Repo)
+		if err != nil {
+			return rows, err
+		}
+
+		if commits != nil {
+			additions, deletions, changed := "", "", ""
+			if commit.HasLineStats {
+				additions = strconv.FormatInt(commit.Additions, 10)
+				deletions = strconv.FormatInt(commit.Deletions, 10)
+				changed = strconv.Itoa(len(commit.Files))
+			}
+			err = commits.Write([]string{
+				commit.Hash, repo, commit.When.Format(time.RFC3339), commit.Name, commit.Email, commit.Subject,
+				strconv.FormatBool(commit.Merge), additions, deletions, changed,
+			})
+			if err != nil {
+				return rows, err
+			}
+		}
+
+		if files != nil {
+			for _, file := range commit.Files {
+				path, oldPath := file.Path, ""
+				if from, to, renamed := strings.Cut(file.Path, " => "); renamed {
+					path, oldPath = to, from
+				}
+				err = files.Write([]string{
+					commit.Hash, repo, path, oldPath, file.Change,
+					strconv.FormatInt(file.Additions, 10), strconv.FormatInt(file.Deletions, 10),
+				})
+				if err != nil {
+					return rows, err
+				}
+				rows++
+			}
+		}
+	}
+
+	return rows, nil
+}
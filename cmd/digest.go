@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"git-wrapped/pkg/wrapped"
+	"strings"
+	"time"
+)
+
+// digestFlags are the flags turning the annual wrapped into the digest of a
+// week or a month.
+type digestFlags struct {
+	period *string
+	date   *string
+}
+
+func addDigestFlags(fs *flag.FlagSet) *digestFlags {
+	flags := &digestFlags{}
+	flags.period = fs.String("period", wrapped.PeriodYear, "The period of the report: "+strings.Join(wrapped.Periods(), ", ")+". A week or a month makes a compact digest of its commits, lines, busiest day and top files compared to the one before, for the most recent complete one or the one containing --date. Weeks start on Monday")
+	flags.date = fs.String("date", "", "A day of the week or month --period digests, like 2024-03-14. Default=the most recent complete one")
+
+	return flags
+}
+
+// enabled reports whether a digest is asked for instead of the wrapped.
+func (f *digestFlags) enabled() bool {
+	return *f.period != wrapped.PeriodYear
+}
+
+// validate checks the --period and the flags it can't be combined with.
+func (f *digestFlags) validate(fs *flag.FlagSet, format string) error {
+	if !isPeriod(*f.period) {
+		return usagef("Unknown --period %q, expected one of %s", *f.period, strings.Join(wrapped.Periods(), ", "))
+	}
+	if !f.enabled() {
+		if *f.date != "" {
+			return usagef("Forgot to set --period to week or month, the period --date picks")
+		}
+		return nil
+	}
+	if isSet(fs, "year") {
+		return usagef("Unable to combine --year with --period %s, set --date to a day of the %s instead", *f.period, *f.period)
+	}
+	for _, known := range wrapped.DigestFormats() {
+		if format == known {
+			return nil
+		}
+	}
+
+	return usagef("Unknown --format %q for --period %s, expected one of %s", format, *f.period, strings.Join(wrapped.DigestFormats(), ", "))
+}
+
+// window returns the window of the digest in the location: the period
+// containing --date, or the most recent one ended by now.
+func (f *digestFlags) window(location *time.Location, now time.Time) (wrapped.AnalysisWindow, error) {
+	if *f.date == "" {
+		return wrapped.LastCompleteWindow(*f.period, now, location)
+	}
+
+	day, err := time.ParseInLocation(time.DateOnly, *f.date, location)
+	if err != nil {
+		return wrapped.AnalysisWindow{}, usagef("Invalid --date %q, expected a day like 2024-03-14. [err=%s]", *f.date, err.Error())
+	}
+
+	return wrapped.NewPeriodWindow(*f.period, day, location)
+}
+
+// isPeriod reports whether the --period is known.
+func isPeriod(period string) bool {
+	for _, known := range wrapped.Periods() {
+		if period == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getDigest analyzes the period of the selection and the one before, and
+// writes their digest. A period before without commits compares as empty.
+func getDigest(ctx context.Context, paths []string, selection wrapped.Selection, opts wrapped.Options, report reportOptions) error {
+	current, err := analyzeSelection(ctx, paths, selection, opts)
+	if err != nil {
+		return err
+	}
+	before := selection
+	before.Window = selection.Window.Previous(report.period)
+	previous, err := analyzeSelection(ctx, paths, before, opts)
+	noCommits := &noCommitsError{}
+	if errors.As(err, &noCommits) {
+		previous, err = wrapped.NewSummary(opts.Fast, before.Window), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, summary := range []*wrapped.Summary{current, previous} {
+		summary.Generator = generator()
+		summary.Stats = report.stats
+		if report.anonymizer != nil {
+			report.anonymizer.Apply(summary)
+		}
+	}
+	stopRender := opts.Timings.Start(wrapped.PhaseRender)
+	output, err := wrapped.RenderDigest(report.format, wrapped.Digest{Period: report.period, Current: current, Previous: previous}, report.render)
+	stopRender()
+	if err != nil {
+		return err
+	}
+	err = report.output.write(output)
+	if err != nil {
+		return err
+	}
+
+	warnStatsErrors(current.StatsErrors)
+	warnCorruptCommits(current.CorruptSkipped)
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"os"
+)
+
+// dotFlags are the flags writing the co-change graph.
+type dotFlags struct {
+	path       *string
+	files      *int
+	minCommits *int
+}
+
+func addDOTFlags(fs *flag.FlagSet) *dotFlags {
+	flags := &dotFlags{}
+	flags.path = fs.String("dot", "", "Also write the graph of the files you changed the most, connected when changed in the same commits, to this graphviz DOT file, e.g. for dot -Tsvg")
+	flags.files = fs.Int("dot-files", 30, "How many of the files changed in the most commits make up the --dot graph")
+	flags.minCommits = fs.Int("dot-min-commits", 2, "How many commits have to change two files together for the --dot graph to connect them")
+
+	return flags
+}
+
+// enabled reports whether the graph was asked for, it needs the commits
+// collected.
+func (f *dotFlags) enabled() bool {
+	return *f.path != ""
+}
+
+func (f *dotFlags) validate(fs *flag.FlagSet, fast bool) error {
+	if !f.enabled() {
+		if isSet(fs, "dot-files") || isSet(fs, "dot-min-commits") {
+			return usagef("Forgot to set --dot for --dot-files or --dot-min-commits")
+		}
+		return nil
+	}
+	if fast {
+		return usagef("Unable to combine --dot with --fast, the files changed together need the line stats")
+	}
+	if *f.files < 1 {
+		return usagef("Invalid --dot-files %d, expected at least 1", *f.files)
+	}
+	if *f.minCommits < 1 {
+		return usagef("Invalid --dot-min-commits %d, expected at least 1", *f.minCommits)
+	}
+
+	return nil
+}
+
+// find adds the co-change graph to the summary.
+func (f *dotFlags) find(summary *wrapped.Summary) error {
+	if !f.enabled() {
+		return nil
+	}
+
+	return summary.FindCoChanges(*f.files, *f.minCommits)
+}
+
+// write writes the co-change graph when --dot is set.
+func (f *dotFlags) write(summary *wrapped.Summary) error {
+	if !f.enabled() || summary.CoChanges == nil {
+		return nil
+	}
+
+	err := os.WriteFile(*f.path, []byte(summary.CoChanges.DOT()), 0o644)
+	if err != nil {
+		return fmt.Errorf("unable to write the graph to %s. [err=%s]", *f.path, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// smtpPasswordEnv is the only way to pass the SMTP password, so it never
+// shows up in the process list or the shell history.
+const smtpPasswordEnv = "GIT_WRAPPED_SMTP_PASSWORD"
+
+// TLS modes of --smtp-tls.
+const (
+	smtpStartTLS = "starttls"
+	smtpImplicit = "implicit"
+	smtpNoTLS    = "none"
+)
+
+var sendEmailCommand = &command{
+	name:        "send-email",
+	summary:     "Email the wrapped of an author",
+	description: "Email the wrapped of an author through an SMTP server. The password is read from the " + smtpPasswordEnv + " environment variable.",
+	args:        "[path...]",
+	examples: []string{
+		"git-wrapped send-email --emails me@example.com --to me@example.com --from wrapped@example.com --smtp-host smtp.example.com",
+		"git-wrapped send-email --emails me@example.com --to me@example.com --from wrapped@example.com --html --dry-run",
+	},
+	setup: setupSendEmail,
+}
+
+func setupSendEmail(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	selectionFlags := addSelectionFlags(fs, true)
+	analysisFlags := addAnalysisFlags(fs)
+	topFlags := addTopFlags(fs, map[string]string{wrapped.SectionFiles: "most changed files"})
+	configFlags := addConfigFlags(fs)
+	logFlags := addLogFlags(fs)
+	smtpFlags := &smtpFlags{}
+	smtpFlags.host = fs.String("smtp-host", "", "The SMTP server the email is sent through")
+	smtpFlags.port = fs.Int("smtp-port", 587, "The port of the SMTP server, usually 587 with starttls and 465 with implicit TLS")
+	smtpFlags.user = fs.String("smtp-user", "", "The user to authenticate as, with the password in "+smtpPasswordEnv+". Default=no authentication")
+	smtpFlags.tls = fs.String("smtp-tls", smtpStartTLS, "How the connection is encrypted: starttls, implicit or none")
+	var to stringsFlag
+	fs.Var(&to, "to", "The address the email is sent to. Can be repeated")
+	fromFlag := fs.String("from", "", "The address the email is sent from")
+	subjectFlag := fs.String("subject", "", "The subject of the email. Default=Your git-wrapped <year>")
+	htmlFlag := fs.Bool("html", false, "Add the HTML report next to the plain text one")
+	dryRunFlag := fs.Bool("dry-run", false, "Print the composed email instead of sending it")
+
+	return func(ctx context.Context, args []string) error {
+		config, err := configFlags.load(fs)
+		if err != nil {
+			return err
+		}
+		if *configFlags.printConfig {
+			return config.print(fs)
+		}
+
+		paths := repoPaths(selectionFlags.paths, args)
+		selection, err := selectionFlags.selection()
+		if err != nil {
+			return err
+		}
+		opts, err := analysisFlags.options()
+		if err != nil {
+			return err
+		}
+		opts.Overrides = config.overrides(paths, opts.Filter)
+		logFlags.apply(&opts)
+		logConfiguration(opts.Logger, paths, selection, opts)
+		renderOpts, err := topFlags.options()
+		if err != nil {
+			return err
+		}
+
+		from, err := mail.ParseAddress(*fromFlag)
+		if err != nil {
+			return usagef("Invalid --from %q, expected an email address", *fromFlag)
+		}
+		recipients := make([]*mail.Address, 0, len(to))
+		for _, address := range to {
+			recipient, err := mail.ParseAddress(address)
+			if err != nil {
+				return usagef("Invalid --to %q, expected an email address", address)
+			}
+			recipients = append(recipients, recipient)
+		}
+		if len(recipients) == 0 {
+			return usagef("Forgot to specify the --to address the email is sent to")
+		}
+		if !*dryRunFlag {
+			if err := smtpFlags.validate(); err != nil {
+				return err
+			}
+		}
+		subject := *subjectFlag
+		if subject == "" {
+			subject = fmt.Sprintf("Your git-wrapped %d", *selectionFlags.year)
+		}
+
+		ctx, cancel := analysisFlags.withTimeout(ctx)
+		defer cancel()
+
+		return analysisFlags.runProfiled(opts, func() error {
+			summary, err := analyzeSelection(ctx, paths, selection, opts)
+			if err != nil {
+				return err
+			}
+			summary.Generator = generator()
+
+			message, err := composeEmail(summary, renderOpts, from, recipients, subject, *htmlFlag)
+			if err != nil {
+				return err
+			}
+			if *dryRunFlag {
+				_, err = os.Stdout.Write(message)
+				return err
+			}
+
+			err = smtpFlags.send(from, recipients, message)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Sent the wrapped to %s\n", strings.Join(to, ", "))
+			return nil
+		})
+	}
+}
+
+// composeEmail builds the email carrying the report, as plain text or as
+// alternative plain text and HTML parts.
+func composeEmail(summary *wrapped.Summary, renderOpts wrapped.RenderOptions, from *mail.Address, to []*mail.Address, subject string, withHTML bool) ([]byte, error) {
+	text, err := wrapped.Render("text", summary, renderOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := make([]string, 0, len(to))
+	for _, address := range to {
+		recipients = append(recipients, address.String())
+	}
+
+	message := bytes.Buffer{}
+	header := func(name string, value string) {
+		fmt.Fprintf(&message, "%s: %s\r\n", name, value)
+	}
+	header("From", from.String())
+	header("To", strings.Join(recipients, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+
+	if !withHTML {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		message.WriteString("\r\n")
+		err = writeQuotedPrintable(&message, text)
+		return message.Bytes(), err
+	}
+
+	page, err := wrapped.Render("html", summary, renderOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := multipart.NewWriter(&message)
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	message.WriteString("\r\n")
+	for _, part := range []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", page},
+	} {
+		writer, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = writeQuotedPrintable(writer, part.body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = parts.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return message.Bytes(), nil
+}
+
+// writeQuotedPrintable writes the body with CRLF line endings, as emails
+// need them.
+func writeQuotedPrintable(out io.Writer, body string) error {
+	writer := quotedprintable.NewWriter(out)
+	_, err := writer.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n") + "\r\n"))
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// smtpFlags are the flags choosing the SMTP server.
+type smtpFlags struct {
+	host *string
+	port *int
+	user *string
+	tls  *string
+}
+
+func (f *smtpFlags) validate() error {
+	if *f.host == "" {
+		return usagef("Forgot to specify the --smtp-host the email is sent through")
+	}
+	switch *f.tls {
+	case smtpStartTLS, smtpImplicit, smtpNoTLS:
+	default:
+		return usagef("Unknown --smtp-tls %q, expected %s, %s or %s", *f.tls, smtpStartTLS, smtpImplicit, smtpNoTLS)
+	}
+	if *f.user != "" && os.Getenv(smtpPasswordEnv) == "" {
+		return usagef("Forgot to set %s for --smtp-user %s", smtpPasswordEnv, *f.user)
+	}
+
+	return nil
+}
+
+// send delivers the message through the SMTP server.
+func (f *smtpFlags) send(from *mail.Address, to []*mail.Address, message []byte) error {
+	addr := net.JoinHostPort(*f.host, strconv.Itoa(*f.port))
+	fail := func(err error) error {
+		return &deliveryError{action: "send the email through " + addr, err: err}
+	}
+	tlsConfig := &tls.Config{ServerName: *f.host}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	if *f.tls == smtpImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	client, err := smtp.NewClient(conn, *f.host)
+	if err != nil {
+		conn.Close()
+		return fail(err)
+	}
+	defer client.Close()
+
+	if *f.tls == smtpStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fail(fmt.Errorf("the server doesn't support STARTTLS, pass --smtp-tls %s or %s", smtpImplicit, smtpNoTLS))
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fail(err)
+		}
+	}
+	if *f.user != "" {
+		err = client.Auth(smtp.PlainAuth("", *f.user, os.Getenv(smtpPasswordEnv), *f.host))
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return fail(err)
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient.Address); err != nil {
+			return fail(err)
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return fail(err)
+	}
+	_, err = writer.Write(message)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// envPrefix is the prefix of the environment variables mirroring the flags.
+const envPrefix = "GIT_WRAPPED_"
+
+// envName returns the environment variable mirroring a flag, e.g.
+// GIT_WRAPPED_EXCLUDE_PATH for --exclude-path.
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// hasEnv reports whether the flag is mirrored by an environment variable.
+// Single letter shorthands share the variable of their long form.
+func hasEnv(f *flag.Flag) bool {
+	return f.Name != "no-env" && len(f.Name) > 1
+}
+
+// envPlaceholder describes the value of the flag's environment variable in
+// the usage.
+func envPlaceholder(f *flag.Flag) string {
+	if isRepeatable(f) {
+		return "a,b"
+	}
+	if isBoolFlag(f) {
+		return "true"
+	}
+
+	name, _ := flag.UnquoteUsage(f)
+	if name == "" {
+		return "value"
+	}
+
+	return name
+}
+
+// isRepeatable reports whether the flag collects every value it's passed.
+func isRepeatable(f *flag.Flag) bool {
+	switch f.Value.(type) {
+	case *stringsFlag, pluginFlag:
+		return true
+	}
+
+	return false
+}
+
+// isBoolFlag reports whether the flag is a switch without a value.
+func isBoolFlag(f *flag.Flag) bool {
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return false
+	}
+	_, ok = getter.Get().(bool)
+
+	return ok
+}
+
+// applyEnv sets every flag that wasn't passed from its environment variable.
+// Repeatable flags take a comma separated list. Flags set this way count as
+// passed, so they take precedence over the config files.
+func applyEnv(fs *flag.FlagSet, lookupEnv func(string) (string, bool)) error {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || set[f.Name] || !hasEnv(f) {
+			return
+		}
+		value, ok := lookupEnv(envName(f.Name))
+		if !ok {
+			return
+		}
+
+		values := []string{value}
+		if isRepeatable(f) {
+			values = strings.Split(value, ",")
+		}
+		for _, value := range values {
+			setErr := fs.Set(f.Name, strings.TrimSpace(value))
+			if setErr != nil {
+				err = fmt.Errorf("Invalid %s %q. [err=%s]", envName(f.Name), value, setErr.Error())
+				return
+			}
+		}
+	})
+
+	return err
+}
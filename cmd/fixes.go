@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"flag"
+	"git-wrapped/pkg/wrapped"
+	"regexp"
+	"strings"
+)
+
+// fixFlags are the flags telling the fix commits from the feature ones.
+type fixFlags struct {
+	fixes           *bool
+	fixKeywords     stringsFlag
+	featureKeywords stringsFlag
+	fixPattern      *regexp.Regexp
+	featurePattern  *regexp.Regexp
+}
+
+func addFixFlags(fs *flag.FlagSet) *fixFlags {
+	flags := &fixFlags{}
+	flags.fixes = fs.Bool("fixes", false, "Report the ratio of fix commits to feature commits, the month with the most fixes and the most fixed file, classifying the commits by their conventional commit type or the keywords of their subject")
+	fs.Var(&flags.fixKeywords, "fix-keyword", "A word marking the subjects of fixes, matched as a whole word ignoring case. Repeat it for several, e.g. for other languages. Default="+strings.Join(wrapped.DefaultFixKeywords, ","))
+	fs.Var(&flags.featureKeywords, "feature-keyword", "A word marking the subjects of features, like --fix-keyword. Default="+strings.Join(wrapped.DefaultFeatureKeywords, ","))
+
+	return flags
+}
+
+// enabled reports whether fixes were asked for, they need the commits
+// collected.
+func (f *fixFlags) enabled() bool {
+	return *f.fixes
+}
+
+func (f *fixFlags) validate() error {
+	if !f.enabled() {
+		if len(f.fixKeywords) > 0 || len(f.featureKeywords) > 0 {
+			return usagef("Forgot to set --fixes for --fix-keyword or --feature-keyword")
+		}
+		return nil
+	}
+
+	var err error
+	f.fixPattern, err = wrapped.KeywordPattern(keywordsOr(f.fixKeywords, wrapped.DefaultFixKeywords))
+	if err != nil {
+		return usagef("Invalid --fix-keyword. [err=%s]", err.Error())
+	}
+	f.featurePattern, err = wrapped.KeywordPattern(keywordsOr(f.featureKeywords, wrapped.DefaultFeatureKeywords))
+	if err != nil {
+		return usagef("Invalid --feature-keyword. [err=%s]", err.Error())
+	}
+
+	return nil
+}
+
+// keywordsOr returns the keywords, or the defaults when none were set.
+func keywordsOr(keywords []string, defaults []string) []string {
+	if len(keywords) == 0 {
+		return defaults
+	}
+
+	return keywords
+}
+
+// count adds the fixes to the summary.
+func (f *fixFlags) count(summary *wrapped.Summary) error {
+	if !f.enabled() {
+		return nil
+	}
+
+	return summary.CountFixes(f.fixPattern, f.featurePattern)
+}
@@ -0,0 +1,428 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"io"
+	"math"
+	"os"
+	"path"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stringsFlag is a flag that can be repeated, collecting every value.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// isSet reports whether the flag was passed, set from the environment or from
+// a config file, rather than left at its default.
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
+
+// defaultYear is the year analyzed unless another one is asked for.
+const defaultYear = 2023
+
+// selectionFlags are the flags choosing which commits are analyzed.
+type selectionFlags struct {
+	paths              stringsFlag
+	year               *int
+	emails             *string
+	email              stringsFlag
+	identitiesFile     *string
+	tz                 *string
+	includeUnreachable *bool
+	excludeMerges      *bool
+	excludeEmails      *string
+}
+
+// addSelectionFlags registers the selection flags, leaving out --emails for
+// commands looking at every author.
+func addSelectionFlags(fs *flag.FlagSet, withEmails bool) *selectionFlags {
+	flags := &selectionFlags{}
+	fs.Var(&flags.paths, "path", "The path to a repository to be analyzed, repeat it to combine several repositories. Paths can also be passed as arguments. Default=the current directory")
+	flags.year = fs.Int("year", defaultYear, "The year for which the wrapped should be generated. Default=2023")
+	if withEmails {
+		flags.emails = fs.String("emails", "", "A comma separated list of emails to identify the author")
+		fs.Var(&flags.email, "email", "An email identifying the author, repeat it for every email the author commits with")
+		flags.identitiesFile = fs.String("identities-file", "", "A file listing an email identifying the author per line, # starts a comment")
+	}
+	flags.tz = fs.String("tz", "Local", "The time zone commit times are normalized into, e.g. UTC or Europe/Berlin")
+	flags.includeUnreachable = fs.Bool("include-unreachable", false, "Also count commits no branch or tag can reach, like rebased away or amended commits")
+	flags.excludeMerges = fs.Bool("exclude-merges", false, "Leave merge commits out of the analysis entirely")
+	flags.excludeEmails = fs.String("exclude-emails", "", "A comma separated list of emails whose commits are left out, * matching any characters like in ci@example.com,*+bot@*. An excluded email is left out even when it's one of the --emails")
+
+	return flags
+}
+
+// selection validates the flags and returns the selection of the year.
+func (f *selectionFlags) selection() (wrapped.Selection, error) {
+	var emails map[string]bool
+	if f.emails != nil {
+		all := append(strings.Split(*f.emails, ","), f.email...)
+		if *f.identitiesFile != "" {
+			listed, err := readIdentitiesFile(*f.identitiesFile)
+			if err != nil {
+				return wrapped.Selection{}, err
+			}
+			all = append(all, listed...)
+		}
+
+		emails = emailSet(all)
+		if len(emails) == 0 {
+			return wrapped.Selection{}, usagef("Forgot to specify a valid email address of the author for which the wrapped will be created")
+		}
+	}
+
+	return f.selectionOf(emails)
+}
+
+// emailsMissing reports whether none of the email flags is set.
+func (f *selectionFlags) emailsMissing() bool {
+	return f.emails != nil && *f.emails == "" && len(f.email) == 0 && *f.identitiesFile == ""
+}
+
+// pickSelection returns the selection of the year for the identities picked
+// among the ones committing in the repositories, read from in with the
+// choices written to out.
+func (f *selectionFlags) pickSelection(ctx context.Context, paths []string, in io.Reader, out io.Writer) (wrapped.Selection, error) {
+	selection, err := f.selectionOf(nil)
+	if err != nil {
+		return wrapped.Selection{}, err
+	}
+
+	identities, err := wrapped.CountIdentities(ctx, paths, selection)
+	if err != nil {
+		return wrapped.Selection{}, err
+	}
+	if len(identities) == 0 {
+		return wrapped.Selection{}, usagef("Forgot to specify the emails of the author, and nobody committed in %d to pick them from", *f.year)
+	}
+
+	selection.Authors, err = pickIdentities(in, out, identities, *f.year)
+	if err != nil {
+		return wrapped.Selection{}, err
+	}
+	// The picked emails stand in for --emails, like in the command line
+	// reproducing the run.
+	emails := make([]string, 0, len(selection.Authors))
+	for email := range selection.Authors {
+		emails = append(emails, email)
+	}
+	sort.Strings(emails)
+	*f.emails = strings.Join(emails, ",")
+
+	return selection, nil
+}
+
+// selectionOf returns the selection of the year for the authors, ignoring
+// the email flags but --exclude-emails.
+func (f *selectionFlags) selectionOf(emails map[string]bool) (wrapped.Selection, error) {
+	location, err := time.LoadLocation(*f.tz)
+	if err != nil {
+		return wrapped.Selection{}, usagef("Unknown --tz time zone %q. [err=%s]", *f.tz, err.Error())
+	}
+
+	excluded := make([]string, 0)
+	for pattern := range emailSet(strings.Split(*f.excludeEmails, ",")) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return wrapped.Selection{}, usagef("Invalid --exclude-emails %q. [err=%s]", pattern, err.Error())
+		}
+		excluded = append(excluded, pattern)
+	}
+	sort.Strings(excluded)
+
+	return wrapped.Selection{
+		Window:             wrapped.NewYearWindow(*f.year, location),
+		Authors:            emails,
+		IncludeUnreachable: *f.includeUnreachable,
+		ExcludeMerges:      *f.excludeMerges,
+		ExcludedAuthors:    excluded,
+	}, nil
+}
+
+// repoPaths returns the repositories passed with --path and as arguments, or
+// the current directory when there are none. Paths inside a repository are
+// resolved to its root once it's opened.
+func repoPaths(paths []string, args []string) []string {
+	all := append(append([]string{}, paths...), args...)
+	if len(all) == 0 {
+		return []string{"."}
+	}
+
+	return all
+}
+
+// splitRevRange returns the arguments but the revision range among them, like
+// v2.0..v3.0, and the range, nil when there's none. An argument with .. is
+// only taken for a path when it exists.
+func splitRevRange(args []string) ([]string, *wrapped.RevRange, error) {
+	var revisions *wrapped.RevRange
+	paths := make([]string, 0, len(args))
+	for _, arg := range args {
+		if _, err := os.Stat(arg); err == nil {
+			paths = append(paths, arg)
+			continue
+		}
+		parsed, err := wrapped.ParseRevRange(arg)
+		if err != nil {
+			return nil, nil, usagef("Invalid revision range %q. [err=%s]", arg, err.Error())
+		}
+		if parsed == nil {
+			paths = append(paths, arg)
+			continue
+		}
+		if revisions != nil {
+			return nil, nil, usagef("Unable to walk both %s and %s, expected a single revision range", revisions, parsed)
+		}
+		revisions = parsed
+	}
+
+	return paths, revisions, nil
+}
+
+// emailSet returns the set of the trimmed emails, leaving out empty ones.
+func emailSet(emails []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, email := range emails {
+		email = strings.TrimSpace(email)
+		if email != "" {
+			set[email] = true
+		}
+	}
+
+	return set
+}
+
+// readIdentitiesFile returns the emails listed in an --identities-file, one
+// per line. Everything after a # is a comment.
+func readIdentitiesFile(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, usagef("Unable to read the --identities-file. [err=%s]", err.Error())
+	}
+
+	emails := make([]string, 0)
+	for _, line := range strings.Split(string(content), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		emails = append(emails, strings.TrimSpace(line))
+	}
+
+	return emails, nil
+}
+
+// analysisFlags are the flags controlling how the stats are computed.
+type analysisFlags struct {
+	jobs             *int
+	maxMemory        *string
+	objectCacheMB    *int
+	cacheDir         *string
+	noCache          *bool
+	quiet            *bool
+	fast             *bool
+	sample           *int
+	sampleSeed       *string
+	excludePaths     stringsFlag
+	excludeLockfiles *bool
+	strict           *bool
+	mergeStats       *string
+	maxChangedFiles  *int
+	maxBlobSize      *int64
+	commitTimeout    *time.Duration
+	timeout          *time.Duration
+	profile          *string
+	profileMem       *string
+	timings          *bool
+}
+
+func addAnalysisFlags(fs *flag.FlagSet) *analysisFlags {
+	flags := &analysisFlags{}
+	flags.jobs = fs.Int("jobs", 0, "The number of workers used to compute commit stats, 1 computing them one commit after the other and 0 a worker per CPU. Default=a worker per CPU")
+	flags.maxMemory = fs.String("max-memory", "", "Bound the files diffed at once by their size, e.g. 512MB or 2GB in units of 1024, the workers waiting for the diffs in flight to finish instead of going over it. Default=no bound")
+	flags.objectCacheMB = fs.Int("object-cache-mb", 256, "The MiB of the object cache every handle on a repository shares, keeping the trees the workers read for the others instead of rereading them. 0 leaves every handle go-git's default cache of 96 MiB, to compare")
+	flags.cacheDir = fs.String("cache-dir", "", "The directory used to cache commit stats between runs. Default=<user cache dir>/git-wrapped")
+	flags.noCache = fs.Bool("no-cache", false, "Compute every commit's stats without reading or writing the cache")
+	flags.quiet = fs.Bool("quiet", false, "Don't report progress on stderr")
+	flags.fast = fs.Bool("fast", false, "Skip computing diffs, leaving line based stats out of the report")
+	flags.sample = fs.Int("sample", 0, "Compute the line stats of this many of the matched commits only, evenly spaced in time, and estimate the others' from them for a quick preview. The commit counts stay exact. Default=every commit")
+	flags.sampleSeed = fs.String("sample-seed", "", "Pick the --sample commits at random from this seed instead, the same seed picking the same commits")
+	fs.Var(&flags.excludePaths, "exclude-path", "A glob matching paths to leave out of the line stats, e.g. vendor or *.pb.go. Can be repeated")
+	flags.excludeLockfiles = fs.Bool("exclude-lockfiles", true, "Leave dependency lock files like go.sum and package-lock.json out of the line stats")
+	flags.strict = fs.Bool("strict", false, "Abort when a commit can't be read or its line stats can't be computed instead of skipping it")
+	flags.mergeStats = fs.String("merge-stats", wrapped.MergeStatsNone, "How merge commits count towards line stats: none, they're only counted as merges, or first-parent, their diff against the first parent like git show")
+	flags.maxChangedFiles = fs.Int("max-changed-files", 0, "Leave the commits changing more files than this out of the line stats, counting them as oversized commits instead. Default=no limit")
+	flags.maxBlobSize = fs.Int64("max-blob-size", 0, "Leave the commits changing a file larger than this many bytes out of the line stats, counting them as oversized commits instead. Default=no limit")
+	flags.commitTimeout = fs.Duration("commit-timeout", 0, "Leave the commits whose line stats take longer than this duration to compute, e.g. 30s, out of the line stats, counting them as oversized commits instead. Default=no timeout")
+	flags.timeout = fs.Duration("timeout", 0, "Cancel the analysis if it runs longer than this duration, e.g. 10m. Default=no timeout")
+	flags.profile = fs.String("profile", "", "Write a CPU profile to this file")
+	flags.profileMem = fs.String("profile-mem", "", "Write a heap profile to this file once the analysis is done")
+	flags.timings = fs.Bool("timings", false, "Print how long each phase of the run took to stderr")
+
+	return flags
+}
+
+// options validates the flags and returns the analysis options.
+func (f *analysisFlags) options() (wrapped.Options, error) {
+	if *f.mergeStats != wrapped.MergeStatsNone && *f.mergeStats != wrapped.MergeStatsFirstParent {
+		return wrapped.Options{}, usagef("Unknown --merge-stats %q, expected %s or %s", *f.mergeStats, wrapped.MergeStatsNone, wrapped.MergeStatsFirstParent)
+	}
+	if *f.sample < 0 {
+		return wrapped.Options{}, usagef("Invalid --sample %d, expected a number of commits", *f.sample)
+	}
+	if *f.sample > 0 && *f.fast {
+		return wrapped.Options{}, usagef("Unable to combine --sample with --fast, which computes no line stats to sample")
+	}
+	if *f.sampleSeed != "" && *f.sample == 0 {
+		return wrapped.Options{}, usagef("Forgot to set --sample, the number of commits --sample-seed picks")
+	}
+	if *f.jobs < 0 {
+		return wrapped.Options{}, usagef("Invalid --jobs %d, expected a number of workers or 0 for one per CPU", *f.jobs)
+	}
+	maxMemory, err := parseByteSize(*f.maxMemory)
+	if err != nil {
+		return wrapped.Options{}, usagef("Invalid --max-memory %q, expected a size like 512MB or 2GB. [err=%s]", *f.maxMemory, err.Error())
+	}
+	if *f.objectCacheMB < 0 || *f.objectCacheMB > math.MaxInt64>>20 {
+		return wrapped.Options{}, usagef("Invalid --object-cache-mb %d, expected a number of MiB", *f.objectCacheMB)
+	}
+	if *f.maxChangedFiles < 0 {
+		return wrapped.Options{}, usagef("Invalid --max-changed-files %d, expected a number of files", *f.maxChangedFiles)
+	}
+	if *f.maxBlobSize < 0 {
+		return wrapped.Options{}, usagef("Invalid --max-blob-size %d, expected a number of bytes", *f.maxBlobSize)
+	}
+	if *f.commitTimeout < 0 {
+		return wrapped.Options{}, usagef("Invalid --commit-timeout %s, expected a positive duration", *f.commitTimeout)
+	}
+
+	opts := wrapped.Options{
+		Jobs:             *f.jobs,
+		MaxMemory:        maxMemory,
+		ObjectCacheSize:  int64(*f.objectCacheMB) << 20,
+		Fast:             *f.fast,
+		CountUnreachable: true,
+		Filter: wrapped.PathFilter{
+			Excluded:         f.excludePaths,
+			ExcludeLockfiles: *f.excludeLockfiles,
+		},
+		Limits: wrapped.CommitLimits{
+			MaxChangedFiles: *f.maxChangedFiles,
+			MaxBlobSize:     *f.maxBlobSize,
+			Timeout:         *f.commitTimeout,
+		},
+		Quiet:      *f.quiet,
+		Timings:    wrapped.NewTimings(*f.timings),
+		Strict:     *f.strict,
+		MergeStats: *f.mergeStats,
+		Sample:     *f.sample,
+		SampleSeed: *f.sampleSeed,
+	}
+
+	if opts.Jobs == 0 {
+		opts.Jobs = runtime.NumCPU()
+	}
+
+	if !*f.noCache {
+		cacheDir, err := f.resolveCacheDir()
+		if err != nil {
+			return wrapped.Options{}, err
+		}
+		opts.CacheDir = cacheDir
+	}
+
+	return opts, nil
+}
+
+// byteUnits are the units of the sizes parseByteSize reads, in powers of 1024.
+var byteUnits = map[string]int{"": 0, "B": 0, "K": 1, "KB": 1, "KIB": 1, "M": 2, "MB": 2, "MIB": 2, "G": 3, "GB": 3, "GIB": 3, "T": 4, "TB": 4, "TIB": 4}
+
+// parseByteSize parses a size in bytes with an optional unit, e.g. 1500, 512MB
+// or 2GiB, 0 when it's empty.
+func parseByteSize(size string) (int64, error) {
+	size = strings.TrimSpace(size)
+	if size == "" {
+		return 0, nil
+	}
+
+	digits := strings.TrimRightFunc(size, func(char rune) bool {
+		return char < '0' || char > '9'
+	})
+	power, ok := byteUnits[strings.ToUpper(strings.TrimSpace(size[len(digits):]))]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", strings.TrimSpace(size[len(digits):]))
+	}
+	value, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if value < 0 {
+		return 0, errors.New("the size is negative")
+	}
+	for i := 0; i < power; i++ {
+		if value > math.MaxInt64/1024 {
+			return 0, errors.New("the size is too large")
+		}
+		value *= 1024
+	}
+
+	return value, nil
+}
+
+// resolveCacheDir returns --cache-dir, or the default cache directory.
+func (f *analysisFlags) resolveCacheDir() (string, error) {
+	if *f.cacheDir != "" {
+		return *f.cacheDir, nil
+	}
+
+	dir, err := wrapped.DefaultCacheDir()
+	if err != nil {
+		return "", usagef("Unable to find a cache directory, specify one with --cache-dir or pass --no-cache. [err=%s]", err.Error())
+	}
+
+	return dir, nil
+}
+
+// withTimeout applies --timeout to the context.
+func (f *analysisFlags) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if *f.timeout > 0 {
+		return context.WithTimeout(ctx, *f.timeout)
+	}
+
+	return context.WithCancel(ctx)
+}
+
+// runProfiled runs fn with the profiles and timings the flags asked for.
+func (f *analysisFlags) runProfiled(opts wrapped.Options, fn func() error) error {
+	stopProfiling, err := startProfiling(*f.profile, *f.profileMem)
+	if err != nil {
+		return usagef("Unable to start profiling. [err=%s]", err.Error())
+	}
+
+	err = fn()
+	stopProfiling()
+	opts.Timings.Report(os.Stderr)
+
+	return err
+}
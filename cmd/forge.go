@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// apiCacheFresh is how long a cached API response is reused without
+	// asking the forge, after which it's revalidated with its ETag.
+	apiCacheFresh = time.Hour
+	// rateLimitRetries caps how many times a rate limited request is retried.
+	rateLimitRetries = 3
+	// maxRateLimitWait is the longest the forge is waited for, a longer
+	// rate limit fails the request instead.
+	maxRateLimitWait = 2 * time.Minute
+)
+
+// cachedResponse is the on-disk representation of an API response.
+type cachedResponse struct {
+	URL     string          `json:"url"`
+	ETag    string          `json:"etag,omitempty"`
+	Next    string          `json:"next,omitempty"`
+	Total   string          `json:"total,omitempty"`
+	Fetched time.Time       `json:"fetched"`
+	Body    json.RawMessage `json:"body"`
+}
+
+// forgeClient calls the API of a forge like GitHub. Responses are cached in
+// cacheDir, when it's set, so re-runs don't use up the rate limit, and rate
+// limited requests are retried once the forge allows them.
+type forgeClient struct {
+	name     string
+	token    string
+	cacheDir string
+	logger   wrapped.Logger
+	// authorize sets the token on a request, the header differs by forge.
+	authorize func(request *http.Request, token string)
+}
+
+// newForgeClient returns a client caching its responses in the api
+// directory of the stats cache, or caching nothing when the cache is off.
+func newForgeClient(name string, token string, opts wrapped.Options, authorize func(*http.Request, string)) *forgeClient {
+	client := &forgeClient{name: name, token: token, logger: opts.Logger, authorize: authorize}
+	if client.logger == nil {
+		client.logger = wrapped.NewLogger(io.Discard, wrapped.LevelQuiet)
+	}
+	if opts.CacheDir != "" {
+		client.cacheDir = filepath.Join(opts.CacheDir, "api")
+	}
+
+	return client
+}
+
+// page is a response of the API, with the URL of the next page when the
+// response is paginated.
+type page struct {
+	body []byte
+	next string
+	// total is the X-Total header GitLab sets on paginated responses.
+	total string
+}
+
+// getJSON fetches the URL and decodes its body into value.
+func (c *forgeClient) getJSON(ctx context.Context, url string, value any) error {
+	response, err := c.get(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	return c.decode(url, response.body, value)
+}
+
+// getPages fetches the URL and every page after it, handing each body to
+// visit until it returns false.
+func (c *forgeClient) getPages(ctx context.Context, url string, visit func(body []byte) (bool, error)) error {
+	for url != "" {
+		response, err := c.get(ctx, url)
+		if err != nil {
+			return err
+		}
+		more, err := visit(response.body)
+		if err != nil {
+			return c.decodeError(url, err)
+		}
+		if !more {
+			return nil
+		}
+		url = response.next
+	}
+
+	return nil
+}
+
+func (c *forgeClient) decode(url string, body []byte, value any) error {
+	err := json.Unmarshal(body, value)
+	if err != nil {
+		return c.decodeError(url, err)
+	}
+
+	return nil
+}
+
+func (c *forgeClient) decodeError(url string, err error) error {
+	return fmt.Errorf("unexpected %s response for %s. [err=%s]", c.name, redactURL(url), err.Error())
+}
+
+// get returns the response for the URL, from the cache when it's fresh or
+// still valid.
+func (c *forgeClient) get(ctx context.Context, url string) (*page, error) {
+	cached := c.readCache(url)
+	if cached != nil && time.Since(cached.Fetched) < apiCacheFresh {
+		c.logger.Logf(wrapped.LevelDebug, "%s API %s: cached", c.name, redactURL(url))
+		return &page{body: cached.Body, next: cached.Next, total: cached.Total}, nil
+	}
+
+	for attempt := 0; ; attempt++ {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("Accept", "application/json")
+		if c.token != "" {
+			c.authorize(request, c.token)
+		}
+		if cached != nil && cached.ETag != "" {
+			request.Header.Set("If-None-Match", cached.ETag)
+		}
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			return nil, fmt.Errorf("unable to call the %s API. [err=%s]", c.name, err.Error())
+		}
+		body, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the %s API response. [err=%s]", c.name, err.Error())
+		}
+		c.logger.Logf(wrapped.LevelDebug, "%s API %s: %s", c.name, redactURL(url), response.Status)
+
+		switch {
+		case response.StatusCode == http.StatusNotModified && cached != nil:
+			cached.Fetched = time.Now()
+			c.writeCache(cached)
+			return &page{body: cached.Body, next: cached.Next, total: cached.Total}, nil
+		case response.StatusCode >= 200 && response.StatusCode < 300:
+			fetched := &page{body: body, next: nextLink(response.Header.Get("Link")), total: response.Header.Get("X-Total")}
+			c.writeCache(&cachedResponse{
+				URL:     url,
+				ETag:    response.Header.Get("ETag"),
+				Next:    fetched.next,
+				Total:   fetched.total,
+				Fetched: time.Now(),
+				Body:    body,
+			})
+			return fetched, nil
+		}
+
+		wait, limited := rateLimitWait(response, time.Now())
+		if !limited {
+			return nil, c.statusError(response, body)
+		}
+		if attempt == rateLimitRetries || wait > maxRateLimitWait {
+			return nil, fmt.Errorf("the %s API rate limit is exhausted, retry in %s", c.name, wait.Round(time.Second))
+		}
+		fmt.Fprintf(os.Stderr, "The %s API rate limit is exhausted, retrying in %s\n", c.name, wait.Round(time.Second))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *forgeClient) statusError(response *http.Response, body []byte) error {
+	apiError := struct {
+		Message string `json:"message"`
+	}{}
+	_ = json.Unmarshal(body, &apiError)
+	message := apiError.Message
+	if message == "" {
+		message = strings.TrimSpace(string(body))
+		if len(message) > maxResponseSnippet {
+			message = message[:maxResponseSnippet]
+		}
+	}
+	switch response.StatusCode {
+	case http.StatusUnauthorized:
+		message += ", check the token"
+	case http.StatusNotFound:
+		// Forges hide what the token can't access behind a 404.
+		message += ", check the name and that the token can access it"
+	}
+
+	return fmt.Errorf("the %s API answered %s: %s", c.name, response.Status, message)
+}
+
+// rateLimitWait reports whether the response is a rate limit and how long
+// to wait before retrying, from Retry-After or the time the limit resets.
+func rateLimitWait(response *http.Response, now time.Time) (time.Duration, bool) {
+	if response.StatusCode != http.StatusTooManyRequests && response.StatusCode != http.StatusForbidden {
+		return 0, false
+	}
+
+	if retryAfter := response.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if at, err := http.ParseTime(retryAfter); err == nil {
+			return at.Sub(now), true
+		}
+	}
+	// GitHub answers 403 both for rate limits and missing permissions, only
+	// an exhausted quota tells them apart.
+	if response.StatusCode == http.StatusForbidden && response.Header.Get("X-RateLimit-Remaining") != "0" {
+		return 0, false
+	}
+	for _, header := range []string{"X-RateLimit-Reset", "RateLimit-Reset"} {
+		if reset, err := strconv.ParseInt(response.Header.Get(header), 10, 64); err == nil {
+			wait := time.Unix(reset, 0).Sub(now)
+			if wait < time.Second {
+				wait = time.Second
+			}
+			return wait, true
+		}
+	}
+
+	return time.Minute, response.StatusCode == http.StatusTooManyRequests
+}
+
+// linkNext matches the next page in a Link header.
+var linkNext = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// nextLink returns the URL of the next page from a Link header, empty on
+// the last page.
+func nextLink(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		if match := linkNext.FindStringSubmatch(link); match != nil {
+			return match[1]
+		}
+	}
+
+	return ""
+}
+
+// redactURL leaves the query out of logged URLs, searches name the author.
+func redactURL(url string) string {
+	path, _, _ := strings.Cut(url, "?")
+	return path
+}
+
+// cachePath returns where the response for the URL is cached. The token is
+// part of the key since what a response contains depends on who asks.
+func (c *forgeClient) cachePath(url string) string {
+	sum := sha256.Sum256([]byte(c.token + "\n" + url))
+	return filepath.Join(c.cacheDir, hex.EncodeToString(sum[:]))
+}
+
+func (c *forgeClient) readCache(url string) *cachedResponse {
+	if c.cacheDir == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(c.cachePath(url))
+	if err != nil {
+		return nil
+	}
+	cached := &cachedResponse{}
+	if json.Unmarshal(content, cached) != nil || cached.URL != url {
+		return nil
+	}
+
+	return cached
+}
+
+// writeCache stores the response through a rename like the stats cache,
+// failures only cost a request on the next run.
+func (c *forgeClient) writeCache(cached *cachedResponse) {
+	if c.cacheDir == "" {
+		return
+	}
+
+	content, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+	err = os.MkdirAll(c.cacheDir, 0o700)
+	if err != nil {
+		return
+	}
+	path := c.cachePath(cached.URL)
+	temp, err := os.CreateTemp(c.cacheDir, ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = temp.Write(content)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+	}
+}
+
+// warnReviews warns about the review stats that couldn't be fetched, the
+// report is complete without them.
+func warnReviews(forge string, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: leaving the %s review stats out of the report. [err=%s]\n", forge, err.Error())
+}
@@ -0,0 +1,784 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var generateCommand = &command{
+	name:        "generate",
+	summary:     "Generate the wrapped of an author (the default)",
+	description: "Generate a wrap-up of the commits an author made during the year.",
+	args:        "[<from>..<to>] [path...]",
+	examples: []string{
+		"git-wrapped generate --emails me@example.com",
+		"git-wrapped generate --emails me@work.com,me@example.com ~/work/api ~/work/web",
+		"git-wrapped generate --emails me@example.com --year 2022 --tz UTC --format json",
+		"git-wrapped generate --emails me@example.com v2.0..v3.0",
+	},
+	setup: setupGenerate,
+}
+
+func setupGenerate(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	return setupReport(fs, nil)
+}
+
+// repoLister returns more repositories to analyze, like the clones of a
+// GitHub organization.
+type repoLister func(ctx context.Context, selection wrapped.Selection, opts wrapped.Options) ([]string, error)
+
+// setupReport registers the flags of generate. The repositories of
+// listRepos, when set, are analyzed together with the --path ones instead of
+// the current directory, listed once every flag is validated.
+func setupReport(fs *flag.FlagSet, listRepos repoLister) func(ctx context.Context, args []string) error {
+	selectionFlags := addSelectionFlags(fs, true)
+	analysisFlags := addAnalysisFlags(fs)
+	formatFlag := fs.String("format", "text", "The format of the report: "+strings.Join(wrapped.Formats(), ", "))
+	themeFlags := addThemeFlags(fs)
+	printSchemaFlag := fs.Bool("print-schema", false, "Print the JSON Schema of the json report and exit")
+	printCommitSchemaFlag := fs.Bool("print-commit-schema", false, "Print the JSON Schema of a line of --jsonl and exit")
+	reproduceFlag := fs.Bool("reproduce", false, "Print the command line generating the same wrapped, with every setting the flags, the environment and the config files made, and exit")
+	noAchievementsFlag := fs.Bool("no-achievements", false, "Leave out the achievements unlocked by stats reaching their thresholds, like --disable=achievements")
+	noFooterFlag := fs.Bool("no-footer", false, "Leave out the footer of the text, markdown and HTML reports telling how the wrapped was computed: the version, the window, the refs walked, the emails and the filters")
+	topFlags := addTopFlags(fs, map[string]string{wrapped.SectionFiles: "most changed files", wrapped.SectionNewContributors: "new contributors of --team", wrapped.SectionTickets: "tickets of --ticket-pattern", wrapped.SectionRepos: "repositories when analyzing several"})
+	highlightsFlag := fs.Int("highlights", 0, "Cut the text, markdown and HTML reports down to the `n` most interesting stats, picked at random weighing how unusual they are, and list them under highlights in the json one. 0 shows every stat")
+	seedFlag := fs.String("seed", "", "The seed --highlights are picked by, the same seed picking the same ones. Default=the date of the day, picking others every day")
+	byIdentityFlag := fs.Bool("by-identity", false, "Break the activity down by the emails of the author, when the commits were made under more than one")
+	teamFlag := fs.Bool("team", false, "Aggregate the commits of every author into a collective wrapped, with the number of contributors, the new ones and the combined activity, ignoring --emails")
+	deepStatsFlag := fs.Bool("deep-stats", false, "Also walk every author's commits to the files of the author, ranking the code neighbors changing the same files and counting the files the author owns, and blame the files to find how much of the added code survived. Slower, it diffs the commits of the whole team")
+	ownershipWindowFlag := fs.String("ownership-window", wrapped.OwnershipAll, "The history --deep-stats counts the top committer of a file over: "+strings.Join(wrapped.OwnershipWindows(), ", "))
+	listCommitsFlag := fs.Bool("list-commits", false, "Instead of the report, list every matched commit chronologically with its line stats, to compare against git log")
+	bannerFlag := fs.Bool("banner", true, "Start the text report printed to a terminal with the year and the commits drawn in large digits fitted to its width, or a line of plain text under 60 columns")
+	branchFlag := fs.String("branch", "", "The branch the code at the end of the window is read from, like the files --deep-stats owns, and a revision range left open ends at. Default=the branch HEAD points at, else origin/HEAD's, main or master")
+	attributeCoauthorsFlag := fs.Bool("attribute-coauthors", false, "Also credit the author with the commits someone else made naming one of the --emails in a Co-authored-by line, like the squash merges of the author's pull requests, counted apart from the author's own")
+	coauthorsCountFullFlag := fs.Bool("coauthors-count-full", false, "Count the commits --attribute-coauthors credits towards every stat like the author's own, still reporting how many of them there are")
+	unshallowFlag := fs.Bool("unshallow", false, "Fetch the history missing from the shallow clones among the repositories from their remote before analyzing, instead of warning that it's truncated")
+	tuiFlag := fs.Bool("tui", false, "Browse the wrapped in a terminal UI, falling back to the report when stdout isn't a terminal")
+	githubFlags := addGithubFlags(fs)
+	postFlags := addPostFlags(fs)
+	gistFlags := addGistFlags(fs)
+	anonymizeFlag := fs.Bool("anonymize", false, "Replace emails with short hashes, redact file paths to their depth and extension and strip commit messages in every output, to share the wrapped publicly")
+	anonymizeSeedFlag := fs.String("anonymize-seed", "", "The seed of the --anonymize hashes, the same seed gives the same hashes across runs. Default=a random seed")
+	pdfFlags := addPDFFlags(fs)
+	pngFlags := addPNGFlags(fs)
+	outputFlags := addOutputFlags(fs, pdfFlags, analysisFlags.quiet)
+	sqliteFlag := fs.String("sqlite", "", "Also write every matched commit, the files it changed and the summary to this SQLite database, updating the commits already in it")
+	icalFlag := fs.String("ical", "", "Also write an all-day calendar event per active day to this iCalendar file, with the number of commits and the subject of the largest one. Importing it again updates the events")
+	stateFileFlag := fs.String("state-file", "", "Remember the commits analyzed in this file, e.g. wrapped.state, so the next run only walks the history the refs gained since and adds it to them. Changing the settings deciding the commits or their line stats, or rewriting a ref, starts a repository over, and deleting the file starts every repository over")
+	jsonlFlag := fs.String("jsonl", "", "Also write a JSON object per analyzed commit to this JSON Lines file, in chronological order, for your own analysis. See --print-commit-schema")
+	csvFlags := addCSVFlags(fs)
+	reviewFlags := addReviewFlags(fs)
+	pluginFlags := addPluginFlags(fs)
+	ticketFlags := addTicketFlags(fs)
+	trailerFlags := addTrailerFlags(fs)
+	signoffFlags := addSignoffFlags(fs)
+	fixFlags := addFixFlags(fs)
+	workPatternFlags := addWorkPatternFlags(fs)
+	dotFlags := addDOTFlags(fs)
+	historicalRankFlag := fs.Bool("historical-rank", false, "Also count the commits of every year in the history and report where the year ranks among them, with a bar per year. Only the commit times are read, no diffs")
+	releasesFlag := fs.Bool("releases", false, "Report how many of the tags created in the window shipped the author's commits, crediting every commit to the first tag reaching it")
+	statFlags := addStatFlags(fs)
+	digestFlags := addDigestFlags(fs)
+	clusterFlags := addClusterFlags(fs, "matching the commits of every identity clustered with one of the --emails")
+	showIdentitiesFlag := fs.Bool("show-identities", false, "Print the commits per provided email and the other emails committing in the same period to stderr")
+	clearCacheFlag := fs.Bool("clear-cache", false, "Remove the cached commit stats for the repository and exit, like git-wrapped cache clear")
+	configFlags := addConfigFlags(fs)
+	logFlags := addLogFlags(fs)
+
+	return func(ctx context.Context, args []string) error {
+		config, err := configFlags.load(fs)
+		if err != nil {
+			return err
+		}
+		if *configFlags.printConfig {
+			return config.print(fs)
+		}
+		if *printSchemaFlag {
+			fmt.Print(wrapped.JSONSchema())
+			return nil
+		}
+		if *printCommitSchemaFlag {
+			fmt.Print(wrapped.CommitJSONSchema())
+			return nil
+		}
+		if *statFlags.list {
+			return listStats(os.Stdout)
+		}
+
+		args, revisions, err := splitRevRange(args)
+		if err != nil {
+			return err
+		}
+		paths := repoPaths(selectionFlags.paths, args)
+		if *clearCacheFlag {
+			return clearCaches(paths, analysisFlags)
+		}
+
+		var selection wrapped.Selection
+		switch {
+		case *teamFlag:
+			selection, err = selectionFlags.selectionOf(nil)
+		case selectionFlags.emailsMissing() && listRepos == nil && isTerminal(os.Stdin) && isTerminal(os.Stderr):
+			selection, err = selectionFlags.pickSelection(ctx, paths, os.Stdin, os.Stderr)
+		default:
+			selection, err = selectionFlags.selection()
+		}
+		if err != nil {
+			return err
+		}
+		if err := digestFlags.validate(fs, *formatFlag); err != nil {
+			return err
+		}
+		if digestFlags.enabled() {
+			selection.Window, err = digestFlags.window(selection.Window.Location, time.Now())
+			if err != nil {
+				return err
+			}
+		}
+		if revisions != nil && selection.IncludeUnreachable {
+			return usagef("Unable to combine a revision range with --include-unreachable, no range reaches the unreachable commits")
+		}
+		selection.Range = revisions
+		selection.Branch = *branchFlag
+		if *coauthorsCountFullFlag && !*attributeCoauthorsFlag {
+			return usagef("Forgot to set --attribute-coauthors, the commits --coauthors-count-full counts")
+		}
+		if *attributeCoauthorsFlag {
+			if *teamFlag {
+				return usagef("Unable to combine --attribute-coauthors with --team, every author's commits are counted already")
+			}
+			selection.Coauthors = wrapped.CoauthorsSeparate
+			if *coauthorsCountFullFlag {
+				selection.Coauthors = wrapped.CoauthorsFull
+			}
+		}
+		opts, err := analysisFlags.options()
+		if err != nil {
+			return err
+		}
+		// --require-signoff is --signoff with a threshold, it opts in too.
+		if *signoffFlags.require > 0 {
+			*signoffFlags.signoff = true
+		}
+		optIns := map[string]*bool{
+			wrapped.StatWorkPattern: workPatternFlags.workPattern,
+			wrapped.StatHistory:     historicalRankFlag,
+			wrapped.StatOwnership:   deepStatsFlag,
+			wrapped.StatSurvival:    deepStatsFlag,
+			wrapped.StatFixes:       fixFlags.fixes,
+			wrapped.StatSignoffs:    signoffFlags.signoff,
+			wrapped.StatNeighbors:   deepStatsFlag,
+			wrapped.StatReleases:    releasesFlag,
+		}
+		stats, err := statFlags.stats(optIns, *analysisFlags.fast)
+		if err != nil {
+			return err
+		}
+		if *noAchievementsFlag {
+			delete(stats, wrapped.StatAchievements)
+		}
+		setOptIns(stats, optIns)
+		if err := validateStats(stats, *teamFlag, *analysisFlags.fast); err != nil {
+			return err
+		}
+		if *signoffFlags.require > 0 && !stats.Has(wrapped.StatSignoffs) {
+			return usagef("Unable to combine --require-signoff with disabling the signoffs stat it checks")
+		}
+		// Without a stat needing them, only the exports and the plugins
+		// reading the line stats of the commits need the diffs.
+		if !stats.Deep() && !*listCommitsFlag && *jsonlFlag == "" && *sqliteFlag == "" && *icalFlag == "" && !pluginFlags.enabled() && !dotFlags.enabled() && !csvFlags.enabled() {
+			opts.Fast = true
+		}
+		opts.Capabilities = stats.Needs()
+		opts.Overrides = config.overrides(paths, opts.Filter)
+		if *teamFlag {
+			clearAuthorOverrides(opts.Overrides)
+		}
+		opts.ListCommits = *listCommitsFlag || *sqliteFlag != "" || *icalFlag != "" || wrapped.FormatNeedsCommits(*formatFlag) || pluginFlags.enabled() || (ticketFlags.enabled() && stats.Has(wrapped.StatTickets)) || (trailerFlags.enabled() && stats.Has(wrapped.StatTrailers)) || signoffFlags.enabled() || *releasesFlag || fixFlags.enabled() || workPatternFlags.enabled() || dotFlags.enabled() || csvFlags.enabled()
+		if *jsonlFlag != "" {
+			opts.CommitLog = wrapped.NewCommitLog()
+		}
+		logFlags.apply(&opts)
+		if *stateFileFlag != "" {
+			if revisions != nil || selection.IncludeUnreachable || opts.Sample > 0 {
+				return usagef("Unable to combine --state-file with a revision range, --include-unreachable or --sample, the state only follows the refs of every commit")
+			}
+			opts.State, err = wrapped.LoadState(*stateFileFlag, opts.Logger)
+			if err != nil {
+				return err
+			}
+		}
+		renderOpts, err := topFlags.options()
+		if err != nil {
+			return err
+		}
+		renderOpts.Identities = *byIdentityFlag
+		if *highlightsFlag < 0 {
+			return usagef("Invalid --highlights %d, expected 0 or more", *highlightsFlag)
+		}
+		if *seedFlag != "" && *highlightsFlag == 0 {
+			return usagef("Forgot to set --highlights, the number of stats --seed picks")
+		}
+		renderOpts.Highlights = *highlightsFlag
+		renderOpts.HighlightSeed = *seedFlag
+		if renderOpts.HighlightSeed == "" {
+			renderOpts.HighlightSeed = time.Now().Format(time.DateOnly)
+			// The day's seed picks other highlights tomorrow, the command
+			// line reproducing them needs it.
+			if renderOpts.Highlights > 0 {
+				*seedFlag = renderOpts.HighlightSeed
+			}
+		}
+		renderOpts.Footer = !*noFooterFlag
+		renderOpts.JiraURL = *ticketFlags.jiraURL
+		if *bannerFlag && *formatFlag == "text" && outputFlags.toStdout() && isTerminal(os.Stdout) {
+			renderOpts.BannerWidth = terminalWidth(os.Stdout)
+		}
+		renderOpts.Theme, err = themeFlags.theme()
+		if err != nil {
+			return err
+		}
+		renderOpts.ANSI = renderOpts.Theme != nil && *formatFlag == "text" && outputFlags.toStdout() && colorsTerminal(os.Stdout)
+		if _, err := postFlags.header(); err != nil {
+			return err
+		}
+		if err := gistFlags.validate(); err != nil {
+			return err
+		}
+		if err := reviewFlags.validate(); err != nil {
+			return err
+		}
+		if err := pluginFlags.validate(); err != nil {
+			return err
+		}
+		if err := ticketFlags.validate(); err != nil {
+			return err
+		}
+		if err := signoffFlags.validate(); err != nil {
+			return err
+		}
+		if err := fixFlags.validate(); err != nil {
+			return err
+		}
+		if err := pdfFlags.validate(); err != nil {
+			return err
+		}
+		if err := outputFlags.validate(fs); err != nil {
+			return err
+		}
+		if err := workPatternFlags.validate(); err != nil {
+			return err
+		}
+		if err := clusterFlags.validate(); err != nil {
+			return err
+		}
+		if err := dotFlags.validate(fs, *analysisFlags.fast); err != nil {
+			return err
+		}
+		if err := csvFlags.validate(*analysisFlags.fast); err != nil {
+			return err
+		}
+		if !isOwnershipWindow(*ownershipWindowFlag) {
+			return usagef("Unknown --ownership-window %q, expected one of %s", *ownershipWindowFlag, strings.Join(wrapped.OwnershipWindows(), ", "))
+		}
+		if *anonymizeSeedFlag != "" && !*anonymizeFlag {
+			return usagef("Forgot to set --anonymize for --anonymize-seed")
+		}
+		if !isFormat(*formatFlag) {
+			return usagef("Unknown --format %q, expected one of %s", *formatFlag, strings.Join(wrapped.Formats(), ", "))
+		}
+		exports := *sqliteFlag != "" || *icalFlag != "" || *jsonlFlag != "" || *pdfFlags.path != "" || pngFlags.enabled() || dotFlags.enabled() || csvFlags.enabled()
+		if digestFlags.enabled() && (exports || *listCommitsFlag || *teamFlag || *highlightsFlag > 0 || *deepStatsFlag || *historicalRankFlag || *stateFileFlag != "") {
+			return usagef("Unable to combine --period %s with --list-commits, --team, --highlights, --deep-stats, --historical-rank, --state-file or the exports, a digest only has the stats of its period", *digestFlags.period)
+		}
+		resolved := map[string]string{"tz": zoneName(selection.Window.Location)}
+		if digestFlags.enabled() {
+			resolved["date"] = selection.Window.Start.Format(time.DateOnly)
+		} else {
+			resolved["year"] = strconv.Itoa(selection.Window.Start.Year())
+		}
+		command := reproduceCommand(fs, resolved)
+		if *reproduceFlag {
+			fmt.Println(command)
+			return nil
+		}
+		if listRepos != nil {
+			listed, err := listRepos(ctx, selection, opts)
+			if err != nil {
+				return err
+			}
+			paths = append(append(append([]string{}, selectionFlags.paths...), args...), listed...)
+		}
+		logConfiguration(opts.Logger, paths, selection, opts)
+
+		ctx, cancel := analysisFlags.withTimeout(ctx)
+		defer cancel()
+		err = checkShallow(ctx, paths, selection.Window, *unshallowFlag, opts.Logger)
+		if err != nil {
+			return err
+		}
+		if *clusterFlags.identities {
+			identities, err := wrapped.CountIdentities(ctx, paths, selection)
+			if err != nil {
+				return err
+			}
+			clusters := clusterFlags.cluster(os.Stderr, identities, selection, opts.Logger)
+			if *clusterFlags.apply && selection.Authors != nil {
+				selection.Authors = clusterAuthors(selection.Authors, clusters, opts.Logger)
+			}
+		}
+
+		if *tuiFlag && !digestFlags.enabled() && !*listCommitsFlag && !exports && !*anonymizeFlag && !*outputFlags.copy && outputFlags.toStdout() && isTerminal(os.Stdout) {
+			return runTUI(ctx, paths, selection, opts, renderOpts)
+		}
+
+		var anonymizer *wrapped.Anonymizer
+		if *anonymizeFlag {
+			anonymizer, err = newAnonymizer(*anonymizeSeedFlag)
+			if err != nil {
+				return err
+			}
+		}
+
+		return analysisFlags.runProfiled(opts, func() error {
+			return getWrapped(ctx, paths, selection, opts, reportOptions{
+				format:         *formatFlag,
+				listCommits:    *listCommitsFlag,
+				sqlite:         *sqliteFlag,
+				ical:           *icalFlag,
+				jsonl:          *jsonlFlag,
+				csv:            csvFlags,
+				pdf:            pdfFlags,
+				png:            pngFlags,
+				output:         outputFlags,
+				render:         renderOpts,
+				showIdentities: *showIdentitiesFlag,
+				github:         githubFlags,
+				post:           postFlags,
+				gist:           gistFlags,
+				reviews:        reviewFlags,
+				plugins:        pluginFlags,
+				tickets:        ticketFlags,
+				trailers:       trailerFlags,
+				signoffs:       signoffFlags,
+				releases:       *releasesFlag,
+				historicalRank: *historicalRankFlag,
+				fixes:          fixFlags,
+				workPattern:    workPatternFlags,
+				dot:            dotFlags,
+				team:           *teamFlag,
+				deepStats:      *deepStatsFlag,
+				ownership:      *ownershipWindowFlag,
+				anonymizer:     anonymizer,
+				command:        command,
+				stats:          stats,
+				period:         *digestFlags.period,
+			})
+		})
+	}
+}
+
+// isFormat reports whether there's a renderer for the --format.
+func isFormat(format string) bool {
+	for _, known := range wrapped.Formats() {
+		if format == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isOwnershipWindow reports whether the --ownership-window is known.
+func isOwnershipWindow(window string) bool {
+	for _, known := range wrapped.OwnershipWindows() {
+		if window == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// noCommitsError is returned when no commits matched, describing what was
+// searched so the user can tell what went wrong.
+type noCommitsError struct {
+	window wrapped.AnalysisWindow
+	emails []string
+	// suggestions lists the identities that did commit in the window.
+	suggestions string
+}
+
+func (e *noCommitsError) Error() string {
+	return fmt.Sprintf("unable to generate a git-wrapped for the provided author, no commits were found between %s and %s for %s",
+		e.window.Start.Format(time.DateOnly), e.window.LastDay().Format(time.DateOnly), strings.Join(e.emails, ", "))
+}
+
+// reportOptions are how generate reports the summary.
+type reportOptions struct {
+	format         string
+	listCommits    bool
+	sqlite         string
+	ical           string
+	jsonl          string
+	csv            *csvFlags
+	pdf            *pdfFlags
+	png            *pngFlags
+	output         *outputFlags
+	render         wrapped.RenderOptions
+	showIdentities bool
+	github         *githubFlags
+	post           *postFlags
+	gist           *gistFlags
+	reviews        *reviewFlags
+	plugins        *pluginFlags
+	tickets        *ticketFlags
+	trailers       *trailerFlags
+	signoffs       *signoffFlags
+	releases       bool
+	historicalRank bool
+	fixes          *fixFlags
+	workPattern    *workPatternFlags
+	dot            *dotFlags
+	team           bool
+	deepStats      bool
+	// ownership is the --ownership-window of the deep stats.
+	ownership string
+	// anonymizer is applied to the summary before it's output, when set.
+	anonymizer *wrapped.Anonymizer
+	// command is the command line generating the same report.
+	command string
+	// stats are the stats enabled, the finders of the disabled ones are
+	// skipped.
+	stats wrapped.StatSet
+	// period is the --period, a week or a month rendering the digest of
+	// the window instead of the wrapped.
+	period string
+}
+
+func getWrapped(ctx context.Context, paths []string, selection wrapped.Selection, opts wrapped.Options, report reportOptions) error {
+	if report.period != wrapped.PeriodYear {
+		return getDigest(ctx, paths, selection, opts, report)
+	}
+	summary, err := analyzeSelection(ctx, paths, selection, opts)
+	if err != nil {
+		return err
+	}
+	if opts.State != nil {
+		err = opts.State.Save()
+		if err != nil {
+			return err
+		}
+	}
+
+	summary.Generator = generator()
+	summary.Metadata = &wrapped.Metadata{GeneratedAt: time.Now(), Filter: opts.Filter, Command: report.command}
+	summary.Stats = report.stats
+	if report.team {
+		summary.Team, err = wrapped.FindTeam(ctx, paths, selection)
+		if err != nil {
+			return err
+		}
+	}
+	if report.historicalRank && !report.listCommits {
+		summary.History, err = wrapped.FindHistory(ctx, paths, selection)
+		if err != nil {
+			return err
+		}
+	}
+	if !report.listCommits && !report.team && summary.HasLineStats() && report.stats.Has(wrapped.StatSpotlight) {
+		summary.Spotlight, err = wrapped.FindSpotlight(ctx, paths, selection, summary)
+		if err != nil {
+			return err
+		}
+	}
+	if report.deepStats && !report.listCommits {
+		if report.stats.Has(wrapped.StatNeighbors) {
+			summary.Neighbors, err = wrapped.FindNeighbors(ctx, paths, selection, summary)
+			if err != nil {
+				return err
+			}
+		}
+		if report.stats.Has(wrapped.StatOwnership) {
+			summary.Ownership, err = wrapped.FindOwnership(ctx, paths, selection, summary, report.ownership)
+			if err != nil {
+				return err
+			}
+		}
+		if report.stats.Has(wrapped.StatSurvival) {
+			summary.Survival, err = wrapped.FindSurvival(ctx, paths, selection, summary)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if !report.listCommits {
+		err = report.plugins.run(ctx, summary)
+		if err != nil {
+			return err
+		}
+		if report.stats.Has(wrapped.StatTickets) {
+			err = report.tickets.count(summary)
+			if err != nil {
+				return err
+			}
+		}
+		if report.stats.Has(wrapped.StatTrailers) {
+			err = report.trailers.count(ctx, summary, paths)
+			if err != nil {
+				return err
+			}
+		}
+		err = report.signoffs.count(summary)
+		if err != nil {
+			return err
+		}
+		err = report.fixes.count(summary)
+		if err != nil {
+			return err
+		}
+		err = report.workPattern.count(summary)
+		if err != nil {
+			return err
+		}
+		err = report.dot.find(summary)
+		if err != nil {
+			return err
+		}
+		if report.releases {
+			summary.Releases, err = wrapped.FindReleases(ctx, paths, summary)
+			if err != nil {
+				return err
+			}
+		}
+		report.reviews.fetch(ctx, summary, opts)
+	}
+	if report.anonymizer != nil {
+		report.anonymizer.Apply(summary)
+		if opts.CommitLog != nil {
+			report.anonymizer.ApplyLog(opts.CommitLog)
+		}
+	}
+	stopRender := opts.Timings.Start(wrapped.PhaseRender)
+	var output string
+	if report.listCommits {
+		output, err = wrapped.RenderCommits(report.format, summary)
+	} else {
+		output, err = wrapped.Render(report.format, summary, report.render)
+	}
+	stopRender()
+	if err != nil {
+		return err
+	}
+	err = report.output.write(output)
+	if err != nil {
+		return err
+	}
+
+	if report.sqlite != "" {
+		repos := paths
+		if report.anonymizer != nil {
+			repos = make([]string, 0, len(paths))
+			for _, path := range paths {
+				repos = append(repos, report.anonymizer.Pseudonym(path))
+			}
+		}
+		err = exportSQLite(ctx, report.sqlite, summary, repos)
+		if err != nil {
+			return err
+		}
+	}
+	if report.ical != "" {
+		err = exportICal(report.ical, summary, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+	if report.jsonl != "" {
+		err = exportJSONL(report.jsonl, opts.CommitLog)
+		if err != nil {
+			return err
+		}
+	}
+	err = report.csv.write(summary)
+	if err != nil {
+		return err
+	}
+
+	if !report.listCommits {
+		err = report.pdf.write(summary)
+		if err != nil {
+			return err
+		}
+		err = report.png.write(summary, report.render.Theme)
+		if err != nil {
+			return err
+		}
+		err = report.dot.write(summary)
+		if err != nil {
+			return err
+		}
+	}
+	report.output.openReport()
+
+	err = report.github.publish(summary, report.render)
+	if err != nil {
+		return err
+	}
+	err = report.post.deliver(ctx, summary, paths)
+	if err != nil {
+		return err
+	}
+	err = report.gist.share(ctx, summary, report.render)
+	if err != nil {
+		return err
+	}
+
+	warnStatsErrors(summary.StatsErrors)
+	warnCorruptCommits(summary.CorruptSkipped)
+	if report.showIdentities {
+		identities, err := wrapped.CountIdentities(ctx, paths, selection)
+		if err != nil {
+			return err
+		}
+		writeIdentities(os.Stderr, identities, selection.Authors)
+	}
+	if len(summary.Oversized) > 0 {
+		fmt.Fprintf(os.Stderr, "Left %s out of the line stats, pass --verbose to list them\n", wrapped.FormatCountOf(len(summary.Oversized), "oversized commit", "oversized commits"))
+	}
+	if summary.UnreachableSkipped > 0 {
+		fmt.Fprintf(os.Stderr, "Skipped %s, pass --include-unreachable to count them\n", wrapped.FormatCountOf(summary.UnreachableSkipped, "unreachable commit", "unreachable commits"))
+	}
+
+	return report.signoffs.check(summary)
+}
+
+// analyzeSelection analyzes every repository and merges their summaries.
+// Repositories that fail are skipped with a warning as long as one of them
+// could be analyzed, and a *noCommitsError is returned when none of their
+// commits matched.
+func analyzeSelection(ctx context.Context, paths []string, selection wrapped.Selection, opts wrapped.Options) (*wrapped.Summary, error) {
+	opts.Selection = selection
+	results := wrapped.AnalyzeRepos(ctx, paths, opts)
+
+	summary := wrapped.NewSummary(opts.Fast, selection.Window)
+	summary.MergeStats = opts.MergeStats
+	summary.Selection = selection
+	interrupted := &wrapped.InterruptedError{}
+	failures := 0
+	var err error
+	for _, result := range results {
+		repoInterrupted := &wrapped.InterruptedError{}
+		if errors.As(result.Err, &repoInterrupted) {
+			interrupted.Processed += repoInterrupted.Processed
+			interrupted.Found += repoInterrupted.Found
+			interrupted.Cause = repoInterrupted.Cause
+			continue
+		}
+		if result.Err != nil {
+			failures++
+			err = result.Err
+			if len(results) > 1 {
+				fmt.Fprintf(os.Stderr, "Skipping %s. [err=%s]\n", result.Path, result.Err.Error())
+			}
+			continue
+		}
+
+		interrupted.Processed += int(result.Summary.TotalCommits)
+		interrupted.Found += int(result.Summary.TotalCommits)
+		summary.MergeRepo(result.Path, result.Summary)
+	}
+	summary.Finish()
+
+	if interrupted.Cause != nil {
+		return nil, interrupted
+	}
+
+	if failures == len(results) {
+		if len(results) > 1 {
+			return nil, fmt.Errorf("none of the repositories could be analyzed: %w", err)
+		}
+		return nil, err
+	}
+
+	if summary.TotalCommits == 0 {
+		emails := make([]string, 0, len(selection.Authors))
+		for email := range selection.Authors {
+			emails = append(emails, email)
+		}
+		sort.Strings(emails)
+		if selection.Authors == nil {
+			emails = []string{"any author"}
+		}
+
+		return nil, &noCommitsError{
+			window:      selection.Window,
+			emails:      emails,
+			suggestions: identityHint(ctx, paths, selection),
+		}
+	}
+
+	return summary, nil
+}
+
+// maxListedErrors caps how many skipped commits are listed in a warning.
+const maxListedErrors = 5
+
+// warnStatsErrors warns on stderr about the commits left out of the line stats.
+func warnStatsErrors(errs []wrapped.CommitError) {
+	if len(errs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: line stats unavailable for %s, pass --strict to abort instead\n", wrapped.FormatCountOf(len(errs), "commit", "commits"))
+	for i, commitErr := range errs {
+		if i == maxListedErrors {
+			fmt.Fprintf(os.Stderr, "  ... and %s more\n", wrapped.FormatCount(len(errs)-maxListedErrors))
+			break
+		}
+		fmt.Fprintf(os.Stderr, "  %s: %s\n", commitErr.Hash, commitErr.Err.Error())
+	}
+}
+
+// warnCorruptCommits warns on stderr about the commits the walk couldn't read.
+func warnCorruptCommits(errs []wrapped.CommitError) {
+	if len(errs) == 0 {
+		return
+	}
+
+	hashes := make([]string, 0, maxListedErrors+1)
+	for i, commitErr := range errs {
+		if i == maxListedErrors {
+			hashes = append(hashes, fmt.Sprintf("and %s more", wrapped.FormatCount(len(errs)-maxListedErrors)))
+			break
+		}
+		hashes = append(hashes, commitErr.Hash)
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s skipped due to repository corruption: %s\n", wrapped.FormatCountOf(len(errs), "commit", "commits"), strings.Join(hashes, ", "))
+	fmt.Fprintf(os.Stderr, "  The history only they reach wasn't analyzed, run git fsck to check the repository or pass --strict to abort instead\n")
+}
+
+// newAnonymizer returns the anonymizer of the --anonymize-seed, or of a
+// random seed so the hashes can't be matched across runs.
+func newAnonymizer(seed string) (*wrapped.Anonymizer, error) {
+	if seed == "" {
+		random := make([]byte, 16)
+		_, err := rand.Read(random)
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate the --anonymize seed. [err=%s]", err.Error())
+		}
+		seed = hex.EncodeToString(random)
+	}
+
+	return wrapped.NewAnonymizer(seed), nil
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultGithubAPI is used unless GITHUB_API_URL points at a GitHub
+// Enterprise server, as it does in Actions runs.
+const defaultGithubAPI = "https://api.github.com"
+
+// gistFlags are the flags sharing the report as a GitHub gist.
+type gistFlags struct {
+	enabled  *bool
+	public   *bool
+	id       *string
+	withJSON *bool
+}
+
+func addGistFlags(fs *flag.FlagSet) *gistFlags {
+	flags := &gistFlags{}
+	flags.enabled = fs.Bool("gist", false, "Share the markdown report as a secret gist, with the token in GITHUB_TOKEN, and print its URL")
+	flags.public = fs.Bool("gist-public", false, "Create a public gist instead of a secret one")
+	flags.id = fs.String("gist-id", "", "Update the gist with this ID instead of creating one")
+	flags.withJSON = fs.Bool("gist-json", false, "Add the json report to the gist")
+
+	return flags
+}
+
+func (f *gistFlags) validate() error {
+	if !*f.enabled {
+		return nil
+	}
+	if os.Getenv("GITHUB_TOKEN") == "" {
+		return usagef("Forgot to set GITHUB_TOKEN to a token with the gist scope for --gist")
+	}
+
+	return nil
+}
+
+type gistFile struct {
+	Content string `json:"content"`
+}
+
+type gistRequest struct {
+	Description string              `json:"description"`
+	Public      *bool               `json:"public,omitempty"`
+	Files       map[string]gistFile `json:"files"`
+}
+
+// share creates or updates the gist and prints its URL. The report has
+// already been printed, so a failure here never loses it.
+func (f *gistFlags) share(ctx context.Context, summary *wrapped.Summary, renderOpts wrapped.RenderOptions) error {
+	if !*f.enabled {
+		return nil
+	}
+
+	year := summary.Window.Start.Year()
+	markdown, err := wrapped.Render("markdown", summary, renderOpts)
+	if err != nil {
+		return err
+	}
+	gist := gistRequest{
+		Description: fmt.Sprintf("git-wrapped %s", summary.Window),
+		Files:       map[string]gistFile{fmt.Sprintf("git-wrapped-%d.md", year): {Content: markdown}},
+	}
+	if *f.withJSON {
+		report, err := wrapped.Render("json", summary, renderOpts)
+		if err != nil {
+			return err
+		}
+		gist.Files[fmt.Sprintf("git-wrapped-%d.json", year)] = gistFile{Content: report}
+	}
+
+	api := githubAPI()
+	method, endpoint, action := http.MethodPost, api+"/gists", "create the gist"
+	if *f.id != "" {
+		method, endpoint, action = http.MethodPatch, api+"/gists/"+*f.id, "update gist "+*f.id
+	} else {
+		// Visibility can only be chosen when the gist is created.
+		gist.Public = f.public
+	}
+	body, err := json.Marshal(gist)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return &deliveryError{action: action, err: err}
+	}
+	request.Header.Set("Accept", "application/vnd.github+json")
+	request.Header.Set("Authorization", "Bearer "+os.Getenv("GITHUB_TOKEN"))
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return &deliveryError{action: action, err: err}
+	}
+	defer response.Body.Close()
+
+	content, _ := io.ReadAll(response.Body)
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		// Classic tokens list their scopes, GitHub answers 404 when the gist
+		// scope is missing.
+		scopes, listed := response.Header["X-Oauth-Scopes"]
+		if (response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusForbidden) && listed && !hasScope(strings.Join(scopes, ","), "gist") {
+			return &deliveryError{action: action, err: fmt.Errorf("GITHUB_TOKEN lacks the gist scope, it only has %q", strings.Join(scopes, ","))}
+		}
+
+		snippet := content
+		if len(snippet) > maxResponseSnippet {
+			snippet = snippet[:maxResponseSnippet]
+		}
+		return &deliveryError{action: action, status: response.Status, snippet: strings.TrimSpace(string(snippet))}
+	}
+
+	created := struct {
+		HTMLURL string `json:"html_url"`
+	}{}
+	err = json.Unmarshal(content, &created)
+	if err != nil {
+		return &deliveryError{action: action, err: fmt.Errorf("unexpected response: %w", err)}
+	}
+	fmt.Fprintf(os.Stderr, "Shared the wrapped at %s\n", created.HTMLURL)
+
+	return nil
+}
+
+// hasScope reports whether the comma separated OAuth scopes include scope.
+func hasScope(scopes string, scope string) bool {
+	for _, granted := range strings.Split(scopes, ",") {
+		if strings.TrimSpace(granted) == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"os"
+	"strings"
+)
+
+// githubFlags are the flags publishing the report to a GitHub Actions run.
+type githubFlags struct {
+	summary *bool
+	output  *bool
+}
+
+func addGithubFlags(fs *flag.FlagSet) *githubFlags {
+	flags := &githubFlags{}
+	flags.summary = fs.Bool("github-summary", false, "Append the markdown report to $GITHUB_STEP_SUMMARY, shown in the summary of the GitHub Actions run")
+	flags.output = fs.Bool("github-output", false, "Write total_commits, additions, deletions, active_days and the markdown report to $GITHUB_OUTPUT for the following steps")
+
+	return flags
+}
+
+// publish appends the report to the files GitHub Actions reads, warning
+// instead when the variables naming them aren't set.
+func (f *githubFlags) publish(summary *wrapped.Summary, renderOpts wrapped.RenderOptions) error {
+	if *f.summary {
+		err := appendToGithubFile("GITHUB_STEP_SUMMARY", "--github-summary", func() (string, error) {
+			report, err := wrapped.Render("markdown", summary, renderOpts)
+			return report + "\n", err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if *f.output {
+		report, err := wrapped.Render("markdown", summary, renderOpts)
+		if err != nil {
+			return err
+		}
+		return appendToGithubFile("GITHUB_OUTPUT", "--github-output", func() (string, error) {
+			outputs := []struct {
+				name  string
+				value string
+			}{
+				{"total_commits", fmt.Sprint(summary.TotalCommits)},
+				{"additions", fmt.Sprint(summary.TotalAdditions())},
+				{"deletions", fmt.Sprint(summary.TotalDeletions())},
+				{"active_days", fmt.Sprint(summary.ActiveDays())},
+				{"report", report},
+			}
+
+			builder := strings.Builder{}
+			for _, output := range outputs {
+				entry, err := githubOutput(output.name, output.value)
+				if err != nil {
+					return "", err
+				}
+				builder.WriteString(entry)
+			}
+			return builder.String(), nil
+		})
+	}
+
+	return nil
+}
+
+// appendToGithubFile appends the content to the file named by the variable.
+func appendToGithubFile(variable string, flagName string, content func() (string, error)) error {
+	path := os.Getenv(variable)
+	if path == "" {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring %s, %s isn't set outside of GitHub Actions\n", flagName, variable)
+		return nil
+	}
+
+	data, err := content()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("unable to open %s for %s: %w", variable, flagName, err)
+	}
+	_, err = file.WriteString(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("unable to write %s for %s: %w", variable, flagName, err)
+	}
+
+	return nil
+}
+
+// githubOutput formats a single output. Multi-line values are written with
+// a random heredoc delimiter, which the value can't contain by chance.
+func githubOutput(name string, value string) (string, error) {
+	if !strings.Contains(value, "\n") {
+		return fmt.Sprintf("%s=%s\n", name, value), nil
+	}
+
+	random := make([]byte, 16)
+	_, err := rand.Read(random)
+	if err != nil {
+		return "", err
+	}
+	delimiter := "ghadelimiter_" + hex.EncodeToString(random)
+
+	return fmt.Sprintf("%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter), nil
+}
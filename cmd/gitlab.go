@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// defaultGitlabURL is used unless --gitlab-url points at a self-hosted
+// instance.
+const defaultGitlabURL = "https://gitlab.com"
+
+type gitlabMergeRequest struct {
+	CreatedAt time.Time  `json:"created_at"`
+	MergedAt  *time.Time `json:"merged_at"`
+}
+
+type gitlabEvent struct {
+	ProjectID int `json:"project_id"`
+}
+
+// gitlabReviews counts the merge requests of the user in the project: the
+// ones opened and merged during the window, the ones of others opened
+// during the window the user was a reviewer of, and the merge requests the
+// user approved during the window.
+func gitlabReviews(ctx context.Context, client *forgeClient, baseURL string, project string, username string, window wrapped.AnalysisWindow) (wrapped.ReviewStats, error) {
+	stats := wrapped.ReviewStats{Forge: "GitLab", Noun: "MR"}
+	api := baseURL + "/api/v4"
+
+	user := struct {
+		ID       int    `json:"id"`
+		Username string `json:"username"`
+	}{}
+	if username == "" {
+		err := client.getJSON(ctx, api+"/user", &user)
+		if err != nil {
+			return stats, err
+		}
+	} else {
+		users := []struct {
+			ID       int    `json:"id"`
+			Username string `json:"username"`
+		}{}
+		err := client.getJSON(ctx, api+"/users?"+url.Values{"username": {username}}.Encode(), &users)
+		if err != nil {
+			return stats, err
+		}
+		if len(users) == 0 {
+			return stats, fmt.Errorf("unknown GitLab user %q", username)
+		}
+		user = users[0]
+	}
+
+	projectInfo := struct {
+		ID int `json:"id"`
+	}{}
+	projectURL := api + "/projects/" + url.PathEscape(project)
+	err := client.getJSON(ctx, projectURL, &projectInfo)
+	if err != nil {
+		return stats, err
+	}
+
+	start, end := window.Start.Format(time.RFC3339), window.End.Format(time.RFC3339)
+	mergeRequests := func(query url.Values, perPage int) string {
+		query.Set("scope", "all")
+		query.Set("per_page", strconv.Itoa(perPage))
+		return projectURL + "/merge_requests?" + query.Encode()
+	}
+	count := func(query url.Values) (int, error) {
+		response, err := client.get(ctx, mergeRequests(query, 1))
+		if err != nil {
+			return 0, err
+		}
+		total, err := strconv.Atoi(response.total)
+		if err != nil {
+			return 0, fmt.Errorf("the GitLab API left out the X-Total of %s", redactURL(projectURL))
+		}
+		return total, nil
+	}
+
+	stats.Opened, err = count(url.Values{"author_username": {user.Username}, "created_after": {start}, "created_before": {end}})
+	if err != nil {
+		return stats, err
+	}
+	stats.Reviewed, err = count(url.Values{"reviewer_username": {user.Username}, "not[author_username]": {user.Username}, "created_after": {start}, "created_before": {end}})
+	if err != nil {
+		return stats, err
+	}
+
+	// The API can't filter on when a merge request was merged, but merging
+	// updates it, so the ones updated since the start hold every candidate.
+	mergeTimes := make([]time.Duration, 0)
+	merged := url.Values{"author_username": {user.Username}, "state": {"merged"}, "updated_after": {start}}
+	err = client.getPages(ctx, mergeRequests(merged, 100), func(body []byte) (bool, error) {
+		page := []gitlabMergeRequest{}
+		err := json.Unmarshal(body, &page)
+		if err != nil {
+			return false, err
+		}
+		for _, mergeRequest := range page {
+			if mergeRequest.MergedAt == nil || mergeRequest.MergedAt.Before(window.Start) || !mergeRequest.MergedAt.Before(window.End) {
+				continue
+			}
+			stats.Merged++
+			mergeTimes = append(mergeTimes, mergeRequest.MergedAt.Sub(mergeRequest.CreatedAt))
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	stats.MedianTimeToMerge = wrapped.MedianDuration(mergeTimes)
+
+	// Approvals are only listed as events of the user, whichever the tier.
+	// after and before are exclusive dates.
+	events := url.Values{
+		"action":      {"approved"},
+		"target_type": {"merge_request"},
+		"after":       {window.Start.AddDate(0, 0, -1).Format(time.DateOnly)},
+		"before":      {window.End.Format(time.DateOnly)},
+		"per_page":    {"100"},
+	}
+	err = client.getPages(ctx, fmt.Sprintf("%s/users/%d/events?%s", api, user.ID, events.Encode()), func(body []byte) (bool, error) {
+		page := []gitlabEvent{}
+		err := json.Unmarshal(body, &page)
+		if err != nil {
+			return false, err
+		}
+		for _, event := range page {
+			if event.ProjectID == projectInfo.ID {
+				stats.Approved++
+			}
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+func authorizeGitlab(request *http.Request, token string) {
+	request.Header.Set("PRIVATE-TOKEN", token)
+}
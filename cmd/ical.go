@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// icalDateLayout is the layout of DATE values, icalStampLayout the one of
+// DATE-TIME values in UTC.
+const (
+	icalDateLayout  = "20060102"
+	icalStampLayout = "20060102T150405Z"
+	// icalLineOctets is how long a content line can get before it's folded.
+	icalLineOctets = 75
+)
+
+// icalDay is the commits of a day in one repository, an event of --ical.
+type icalDay struct {
+	repo    string
+	day     time.Time
+	commits []*wrapped.ListedCommit
+}
+
+// exportICal writes an all-day event per repository and active day to path,
+// with the number of commits and the subject of the largest one as its
+// summary. The UIDs are derived from the repository and the date, so
+// importing the file again updates the events rather than duplicating them.
+func exportICal(path string, summary *wrapped.Summary, now time.Time) error {
+	fail := func(err error) error {
+		return fmt.Errorf("unable to export to %s. [err=%s]", path, err.Error())
+	}
+
+	days, err := icalDays(summary)
+	if err != nil {
+		return fail(err)
+	}
+	names := make(map[string]string)
+	if len(summary.Repos) > 1 {
+		for _, repo := range summary.Repos {
+			names[repo.Path] = repo.Name
+		}
+	}
+
+	builder := &strings.Builder{}
+	line := func(name string, value string) {
+		writeICalLine(builder, name+":"+value)
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//git-wrapped//git-wrapped//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("X-WR-CALNAME", icalText("git-wrapped "+summary.Window.String()))
+	stamp := now.UTC().Format(icalStampLayout)
+	for _, day := range days {
+		line("BEGIN", "VEVENT")
+		line("UID", icalUID(day.repo, day.day))
+		line("DTSTAMP", stamp)
+		line("DTSTART;VALUE=DATE", day.day.Format(icalDateLayout))
+		line("DTEND;VALUE=DATE", day.day.AddDate(0, 0, 1).Format(icalDateLayout))
+		line("SUMMARY", icalText(day.summary(names[day.commits[0].Repo])))
+		line("DESCRIPTION", icalText(day.description()))
+		line("TRANSP", "TRANSPARENT")
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+
+	err = os.WriteFile(path, []byte(builder.String()), 0o644)
+	if err != nil {
+		return fail(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %s days of commits to %s\n", wrapped.FormatCount(len(days)), path)
+	return nil
+}
+
+// icalDays groups the listed commits by their repository and their day in
+// the window's time zone, oldest day first.
+func icalDays(summary *wrapped.Summary) ([]*icalDay, error) {
+	type dayKey struct {
+		repo string
+		day  string
+	}
+
+	byDay := make(map[dayKey]*icalDay)
+	days := make([]*icalDay, 0)
+	for i := range summary.Commits {
+		commit := &summary.Commits[i]
+		repo, err := repoPath(summary, commit.Repo)
+		if err != nil {
+			return nil, err
+		}
+		when := commit.When
+		key := dayKey{repo: repo, day: when.Format(time.DateOnly)}
+		day, ok := byDay[key]
+		if !ok {
+			day = &icalDay{repo: repo, day: time.Date(when.Year(), when.Month(), when.Day(), 0, 0, 0, 0, time.UTC)}
+			byDay[key] = day
+			days = append(days, day)
+		}
+		day.commits = append(day.commits, commit)
+	}
+	sort.SliceStable(days, func(i, j int) bool {
+		if !days[i].day.Equal(days[j].day) {
+			return days[i].day.Before(days[j].day)
+		}
+		return days[i].repo < days[j].repo
+	})
+
+	return days, nil
+}
+
+// summary returns the title of the event, e.g. "3 commits — fix flaky retry
+// test", naming the repository when the export spans several.
+func (d *icalDay) summary(repo string) string {
+	title := fmt.Sprintf("%d commits", len(d.commits))
+	if len(d.commits) == 1 {
+		title = "1 commit"
+	}
+	if repo != "" {
+		title += " in " + repo
+	}
+	if subject := d.top().Subject; subject != "" {
+		title += " — " + subject
+	}
+
+	return title
+}
+
+// top returns the commit changing the most lines, the earliest of them
+// without line stats or on ties.
+func (d *icalDay) top() *wrapped.ListedCommit {
+	top := d.commits[0]
+	for _, commit := range d.commits[1:] {
+		if commit.Additions+commit.Deletions > top.Additions+top.Deletions {
+			top = commit
+		}
+	}
+
+	return top
+}
+
+// description lists every commit of the day with its time and short hash.
+func (d *icalDay) description() string {
+	lines := make([]string, 0, len(d.commits))
+	for _, commit := range d.commits {
+		lines = append(lines, fmt.Sprintf("%s %s %s", commit.When.Format("15:04"), commit.Hash[:7], commit.Subject))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// icalUID returns the UID of the event of the repository's day.
+func icalUID(repo string, day time.Time) string {
+	sum := sha256.Sum256([]byte(repo + "\n" + day.Format(icalDateLayout)))
+	return hex.EncodeToString(sum[:8]) + "-" + day.Format(icalDateLayout) + "@git-wrapped"
+}
+
+// icalText escapes a TEXT value as RFC 5545 requires.
+func icalText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(text)
+}
+
+// writeICalLine writes the content line ending with CRLF, folded into lines
+// of at most 75 octets continued with a space. Lines are only folded between
+// characters, never inside one.
+func writeICalLine(builder *strings.Builder, line string) {
+	limit := icalLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		builder.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// The space starting the continuation counts towards its octets.
+		limit = icalLineOctets - 1
+	}
+	builder.WriteString(line + "\r\n")
+}
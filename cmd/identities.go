@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxSuggestions caps how many unmatched identities are suggested.
+const maxSuggestions = 5
+
+// writeIdentities writes the per email breakdown of the provided emails that
+// matched, followed by the most active identities that didn't match as
+// suggestions.
+func writeIdentities(out io.Writer, identities []wrapped.IdentityCount, authors map[string]bool) {
+	matched := make([]wrapped.IdentityCount, 0)
+	unmatched := make([]wrapped.IdentityCount, 0)
+	for _, identity := range identities {
+		if authors[identity.Email] {
+			matched = append(matched, identity)
+		} else {
+			unmatched = append(unmatched, identity)
+		}
+	}
+
+	if len(matched) > 1 {
+		fmt.Fprintln(out, "Commits per provided email:")
+		for _, identity := range matched {
+			fmt.Fprintf(out, "  %s: %s\n", identity.Email, wrapped.FormatCountOf(identity.Commits, "commit", "commits"))
+		}
+	}
+
+	if len(unmatched) > maxSuggestions {
+		unmatched = unmatched[:maxSuggestions]
+	}
+	if len(unmatched) > 0 {
+		fmt.Fprintln(out, "Other identities committing in the same period:")
+		for _, identity := range unmatched {
+			fmt.Fprintf(out, "  did you mean %s (%s)? %s\n", identity.Email, identity.Name, wrapped.FormatCountOf(identity.Commits, "commit", "commits"))
+		}
+	}
+}
+
+// identityHint renders the hint of the error when no commits matched: how
+// many commits the window has by any author and the identities that made
+// them, then the other years the provided emails did commit in. It walks the
+// history again, which only the failure path pays for. What couldn't be
+// counted is left out.
+func identityHint(ctx context.Context, paths []string, selection wrapped.Selection) string {
+	builder := strings.Builder{}
+	identities, err := wrapped.CountIdentities(ctx, paths, selection)
+	if err == nil {
+		total := 0
+		for _, identity := range identities {
+			total += identity.Commits
+		}
+		if total == 0 {
+			fmt.Fprintf(&builder, "Nobody committed in %d.\n", selection.Window.Start.Year())
+		} else {
+			fmt.Fprintf(&builder, "%s made in %d by %s.\n", wrapped.FormatCountOf(total, "commit", "commits"), selection.Window.Start.Year(), wrapped.FormatCountOf(len(identities), "author", "authors"))
+		}
+		writeIdentities(&builder, identities, selection.Authors)
+	}
+	if selection.Authors != nil {
+		builder.WriteString(yearHint(ctx, paths, selection))
+	}
+
+	return builder.String()
+}
+
+// yearHint suggests the year closest to the window's in which the provided
+// emails committed, the busier one when two are as close, e.g. "No 2023
+// commits for those emails, but 2021 has 178 commits — wrong --year?".
+func yearHint(ctx context.Context, paths []string, selection wrapped.Selection) string {
+	history, err := wrapped.FindHistory(ctx, paths, selection)
+	if err != nil {
+		return ""
+	}
+
+	year := selection.Window.Start.Year()
+	emails := "those emails"
+	if len(selection.Authors) == 1 {
+		emails = "that email"
+	}
+	closest := wrapped.YearCount{}
+	for _, count := range history.Years {
+		if count.Year == year || count.Commits == 0 {
+			continue
+		}
+		distance, best := abs(count.Year-year), abs(closest.Year-year)
+		if closest.Commits == 0 || distance < best || (distance == best && count.Commits > closest.Commits) {
+			closest = count
+		}
+	}
+	if closest.Commits == 0 {
+		return fmt.Sprintf("No commits in any year for %s either, check --emails.\n", emails)
+	}
+
+	return fmt.Sprintf("No %d commits for %s, but %d has %s — wrong --year?\n", year, emails, closest.Year, wrapped.FormatCountOf(closest.Commits, "commit", "commits"))
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
+
+// maxPicks caps how many identities are offered to pick from.
+const maxPicks = 10
+
+// pickIdentities offers the identities with the most commits to pick from on
+// out and reads the numbers of the picked ones from in, separated by commas.
+// It asks again until the answer is valid, and fails when in is closed
+// without one.
+func pickIdentities(in io.Reader, out io.Writer, identities []wrapped.IdentityCount, year int) (map[string]bool, error) {
+	if len(identities) > maxPicks {
+		identities = identities[:maxPicks]
+	}
+
+	fmt.Fprintf(out, "Forgot to set --emails, these identities committed in %d:\n", year)
+	for i, identity := range identities {
+		fmt.Fprintf(out, "%3d. %s (%s): %s\n", i+1, identity.Email, identity.Name, wrapped.FormatCountOf(identity.Commits, "commit", "commits"))
+	}
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "Pick yours by their numbers, e.g. 1,3: ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			if err := scanner.Err(); err != nil {
+				return nil, fmt.Errorf("unable to read the picked identities. [err=%s]", err.Error())
+			}
+			return nil, usagef("Forgot to pick an identity, or to set --emails")
+		}
+
+		picked, err := parsePicks(scanner.Text(), len(identities))
+		if err != nil {
+			fmt.Fprintln(out, err.Error())
+			continue
+		}
+
+		emails := make(map[string]bool, len(picked))
+		for _, pick := range picked {
+			emails[identities[pick-1].Email] = true
+		}
+		return emails, nil
+	}
+}
+
+// parsePicks parses the comma separated numbers of the picked identities,
+// each between 1 and count.
+func parsePicks(answer string, count int) ([]int, error) {
+	picks := make([]int, 0)
+	for _, field := range strings.Split(answer, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		pick, err := strconv.Atoi(field)
+		if err != nil || pick < 1 || pick > count {
+			return nil, fmt.Errorf("Unknown identity %q, expected numbers from 1 to %d", field, count)
+		}
+		picks = append(picks, pick)
+	}
+	if len(picks) == 0 {
+		return nil, fmt.Errorf("Pick at least one identity, expected numbers from 1 to %d", count)
+	}
+
+	return picks, nil
+}
+
+// clusterFlags are the flags clustering the identities of the same author
+// that no mailmap joins.
+type clusterFlags struct {
+	identities *bool
+	apply      *bool
+}
+
+// addClusterFlags registers --cluster-identities and --cluster-apply, what
+// applying the clusters does told by apply.
+func addClusterFlags(fs *flag.FlagSet, apply string) *clusterFlags {
+	return &clusterFlags{
+		identities: fs.Bool("cluster-identities", false, "Propose clusters of the identities committing in the window that are likely the same author, by their names once case, diacritics and abbreviations like Rob for Robert are ignored, or the local part of their emails. Excluded emails are never clustered, see -v for why the identities were clustered"),
+		apply:      fs.Bool("cluster-apply", false, "Apply the --cluster-identities clusters, "+apply),
+	}
+}
+
+func (f *clusterFlags) validate() error {
+	if *f.apply && !*f.identities {
+		return usagef("Forgot to set --cluster-identities, the clusters --cluster-apply applies")
+	}
+
+	return nil
+}
+
+// cluster clusters the identities and writes the proposed clusters to out.
+func (f *clusterFlags) cluster(out io.Writer, identities []wrapped.IdentityCount, selection wrapped.Selection, logger wrapped.Logger) []wrapped.IdentityCluster {
+	clusters := wrapped.ClusterIdentities(identities, selection, logger)
+	if len(clusters) == 0 {
+		fmt.Fprintln(out, "No identities to cluster, none of them look like the same author")
+		return clusters
+	}
+
+	verb := "Proposed identity clusters, apply them with --cluster-apply:"
+	if *f.apply {
+		verb = "Clustered identities:"
+	}
+	fmt.Fprintln(out, verb)
+	for i, cluster := range clusters {
+		members := make([]string, 0, len(cluster.Identities))
+		for _, identity := range cluster.Identities {
+			members = append(members, fmt.Sprintf("%s <%s>", identity.Name, identity.Email))
+		}
+		fmt.Fprintf(out, "%3d. %s: %s\n", i+1, strings.Join(members, ", "), wrapped.FormatCountOf(cluster.Commits(), "commit", "commits"))
+	}
+
+	return clusters
+}
+
+// clusterAuthors adds the other emails of the clusters of the authors to
+// them, so the commits of every identity of the author are matched.
+func clusterAuthors(authors map[string]bool, clusters []wrapped.IdentityCluster, logger wrapped.Logger) map[string]bool {
+	clustered := make(map[string]bool, len(authors))
+	for email := range authors {
+		clustered[email] = true
+	}
+	for _, cluster := range clusters {
+		matched := false
+		for _, identity := range cluster.Identities {
+			matched = matched || authors[identity.Email]
+		}
+		if !matched {
+			continue
+		}
+		for _, identity := range cluster.Identities {
+			if !clustered[identity.Email] {
+				logger.Logf(wrapped.LevelVerbose, "Matching %s too, it's clustered with the author", identity.Email)
+				clustered[identity.Email] = true
+			}
+		}
+	}
+
+	return clustered
+}
+
+// mergeClusters counts the identities of every cluster as the one with the
+// most commits, ranking the identities again.
+func mergeClusters(identities []wrapped.IdentityCount, clusters []wrapped.IdentityCluster) []wrapped.IdentityCount {
+	clusterOf := make(map[string]int)
+	for i, cluster := range clusters {
+		for _, identity := range cluster.Identities {
+			clusterOf[identity.Email] = i
+		}
+	}
+
+	merged := make([]wrapped.IdentityCount, 0, len(identities))
+	for _, identity := range identities {
+		i, ok := clusterOf[identity.Email]
+		switch {
+		case !ok:
+			merged = append(merged, identity)
+		case clusters[i].Identities[0].Email == identity.Email:
+			identity.Commits = clusters[i].Commits()
+			merged = append(merged, identity)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Commits > merged[j].Commits
+	})
+
+	return merged
+}
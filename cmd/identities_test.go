@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"git-wrapped/pkg/wrapped"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdentityHintSingleCommit(t *testing.T) {
+	repo := newTestRepo(t, time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC))
+	tests := []struct {
+		year    int
+		authors map[string]bool
+		want    string
+	}{
+		{year: 2021, want: "1 commit made in 2021 by 1 author.\n"},
+		{year: 2023, authors: map[string]bool{"dev@example.com": true}, want: "No 2023 commits for that email, but 2021 has 1 commit — wrong --year?\n"},
+	}
+	for _, test := range tests {
+		selection := wrapped.Selection{Window: wrapped.NewYearWindow(test.year, time.UTC), Authors: test.authors}
+		if hint := identityHint(context.Background(), []string{repo}, selection); !strings.Contains(hint, test.want) {
+			t.Errorf("hint for %d = %q, want it to contain %q", test.year, hint, test.want)
+		}
+	}
+}
+
+func TestExcludeEmailsBeatsEmails(t *testing.T) {
+	repo := newTestRepo(t, time.Date(2023, time.March, 14, 10, 0, 0, 0, time.UTC))
+	tests := []struct {
+		exclude string
+		code    int
+	}{
+		{exclude: "dev@example.com", code: exitNoCommits},
+		{exclude: "dev@*", code: exitNoCommits},
+		{exclude: "*@example.com", code: exitNoCommits},
+		{exclude: "d?v@example.[a-z]om", code: exitNoCommits},
+		{exclude: "ci@*", code: exitOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.exclude, func(t *testing.T) {
+			result := execute(t, "--no-env", "--quiet", "--tz", "UTC", "--year", "2023", "--path", repo, "--emails", "dev@example.com", "--exclude-emails", tt.exclude)
+			if result.code != tt.code {
+				t.Errorf("exited with %d, want %d\nstderr:\n%s", result.code, tt.code, result.stderr)
+			}
+		})
+	}
+}
+
+func TestExcludeIdentities(t *testing.T) {
+	identities := []wrapped.IdentityCount{
+		{Email: "dev@example.com", Commits: 3},
+		{Email: "ci@example.com", Commits: 1},
+		{Email: "renovate+bot@example.com", Commits: 2},
+	}
+	selection := wrapped.Selection{
+		Authors:         map[string]bool{"dev@example.com": true, "ci@example.com": true},
+		ExcludedAuthors: []string{"ci@example.com", "*+bot@*"},
+	}
+	out := &bytes.Buffer{}
+
+	kept := excludeIdentities(identities, selection, wrapped.NewLogger(out, wrapped.LevelVerbose))
+	if len(kept) != 1 || kept[0].Email != "dev@example.com" {
+		t.Errorf("kept %v, want dev@example.com alone", kept)
+	}
+	for _, want := range []string{"Excluded ci@example.com: 1 commit removed", "Excluded renovate+bot@example.com: 2 commits removed"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("logged %q, want it to contain %q", out.String(), want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"os"
+)
+
+// exportJSONL writes the commits of the log to path as JSON Lines.
+func exportJSONL(path string, log *wrapped.CommitLog) error {
+	fail := func(err error) error {
+		return fmt.Errorf("unable to export to %s. [err=%s]", path, err.Error())
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fail(err)
+	}
+	err = log.Write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %s commits to %s\n", wrapped.FormatCount(log.Len()), path)
+	return nil
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"os"
+	"strings"
+)
+
+var leaderboardCommand = &command{
+	name:        "leaderboard",
+	summary:     "Rank every author by their commits during the year",
+	description: "Rank every author of the repositories by the number of commits they made during the year.",
+	args:        "[path...]",
+	examples: []string{
+		"git-wrapped leaderboard",
+		"git-wrapped leaderboard --year 2022 --top 20 ~/work/api ~/work/web",
+	},
+	setup: setupLeaderboard,
+}
+
+func setupLeaderboard(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	selectionFlags := addSelectionFlags(fs, false)
+	topFlags := addTopFlags(fs, map[string]string{sectionAuthors: "authors"})
+	clusterFlags := addClusterFlags(fs, "ranking every cluster as a single author")
+	configFlags := addConfigFlags(fs)
+	logFlags := addLogFlags(fs)
+
+	return func(ctx context.Context, args []string) error {
+		config, err := configFlags.load(fs)
+		if err != nil {
+			return err
+		}
+		if *configFlags.printConfig {
+			return config.print(fs)
+		}
+
+		paths := repoPaths(selectionFlags.paths, args)
+		selection, err := selectionFlags.selection()
+		if err != nil {
+			return err
+		}
+
+		renderOpts, err := topFlags.options()
+		if err != nil {
+			return err
+		}
+		if err := clusterFlags.validate(); err != nil {
+			return err
+		}
+
+		logger := logFlags.logger()
+		logger.Logf(wrapped.LevelVerbose, "Repositories: %s", strings.Join(paths, ", "))
+		logger.Logf(wrapped.LevelVerbose, "Window: %s", selection.Window)
+		logger.Logf(wrapped.LevelVerbose, "Excluded authors: %s", describeList(selection.ExcludedAuthors))
+
+		// The excluded authors are counted, only to log the commits they
+		// removed.
+		counted := selection
+		counted.ExcludedAuthors = nil
+		identities, err := wrapped.CountIdentities(ctx, paths, counted)
+		if err != nil {
+			return err
+		}
+		identities = excludeIdentities(identities, selection, logger)
+		if *clusterFlags.identities {
+			clusters := clusterFlags.cluster(os.Stderr, identities, selection, logger)
+			if *clusterFlags.apply {
+				identities = mergeClusters(identities, clusters)
+			}
+		}
+		if len(identities) == 0 {
+			return &noCommitsError{window: selection.Window, emails: []string{"any author"}}
+		}
+
+		top := renderOpts.Limit(sectionAuthors)
+		if top == 0 {
+			return nil
+		}
+		fmt.Printf("🏆 Most commits %s\n", selection.Window)
+		for i, identity := range identities {
+			if i == top {
+				break
+			}
+			fmt.Printf("%3d. %s <%s>: %s commits\n", i+1, identity.Name, identity.Email, wrapped.FormatCount(identity.Commits))
+		}
+
+		return nil
+	}
+}
+
+// excludeIdentities returns the identities the selection doesn't exclude,
+// logging the commits of the ones it does.
+func excludeIdentities(identities []wrapped.IdentityCount, selection wrapped.Selection, logger wrapped.Logger) []wrapped.IdentityCount {
+	kept := make([]wrapped.IdentityCount, 0, len(identities))
+	for _, identity := range identities {
+		if selection.Excludes(identity.Email) {
+			logger.Logf(wrapped.LevelVerbose, "Excluded %s: %s removed", identity.Email, wrapped.FormatCountOf(identity.Commits, "commit", "commits"))
+			continue
+		}
+		kept = append(kept, identity)
+	}
+
+	return kept
+}
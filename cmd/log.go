@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"os"
+	"sort"
+	"strings"
+)
+
+// logFlags are the flags choosing how much is logged to stderr.
+type logFlags struct {
+	verbose *bool
+	debug   *bool
+}
+
+func addLogFlags(fs *flag.FlagSet) *logFlags {
+	flags := &logFlags{verbose: new(bool)}
+	fs.BoolVar(flags.verbose, "v", false, "Shorthand for --verbose")
+	fs.BoolVar(flags.verbose, "verbose", false, "Log the resolved configuration, the commits left at each filtering stage, the unreachable commits skipped and the time each phase took to stderr")
+	flags.debug = fs.Bool("debug", false, "Like --verbose, and also log every ref walked")
+
+	return flags
+}
+
+func (f *logFlags) level() wrapped.LogLevel {
+	switch {
+	case *f.debug:
+		return wrapped.LevelDebug
+	case *f.verbose:
+		return wrapped.LevelVerbose
+	default:
+		return wrapped.LevelQuiet
+	}
+}
+
+func (f *logFlags) logger() wrapped.Logger {
+	return wrapped.NewLogger(os.Stderr, f.level())
+}
+
+// apply sets up the logger of the analysis. Logging verbosely includes the
+// timings of every phase.
+func (f *logFlags) apply(opts *wrapped.Options) {
+	opts.Logger = f.logger()
+	if f.level() >= wrapped.LevelVerbose {
+		opts.Timings = wrapped.NewTimings(true)
+	}
+}
+
+// logConfiguration logs the settings the analysis runs with, once every
+// flag, environment variable and config file has been resolved.
+func logConfiguration(logger wrapped.Logger, paths []string, selection wrapped.Selection, opts wrapped.Options) {
+	logger.Logf(wrapped.LevelVerbose, "Repositories: %s", strings.Join(paths, ", "))
+	logger.Logf(wrapped.LevelVerbose, "Window: %s", selection.Window)
+	logger.Logf(wrapped.LevelVerbose, "Authors: %s", describeAuthors(selection.Authors))
+	logger.Logf(wrapped.LevelVerbose, "Include unreachable: %t, exclude merges: %t", selection.IncludeUnreachable, selection.ExcludeMerges)
+	logger.Logf(wrapped.LevelVerbose, "Excluded authors: %s", describeList(selection.ExcludedAuthors))
+	if selection.Branch != "" {
+		logger.Logf(wrapped.LevelVerbose, "Branch: %s", selection.Branch)
+	}
+	if selection.Range != nil {
+		logger.Logf(wrapped.LevelVerbose, "Revision range: %s", selection.Range)
+	}
+	if selection.Coauthors != "" {
+		logger.Logf(wrapped.LevelVerbose, "Co-authored commits: %s", selection.Coauthors)
+	}
+
+	cacheDir := opts.CacheDir
+	if cacheDir == "" {
+		cacheDir = "disabled"
+	}
+	logger.Logf(wrapped.LevelVerbose, "Jobs: %d, cache: %s, fast: %t, strict: %t, merge stats: %s", opts.Jobs, cacheDir, opts.Fast, opts.Strict, opts.MergeStats)
+	if opts.MaxMemory > 0 {
+		logger.Logf(wrapped.LevelVerbose, "Max memory: %s", wrapped.FormatBytes(opts.MaxMemory))
+	}
+	objectCache := "go-git's default per handle"
+	if opts.ObjectCacheSize > 0 {
+		objectCache = wrapped.FormatBytes(opts.ObjectCacheSize) + " shared per repository"
+	}
+	logger.Logf(wrapped.LevelVerbose, "Object cache: %s", objectCache)
+	logger.Logf(wrapped.LevelVerbose, "Excluded paths: %s, exclude lock files: %t", describeList(opts.Filter.Excluded), opts.Filter.ExcludeLockfiles)
+	if opts.Limits != (wrapped.CommitLimits{}) {
+		timeout := "none"
+		if opts.Limits.Timeout > 0 {
+			timeout = opts.Limits.Timeout.String()
+		}
+		logger.Logf(wrapped.LevelVerbose, "Max changed files: %s, max blob size: %s, commit timeout: %s", describeLimit(int64(opts.Limits.MaxChangedFiles)), describeLimit(opts.Limits.MaxBlobSize), timeout)
+	}
+	if opts.Sample > 0 {
+		picked := "evenly spaced"
+		if opts.SampleSeed != "" {
+			picked = fmt.Sprintf("at random from the seed %q", opts.SampleSeed)
+		}
+		logger.Logf(wrapped.LevelVerbose, "Sample: %s commits, %s", wrapped.FormatCount(opts.Sample), picked)
+	}
+
+	overridden := make([]string, 0, len(opts.Overrides))
+	for path := range opts.Overrides {
+		overridden = append(overridden, path)
+	}
+	sort.Strings(overridden)
+	for _, path := range overridden {
+		override := opts.Overrides[path]
+		if override.Authors != nil {
+			logger.Logf(wrapped.LevelVerbose, "%s: authors overridden by the config: %s", path, describeAuthors(override.Authors))
+		}
+		if override.Filter != nil {
+			logger.Logf(wrapped.LevelVerbose, "%s: excluded paths overridden by the config: %s", path, describeList(override.Filter.Excluded))
+		}
+	}
+}
+
+func describeAuthors(authors map[string]bool) string {
+	if authors == nil {
+		return "any"
+	}
+
+	emails := make([]string, 0, len(authors))
+	for email := range authors {
+		emails = append(emails, email)
+	}
+	sort.Strings(emails)
+
+	return describeList(emails)
+}
+
+// describeLimit describes a limit, 0 being none.
+func describeLimit(limit int64) string {
+	if limit == 0 {
+		return "none"
+	}
+
+	return wrapped.FormatCount(int(limit))
+}
+
+func describeList(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+
+	return strings.Join(values, ", ")
+}
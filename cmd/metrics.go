@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+)
+
+// serverMetrics are the metrics serve exposes on /metrics, registered on a
+// registry of their own rather than the global one.
+type serverMetrics struct {
+	registry  *prometheus.Registry
+	requests  *prometheus.CounterVec
+	durations *prometheus.HistogramVec
+}
+
+// newServerMetrics registers the metrics of the server's requests and of its
+// cached analyses, and with stats the stats of the cached analyses too.
+func newServerMetrics(server *wrappedServer, stats bool) *serverMetrics {
+	m := &serverMetrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "git_wrapped_http_requests_total",
+			Help: "The HTTP requests served, by handler, method and status code.",
+		}, []string{"handler", "method", "code"}),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "git_wrapped_http_request_duration_seconds",
+			Help:    "How long the HTTP requests took to serve, by handler and method.",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 120},
+		}, []string{"handler", "method"}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.durations,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "git_wrapped_cached_analyses",
+			Help: "The finished analyses kept for later requests.",
+		}, func() float64 {
+			cached, _ := server.countAnalyses()
+			return float64(cached)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "git_wrapped_running_analyses",
+			Help: "The analyses still running.",
+		}, func() float64 {
+			_, running := server.countAnalyses()
+			return float64(running)
+		}),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	if stats {
+		m.registry.MustRegister(newStatsCollector(server))
+	}
+
+	return m
+}
+
+// instrument counts and times the requests of the handler.
+func (m *serverMetrics) instrument(name string, handler http.HandlerFunc) http.Handler {
+	labels := prometheus.Labels{"handler": name}
+	return promhttp.InstrumentHandlerDuration(m.durations.MustCurryWith(labels),
+		promhttp.InstrumentHandlerCounter(m.requests.MustCurryWith(labels), handler))
+}
+
+// handler serves the metrics in the Prometheus text format.
+func (m *serverMetrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// statsCollector exports the stats of the cached analyses when scraped, so
+// they're as fresh as the cache. The emails are only exported hashed.
+type statsCollector struct {
+	server     *wrappedServer
+	commits    *prometheus.Desc
+	additions  *prometheus.Desc
+	deletions  *prometheus.Desc
+	activeDays *prometheus.Desc
+}
+
+func newStatsCollector(server *wrappedServer) *statsCollector {
+	labels := []string{"repo", "year", "email_hash"}
+	return &statsCollector{
+		server:     server,
+		commits:    prometheus.NewDesc("git_wrapped_commits_total", "The commits of the authors in the year.", labels, nil),
+		additions:  prometheus.NewDesc("git_wrapped_additions_total", "The lines the authors added in the year.", labels, nil),
+		deletions:  prometheus.NewDesc("git_wrapped_deletions_total", "The lines the authors deleted in the year.", labels, nil),
+		activeDays: prometheus.NewDesc("git_wrapped_active_days", "The days of the year with commits of the authors.", labels, nil),
+	}
+}
+
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.commits
+	ch <- c.additions
+	ch <- c.deletions
+	ch <- c.activeDays
+}
+
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	// Analyses differing only by their time zone would export the same
+	// labels twice, only the first of them is kept.
+	seen := make(map[[3]string]bool)
+	for _, analysis := range c.server.cachedAnalyses() {
+		labels := [3]string{analysis.key.repo, strconv.Itoa(analysis.key.year), emailHash(analysis.key.emails)}
+		if seen[labels] {
+			continue
+		}
+		seen[labels] = true
+
+		summary := analysis.summary
+		ch <- prometheus.MustNewConstMetric(c.commits, prometheus.CounterValue, float64(summary.TotalCommits), labels[:]...)
+		ch <- prometheus.MustNewConstMetric(c.additions, prometheus.CounterValue, float64(summary.TotalAdditions()), labels[:]...)
+		ch <- prometheus.MustNewConstMetric(c.deletions, prometheus.CounterValue, float64(summary.TotalDeletions()), labels[:]...)
+		ch <- prometheus.MustNewConstMetric(c.activeDays, prometheus.GaugeValue, float64(summary.ActiveDays()), labels[:]...)
+	}
+}
+
+// emailHash returns the label standing for the comma separated emails, so
+// the addresses never show up in the metrics.
+func emailHash(emails string) string {
+	sum := sha256.Sum256([]byte(emails))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// outputFlags are the flags choosing where the report is written and
+// whether it's opened once it is.
+type outputFlags struct {
+	output *string
+	open   *bool
+	copy   *bool
+	// pdf is the --pdf file, opened when the report goes to stdout.
+	pdf *string
+	// quiet is --quiet, the copied report isn't printed too with it.
+	quiet *bool
+}
+
+func addOutputFlags(fs *flag.FlagSet, pdf *pdfFlags, quiet *bool) *outputFlags {
+	flags := &outputFlags{pdf: pdf.path, quiet: quiet}
+	flags.output = fs.String("output", "-", "The file the report is written to, - for stdout")
+	flags.open = fs.Bool("open", false, "Open the --output report, or else the --pdf one, in the browser or viewer of the system once it's written. The path is printed instead when there's no display to open it on")
+	flags.copy = fs.Bool("copy", false, "Also copy the report to the clipboard, with pbcopy, wl-copy, xclip or xsel or the clipboard of Windows. It's still printed to stdout unless --quiet is set")
+
+	return flags
+}
+
+func (f *outputFlags) validate(fs *flag.FlagSet) error {
+	if !*f.open {
+		return nil
+	}
+	if *f.output == "-" && isSet(fs, "output") {
+		return usagef("Unable to --open the report written to stdout with --output -")
+	}
+	if f.opened() == "" {
+		return usagef("Forgot to set --output or --pdf for --open to open a file")
+	}
+
+	return nil
+}
+
+// toStdout reports whether the report is printed rather than written to a
+// file.
+func (f *outputFlags) toStdout() bool {
+	return *f.output == "-"
+}
+
+// write prints the report or writes it to --output, copying it to the
+// clipboard first with --copy. A report that couldn't be copied is printed
+// even with --quiet, so it's never lost.
+func (f *outputFlags) write(report string) error {
+	copied := *f.copy && f.copyReport(report)
+	if f.toStdout() {
+		if !copied || !*f.quiet {
+			fmt.Println(report)
+		}
+		return nil
+	}
+
+	err := os.WriteFile(*f.output, []byte(report+"\n"), 0o644)
+	if err != nil {
+		return fmt.Errorf("unable to write the report to %s. [err=%s]", *f.output, err.Error())
+	}
+
+	return nil
+}
+
+// copyReport copies the report to the clipboard, reporting on stderr whether
+// it could.
+func (f *outputFlags) copyReport(report string) bool {
+	err := copyToClipboard(report + "\n")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to copy the report to the clipboard. [err=%s]\n", err.Error())
+		return false
+	}
+	if !*f.quiet {
+		fmt.Fprintln(os.Stderr, "Copied the report to the clipboard")
+	}
+
+	return true
+}
+
+// opened returns the file --open opens, empty when there's none.
+func (f *outputFlags) opened() string {
+	if !f.toStdout() {
+		return *f.output
+	}
+
+	return *f.pdf
+}
+
+// openReport opens the written report when --open is set. The viewer is
+// started without waiting for it to exit, and when there's no display or no
+// opener the path is printed to stderr instead.
+func (f *outputFlags) openReport() {
+	if !*f.open {
+		return
+	}
+
+	path, err := filepath.Abs(f.opened())
+	if err != nil {
+		path = f.opened()
+	}
+	opener := systemOpener(path)
+	if opener == nil || !hasDisplay() {
+		fmt.Fprintf(os.Stderr, "Your wrapped is at %s\n", path)
+		return
+	}
+	if err := opener.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to open %s, your wrapped is there. [err=%s]\n", path, err.Error())
+		return
+	}
+	opener.Process.Release()
+}
+
+// systemOpener returns the command opening the file with its default
+// application, nil when the system has none.
+func systemOpener(path string) *exec.Cmd {
+	var name string
+	var args []string
+	switch runtime.GOOS {
+	case "darwin":
+		name, args = "open", []string{path}
+	case "windows":
+		name, args = "rundll32", []string{"url.dll,FileProtocolHandler", path}
+	default:
+		name, args = "xdg-open", []string{path}
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return nil
+	}
+
+	return exec.Command(name, args...)
+}
+
+// hasDisplay reports whether a viewer opened on this machine would be seen,
+// which it isn't over SSH or without an X11 or Wayland display.
+func hasDisplay() bool {
+	if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" {
+		return false
+	}
+	switch runtime.GOOS {
+	case "darwin", "windows":
+		return true
+	default:
+		return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+	}
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+var orgCommand = &command{
+	name:        "org",
+	summary:     "Generate the wrapped of an author across a GitHub organization",
+	description: "Generate the wrapped of an author across every repository of a GitHub organization. The repositories are cloned into a cache directory, reused and fetched on the following runs.",
+	args:        "[path...]",
+	examples: []string{
+		"git-wrapped org --github-org mycompany --emails me@mycompany.com",
+		"git-wrapped org --github-org mycompany --emails me@mycompany.com --include 'api-*' --exclude '*-legacy'",
+	},
+	setup: setupOrg,
+}
+
+// githubOrgPattern matches the login of a GitHub organization.
+var githubOrgPattern = regexp.MustCompile(`^[\w.-]+$`)
+
+// orgFlags are the flags choosing the repositories of the organization.
+type orgFlags struct {
+	org             *string
+	tokenEnv        *string
+	include         stringsFlag
+	exclude         stringsFlag
+	includeArchived *bool
+	cloneDir        *string
+	cloneJobs       *int
+}
+
+func setupOrg(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	flags := &orgFlags{}
+	flags.org = fs.String("github-org", "", "The GitHub organization whose repositories are analyzed")
+	flags.tokenEnv = fs.String("token-env", "GITHUB_TOKEN", "The environment variable holding the GitHub token listing and cloning the repositories. Without a token only public repositories are analyzed")
+	fs.Var(&flags.include, "include", "A glob matching the names of the repositories to analyze, e.g. 'api-*'. Can be repeated. Default=every repository")
+	fs.Var(&flags.exclude, "exclude", "A glob matching the names of repositories to leave out, e.g. '*-legacy'. Can be repeated")
+	flags.includeArchived = fs.Bool("include-archived", false, "Also analyze the archived repositories")
+	flags.cloneDir = fs.String("clone-dir", "", "The directory the repositories are cloned into. Default=<user cache dir>/git-wrapped/repos")
+	flags.cloneJobs = fs.Int("clone-jobs", 4, "The number of repositories cloned or fetched at once")
+
+	return setupReport(fs, flags.repos)
+}
+
+func (f *orgFlags) validate() error {
+	if *f.org == "" {
+		return usagef("Forgot to set --github-org")
+	}
+	if !githubOrgPattern.MatchString(*f.org) {
+		return usagef("Invalid --github-org %q, expected the login of an organization", *f.org)
+	}
+	for _, pattern := range append(append([]string{}, f.include...), f.exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return usagef("Invalid --include or --exclude %q. [err=%s]", pattern, err.Error())
+		}
+	}
+	if *f.cloneJobs < 1 {
+		return usagef("Invalid --clone-jobs %d, expected 1 or more", *f.cloneJobs)
+	}
+
+	return nil
+}
+
+// githubRepo is a repository listed by the GitHub API.
+type githubRepo struct {
+	Name     string    `json:"name"`
+	CloneURL string    `json:"clone_url"`
+	Archived bool      `json:"archived"`
+	PushedAt time.Time `json:"pushed_at"`
+}
+
+// repos lists the repositories of the organization and clones or fetches
+// them, returning the paths of the clones.
+func (f *orgFlags) repos(ctx context.Context, selection wrapped.Selection, opts wrapped.Options) ([]string, error) {
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+	cloneDir, err := f.resolveCloneDir()
+	if err != nil {
+		return nil, err
+	}
+
+	token := os.Getenv(*f.tokenEnv)
+	client := newForgeClient("GitHub", token, opts, func(request *http.Request, token string) {
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("Accept", "application/vnd.github+json")
+	})
+	repos, err := listOrgRepos(ctx, client, githubAPI(), *f.org)
+	if err != nil {
+		return nil, err
+	}
+
+	chosen := make([]githubRepo, 0, len(repos))
+	for _, repo := range repos {
+		switch {
+		case repo.Archived && !*f.includeArchived:
+			opts.Logger.Logf(wrapped.LevelVerbose, "Skipping the archived %s/%s", *f.org, repo.Name)
+		case !f.matches(repo.Name):
+			opts.Logger.Logf(wrapped.LevelVerbose, "Skipping %s/%s, left out by --include or --exclude", *f.org, repo.Name)
+		// Nothing pushed since the window started can't hold its commits.
+		case !repo.PushedAt.IsZero() && repo.PushedAt.Before(selection.Window.Start):
+			opts.Logger.Logf(wrapped.LevelVerbose, "Skipping %s/%s, nothing was pushed since %s", *f.org, repo.Name, selection.Window.Start.Format(time.DateOnly))
+		default:
+			chosen = append(chosen, repo)
+		}
+	}
+	if len(chosen) == 0 {
+		return nil, fmt.Errorf("none of the %d repositories of %s are left to analyze", len(repos), *f.org)
+	}
+
+	paths := syncClones(ctx, filepath.Join(cloneDir, *f.org), chosen, token, *f.cloneJobs, opts.Logger)
+	if len(paths) == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("none of the repositories of %s could be cloned", *f.org)
+	}
+
+	return paths, nil
+}
+
+// matches reports whether the repository is included and not excluded.
+func (f *orgFlags) matches(name string) bool {
+	included := len(f.include) == 0
+	for _, pattern := range f.include {
+		if matched, _ := path.Match(pattern, name); matched {
+			included = true
+			break
+		}
+	}
+	for _, pattern := range f.exclude {
+		if matched, _ := path.Match(pattern, name); matched {
+			return false
+		}
+	}
+
+	return included
+}
+
+// resolveCloneDir returns --clone-dir, or the repos directory next to the
+// default stats cache.
+func (f *orgFlags) resolveCloneDir() (string, error) {
+	if *f.cloneDir != "" {
+		return *f.cloneDir, nil
+	}
+
+	dir, err := wrapped.DefaultCacheDir()
+	if err != nil {
+		return "", usagef("Unable to find a cache directory, specify one with --clone-dir. [err=%s]", err.Error())
+	}
+
+	return filepath.Join(dir, "repos"), nil
+}
+
+// listOrgRepos lists every repository of the organization the token can see.
+func listOrgRepos(ctx context.Context, client *forgeClient, api string, org string) ([]githubRepo, error) {
+	repos := make([]githubRepo, 0)
+	values := url.Values{"type": {"all"}, "per_page": {"100"}}
+	err := client.getPages(ctx, fmt.Sprintf("%s/orgs/%s/repos?%s", api, url.PathEscape(org), values.Encode()), func(body []byte) (bool, error) {
+		page := make([]githubRepo, 0)
+		err := json.Unmarshal(body, &page)
+		if err != nil {
+			return false, err
+		}
+		repos = append(repos, page...)
+
+		return len(page) > 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repos, nil
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"os"
+	"strings"
+)
+
+// pdfFlags are the flags writing the one page PDF report.
+type pdfFlags struct {
+	path *string
+	page *string
+}
+
+func addPDFFlags(fs *flag.FlagSet) *pdfFlags {
+	flags := &pdfFlags{}
+	flags.path = fs.String("pdf", "", "Also write a one page PDF of the headline stats, the commits per month and the heatmap to this file, e.g. for a slide deck")
+	flags.page = fs.String("pdf-page", "a4", "The page size of --pdf, one of "+strings.Join(wrapped.PDFPageSizes(), ", "))
+
+	return flags
+}
+
+func (f *pdfFlags) validate() error {
+	for _, size := range wrapped.PDFPageSizes() {
+		if *f.page == size {
+			return nil
+		}
+	}
+
+	return usagef("Invalid --pdf-page %q, expected one of %s", *f.page, strings.Join(wrapped.PDFPageSizes(), ", "))
+}
+
+// write writes the PDF report when --pdf is set.
+func (f *pdfFlags) write(summary *wrapped.Summary) error {
+	if *f.path == "" {
+		return nil
+	}
+
+	output, err := wrapped.RenderPDF(summary, *f.page)
+	if err != nil {
+		return fmt.Errorf("unable to render the PDF. [err=%s]", err.Error())
+	}
+	err = os.WriteFile(*f.path, output, 0o644)
+	if err != nil {
+		return fmt.Errorf("unable to write the PDF to %s. [err=%s]", *f.path, err.Error())
+	}
+
+	return nil
+}
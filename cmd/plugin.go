@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// pluginSpec is a --stat or --plugin, kept in one list so the plugins run
+// and their lines appear in the order of the command line.
+type pluginSpec struct {
+	// path is the executable of a --plugin, empty for a --stat.
+	path string
+	// stat is the name=arg of a built-in --stat.
+	stat string
+}
+
+// pluginFlags are the flags adding custom stats to the report.
+type pluginFlags struct {
+	specs []pluginSpec
+}
+
+// pluginFlag appends a --stat or --plugin to the shared list.
+type pluginFlag struct {
+	specs *[]pluginSpec
+	exec  bool
+}
+
+func (f pluginFlag) String() string {
+	return strings.Join(f.values(), ",")
+}
+
+// values returns the value of every repetition of the flag.
+func (f pluginFlag) values() []string {
+	if f.specs == nil {
+		return nil
+	}
+	values := make([]string, 0, len(*f.specs))
+	for _, spec := range *f.specs {
+		if f.exec && spec.path != "" {
+			values = append(values, spec.path)
+		} else if !f.exec && spec.path == "" {
+			values = append(values, spec.stat)
+		}
+	}
+
+	return values
+}
+
+func (f pluginFlag) Set(value string) error {
+	if f.exec {
+		*f.specs = append(*f.specs, pluginSpec{path: value})
+	} else {
+		*f.specs = append(*f.specs, pluginSpec{stat: value})
+	}
+	return nil
+}
+
+func addPluginFlags(fs *flag.FlagSet) *pluginFlags {
+	flags := &pluginFlags{}
+	fs.Var(pluginFlag{specs: &flags.specs}, "stat", "Add a built-in stat to the report, name=arg, repeat it for several: "+strings.Join(wrapped.BuiltinStats(), ", "))
+	fs.Var(pluginFlag{specs: &flags.specs, exec: true}, "plugin", "Add the stat lines of this executable to the report, it reads a JSON commit per line on stdin and writes a label: value line per stat on stdout")
+
+	return flags
+}
+
+// enabled reports whether a plugin was asked for, they need the commits
+// collected.
+func (f *pluginFlags) enabled() bool {
+	return len(f.specs) > 0
+}
+
+func (f *pluginFlags) validate() error {
+	for _, spec := range f.specs {
+		if spec.path != "" {
+			if _, err := exec.LookPath(spec.path); err != nil {
+				return usagef("Invalid --plugin %q. [err=%s]", spec.path, err.Error())
+			}
+			continue
+		}
+		if _, err := wrapped.NewBuiltinStat(spec.stat); err != nil {
+			return usagef("Invalid --stat %q. [err=%s]", spec.stat, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// run adds the stat lines of every plugin to the summary, failing when a
+// --plugin does.
+func (f *pluginFlags) run(ctx context.Context, summary *wrapped.Summary) error {
+	if !f.enabled() {
+		return nil
+	}
+
+	plugins := make([]wrapped.StatPlugin, 0, len(f.specs))
+	executables := make([]*execPlugin, 0)
+	defer func() {
+		for _, plugin := range executables {
+			plugin.kill()
+		}
+	}()
+	for _, spec := range f.specs {
+		if spec.path == "" {
+			plugin, err := wrapped.NewBuiltinStat(spec.stat)
+			if err != nil {
+				return err
+			}
+			plugins = append(plugins, plugin)
+			continue
+		}
+		plugin, err := startExecPlugin(ctx, spec.path)
+		if err != nil {
+			return err
+		}
+		plugins = append(plugins, plugin)
+		executables = append(executables, plugin)
+	}
+
+	err := summary.RunPlugins(plugins)
+	if err != nil {
+		return err
+	}
+	for _, plugin := range executables {
+		if plugin.err != nil {
+			return fmt.Errorf("the %s plugin failed. [err=%s]", plugin.path, plugin.err.Error())
+		}
+	}
+
+	return nil
+}
+
+// pluginRecord is the JSON line a --plugin reads per commit. The line stats
+// are left out when they weren't computed, like under --fast.
+type pluginRecord struct {
+	Hash      string             `json:"hash"`
+	When      string             `json:"when"`
+	Name      string             `json:"name"`
+	Email     string             `json:"email"`
+	Message   string             `json:"message"`
+	Merge     bool               `json:"merge"`
+	Additions *int64             `json:"additions,omitempty"`
+	Deletions *int64             `json:"deletions,omitempty"`
+	Files     []pluginRecordFile `json:"files,omitempty"`
+}
+
+type pluginRecordFile struct {
+	Path      string `json:"path"`
+	Additions int64  `json:"additions"`
+	Deletions int64  `json:"deletions"`
+}
+
+// execPlugin is a StatPlugin running an executable. The commits are written
+// to its stdin as they're observed and its stdout is read once stdin is
+// closed, a failure is kept in err.
+type execPlugin struct {
+	path    string
+	command *exec.Cmd
+	stdin   io.WriteCloser
+	writer  *bufio.Writer
+	encoder *json.Encoder
+	stdout  bytes.Buffer
+	done    bool
+	err     error
+}
+
+func startExecPlugin(ctx context.Context, path string) (*execPlugin, error) {
+	plugin := &execPlugin{path: path, command: exec.CommandContext(ctx, path)}
+	plugin.command.Stdout = &plugin.stdout
+	plugin.command.Stderr = os.Stderr
+	stdin, err := plugin.command.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("unable to start the %s plugin. [err=%s]", path, err.Error())
+	}
+	err = plugin.command.Start()
+	if err != nil {
+		return nil, fmt.Errorf("unable to start the %s plugin. [err=%s]", path, err.Error())
+	}
+	plugin.stdin = stdin
+	plugin.writer = bufio.NewWriter(stdin)
+	plugin.encoder = json.NewEncoder(plugin.writer)
+
+	return plugin, nil
+}
+
+func (p *execPlugin) ObserveCommit(commit *wrapped.Commit, stats wrapped.CommitStats) {
+	if p.err != nil {
+		return
+	}
+
+	record := pluginRecord{
+		Hash:    commit.Hash,
+		When:    commit.Author.When.Format(time.RFC3339),
+		Name:    commit.Author.Name,
+		Email:   commit.Author.Email,
+		Message: commit.Message,
+		Merge:   stats.Merge,
+	}
+	if stats.HasLineStats {
+		record.Additions, record.Deletions = &stats.Additions, &stats.Deletions
+		record.Files = make([]pluginRecordFile, 0, len(stats.Files))
+		for _, file := range stats.Files {
+			record.Files = append(record.Files, pluginRecordFile{Path: file.Path, Additions: file.Additions, Deletions: file.Deletions})
+		}
+	}
+	p.err = p.encoder.Encode(record)
+}
+
+// Finalize closes stdin, waits for the plugin to exit and turns every line
+// it wrote into a stat, split into label and value on the first ": ". A line
+// without one is labelled with the name of the plugin.
+func (p *execPlugin) Finalize() []wrapped.StatLine {
+	p.done = true
+	if p.err == nil {
+		p.err = p.writer.Flush()
+	}
+	p.stdin.Close()
+	// A plugin exiting fine is trusted with its lines, even when it stopped
+	// reading the commits early.
+	p.err = p.command.Wait()
+	if p.err != nil {
+		return nil
+	}
+
+	lines := make([]wrapped.StatLine, 0)
+	for _, line := range strings.Split(p.stdout.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		label, value, found := strings.Cut(line, ": ")
+		if !found {
+			label, value = filepath.Base(p.path), line
+		}
+		lines = append(lines, wrapped.StatLine{Label: label, Value: value})
+	}
+
+	return lines
+}
+
+// kill stops a plugin that wasn't finalized, after an earlier failure.
+func (p *execPlugin) kill() {
+	if p.done {
+		return
+	}
+	p.stdin.Close()
+	p.command.Process.Kill()
+	p.command.Wait()
+}
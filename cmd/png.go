@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"os"
+)
+
+// pngFlags are the flags writing the image cards, by their layout.
+type pngFlags struct {
+	paths map[string]*string
+}
+
+func addPNGFlags(fs *flag.FlagSet) *pngFlags {
+	flags := &pngFlags{paths: make(map[string]*string)}
+	flags.paths[wrapped.CardLandscape] = fs.String("png", "", "Also write a 1200x630 image card of the headline stats and the heatmap to this file, e.g. for a link preview")
+	flags.paths[wrapped.CardSquare] = fs.String("png-square", "", "Also write a 1080x1080 image card of the headline stats over the heatmap to this file, e.g. for an Instagram or Mastodon post")
+
+	return flags
+}
+
+// enabled reports whether any of the image cards is written.
+func (f *pngFlags) enabled() bool {
+	for _, path := range f.paths {
+		if *path != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// write writes the image cards whose flag is set in the colors of the theme.
+func (f *pngFlags) write(summary *wrapped.Summary, theme *wrapped.Theme) error {
+	for _, layout := range wrapped.CardLayouts() {
+		path := *f.paths[layout]
+		if path == "" {
+			continue
+		}
+
+		output, err := wrapped.RenderCard(summary, layout, theme)
+		if err != nil {
+			return fmt.Errorf("unable to render the %s image. [err=%s]", layout, err.Error())
+		}
+		err = os.WriteFile(path, output, 0o644)
+		if err != nil {
+			return fmt.Errorf("unable to write the image to %s. [err=%s]", path, err.Error())
+		}
+	}
+
+	return nil
+}
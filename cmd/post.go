@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// postRetries is how many times a delivery failing with a server error is
+// retried, waiting twice as long before every retry.
+const (
+	postRetries = 3
+	postBackoff = time.Second
+)
+
+// maxResponseSnippet caps how much of a failed response is reported.
+const maxResponseSnippet = 200
+
+// postFlags are the flags delivering the report to a webhook.
+type postFlags struct {
+	url     *string
+	headers stringsFlag
+	timeout *time.Duration
+}
+
+func addPostFlags(fs *flag.FlagSet) *postFlags {
+	flags := &postFlags{}
+	flags.url = fs.String("post-url", "", "POST the json report, with the tool version, a hash of the repository paths and the time it was generated, to this URL")
+	fs.Var(&flags.headers, "post-header", "A header sent with --post-url, e.g. \"Authorization: Bearer <token>\". Can be repeated")
+	flags.timeout = fs.Duration("post-timeout", 30*time.Second, "How long every --post-url attempt may take")
+
+	return flags
+}
+
+// header validates --post-url and returns the headers to send.
+func (f *postFlags) header() (http.Header, error) {
+	header := http.Header{}
+	if *f.url == "" {
+		return header, nil
+	}
+
+	parsed, err := url.Parse(*f.url)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, usagef("Invalid --post-url %q, expected an http or https URL", *f.url)
+	}
+	for _, line := range f.headers {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, usagef("Invalid --post-header %q, expected \"Name: value\"", line)
+		}
+		header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	header.Set("Content-Type", "application/json")
+
+	return header, nil
+}
+
+// deliveryError is returned when the report couldn't be delivered.
+type deliveryError struct {
+	// action describes the delivery that failed, e.g. "post the report to
+	// https://example.com".
+	action string
+	status string
+	// snippet is the start of the last response body.
+	snippet string
+	err     error
+}
+
+func (e *deliveryError) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf("unable to %s: %s", e.action, e.err.Error())
+	}
+
+	return fmt.Sprintf("unable to %s: %s: %s", e.action, e.status, e.snippet)
+}
+
+func (e *deliveryError) Unwrap() error {
+	return e.err
+}
+
+type postMetadata struct {
+	Tool         string `json:"tool"`
+	Version      string `json:"version"`
+	RepoPathHash string `json:"repo_path_hash"`
+	GeneratedAt  string `json:"generated_at"`
+}
+
+type postEnvelope struct {
+	Metadata postMetadata    `json:"metadata"`
+	Wrapped  json.RawMessage `json:"wrapped"`
+}
+
+func (f *postFlags) action() string {
+	return "post the report to " + *f.url
+}
+
+// deliver posts the report to --post-url, retrying server errors. Nothing is
+// posted without a URL.
+func (f *postFlags) deliver(ctx context.Context, summary *wrapped.Summary, paths []string) error {
+	if *f.url == "" {
+		return nil
+	}
+	header, err := f.header()
+	if err != nil {
+		return err
+	}
+
+	toolVersion, _, _ := buildMetadata()
+	report, err := wrapped.Render("json", summary, wrapped.RenderOptions{})
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(postEnvelope{
+		Metadata: postMetadata{
+			Tool:         "git-wrapped",
+			Version:      toolVersion,
+			RepoPathHash: repoPathHash(paths),
+			GeneratedAt:  time.Now().UTC().Format(time.RFC3339),
+		},
+		Wrapped: json.RawMessage(report),
+	})
+	if err != nil {
+		return err
+	}
+
+	backoff := postBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := f.post(ctx, header, body)
+		if err == nil || !retry || attempt == postRetries {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return &deliveryError{action: f.action(), err: ctx.Err()}
+		}
+	}
+}
+
+// post makes a single attempt, reporting whether a failure is worth a retry.
+func (f *postFlags) post(ctx context.Context, header http.Header, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, *f.timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, *f.url, bytes.NewReader(body))
+	if err != nil {
+		return false, &deliveryError{action: f.action(), err: err}
+	}
+	request.Header = header.Clone()
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return !errors.Is(err, context.Canceled), &deliveryError{action: f.action(), err: err}
+	}
+	defer response.Body.Close()
+
+	snippet, _ := io.ReadAll(io.LimitReader(response.Body, maxResponseSnippet))
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+
+	return response.StatusCode >= 500, &deliveryError{
+		action:  f.action(),
+		status:  response.Status,
+		snippet: strings.TrimSpace(string(snippet)),
+	}
+}
+
+// repoPathHash fingerprints the analyzed repositories without disclosing
+// where they're checked out.
+func repoPathHash(paths []string) string {
+	roots := make([]string, 0, len(paths))
+	for _, path := range paths {
+		root, err := wrapped.FindRepoRoot(path)
+		if err != nil {
+			root = path
+		}
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+		roots = append(roots, root)
+	}
+	sort.Strings(roots)
+
+	sum := sha256.Sum256([]byte(strings.Join(roots, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
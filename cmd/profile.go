@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// startProfiling starts a CPU profile written to cpuPath and arranges for a
+// heap profile to be written to memPath, either of which may be empty. The
+// returned function must be called before exiting to flush the profiles.
+func startProfiling(cpuPath string, memPath string) (func(), error) {
+	var cpuFile *os.File
+	if cpuPath != "" {
+		var err error
+		cpuFile, err = os.Create(cpuPath)
+		if err != nil {
+			return nil, err
+		}
+
+		err = pprof.StartCPUProfile(cpuFile)
+		if err != nil {
+			cpuFile.Close()
+			return nil, err
+		}
+	}
+
+	return func() {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			cpuFile.Close()
+		}
+
+		if memPath != "" {
+			memFile, err := os.Create(memPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to write the memory profile. [err=%s]\n", err.Error())
+				return
+			}
+			defer memFile.Close()
+
+			runtime.GC()
+			err = pprof.WriteHeapProfile(memFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to write the memory profile. [err=%s]\n", err.Error())
+			}
+		}
+	}, nil
+}
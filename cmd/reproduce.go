@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// unquotedArg matches the arguments a shell reads as they are.
+var unquotedArg = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// reproduceCommand returns the command line running the command of the flag
+// set with its effective configuration: every flag that isn't at its default,
+// whether it was passed, set from the environment or from a config file,
+// followed by the arguments. The resolved flags are always written, with
+// their resolved values, since their defaults depend on the machine or the
+// day it runs on. --no-env keeps the environment of whoever runs it from
+// changing it.
+func reproduceCommand(fs *flag.FlagSet, resolved map[string]string) string {
+	words := append(strings.Fields(fs.Name()), "--no-env")
+	fs.VisitAll(func(f *flag.Flag) {
+		if value, ok := resolved[f.Name]; ok {
+			words = append(words, "--"+f.Name+"="+shellQuote(value))
+			return
+		}
+		if f.Name == "no-env" || f.Name == "reproduce" || f.Value.String() == f.DefValue {
+			return
+		}
+		if isBoolFlag(f) && f.Value.String() == "true" {
+			words = append(words, "--"+f.Name)
+			return
+		}
+		for _, value := range passedValues(f) {
+			words = append(words, "--"+f.Name+"="+shellQuote(value))
+		}
+	})
+	if fs.NArg() > 0 && strings.HasPrefix(fs.Arg(0), "-") {
+		words = append(words, "--")
+	}
+	for _, arg := range fs.Args() {
+		words = append(words, shellQuote(arg))
+	}
+
+	return strings.Join(words, " ")
+}
+
+// passedValues returns the values of the flag, one per repetition of a
+// repeatable one.
+func passedValues(f *flag.Flag) []string {
+	switch value := f.Value.(type) {
+	case *stringsFlag:
+		return *value
+	case pluginFlag:
+		return value.values()
+	}
+
+	return []string{f.Value.String()}
+}
+
+// zoneName returns the IANA name of the location, resolving the local time
+// zone from TZ or /etc/localtime. Local is returned when it can't be.
+func zoneName(location *time.Location) string {
+	if location != time.Local {
+		return location.String()
+	}
+	if tz, ok := os.LookupEnv("TZ"); ok {
+		if tz == "" {
+			return "UTC"
+		}
+		return strings.TrimPrefix(tz, ":")
+	}
+	if target, err := filepath.EvalSymlinks("/etc/localtime"); err == nil {
+		if _, name, found := strings.Cut(filepath.ToSlash(target), "/zoneinfo/"); found {
+			return name
+		}
+	}
+
+	return location.String()
+}
+
+// shellQuote quotes the argument for a POSIX shell, when it needs to be.
+func shellQuote(arg string) string {
+	if unquotedArg.MatchString(arg) {
+		return arg
+	}
+
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// githubRepoPattern matches the org/name of a GitHub repository.
+var githubRepoPattern = regexp.MustCompile(`^[\w.-]+/[\w.-]+$`)
+
+// reviewFlags are the flags adding the code review stats of forges to the
+// report. Nothing is fetched unless one of them names a project.
+type reviewFlags struct {
+	githubRepo    *string
+	githubUser    *string
+	gitlabProject *string
+	gitlabURL     *string
+	gitlabUser    *string
+}
+
+func addReviewFlags(fs *flag.FlagSet) *reviewFlags {
+	flags := &reviewFlags{}
+	flags.githubRepo = fs.String("github-repo", "", "Add the pull requests opened, merged and reviewed in this org/name GitHub repository to the report, with the token in GITHUB_TOKEN")
+	flags.githubUser = fs.String("github-user", "", "The GitHub login the pull requests are counted for. Default=the owner of GITHUB_TOKEN")
+	flags.gitlabProject = fs.String("gitlab-project", "", "Add the merge requests opened, merged, reviewed and approved in this group/project GitLab project to the report, with the token in GITLAB_TOKEN")
+	flags.gitlabURL = fs.String("gitlab-url", defaultGitlabURL, "The GitLab instance hosting --gitlab-project")
+	flags.gitlabUser = fs.String("gitlab-user", "", "The GitLab username the merge requests are counted for. Default=the owner of GITLAB_TOKEN")
+
+	return flags
+}
+
+func (f *reviewFlags) validate() error {
+	if *f.githubRepo != "" {
+		if !githubRepoPattern.MatchString(*f.githubRepo) {
+			return usagef("Invalid --github-repo %q, expected org/name", *f.githubRepo)
+		}
+		if *f.githubUser == "" && os.Getenv("GITHUB_TOKEN") == "" {
+			return usagef("Forgot to set GITHUB_TOKEN or --github-user for --github-repo")
+		}
+	}
+
+	if *f.gitlabProject != "" {
+		// Projects can sit in nested groups, so only the outer form is checked.
+		if strings.Trim(*f.gitlabProject, "/") != *f.gitlabProject || !strings.Contains(*f.gitlabProject, "/") {
+			return usagef("Invalid --gitlab-project %q, expected group/project", *f.gitlabProject)
+		}
+		parsed, err := url.Parse(*f.gitlabURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return usagef("Invalid --gitlab-url %q, expected the URL of a GitLab instance like %s", *f.gitlabURL, defaultGitlabURL)
+		}
+		if *f.gitlabUser == "" && os.Getenv("GITLAB_TOKEN") == "" {
+			return usagef("Forgot to set GITLAB_TOKEN or --gitlab-user for --gitlab-project")
+		}
+	}
+
+	return nil
+}
+
+// fetch adds the review stats to the summary. Failures are only warned
+// about, the report stands on its own.
+func (f *reviewFlags) fetch(ctx context.Context, summary *wrapped.Summary, opts wrapped.Options) {
+	if *f.githubRepo != "" {
+		client := newForgeClient("GitHub", os.Getenv("GITHUB_TOKEN"), opts, func(request *http.Request, token string) {
+			request.Header.Set("Authorization", "Bearer "+token)
+			request.Header.Set("Accept", "application/vnd.github+json")
+		})
+		stats, err := githubReviews(ctx, client, githubAPI(), *f.githubRepo, *f.githubUser, summary.Window)
+		if err != nil {
+			warnReviews("GitHub", err)
+		} else {
+			summary.Reviews = append(summary.Reviews, stats)
+		}
+	}
+
+	if *f.gitlabProject != "" {
+		client := newForgeClient("GitLab", os.Getenv("GITLAB_TOKEN"), opts, authorizeGitlab)
+		stats, err := gitlabReviews(ctx, client, strings.TrimSuffix(*f.gitlabURL, "/"), *f.gitlabProject, *f.gitlabUser, summary.Window)
+		if err != nil {
+			warnReviews("GitLab", err)
+		} else {
+			summary.Reviews = append(summary.Reviews, stats)
+		}
+	}
+}
+
+// githubAPI returns GITHUB_API_URL, or the API of github.com.
+func githubAPI() string {
+	api := strings.TrimSuffix(os.Getenv("GITHUB_API_URL"), "/")
+	if api == "" {
+		return defaultGithubAPI
+	}
+
+	return api
+}
+
+type githubSearch struct {
+	TotalCount        int  `json:"total_count"`
+	IncompleteResults bool `json:"incomplete_results"`
+	Items             []struct {
+		CreatedAt   time.Time `json:"created_at"`
+		PullRequest struct {
+			MergedAt *time.Time `json:"merged_at"`
+		} `json:"pull_request"`
+	} `json:"items"`
+}
+
+// githubReviews counts the pull requests of the login in the repository
+// through the search API: the ones opened and merged during the window, and
+// the ones of others opened during the window that the login reviewed.
+// Searches can't filter on when a review was submitted, so reviews of pull
+// requests opened before the window aren't counted.
+func githubReviews(ctx context.Context, client *forgeClient, api string, repo string, login string, window wrapped.AnalysisWindow) (wrapped.ReviewStats, error) {
+	stats := wrapped.ReviewStats{Forge: "GitHub", Noun: "PR"}
+	if login == "" {
+		user := struct {
+			Login string `json:"login"`
+		}{}
+		err := client.getJSON(ctx, api+"/user", &user)
+		if err != nil {
+			return stats, err
+		}
+		login = user.Login
+	}
+
+	period := window.Start.Format(time.RFC3339) + ".." + window.End.Add(-time.Second).Format(time.RFC3339)
+	search := func(query string, perPage int) string {
+		values := url.Values{"q": {fmt.Sprintf("repo:%s is:pr %s", repo, query)}, "per_page": {fmt.Sprint(perPage)}}
+		return api + "/search/issues?" + values.Encode()
+	}
+	count := func(query string) (int, error) {
+		result := githubSearch{}
+		err := client.getJSON(ctx, search(query, 1), &result)
+		return result.TotalCount, err
+	}
+
+	var err error
+	stats.Opened, err = count(fmt.Sprintf("author:%s created:%s", login, period))
+	if err != nil {
+		return stats, err
+	}
+	stats.Reviewed, err = count(fmt.Sprintf("reviewed-by:%s -author:%s created:%s", login, login, period))
+	if err != nil {
+		return stats, err
+	}
+
+	mergeTimes := make([]time.Duration, 0)
+	err = client.getPages(ctx, search(fmt.Sprintf("author:%s is:merged merged:%s", login, period), 100), func(body []byte) (bool, error) {
+		result := githubSearch{}
+		err := json.Unmarshal(body, &result)
+		if err != nil {
+			return false, err
+		}
+		stats.Merged = result.TotalCount
+		for _, item := range result.Items {
+			if item.PullRequest.MergedAt != nil {
+				mergeTimes = append(mergeTimes, item.PullRequest.MergedAt.Sub(item.CreatedAt))
+			}
+		}
+
+		return len(result.Items) > 0, nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	stats.MedianTimeToMerge = wrapped.MedianDuration(mergeTimes)
+
+	return stats, nil
+}
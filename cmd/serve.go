@@ -0,0 +1,423 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var serveCommand = &command{
+	name:        "serve",
+	summary:     "Serve the wrapped of the repositories in a directory over HTTP",
+	description: "Serve the wrapped of the repositories in a directory over HTTP. GET /wrapped?repo=<name>&year=<year>&email=<email> returns the json report, or an HTML page for requests accepting text/html. GET /healthz reports whether the server is up and GET /metrics exposes Prometheus metrics of the requests and the cached analyses.",
+	examples: []string{
+		"git-wrapped serve --repos /srv/git",
+		"git-wrapped serve --listen 127.0.0.1:8080 --repos /srv/git --cache-ttl 1h",
+	},
+	setup: setupServe,
+}
+
+func setupServe(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	listenFlag := fs.String("listen", ":8080", "The address the server listens on")
+	reposFlag := fs.String("repos", "", "The directory holding the repositories, the repo parameter names one of them")
+	tzFlag := fs.String("tz", "Local", "The time zone commit times are normalized into unless the request passes tz")
+	cacheTTLFlag := fs.Duration("cache-ttl", 10*time.Minute, "How long the analysis of a repository, year and emails is reused by later requests")
+	metricsStatsFlag := fs.Bool("metrics-stats", false, "Also export the stats of the cached analyses on /metrics, labeled with the repo, the year and a hash of the emails")
+	analysisFlags := addAnalysisFlags(fs)
+	logFlags := addLogFlags(fs)
+
+	return func(ctx context.Context, args []string) error {
+		if len(args) > 0 {
+			return usagef("Unexpected arguments %s, serve takes none", strings.Join(args, " "))
+		}
+		if *reposFlag == "" {
+			return usagef("Forgot to specify the --repos directory holding the repositories")
+		}
+		info, err := os.Stat(*reposFlag)
+		if err != nil || !info.IsDir() {
+			return usagef("Invalid --repos %q, expected a directory", *reposFlag)
+		}
+		if _, err := time.LoadLocation(*tzFlag); err != nil {
+			return usagef("Unknown --tz time zone %q. [err=%s]", *tzFlag, err.Error())
+		}
+
+		opts, err := analysisFlags.options()
+		if err != nil {
+			return err
+		}
+		// Progress and timings would interleave between concurrent requests.
+		opts.Quiet = true
+		opts.Timings = nil
+		opts.Logger = logFlags.logger()
+
+		server := &wrappedServer{
+			reposDir: *reposFlag,
+			tz:       *tzFlag,
+			opts:     opts,
+			flags:    analysisFlags,
+			ttl:      *cacheTTLFlag,
+			base:     ctx,
+			entries:  make(map[analysisKey]*analysisEntry),
+		}
+		server.metrics = newServerMetrics(server, *metricsStatsFlag)
+
+		return analysisFlags.runProfiled(opts, func() error {
+			return server.listenAndServe(ctx, *listenFlag)
+		})
+	}
+}
+
+// analysisKey identifies the analyses requests can share.
+type analysisKey struct {
+	repo   string
+	year   int
+	tz     string
+	emails string
+}
+
+// analysisEntry is an analysis shared by every request for its key, running
+// until it's done or every request waiting for it went away.
+type analysisEntry struct {
+	done     chan struct{}
+	summary  *wrapped.Summary
+	err      error
+	finished time.Time
+	waiters  int
+	cancel   context.CancelFunc
+}
+
+// wrappedServer serves the wrapped of the repositories in reposDir,
+// remembering every analysis for ttl.
+type wrappedServer struct {
+	reposDir string
+	tz       string
+	opts     wrapped.Options
+	flags    *analysisFlags
+	ttl      time.Duration
+	// base is cancelled when the server shuts down, stopping every analysis.
+	base    context.Context
+	metrics *serverMetrics
+
+	mu      sync.Mutex
+	entries map[analysisKey]*analysisEntry
+}
+
+// handler routes the requests of the server, logging them.
+func (s *wrappedServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/wrapped", s.metrics.instrument("wrapped", s.handleWrapped))
+	mux.Handle("/healthz", s.metrics.instrument("healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	}))
+	mux.Handle("/metrics", s.metrics.handler())
+
+	return logRequests(mux)
+}
+
+// listenAndServe serves requests until the context is cancelled, then lets
+// the requests in flight finish.
+func (s *wrappedServer) listenAndServe(ctx context.Context, addr string) error {
+	server := &http.Server{Addr: addr, Handler: s.handler()}
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		shutdownErr <- server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Serving the repositories in %s on %s\n", s.reposDir, addr)
+	err := server.ListenAndServe()
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return <-shutdownErr
+}
+
+func (s *wrappedServer) handleWrapped(w http.ResponseWriter, r *http.Request) {
+	html := strings.Contains(r.Header.Get("Accept"), "text/html")
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, html, http.StatusMethodNotAllowed, "only GET is supported")
+		return
+	}
+
+	key, selection, err := s.parseRequest(r)
+	if err != nil {
+		writeError(w, html, http.StatusBadRequest, err.Error())
+		return
+	}
+	if info, err := os.Stat(filepath.Join(s.reposDir, key.repo)); err != nil || !info.IsDir() {
+		writeError(w, html, http.StatusNotFound, fmt.Sprintf("unknown repository %q", key.repo))
+		return
+	}
+
+	summary, err := s.analysis(r.Context(), key, selection)
+	if err != nil {
+		writeError(w, html, errorStatus(err), err.Error())
+		return
+	}
+
+	if html {
+		writeHTML(w, summary)
+		return
+	}
+	output, err := wrapped.Render("json", summary, wrapped.RenderOptions{})
+	if err != nil {
+		writeError(w, html, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintln(w, output)
+}
+
+// parseRequest validates the query of a /wrapped request. Emails can be
+// repeated or comma separated.
+func (s *wrappedServer) parseRequest(r *http.Request) (analysisKey, wrapped.Selection, error) {
+	query := r.URL.Query()
+	key := analysisKey{repo: filepath.Clean(query.Get("repo")), tz: s.tz}
+	if query.Get("repo") == "" || !filepath.IsLocal(key.repo) {
+		return key, wrapped.Selection{}, fmt.Errorf("invalid repo %q, expected the name of a repository", query.Get("repo"))
+	}
+
+	key.year = defaultYear
+	if year := query.Get("year"); year != "" {
+		parsed, err := strconv.Atoi(year)
+		if err != nil {
+			return key, wrapped.Selection{}, fmt.Errorf("invalid year %q", year)
+		}
+		key.year = parsed
+	}
+
+	if tz := query.Get("tz"); tz != "" {
+		key.tz = tz
+	}
+	location, err := time.LoadLocation(key.tz)
+	if err != nil {
+		return key, wrapped.Selection{}, fmt.Errorf("unknown time zone %q", key.tz)
+	}
+
+	emails := make([]string, 0)
+	for _, email := range query["email"] {
+		emails = append(emails, strings.Split(email, ",")...)
+	}
+	authors := emailSet(emails)
+	if len(authors) == 0 {
+		return key, wrapped.Selection{}, errors.New("missing email, pass the email of the author")
+	}
+	sorted := make([]string, 0, len(authors))
+	for email := range authors {
+		sorted = append(sorted, email)
+	}
+	sort.Strings(sorted)
+	key.emails = strings.Join(sorted, ",")
+
+	return key, wrapped.Selection{Window: wrapped.NewYearWindow(key.year, location), Authors: authors}, nil
+}
+
+// analysis returns the summary for the key, joining the analysis another
+// request started when there is one. An analysis is only cancelled once
+// every request waiting for it is gone, and only successful ones and the
+// ones that found no commits are reused.
+func (s *wrappedServer) analysis(ctx context.Context, key analysisKey, selection wrapped.Selection) (*wrapped.Summary, error) {
+	s.mu.Lock()
+	s.pruneLocked(time.Now())
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &analysisEntry{done: make(chan struct{})}
+		analysisCtx, cancel := s.flags.withTimeout(s.base)
+		entry.cancel = cancel
+		s.entries[key] = entry
+		go s.analyze(analysisCtx, key, selection, entry)
+	}
+	entry.waiters++
+	s.mu.Unlock()
+
+	select {
+	case <-entry.done:
+		s.mu.Lock()
+		entry.waiters--
+		s.mu.Unlock()
+		return entry.summary, entry.err
+	case <-ctx.Done():
+		s.mu.Lock()
+		entry.waiters--
+		if entry.waiters == 0 && entry.finished.IsZero() {
+			entry.cancel()
+			s.removeLocked(key, entry)
+		}
+		s.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (s *wrappedServer) analyze(ctx context.Context, key analysisKey, selection wrapped.Selection, entry *analysisEntry) {
+	defer entry.cancel()
+	path := filepath.Join(s.reposDir, key.repo)
+	summary, err := analyzeSelection(ctx, []string{path}, selection, s.opts)
+	if summary != nil {
+		summary.Generator = generator()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry.summary = summary
+	entry.err = err
+	entry.finished = time.Now()
+	noCommits := &noCommitsError{}
+	if err != nil && !errors.As(err, &noCommits) {
+		s.removeLocked(key, entry)
+	}
+	close(entry.done)
+}
+
+// pruneLocked forgets the analyses older than the ttl.
+func (s *wrappedServer) pruneLocked(now time.Time) {
+	for key, entry := range s.entries {
+		if !entry.finished.IsZero() && now.Sub(entry.finished) > s.ttl {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// countAnalyses returns the number of finished analyses still fresh enough
+// to be reused and the number of the running ones.
+func (s *wrappedServer) countAnalyses() (cached int, running int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, entry := range s.entries {
+		switch {
+		case entry.finished.IsZero():
+			running++
+		case now.Sub(entry.finished) <= s.ttl:
+			cached++
+		}
+	}
+
+	return cached, running
+}
+
+// cachedAnalysis is a successful analysis kept for later requests.
+type cachedAnalysis struct {
+	key     analysisKey
+	summary *wrapped.Summary
+}
+
+// cachedAnalyses returns the successful analyses still fresh enough to be
+// reused.
+func (s *wrappedServer) cachedAnalyses() []cachedAnalysis {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	analyses := make([]cachedAnalysis, 0, len(s.entries))
+	for key, entry := range s.entries {
+		if entry.finished.IsZero() || now.Sub(entry.finished) > s.ttl || entry.err != nil {
+			continue
+		}
+		analyses = append(analyses, cachedAnalysis{key: key, summary: entry.summary})
+	}
+
+	return analyses
+}
+
+// removeLocked forgets the entry, unless a newer analysis replaced it.
+func (s *wrappedServer) removeLocked(key analysisKey, entry *analysisEntry) {
+	if s.entries[key] == entry {
+		delete(s.entries, key)
+	}
+}
+
+// errorStatus maps an analysis error to the status of the response.
+func errorStatus(err error) int {
+	noCommits := &noCommitsError{}
+	emptyRepo := &wrapped.EmptyRepoError{}
+	repoOpen := &wrapped.RepoOpenError{}
+	switch {
+	case errors.As(err, &noCommits):
+		return http.StatusNotFound
+	case errors.As(err, &emptyRepo):
+		return http.StatusNotFound
+	case errors.As(err, &repoOpen):
+		return http.StatusNotFound
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeError(w http.ResponseWriter, html bool, status int, message string) {
+	if html {
+		http.Error(w, message, status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+func writeHTML(w http.ResponseWriter, summary *wrapped.Summary) {
+	page, err := wrapped.Render("html", summary, wrapped.RenderOptions{Top: wrapped.DefaultTop})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintln(w, page)
+}
+
+// statusRecorder remembers the status of a response for the request log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests logs every request with its status and duration to stderr,
+// the emails of the query redacted.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		fmt.Fprintf(os.Stderr, "%s %s %s %d %s\n", start.Format(time.RFC3339), r.Method, redactedURI(r.URL), recorder.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// redactedURI returns the path and query of the URL with every email of the
+// query replaced, so the logs don't collect them.
+func redactedURI(u *url.URL) string {
+	query := u.Query()
+	if len(query["email"]) == 0 {
+		return u.RequestURI()
+	}
+	emails := make([]string, len(query["email"]))
+	for i := range emails {
+		emails[i] = "redacted"
+	}
+	query["email"] = emails
+
+	return u.EscapedPath() + "?" + query.Encode()
+}
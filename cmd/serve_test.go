@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"git-wrapped/pkg/wrapped"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestLogRequestsRedactsEmails(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{target: "/wrapped?repo=app&year=2023", want: " /wrapped?repo=app&year=2023 "},
+		{target: "/wrapped?repo=app&email=dev@example.com&email=me%40example.com", want: " /wrapped?email=redacted&email=redacted&repo=app "},
+		{target: "/healthz", want: " /healthz "},
+	}
+	handler := logRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			logged := captureStderr(t, func() {
+				handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, test.target, nil))
+			})
+			if !strings.Contains(logged, test.want) {
+				t.Errorf("logged %q, want it to contain %q", logged, test.want)
+			}
+			if strings.Contains(logged, "example.com") {
+				t.Errorf("logged %q, want the emails redacted", logged)
+			}
+		})
+	}
+}
+
+func TestMetricsScrapedFromTheirRegistry(t *testing.T) {
+	repo := newTestRepo(t, time.Date(2023, time.March, 14, 10, 0, 0, 0, time.UTC))
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags := addAnalysisFlags(fs)
+	server := &wrappedServer{
+		reposDir: filepath.Dir(repo),
+		tz:       "UTC",
+		opts:     wrapped.Options{Jobs: 1, Quiet: true},
+		flags:    flags,
+		ttl:      time.Hour,
+		base:     context.Background(),
+		entries:  make(map[analysisKey]*analysisEntry),
+	}
+	server.metrics = newServerMetrics(server, true)
+	handler := server.handler()
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		captureStderr(t, func() {
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		})
+		return recorder
+	}
+	if code := serve("/healthz").Code; code != http.StatusOK {
+		t.Fatalf("/healthz returned %d", code)
+	}
+	if code := serve("/wrapped?repo=" + filepath.Base(repo) + "&year=2023&email=dev@example.com").Code; code != http.StatusOK {
+		t.Fatalf("/wrapped returned %d", code)
+	}
+
+	scraped := serve("/metrics").Body.String()
+	for _, want := range []string{
+		`git_wrapped_http_requests_total{code="200",handler="healthz",method="get"} 1`,
+		`git_wrapped_http_requests_total{code="200",handler="wrapped",method="get"} 1`,
+		`git_wrapped_http_request_duration_seconds_count{handler="wrapped",method="get"} 1`,
+		`git_wrapped_http_request_duration_seconds_bucket{handler="healthz",method="get",le="0.01"}`,
+		"git_wrapped_cached_analyses 1",
+		"git_wrapped_running_analyses 0",
+		`git_wrapped_commits_total{email_hash="` + emailHash("dev@example.com") + `",repo="` + filepath.Base(repo) + `",year="2023"} 1`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(scraped, want) {
+			t.Errorf("scraped:\n%s\nwant it to contain %s", scraped, want)
+		}
+	}
+	if strings.Contains(scraped, "dev@example.com") {
+		t.Error("scraped the email of the author, want it hashed")
+	}
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if strings.HasPrefix(family.GetName(), "git_wrapped_") {
+			t.Errorf("the default registry has %s, want every metric on the server's own", family.GetName())
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"os"
+	"time"
+)
+
+// checkShallow warns about the shallow clones among the repositories whose
+// history is cut off after the start of the window, which would leave commits
+// out of the report unnoticed. With unshallow their missing history is fetched
+// instead. Repositories that can't be opened are left to
+// the analysis to report.
+func checkShallow(ctx context.Context, paths []string, window wrapped.AnalysisWindow, unshallow bool, logger wrapped.Logger) error {
+	for _, path := range paths {
+		root, err := wrapped.FindRepoRoot(path)
+		if err != nil {
+			continue
+		}
+		boundary, shallow, err := wrapped.ShallowBoundary(root)
+		if err != nil || !shallow || !boundary.After(window.Start) {
+			continue
+		}
+
+		if unshallow {
+			fmt.Fprintf(os.Stderr, "Fetching the history missing from the shallow clone %s\n", root)
+			err = wrapped.Unshallow(ctx, root, logger)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		fmt.Fprintf(os.Stderr, "Warning: %s is a shallow clone and its history is truncated at %s, its commits from %s until then are missing\n",
+			root, boundary.In(window.Location).Format(time.DateOnly), window.Start.Format(time.DateOnly))
+		fmt.Fprintf(os.Stderr, "  Run git fetch --unshallow in it, or pass --unshallow to fetch them before analyzing\n")
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+)
+
+// signoffFlags are the flags checking the commits for a Signed-off-by
+// trailer.
+type signoffFlags struct {
+	signoff *bool
+	require *float64
+}
+
+func addSignoffFlags(fs *flag.FlagSet) *signoffFlags {
+	flags := &signoffFlags{}
+	flags.signoff = fs.Bool("signoff", false, "Report the percentage of commits with a Signed-off-by trailer naming one of --emails, as the DCO requires")
+	flags.require = fs.Float64("require-signoff", 0, "Like --signoff, but exit with 6 after the report when fewer than this percentage of commits are signed off, e.g. 100 for a year-end compliance check")
+
+	return flags
+}
+
+func (f *signoffFlags) validate() error {
+	if *f.require < 0 || *f.require > 100 {
+		return usagef("Invalid --require-signoff %g, expected a percentage between 0 and 100", *f.require)
+	}
+
+	return nil
+}
+
+// enabled reports whether sign-offs were asked for, they need the commits
+// collected.
+func (f *signoffFlags) enabled() bool {
+	return *f.signoff || *f.require > 0
+}
+
+// count adds the sign-offs to the summary.
+func (f *signoffFlags) count(summary *wrapped.Summary) error {
+	if !f.enabled() {
+		return nil
+	}
+
+	return summary.CountSignoffs()
+}
+
+// check fails when fewer commits than --require-signoff are signed off.
+func (f *signoffFlags) check(summary *wrapped.Summary) error {
+	if *f.require == 0 || summary.Signoffs == nil || summary.Signoffs.Share() >= *f.require {
+		return nil
+	}
+
+	return &complianceError{fmt.Sprintf("only %.1f%% of the commits are signed off, --require-signoff expects %g%%", summary.Signoffs.Share(), *f.require)}
+}
+
+// complianceError is returned when the commits don't meet a requirement like
+// --require-signoff, after the report was output.
+type complianceError struct {
+	reason string
+}
+
+func (e *complianceError) Error() string {
+	return e.reason
+}
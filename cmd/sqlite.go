@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	_ "modernc.org/sqlite"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sqliteSchema creates the tables of --sqlite. Commits are keyed by their
+// hash so exports of several repositories and years accumulate in one
+// database, and line stats are NULL when they weren't computed.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS commits (
+	hash TEXT PRIMARY KEY,
+	repo TEXT NOT NULL,
+	date TEXT NOT NULL,
+	email TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	additions INTEGER,
+	deletions INTEGER,
+	files_changed INTEGER,
+	is_merge INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS commits_email ON commits (email);
+CREATE INDEX IF NOT EXISTS commits_date ON commits (date);
+CREATE TABLE IF NOT EXISTS file_changes (
+	commit_hash TEXT NOT NULL REFERENCES commits (hash),
+	path TEXT NOT NULL,
+	additions INTEGER NOT NULL,
+	deletions INTEGER NOT NULL,
+	PRIMARY KEY (commit_hash, path)
+);
+CREATE TABLE IF NOT EXISTS summary (
+	repos TEXT NOT NULL,
+	emails TEXT NOT NULL,
+	window_start TEXT NOT NULL,
+	window_end TEXT NOT NULL,
+	total_commits INTEGER NOT NULL,
+	additions INTEGER,
+	deletions INTEGER,
+	active_days INTEGER NOT NULL,
+	merge_commits INTEGER NOT NULL,
+	generator TEXT NOT NULL,
+	generated_at TEXT NOT NULL,
+	PRIMARY KEY (repos, emails, window_start, window_end)
+);
+`
+
+// exportSQLite writes the commits and the summary to the database at path,
+// replacing the rows of commits and summaries exported before. Stats a
+// --fast run didn't compute keep their earlier values. The repositories are
+// stored by their absolute path, unless the summary is anonymized and paths
+// are their pseudonyms.
+func exportSQLite(ctx context.Context, path string, summary *wrapped.Summary, paths []string) error {
+	fail := func(err error) error {
+		return fmt.Errorf("unable to export to %s. [err=%s]", path, err.Error())
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fail(err)
+	}
+	defer db.Close()
+
+	_, err = db.ExecContext(ctx, sqliteSchema)
+	if err != nil {
+		return fail(err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fail(err)
+	}
+	defer tx.Rollback()
+
+	err = exportCommits(ctx, tx, summary)
+	if err != nil {
+		return fail(err)
+	}
+	err = exportSummary(ctx, tx, summary, paths)
+	if err != nil {
+		return fail(err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fail(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %s commits to %s\n", wrapped.FormatCount(len(summary.Commits)), path)
+	return nil
+}
+
+func exportCommits(ctx context.Context, tx *sql.Tx, summary *wrapped.Summary) error {
+	upsertCommit, err := tx.PrepareContext(ctx, `
+INSERT INTO commits (hash, repo, date, email, subject, additions, deletions, files_changed, is_merge)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (hash) DO UPDATE SET
+	repo = excluded.repo,
+	date = excluded.date,
+	email = excluded.email,
+	subject = excluded.subject,
+	additions = COALESCE(excluded.additions, commits.additions),
+	deletions = COALESCE(excluded.deletions, commits.deletions),
+	files_changed = COALESCE(excluded.files_changed, commits.files_changed),
+	is_merge = excluded.is_merge`)
+	if err != nil {
+		return err
+	}
+	defer upsertCommit.Close()
+	deleteFiles, err := tx.PrepareContext(ctx, `DELETE FROM file_changes WHERE commit_hash = ?`)
+	if err != nil {
+		return err
+	}
+	defer deleteFiles.Close()
+	insertFile, err := tx.PrepareContext(ctx, `INSERT INTO file_changes (commit_hash, path, additions, deletions) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer insertFile.Close()
+
+	for i := range summary.Commits {
+		commit := &summary.Commits[i]
+		hash := commit.Hash
+		repo, err := repoPath(summary, commit.Repo)
+		if err != nil {
+			return err
+		}
+		additions, deletions, files := sql.NullInt64{}, sql.NullInt64{}, sql.NullInt64{}
+		if commit.HasLineStats {
+			additions = sql.NullInt64{Int64: commit.Additions, Valid: true}
+			deletions = sql.NullInt64{Int64: commit.Deletions, Valid: true}
+			files = sql.NullInt64{Int64: int64(len(commit.Files)), Valid: true}
+		}
+
+		_, err = upsertCommit.ExecContext(ctx, hash, repo, commit.When.Format(time.RFC3339), commit.Email, commit.Subject, additions, deletions, files, commit.Merge)
+		if err != nil {
+			return err
+		}
+		if !commit.HasLineStats {
+			continue
+		}
+		_, err = deleteFiles.ExecContext(ctx, hash)
+		if err != nil {
+			return err
+		}
+		for _, file := range commit.Files {
+			_, err = insertFile.ExecContext(ctx, hash, file.Path, file.Additions, file.Deletions)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// exportSummary writes the summary row of the repositories, authors and
+// window, which later exports of the same ones replace.
+func exportSummary(ctx context.Context, tx *sql.Tx, summary *wrapped.Summary, paths []string) error {
+	repos := make([]string, 0, len(paths))
+	for _, path := range paths {
+		repo, err := repoPath(summary, path)
+		if err != nil {
+			return err
+		}
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	emails := make([]string, 0, len(summary.Selection.Authors))
+	for email := range summary.Selection.Authors {
+		emails = append(emails, email)
+	}
+	sort.Strings(emails)
+
+	additions, deletions := sql.NullInt64{}, sql.NullInt64{}
+	if summary.HasLineStats() {
+		additions = sql.NullInt64{Int64: summary.TotalAdditions(), Valid: true}
+		deletions = sql.NullInt64{Int64: summary.TotalDeletions(), Valid: true}
+	}
+
+	_, err := tx.ExecContext(ctx, `
+INSERT OR REPLACE INTO summary (repos, emails, window_start, window_end, total_commits, additions, deletions, active_days, merge_commits, generator, generated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		strings.Join(repos, ","), strings.Join(emails, ","),
+		summary.Window.Start.Format(time.RFC3339), summary.Window.End.Format(time.RFC3339),
+		summary.TotalCommits, additions, deletions, summary.ActiveDays(), summary.MergeCommits,
+		summary.Generator, time.Now().UTC().Format(time.RFC3339))
+
+	return err
+}
+
+// repoPath returns the absolute path of the repository, or its pseudonym as
+// is in an anonymized summary.
+func repoPath(summary *wrapped.Summary, path string) (string, error) {
+	if summary.Anonymized {
+		return path, nil
+	}
+
+	return filepath.Abs(path)
+}
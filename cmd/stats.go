@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// statFlags are the flags choosing the stats of the report.
+type statFlags struct {
+	enable  *string
+	disable *string
+	list    *bool
+}
+
+func addStatFlags(fs *flag.FlagSet) *statFlags {
+	flags := &statFlags{}
+	flags.enable = fs.String("enable", "", "A comma separated list of the stats to compute and report on top of the default ones, by their ID or their group: "+strings.Join(wrapped.StatGroups(), ", ")+". See --list-stats")
+	flags.disable = fs.String("disable", "", "A comma separated list of the stats to leave out of the analysis and the report, like --enable. Disabling every stat needing diffs skips computing them")
+	flags.list = fs.Bool("list-stats", false, "Print the ID, the group and the description of every stat of --enable and --disable, whether it's enabled by default and whether it needs diffs, and exit")
+
+	return flags
+}
+
+// stats returns the stats enabled: the default ones and the ones of the
+// optIns whose flag is set, keyed by their ID, then the --enable ones, less
+// the --disable ones. Under --fast the default stats needing the diffs are
+// left out, the others needing them having been asked for.
+func (f *statFlags) stats(optIns map[string]*bool, fast bool) (wrapped.StatSet, error) {
+	stats := wrapped.DefaultStats()
+	if fast {
+		for _, stat := range wrapped.Stats() {
+			if stat.Needs&wrapped.CapChanges != 0 {
+				delete(stats, stat.ID)
+			}
+		}
+	}
+	for id, enabled := range optIns {
+		if *enabled {
+			stats[id] = true
+		}
+	}
+	for _, name := range strings.Split(*f.enable, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if err := stats.Set(name, true); err != nil {
+			return nil, usagef("Invalid --enable, expected the IDs or the groups of --list-stats. [err=%s]", err.Error())
+		}
+	}
+	for _, name := range strings.Split(*f.disable, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if err := stats.Set(name, false); err != nil {
+			return nil, usagef("Invalid --disable, expected the IDs or the groups of --list-stats. [err=%s]", err.Error())
+		}
+	}
+
+	return stats, nil
+}
+
+// validateStats rejects the enabled stats the analysis can't compute: the
+// ones needing the diffs under --fast, and the ones of the author's own files
+// for a team.
+func validateStats(stats wrapped.StatSet, team bool, fast bool) error {
+	for _, stat := range wrapped.Stats() {
+		if !stats.Has(stat.ID) {
+			continue
+		}
+		if fast && stat.Needs&wrapped.CapChanges != 0 {
+			return usagef("Unable to combine the %s stat with --fast, it needs the line stats", stat.ID)
+		}
+		if team && (stat.ID == wrapped.StatOwnership || stat.ID == wrapped.StatSurvival || stat.ID == wrapped.StatNeighbors) {
+			return usagef("Unable to combine the %s stat with --team, a team has no files of its own nor neighbors", stat.ID)
+		}
+	}
+
+	return nil
+}
+
+// setOptIns sets the flag of every opt-in stat to whether it's enabled, so
+// enabling the stat works like setting its flag. A flag shared by several
+// stats is set when any of them is.
+func setOptIns(stats wrapped.StatSet, optIns map[string]*bool) {
+	for _, enabled := range optIns {
+		*enabled = false
+	}
+	for id, enabled := range optIns {
+		*enabled = *enabled || stats.Has(id)
+	}
+}
+
+// listStats prints every stat with its group, whether it's enabled by
+// default and whether it needs diffs.
+func listStats(out io.Writer) error {
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tGROUP\tDEFAULT\tDIFFS\tDESCRIPTION")
+	for _, stat := range wrapped.Stats() {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", stat.ID, stat.Group, yesNo(stat.Default), yesNo(stat.Needs&wrapped.CapChanges != 0), stat.Description)
+	}
+
+	return writer.Flush()
+}
+
+// statNames returns the IDs and the groups of the stats, the values of
+// --enable and --disable.
+func statNames() []string {
+	names := make([]string, 0)
+	for _, stat := range wrapped.Stats() {
+		names = append(names, stat.ID)
+	}
+
+	return append(names, wrapped.StatGroups()...)
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"git-wrapped/pkg/wrapped"
+	"strings"
+	"testing"
+)
+
+func TestStatsValidatedOnceResolved(t *testing.T) {
+	tests := []struct {
+		name    string
+		enable  string
+		disable string
+		optIn   bool
+		team    bool
+		fast    bool
+		// err is in the usage error, none when empty.
+		err string
+	}{
+		{name: "defaults"},
+		{name: "fast", fast: true},
+		{name: "fast enabling a deep stat", enable: wrapped.StatOwnership, fast: true, err: "the ownership stat with --fast"},
+		{name: "fast enabling a deep group", enable: wrapped.StatGroupSize, fast: true, err: "the lines stat with --fast"},
+		{name: "fast opting in", optIn: true, fast: true, err: "the ownership stat with --fast"},
+		{name: "fast disabling the opt-in", optIn: true, disable: "ownership,survival,neighbors", fast: true},
+		{name: "team", team: true},
+		{name: "team enabling a stat of the author's files", enable: wrapped.StatNeighbors, team: true, err: "the neighbors stat with --team"},
+		{name: "team opting in", optIn: true, team: true, err: "the ownership stat with --team"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := false
+			flags := &statFlags{enable: &tt.enable, disable: &tt.disable, list: &list}
+			optIn := tt.optIn
+			optIns := map[string]*bool{wrapped.StatOwnership: &optIn, wrapped.StatSurvival: &optIn, wrapped.StatNeighbors: &optIn}
+			stats, err := flags.stats(optIns, tt.fast)
+			if err != nil {
+				t.Fatal(err)
+			}
+			setOptIns(stats, optIns)
+			err = validateStats(stats, tt.team, tt.fast)
+			if tt.err == "" && err != nil {
+				t.Errorf("got %v, want no error", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("got %v, want an error about %q", err, tt.err)
+			}
+		})
+	}
+}
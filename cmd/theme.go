@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	"os"
+	"strings"
+)
+
+// themeFlags are the flags choosing the palette of the colored outputs.
+type themeFlags struct {
+	name *string
+	file *string
+}
+
+func addThemeFlags(fs *flag.FlagSet) *themeFlags {
+	flags := &themeFlags{}
+	flags.name = fs.String("theme", "", "The colors of the text report in a terminal, the image cards and the HTML report: "+strings.Join(wrapped.Themes(), ", ")+". Default=none, leaving the reports uncolored and drawing the cards in the default theme")
+	flags.file = fs.String("theme-file", "", `A JSON file of the colors of --theme, e.g. {"background": "#282a36", "accent": "#bd93f9", "addition": "#50fa7b", "deletion": "#ff5555", "text": "#f8f8f2"}. The ones left out are the default theme's`)
+
+	return flags
+}
+
+// theme returns the theme of the flags, nil when neither is set.
+func (f *themeFlags) theme() (*wrapped.Theme, error) {
+	switch {
+	case *f.name != "" && *f.file != "":
+		return nil, usagef("Unable to combine --theme with --theme-file, the file is the theme")
+	case *f.name != "":
+		theme, ok := wrapped.NamedTheme(*f.name)
+		if !ok {
+			return nil, usagef("Unknown --theme %q, expected one of %s", *f.name, strings.Join(wrapped.Themes(), ", "))
+		}
+		return &theme, nil
+	case *f.file != "":
+		theme, err := wrapped.LoadTheme(*f.file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load the --theme-file. [err=%s]", err.Error())
+		}
+		return &theme, nil
+	}
+
+	return nil, nil
+}
+
+// colorsTerminal reports whether the output colored with a theme is shown
+// with its colors, on a terminal that didn't opt out with NO_COLOR.
+func colorsTerminal(file *os.File) bool {
+	return os.Getenv("NO_COLOR") == "" && isTerminal(file)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"flag"
+	"git-wrapped/pkg/wrapped"
+	"net/url"
+	"regexp"
+)
+
+// ticketFlags are the flags finding ticket references in commit messages.
+type ticketFlags struct {
+	patterns stringsFlag
+	jiraURL  *string
+	compiled []*regexp.Regexp
+}
+
+func addTicketFlags(fs *flag.FlagSet) *ticketFlags {
+	flags := &ticketFlags{}
+	fs.Var(&flags.patterns, "ticket-pattern", "Report the tickets the commit messages reference, matched by this regular expression, e.g. 'PAY-\\d+'. Repeat it for several")
+	flags.jiraURL = fs.String("jira-url", "", "Link the top tickets to this Jira in the markdown and HTML reports, e.g. https://acme.atlassian.net")
+
+	return flags
+}
+
+// enabled reports whether ticket references were asked for, they need the
+// commits collected.
+func (f *ticketFlags) enabled() bool {
+	return len(f.patterns) > 0
+}
+
+func (f *ticketFlags) validate() error {
+	f.compiled = make([]*regexp.Regexp, 0, len(f.patterns))
+	for _, pattern := range f.patterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return usagef("Invalid --ticket-pattern %q. [err=%s]", pattern, err.Error())
+		}
+		f.compiled = append(f.compiled, compiled)
+	}
+
+	if *f.jiraURL != "" {
+		if !f.enabled() {
+			return usagef("Forgot to set --ticket-pattern for --jira-url")
+		}
+		parsed, err := url.Parse(*f.jiraURL)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return usagef("Invalid --jira-url %q, expected an absolute URL like https://acme.atlassian.net", *f.jiraURL)
+		}
+	}
+
+	return nil
+}
+
+// count adds the ticket references to the summary.
+func (f *ticketFlags) count(summary *wrapped.Summary) error {
+	return summary.CountTickets(f.compiled)
+}
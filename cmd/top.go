@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"flag"
+	"git-wrapped/pkg/wrapped"
+	"sort"
+	"strconv"
+)
+
+// sectionAuthors is the ranked list of the leaderboard.
+const sectionAuthors = "authors"
+
+// optionalInt is an int flag that can tell being left out apart from any
+// value it could be set to.
+type optionalInt struct {
+	value int
+	set   bool
+}
+
+func (f *optionalInt) String() string {
+	if f == nil || !f.set {
+		return ""
+	}
+
+	return strconv.Itoa(f.value)
+}
+
+func (f *optionalInt) Set(value string) error {
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+
+	f.value = parsed
+	f.set = true
+	return nil
+}
+
+// topFlags are the flags choosing how many items ranked lists show, --top
+// for all of them and --top-<section> for a single one.
+type topFlags struct {
+	top      *int
+	sections map[string]*optionalInt
+}
+
+// addTopFlags registers --top and an override for every section, described
+// by what its list ranks.
+func addTopFlags(fs *flag.FlagSet, sections map[string]string) *topFlags {
+	flags := &topFlags{sections: make(map[string]*optionalInt)}
+	flags.top = fs.Int("top", wrapped.DefaultTop, "The number of items every ranked list shows, 0 hides them")
+	for section, ranks := range sections {
+		flags.sections[section] = &optionalInt{}
+		fs.Var(flags.sections[section], "top-"+section, "List the top `n` "+ranks+", 0 hides them. Default=--top")
+	}
+
+	return flags
+}
+
+// options validates the flags and returns the render options they describe.
+func (f *topFlags) options() (wrapped.RenderOptions, error) {
+	opts := wrapped.RenderOptions{Top: *f.top, SectionTop: make(map[string]int)}
+	if opts.Top < 0 {
+		return opts, usagef("Invalid --top %d, expected 0 or more", opts.Top)
+	}
+
+	sections := make([]string, 0, len(f.sections))
+	for section := range f.sections {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	for _, section := range sections {
+		top := f.sections[section]
+		if !top.set {
+			continue
+		}
+		if top.value < 0 {
+			return opts, usagef("Invalid --top-%s %d, expected 0 or more", section, top.value)
+		}
+		opts.SectionTop[section] = top.value
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"git-wrapped/pkg/wrapped"
+)
+
+// trailerFlags are the flags counting the trailers of commit messages and
+// their notes.
+type trailerFlags struct {
+	trailers stringsFlag
+	notesRef *string
+}
+
+func addTrailerFlags(fs *flag.FlagSet) *trailerFlags {
+	flags := &trailerFlags{}
+	fs.Var(&flags.trailers, "trailer", "Report how many commits carry this trailer, e.g. Reviewed-by or Signed-off-by. Repeat it for several, Reviewed-by also ranks the reviewers")
+	flags.notesRef = fs.String("notes-ref", "refs/notes/review", "Also read the --trailer trailers of the git notes under this ref, empty reads none")
+
+	return flags
+}
+
+// enabled reports whether trailers were asked for, they need the commits
+// collected.
+func (f *trailerFlags) enabled() bool {
+	return len(f.trailers) > 0
+}
+
+// count adds the trailer counts to the summary, reading the notes of every
+// repository first.
+func (f *trailerFlags) count(ctx context.Context, summary *wrapped.Summary, paths []string) error {
+	if !f.enabled() {
+		return nil
+	}
+
+	notes := map[string]string{}
+	if *f.notesRef != "" {
+		var err error
+		notes, err = wrapped.ReadNotes(ctx, paths, *f.notesRef)
+		if err != nil {
+			return err
+		}
+	}
+
+	return summary.CountTrailers(f.trailers, notes)
+}
@@ -0,0 +1,414 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"git-wrapped/pkg/wrapped"
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/term"
+	"os"
+	"strings"
+	"time"
+)
+
+// tuiTabs are the pages of the terminal UI, in the order they're cycled
+// through.
+var tuiTabs = []string{"Overview", "Heatmap", "Top Files", "Timing", "Leaderboard"}
+
+const (
+	tabOverview = iota
+	tabHeatmap
+	tabTopFiles
+	tabTiming
+	tabLeaderboard
+)
+
+// spinnerFrames are shown in turn while a section is computed.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// isTerminal reports whether the file is an interactive terminal.
+func isTerminal(file *os.File) bool {
+	return term.IsTerminal(int(file.Fd()))
+}
+
+// terminalWidth returns the columns of the terminal, 80 when they can't be
+// read.
+func terminalWidth(file *os.File) int {
+	width, _, err := term.GetSize(int(file.Fd()))
+	if err != nil || width <= 0 {
+		return 80
+	}
+
+	return width
+}
+
+// tuiYear is what was computed for a single year. The overview, heatmap and
+// timing only need the fast analysis, the line stats and the leaderboard are
+// computed the first time their tab is opened.
+type tuiYear struct {
+	fast        *wrapped.Summary
+	fastErr     error
+	deep        *wrapped.Summary
+	deepErr     error
+	identities  []wrapped.IdentityCount
+	identityErr error
+	// loading tracks the sections being computed, keyed by their tab.
+	loading map[int]bool
+}
+
+// summaryMsg delivers an analysis of a year, deep when it computed the line
+// stats.
+type summaryMsg struct {
+	year    int
+	deep    bool
+	summary *wrapped.Summary
+	err     error
+}
+
+type identitiesMsg struct {
+	year       int
+	identities []wrapped.IdentityCount
+	err        error
+}
+
+type spinnerMsg struct{}
+
+// tuiModel is the state of the terminal UI.
+type tuiModel struct {
+	ctx        context.Context
+	paths      []string
+	selection  wrapped.Selection
+	opts       wrapped.Options
+	renderOpts wrapped.RenderOptions
+
+	// firstYear is the year passed with --year, y cycles back from it.
+	firstYear int
+	year      int
+	years     map[int]*tuiYear
+	tab       int
+	scroll    int
+	height    int
+	spinner   int
+	status    string
+}
+
+// runTUI shows the wrapped in the terminal UI until it's quit.
+func runTUI(ctx context.Context, paths []string, selection wrapped.Selection, opts wrapped.Options, renderOpts wrapped.RenderOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Progress lines would draw over the UI.
+	opts.Quiet = true
+	opts.Timings = nil
+	year := selection.Window.Start.Year()
+	model := &tuiModel{
+		ctx:        ctx,
+		paths:      paths,
+		selection:  selection,
+		opts:       opts,
+		renderOpts: renderOpts,
+		firstYear:  year,
+		year:       year,
+		years:      make(map[int]*tuiYear),
+	}
+
+	_, err := tea.NewProgram(model, tea.WithAltScreen(), tea.WithContext(ctx)).Run()
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+
+	return err
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return tea.Batch(m.load(), m.tick())
+}
+
+func (m *tuiModel) tick() tea.Cmd {
+	return tea.Tick(100*time.Millisecond, func(time.Time) tea.Msg {
+		return spinnerMsg{}
+	})
+}
+
+// current returns the state of the year shown, starting its fast analysis
+// the first time.
+func (m *tuiModel) current() *tuiYear {
+	state, ok := m.years[m.year]
+	if !ok {
+		state = &tuiYear{loading: make(map[int]bool)}
+		m.years[m.year] = state
+	}
+
+	return state
+}
+
+// selectionOf returns the selection of another year.
+func (m *tuiModel) selectionOf(year int) wrapped.Selection {
+	selection := m.selection
+	selection.Window = wrapped.NewYearWindow(year, m.selection.Window.Location)
+	return selection
+}
+
+// load starts computing what the current tab needs and isn't computed or
+// being computed yet.
+func (m *tuiModel) load() tea.Cmd {
+	state := m.current()
+	year := m.year
+	selection := m.selectionOf(year)
+
+	cmds := make([]tea.Cmd, 0, 2)
+	if state.fast == nil && state.fastErr == nil && !state.loading[tabOverview] {
+		state.loading[tabOverview] = true
+		opts := m.opts
+		opts.Fast = true
+		cmds = append(cmds, func() tea.Msg {
+			summary, err := analyzeSelection(m.ctx, m.paths, selection, opts)
+			return summaryMsg{year: year, summary: summary, err: err}
+		})
+	}
+
+	switch {
+	case m.tab == tabTopFiles && !m.opts.Fast && state.deep == nil && state.deepErr == nil && !state.loading[tabTopFiles]:
+		state.loading[tabTopFiles] = true
+		opts := m.opts
+		cmds = append(cmds, func() tea.Msg {
+			summary, err := analyzeSelection(m.ctx, m.paths, selection, opts)
+			return summaryMsg{year: year, deep: true, summary: summary, err: err}
+		})
+	case m.tab == tabLeaderboard && state.identities == nil && state.identityErr == nil && !state.loading[tabLeaderboard]:
+		state.loading[tabLeaderboard] = true
+		leaderboard := selection
+		leaderboard.Authors = nil
+		cmds = append(cmds, func() tea.Msg {
+			identities, err := wrapped.CountIdentities(m.ctx, m.paths, leaderboard)
+			return identitiesMsg{year: year, identities: identities, err: err}
+		})
+	}
+
+	return tea.Batch(cmds...)
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+	case spinnerMsg:
+		m.spinner = (m.spinner + 1) % len(spinnerFrames)
+		return m, m.tick()
+	case summaryMsg:
+		state := m.years[msg.year]
+		if msg.deep {
+			state.deep, state.deepErr = msg.summary, msg.err
+			state.loading[tabTopFiles] = false
+		} else {
+			state.fast, state.fastErr = msg.summary, msg.err
+			state.loading[tabOverview] = false
+		}
+	case identitiesMsg:
+		state := m.years[msg.year]
+		state.identities, state.identityErr = msg.identities, msg.err
+		if state.identities == nil && state.identityErr == nil {
+			state.identities = []wrapped.IdentityCount{}
+		}
+		state.loading[tabLeaderboard] = false
+	case tea.KeyMsg:
+		return m, m.handleKey(msg)
+	}
+
+	return m, nil
+}
+
+func (m *tuiModel) handleKey(msg tea.KeyMsg) tea.Cmd {
+	m.status = ""
+	switch msg.String() {
+	case "q", "ctrl+c", "esc":
+		return tea.Quit
+	case "right", "l", "tab":
+		m.tab = (m.tab + 1) % len(tuiTabs)
+		m.scroll = 0
+	case "left", "h", "shift+tab":
+		m.tab = (m.tab + len(tuiTabs) - 1) % len(tuiTabs)
+		m.scroll = 0
+	case "down", "j":
+		m.scroll++
+	case "up", "k":
+		if m.scroll > 0 {
+			m.scroll--
+		}
+	case "y":
+		// Cycle back through the last five years, then start over.
+		m.year--
+		if m.year <= m.firstYear-5 {
+			m.year = m.firstYear
+		}
+		m.scroll = 0
+	case "Y":
+		m.year++
+		if m.year > m.firstYear {
+			m.year = m.firstYear - 4
+		}
+		m.scroll = 0
+	case "e":
+		m.status = m.export()
+	default:
+		return nil
+	}
+
+	return m.load()
+}
+
+// export writes the current view as text, returning the status to show.
+func (m *tuiModel) export() string {
+	name := fmt.Sprintf("git-wrapped-%d-%s.txt", m.year, strings.ReplaceAll(strings.ToLower(tuiTabs[m.tab]), " ", "-"))
+	err := os.WriteFile(name, []byte(m.body()+"\n"), 0o644)
+	if err != nil {
+		return fmt.Sprintf("Unable to export the view. [err=%s]", err.Error())
+	}
+
+	return "Exported the view to " + name
+}
+
+func (m *tuiModel) View() string {
+	builder := strings.Builder{}
+	for i, tab := range tuiTabs {
+		if i == m.tab && m.renderOpts.Theme != nil {
+			builder.WriteString(m.renderOpts.Theme.Highlight(" " + tab + " "))
+		} else if i == m.tab {
+			builder.WriteString("\x1b[7m " + tab + " \x1b[0m")
+		} else {
+			builder.WriteString(" " + tab + " ")
+		}
+	}
+	builder.WriteString(fmt.Sprintf("  %d\n\n", m.year))
+
+	lines := strings.Split(m.body(), "\n")
+	visible := len(lines)
+	if m.height > 4 {
+		visible = m.height - 4
+	}
+	if m.scroll > len(lines)-1 {
+		m.scroll = len(lines) - 1
+	}
+	end := m.scroll + visible
+	if end > len(lines) {
+		end = len(lines)
+	}
+	builder.WriteString(strings.Join(lines[m.scroll:end], "\n"))
+	builder.WriteString("\n\n")
+
+	help := "←/→ tabs  ↑/↓ scroll  y/Y years  e export  q quit"
+	if m.status != "" {
+		help = m.status
+	}
+	builder.WriteString(help)
+
+	return builder.String()
+}
+
+// body renders the current tab as plain text, the way it's exported.
+func (m *tuiModel) body() string {
+	state := m.current()
+	spinner := spinnerFrames[m.spinner] + " "
+
+	switch m.tab {
+	case tabTopFiles:
+		switch {
+		case m.opts.Fast:
+			return "Line stats are off with --fast."
+		case state.deepErr != nil:
+			return state.deepErr.Error()
+		case state.deep == nil:
+			return spinner + "Computing the line stats..."
+		}
+		return tuiTopFiles(state.deep, m.renderOpts.Limit(wrapped.SectionFiles))
+	case tabLeaderboard:
+		switch {
+		case state.identityErr != nil:
+			return state.identityErr.Error()
+		case state.identities == nil:
+			return spinner + "Counting the commits of every author..."
+		}
+		return tuiLeaderboard(state.identities, m.renderOpts.Limit(sectionAuthors))
+	}
+
+	switch {
+	case state.fastErr != nil:
+		return state.fastErr.Error()
+	case state.fast == nil:
+		return spinner + "Analyzing the commits..."
+	}
+
+	switch m.tab {
+	case tabHeatmap:
+		return wrapped.Heatmap(state.fast)
+	case tabTiming:
+		return tuiTiming(state.fast)
+	}
+
+	// The overview shows the line stats once they've been computed.
+	summary := state.fast
+	if state.deep != nil {
+		summary = state.deep
+	}
+	output, err := wrapped.Render("text", summary, wrapped.RenderOptions{})
+	if err != nil {
+		return err.Error()
+	}
+
+	return output
+}
+
+// tuiTiming draws the commits per hour of the day.
+func tuiTiming(summary *wrapped.Summary) string {
+	most := 0
+	for _, count := range summary.ByHour {
+		if count > most {
+			most = count
+		}
+	}
+
+	const width = 40
+	builder := strings.Builder{}
+	for hour, count := range summary.ByHour {
+		bar := 0
+		if most > 0 {
+			bar = count * width / most
+		}
+		builder.WriteString(fmt.Sprintf("%02d:00 %-*s %d\n", hour, width, strings.Repeat("█", bar), count))
+	}
+
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func tuiTopFiles(summary *wrapped.Summary, top int) string {
+	files := summary.TopFiles()
+	if len(files) == 0 {
+		return "No files changed."
+	}
+	if top > 0 && len(files) > top {
+		files = files[:top]
+	}
+
+	builder := strings.Builder{}
+	for i, file := range files {
+		builder.WriteString(fmt.Sprintf("%3d. %s: +%d/-%d in %s\n", i+1, file.Path, file.Additions, file.Deletions, wrapped.FormatCountOf(file.Commits, "commit", "commits")))
+	}
+
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func tuiLeaderboard(identities []wrapped.IdentityCount, top int) string {
+	if len(identities) == 0 {
+		return "No commits in the year."
+	}
+	if top > 0 && len(identities) > top {
+		identities = identities[:top]
+	}
+
+	builder := strings.Builder{}
+	for i, identity := range identities {
+		builder.WriteString(fmt.Sprintf("%3d. %s <%s>: %s commits\n", i+1, identity.Name, identity.Email, wrapped.FormatCount(identity.Commits)))
+	}
+
+	return strings.TrimSuffix(builder.String(), "\n")
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Build metadata, set with
+//
+//	go build -ldflags "-X git-wrapped/cmd.version=v1.2.3 -X git-wrapped/cmd.commit=$(git rev-parse HEAD) -X git-wrapped/cmd.date=$(date -u +%FT%TZ)"
+//
+// Builds without them, like go install, fall back to the build info embedded
+// by the go command.
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+var versionCommand = &command{
+	name:        "version",
+	summary:     "Print the version of git-wrapped",
+	description: "Print the version, commit and build date of git-wrapped and the Go version it was built with.",
+	examples: []string{
+		"git-wrapped version",
+		"git-wrapped --version",
+	},
+	setup: func(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+		return func(ctx context.Context, args []string) error {
+			fmt.Println(buildInfo())
+			return nil
+		}
+	},
+}
+
+func isVersion(arg string) bool {
+	return arg == "-version" || arg == "--version"
+}
+
+// buildMetadata returns the version, commit and build date, filling in from
+// the embedded build info whatever wasn't set through the linker.
+func buildMetadata() (string, string, string) {
+	v, c, d := version, commit, date
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			v = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && c == "":
+				c = setting.Value
+			case setting.Key == "vcs.time" && d == "":
+				d = setting.Value
+			case setting.Key == "vcs.modified" && setting.Value == "true" && c != "" && !strings.HasSuffix(c, "-dirty"):
+				c += "-dirty"
+			}
+		}
+	}
+	if v == "" {
+		v = "dev"
+	}
+
+	return v, c, d
+}
+
+// buildInfo describes the build in a single line.
+func buildInfo() string {
+	v, c, d := buildMetadata()
+	if c == "" {
+		c = "unknown"
+	}
+	if d == "" {
+		d = "unknown"
+	}
+
+	return fmt.Sprintf("git-wrapped %s (commit %s, built %s, %s)", v, c, d, runtime.Version())
+}
+
+// generator identifies the build in generated reports.
+func generator() string {
+	v, c, _ := buildMetadata()
+	if c != "" {
+		return fmt.Sprintf("git-wrapped %s (%s)", v, c)
+	}
+
+	return "git-wrapped " + v
+}
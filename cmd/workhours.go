@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"flag"
+	"git-wrapped/pkg/wrapped"
+	"time"
+)
+
+// workPatternFlags are the flags placing the commits in or out of the work
+// hours.
+type workPatternFlags struct {
+	fs          *flag.FlagSet
+	workPattern *bool
+	workHours   *string
+	workDays    *string
+	hours       wrapped.WorkHours
+}
+
+func addWorkPatternFlags(fs *flag.FlagSet) *workPatternFlags {
+	flags := &workPatternFlags{fs: fs}
+	flags.workPattern = fs.Bool("work-pattern", false, "Report the percentage of commits made in work hours, the commits after 22:00 and the longest run of days with after-hours commits, in the time zone of every commit unless --tz is set")
+	flags.workHours = fs.String("work-hours", "09:00-18:00", "The hours of the day --work-pattern counts as work, the end excluded")
+	flags.workDays = fs.String("work-days", "mon-fri", "The weekdays --work-pattern counts as work, a comma separated list of days or ranges, e.g. mon-thu or mon,wed,fri")
+
+	return flags
+}
+
+// enabled reports whether the work pattern was asked for, it needs the
+// commits collected.
+func (f *workPatternFlags) enabled() bool {
+	return *f.workPattern
+}
+
+func (f *workPatternFlags) validate() error {
+	if !f.enabled() {
+		if isSet(f.fs, "work-hours") || isSet(f.fs, "work-days") {
+			return usagef("Forgot to set --work-pattern for --work-hours or --work-days")
+		}
+		return nil
+	}
+
+	f.hours = wrapped.DefaultWorkHours
+	if err := f.hours.ParseHours(*f.workHours); err != nil {
+		return usagef("Invalid --work-hours. [err=%s]", err.Error())
+	}
+	if err := f.hours.ParseDays(*f.workDays); err != nil {
+		return usagef("Invalid --work-days. [err=%s]", err.Error())
+	}
+
+	return nil
+}
+
+// count adds the work pattern to the summary. The commits stay in their own
+// time zone unless --tz was set, a 23:00 commit from a trip abroad is after
+// hours wherever home is.
+func (f *workPatternFlags) count(summary *wrapped.Summary) error {
+	if !f.enabled() {
+		return nil
+	}
+
+	var location *time.Location
+	if isSet(f.fs, "tz") {
+		location = summary.Window.Location
+	}
+
+	return summary.CountWorkPattern(f.hours, location)
+}
@@ -0,0 +1,130 @@
+package wrapped
+
+import "fmt"
+
+// The thresholds of the achievements.
+const (
+	centurionCommits       = 100
+	nightShiftCommits      = 50
+	springCleanerDeletions = 1000
+	streakMasterDays       = 30
+	// nightShiftEnd is the hour the commits after midnight stop counting
+	// towards the night shift.
+	nightShiftEnd = 5
+)
+
+// Achievement is a badge unlocked by a stat reaching its threshold, with the
+// evidence of when or where it did.
+type Achievement struct {
+	Emoji string
+	Name  string
+	// Rule tells what unlocks the achievement, e.g. "100+ commits".
+	Rule     string
+	Evidence string
+}
+
+// achievement is the definition of a badge: unlock returns the evidence of
+// the summary unlocking it, ok false while it's locked.
+type achievement struct {
+	emoji  string
+	name   string
+	rule   string
+	unlock func(s *Summary) (evidence string, ok bool)
+}
+
+// achievements are every badge, in the order the reports list them.
+var achievements = []achievement{
+	{emoji: "💯", name: "Centurion", rule: fmt.Sprintf("%d+ commits", centurionCommits), unlock: (*Summary).centurion},
+	{emoji: "🌙", name: "Night Shift", rule: fmt.Sprintf("%d+ commits after midnight", nightShiftCommits), unlock: (*Summary).nightShift},
+	{emoji: "🧹", name: "Spring Cleaner", rule: fmt.Sprintf("a commit deleting %s+ lines", FormatCount(springCleanerDeletions)), unlock: (*Summary).springCleaner},
+	{emoji: "🔗", name: "Streak Master", rule: fmt.Sprintf("a %d-day streak", streakMasterDays), unlock: (*Summary).streakMaster},
+}
+
+// Achievements returns the badges the summary unlocked, none when the
+// achievements stat is disabled.
+func (s *Summary) Achievements() []Achievement {
+	if !s.shows(StatAchievements) {
+		return nil
+	}
+
+	unlocked := make([]Achievement, 0)
+	for _, badge := range achievements {
+		if evidence, ok := badge.unlock(s); ok {
+			unlocked = append(unlocked, Achievement{Emoji: badge.emoji, Name: badge.name, Rule: badge.rule, Evidence: evidence})
+		}
+	}
+
+	return unlocked
+}
+
+// centurion is unlocked by the 100th commit, on the day it was made.
+func (s *Summary) centurion() (string, bool) {
+	if s.TotalCommits < centurionCommits {
+		return "", false
+	}
+
+	commits := 0
+	for day := s.Window.Start; day.Before(s.Window.End); day = day.AddDate(0, 0, 1) {
+		if activity, ok := s.ByDay[s.Window.dayKey(day)]; ok {
+			commits += activity.Count
+		}
+		if commits >= centurionCommits {
+			return fmt.Sprintf("commit #%d on %s", centurionCommits, day.Format(yearDayLayout)), true
+		}
+	}
+
+	return "", false
+}
+
+// nightShift is unlocked by the commits made between midnight and 5:00, the
+// earliest riser being the first of them in the day.
+func (s *Summary) nightShift() (string, bool) {
+	commits := 0
+	for _, count := range s.ByHour[:nightShiftEnd] {
+		commits += count
+	}
+	if commits < nightShiftCommits || s.Earliest == nil {
+		return "", false
+	}
+
+	when := s.when(s.Earliest)
+	return fmt.Sprintf("%d commits between midnight and %d:00, as early as %s on %s (%s)", commits, nightShiftEnd, when.Format("15:04"), when.Format(yearDayLayout), s.Earliest.Hash[:shortHashLength]), true
+}
+
+// springCleaner is unlocked by the commit deleting the most lines.
+func (s *Summary) springCleaner() (string, bool) {
+	if !s.has(fieldLineStats) || s.Cleanest == nil || s.CleanestDeletions < springCleanerDeletions {
+		return "", false
+	}
+
+	return fmt.Sprintf("-%s lines on %s (%s)", FormatCount(int(s.CleanestDeletions)), s.when(s.Cleanest).Format(yearDayLayout), s.Cleanest.Hash[:shortHashLength]), true
+}
+
+// streakMaster is unlocked by the longest streak of days with commits.
+func (s *Summary) streakMaster() (string, bool) {
+	start, days := s.longestStreak()
+	if days < streakMasterDays {
+		return "", false
+	}
+
+	last := start.AddDate(0, 0, days-1)
+	return fmt.Sprintf("%d days from %s to %s", days, start.Format(yearDayLayout), last.Format(yearDayLayout)), true
+}
+
+// considerCleanest keeps the commit deleting the most lines, the earliest on
+// ties.
+func (s *Summary) considerCleanest(commit *Commit, deletions int64) {
+	if commit == nil {
+		return
+	}
+	if s.Cleanest == nil || deletions > s.CleanestDeletions || (deletions == s.CleanestDeletions && commitBefore(commit, s.Cleanest)) {
+		s.Cleanest = commit
+		s.CleanestDeletions = deletions
+	}
+}
+
+// sentence describes the achievement and its evidence, e.g. "Centurion (100+
+// commits): commit #100 on Mar 14".
+func (a Achievement) sentence() string {
+	return fmt.Sprintf("%s (%s): %s", a.Name, a.Rule, a.Evidence)
+}
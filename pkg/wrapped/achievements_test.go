@@ -0,0 +1,116 @@
+package wrapped
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// achievementSummary returns the summary of the commits made at every time,
+// the deletions of each.
+func achievementSummary(times []time.Time, deletions int64) *Summary {
+	summary := NewSummary(false, NewYearWindow(2023, time.UTC))
+	for i, when := range times {
+		summary.add(syntheticCommit(fmt.Sprint(i), "dev@example.com", when, 1, deletions), "repo")
+	}
+	summary.Finish()
+
+	return summary
+}
+
+// daily returns the start and the same time on each of the days after it.
+func daily(start time.Time, days int) []time.Time {
+	times := make([]time.Time, 0, days)
+	for i := 0; i < days; i++ {
+		times = append(times, start.AddDate(0, 0, i))
+	}
+
+	return times
+}
+
+// unlocked returns the names of the badges the summary unlocked with their
+// evidence.
+func unlocked(summary *Summary) map[string]string {
+	names := make(map[string]string)
+	for _, achievement := range summary.Achievements() {
+		names[achievement.Name] = achievement.Evidence
+	}
+
+	return names
+}
+
+func TestAchievementThresholds(t *testing.T) {
+	// Every other day at noon, so no streak or night shift gets in the way.
+	everyOtherDay := func(commits int) []time.Time {
+		times := make([]time.Time, 0, commits)
+		for i := 0; i < commits; i++ {
+			times = append(times, time.Date(2023, time.January, 1+2*(i%180), 12, i/180, 0, 0, time.UTC))
+		}
+		return times
+	}
+	night := time.Date(2023, time.February, 1, 2, 0, 0, 0, time.UTC)
+	noon := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		name      string
+		badge     string
+		at, below *Summary
+		evidence  string
+	}{
+		{
+			name:     "centurion",
+			badge:    "Centurion",
+			at:       achievementSummary(everyOtherDay(centurionCommits), 0),
+			below:    achievementSummary(everyOtherDay(centurionCommits-1), 0),
+			evidence: "commit #100 on Jul 18",
+		},
+		{
+			name:     "night shift",
+			badge:    "Night Shift",
+			at:       achievementSummary(daily(night, nightShiftCommits), 0),
+			below:    achievementSummary(daily(night, nightShiftCommits-1), 0),
+			evidence: "50 commits between midnight and 5:00, as early as 02:00 on Feb 1",
+		},
+		{
+			name:     "spring cleaner",
+			badge:    "Spring Cleaner",
+			at:       achievementSummary([]time.Time{noon}, springCleanerDeletions),
+			below:    achievementSummary([]time.Time{noon}, springCleanerDeletions-1),
+			evidence: "-1,000 lines on Mar 1",
+		},
+		{
+			name:     "streak master",
+			badge:    "Streak Master",
+			at:       achievementSummary(daily(noon, streakMasterDays), 0),
+			below:    achievementSummary(daily(noon, streakMasterDays-1), 0),
+			evidence: "30 days from Mar 1 to Mar 30",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			evidence, ok := unlocked(tt.at)[tt.badge]
+			if !ok {
+				t.Fatalf("got %v at the threshold, want %s unlocked", unlocked(tt.at), tt.badge)
+			}
+			if !strings.HasPrefix(evidence, tt.evidence) {
+				t.Errorf("got the evidence %q, want it to start with %q", evidence, tt.evidence)
+			}
+			if _, ok := unlocked(tt.below)[tt.badge]; ok {
+				t.Errorf("got %s unlocked just below the threshold", tt.badge)
+			}
+		})
+	}
+}
+
+func TestAchievementsDisabled(t *testing.T) {
+	summary := achievementSummary(daily(time.Date(2023, time.March, 1, 2, 0, 0, 0, time.UTC), 120), springCleanerDeletions)
+	if got := len(summary.Achievements()); got != len(achievements) {
+		t.Fatalf("got %d achievements, want all %d", got, len(achievements))
+	}
+
+	summary.Stats = DefaultStats()
+	delete(summary.Stats, StatAchievements)
+	if got := summary.Achievements(); len(got) != 0 {
+		t.Errorf("got %v with the stat disabled, want none", got)
+	}
+}
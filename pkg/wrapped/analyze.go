@@ -0,0 +1,936 @@
+package wrapped
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// summaryFields flags the optional parts of a Summary, so renderers can
+// tell stats that weren't computed apart from stats that are zero.
+type summaryFields uint
+
+const (
+	// fieldLineStats covers everything derived from diffs: the averages and
+	// the largest and smallest commits.
+	fieldLineStats summaryFields = 1 << iota
+	// fieldCommitList covers Commits, which is only collected on request
+	// since it keeps every matched commit in memory.
+	fieldCommitList
+)
+
+type Summary struct {
+	Fields summaryFields
+	// Window is the period analyzed. Its time zone is the one every commit
+	// time is normalized into before comparing, so commits made in different
+	// zones rank consistently.
+	Window       AnalysisWindow
+	TotalCommits int64
+	// Earliest and Latest are the commits made the earliest and the latest
+	// in the day, the earliest riser and the latest night, whatever their
+	// date.
+	Earliest *Commit
+	Latest   *Commit
+	// FirstOfYear and LastOfYear are the first and the last commit of the
+	// window by their full timestamp.
+	FirstOfYear *Commit
+	LastOfYear  *Commit
+	Largest     *Commit
+	Smallest    *Commit
+	// Widest is the commit changing the most files counted by the line
+	// stats, WidestFiles of them.
+	Widest      *Commit
+	WidestFiles int
+	// Cleanest is the commit deleting the most lines counted by the line
+	// stats, CleanestDeletions of them.
+	Cleanest          *Commit
+	CleanestDeletions int64
+	// SingleFileCommits is the number of commits changing exactly one file
+	// counted by the line stats.
+	SingleFileCommits int64
+	AverageAdditions  float64
+	AverageDeletions  float64
+	// ByDay is the activity of every day with commits, keyed by its date in
+	// the window's time zone.
+	ByDay map[string]*dayActivity
+	// ByHour is the number of commits made in every hour of the day, in the
+	// window's time zone.
+	ByHour [24]int
+	// CommitSizes counts the commits with line stats in every bucket of
+	// sizeBuckets, by the lines they changed.
+	CommitSizes []int
+	// StatsErrors lists the commits whose line stats couldn't be computed.
+	// They still count towards every stat that doesn't need a diff.
+	StatsErrors []CommitError
+	// Oversized lists the commits left out of the line stats for going over
+	// the CommitLimits, with why. Like StatsErrors they still count towards
+	// every other stat.
+	Oversized []CommitError
+	// Sampling is set when the line stats were estimated from a sample of
+	// the commits.
+	Sampling *Sampling
+	// CoAuthored is set when the commits co-authored by the authors were
+	// looked for, see Selection.Coauthors.
+	CoAuthored *CoAuthored
+	// CorruptSkipped lists the commits the walk couldn't read, corrupt or
+	// missing objects, left out with the history only they reach.
+	CorruptSkipped []CommitError
+	// UnreachableSkipped is the number of matching commits left out because
+	// no ref reaches them.
+	UnreachableSkipped int
+	// MergeCommits is the number of merge commits. Unless merges count
+	// towards the line stats, MergeAdditions and MergeDeletions stay zero.
+	MergeCommits   int64
+	MergeAdditions int64
+	MergeDeletions int64
+	// MergeStats is the --merge-stats policy the summary was computed with.
+	MergeStats string
+	// Branches are the branches the code of the repositories with commits
+	// was read from, sorted, see Selection.Branch.
+	Branches []string
+	// Selection is how the commits were selected, set once the summaries
+	// of the repositories are merged.
+	Selection Selection
+	// Generator names the tool version the report is generated by, so
+	// shared reports can be traced back to it.
+	Generator string
+	// Metadata are the rest of how the report was computed, for its footer
+	// and the metadata of the json report, both left out when nil.
+	Metadata *Metadata
+	// Stats are the stats the reports show, nil showing every stat the
+	// summary has.
+	Stats StatSet
+	// EmptyCommits is the number of commits that changed no lines counted by
+	// the line stats, e.g. ones created with --allow-empty or only changing
+	// file modes. They're never picked as the smallest commit.
+	EmptyCommits int64
+	// Repos is the activity in every repository with commits, most commits
+	// first, when the summaries of the repositories were merged with
+	// MergeRepo.
+	Repos []RepoActivity
+	// Commits lists every matched commit in chronological order when the
+	// summary was asked to collect them.
+	Commits []ListedCommit
+	// Reviews are the code review stats fetched from forges, which the
+	// analysis of the repositories leaves empty.
+	Reviews []ReviewStats
+	// StatLines are the lines added by the stat plugins, see RunPlugins.
+	StatLines []StatLine
+	// Team is set for a team wrapped of every author, see FindTeam.
+	Team *TeamStats
+	// Tickets are the ticket references of the commit messages, set by
+	// CountTickets.
+	Tickets *TicketStats
+	// Trailers are the counts of the trailers of the commit messages and
+	// notes, set by CountTrailers.
+	Trailers *TrailerStats
+	// Signoffs are the commits signed off by the author, set by
+	// CountSignoffs.
+	Signoffs *SignoffStats
+	// Releases are the tags of the window shipping the author's commits, set
+	// from FindReleases.
+	Releases *ReleaseStats
+	// Fixes are the fix and feature commits, set by CountFixes.
+	Fixes *FixStats
+	// WorkPattern is when the commits were made relative to the work hours,
+	// set by CountWorkPattern.
+	WorkPattern *WorkPattern
+	// Neighbors are the other authors changing the same files, set from
+	// FindNeighbors.
+	Neighbors []Neighbor
+	// Ownership counts the files the author is the top committer of, set
+	// from FindOwnership.
+	Ownership *Ownership
+	// Survival counts the added lines still alive at the end of the window,
+	// set from FindSurvival.
+	Survival *Survival
+	// History is the number of commits in every year of the history, set
+	// from FindHistory.
+	History *History
+	// Spotlight is the file the author changed the most, set from
+	// FindSpotlight.
+	Spotlight *Spotlight
+	// CoChanges is the graph of the files changed together, set by
+	// FindCoChanges.
+	CoChanges *CoChangeGraph
+	// Anonymized is set once an Anonymizer replaced the emails, paths and
+	// messages of the summary.
+	Anonymized bool
+
+	// files is the activity of every file counted by the line stats, ranked
+	// by TopFiles.
+	files map[string]*FileActivity
+	// identities is the activity of every email commits were matched by,
+	// see Identities.
+	identities    map[string]*identityActivity
+	largestSize   int64
+	smallestSize  int64
+	additionCount int64
+	deletionCount int64
+	// statsCommits is the number of commits with line stats, which the
+	// averages are taken over.
+	statsCommits int64
+	// unsampled is the number of commits left out of the line stats by the
+	// sample, until they're estimated.
+	unsampled int64
+	// changes are the change records of the analysis, by commit, for the
+	// stats walking the window again, see changeIndex.
+	changes *changeIndex
+}
+
+// CommitError is an error that only affected a single commit.
+type CommitError struct {
+	Hash string
+	Err  error
+}
+
+// dayActivity is the activity of a single day, kept instead of its commits.
+// Only the hash of the first commit of the day is remembered, which is all
+// the report needs to call the day out.
+type dayActivity struct {
+	Count int
+	// First and Last are the times of the first and the last commit of the
+	// day, in the window's location.
+	First time.Time
+	Last  time.Time
+	Hash  string
+	// Additions and Deletions are the line stats of the day's commits.
+	Additions int64
+	Deletions int64
+}
+
+// span describes the hours of the day's commits, e.g. "from 09:12 to
+// 18:40", empty when they were all made at the same minute.
+func (d *dayActivity) span() string {
+	first, last := d.First.Format("15:04"), d.Last.Format("15:04")
+	if first == last {
+		return ""
+	}
+
+	return fmt.Sprintf("from %s to %s", first, last)
+}
+
+func timeToInt(t time.Time) int {
+	return t.Hour()*10000 + t.Minute()*100 + t.Second()
+}
+
+// commitStats is the per-commit record produced by the stats workers and
+// merged into the Summary by the reducer.
+type commitStats struct {
+	commit *object.Commit
+	changeRecord
+	// statsErr is set when the line stats couldn't be computed.
+	statsErr error
+	merge    bool
+	// skipLines is set for merges that don't count towards the line stats.
+	skipLines bool
+	// unsampled is set for the commits the sample left out of the line
+	// stats.
+	unsampled bool
+	// oversized is why the commit was left out of the line stats for going
+	// over the limits.
+	oversized string
+	// coauthor is the author of the selection a commit another author made
+	// is credited to, named by a Co-authored-by line.
+	coauthor string
+}
+
+// identity returns the email the commit is credited to.
+func (c commitStats) identity() string {
+	if c.coauthor != "" {
+		return c.coauthor
+	}
+
+	return c.commit.Author.Email
+}
+
+// fileStats holds the line stats of a single file changed by a commit.
+type fileStats struct {
+	Name      string `json:"name"`
+	Additions int64  `json:"additions"`
+	Deletions int64  `json:"deletions"`
+	// Change is how the commit changed the file, one of the Change
+	// constants.
+	Change string `json:"change"`
+}
+
+func (c commitStats) size() int64 {
+	return c.additions + c.deletions
+}
+
+// has reports whether the summary includes the given optional fields.
+func (s *Summary) has(fields summaryFields) bool {
+	return s.Fields&fields == fields
+}
+
+// HasLineStats reports whether the line based stats were computed, they
+// aren't in fast mode.
+func (s *Summary) HasLineStats() bool {
+	return s.has(fieldLineStats)
+}
+
+// commitSource feeds commits to yield as they're found, stopping early if
+// yield returns an error.
+type commitSource func(yield func(*object.Commit) error) error
+
+// analyzeOptions controls how analyze computes the commit stats.
+type analyzeOptions struct {
+	jobs     int
+	cache    *statsCache
+	progress *progressReporter
+	// capabilities are what's computed on top of the metadata of the
+	// commits, none skipping the diffs and every line based stat.
+	capabilities Capability
+	// diff is how the commits are diffed, commitChanges when nil.
+	diff differ
+	// changes are where the change records are kept, by commit, when the
+	// capabilities include CapChangeIndex.
+	changes *changeIndex
+	// filter decides which files count towards the line stats.
+	filter PathFilter
+	// limits bound the line stats of every commit.
+	limits CommitLimits
+	// budget throttles the diffs computed at once by their size.
+	budget *memoryBudget
+	// objects are the object caches of the handles of the workers, shared
+	// when sized.
+	objects *objectCaches
+	// restored are the commits of the last run, added to the summary along
+	// with the ones of the source, each of which record is called with when
+	// set.
+	restored []commitStats
+	record   func(commitStats)
+	timings  *Timings
+	window   AnalysisWindow
+	// strict aborts the analysis when a single commit's stats fail, instead
+	// of leaving that commit out of the line stats.
+	strict bool
+	// mergeStats is how merge commits count towards the line stats, either
+	// MergeStatsNone or MergeStatsFirstParent.
+	mergeStats string
+	// sample are the commits the line stats are computed for, every commit's
+	// when nil, and sampleSeed the seed they were picked by.
+	sample     map[plumbing.Hash]bool
+	sampleSeed string
+	// listCommits collects every matched commit into the summary.
+	listCommits bool
+	// commitLog records every matched commit when set, with the email of
+	// authors it was matched by.
+	commitLog *CommitLog
+	authors   map[string]bool
+	logger    Logger
+	// label names the repository in log messages.
+	label string
+}
+
+// has reports whether the analysis computes the capabilities.
+func (o analyzeOptions) has(capabilities Capability) bool {
+	return o.capabilities&capabilities == capabilities
+}
+
+// cacheCounts are the number of commits whose line stats were read from the
+// cache and computed, updated atomically by the workers.
+type cacheCounts struct {
+	hits   int64
+	misses int64
+}
+
+const (
+	// MergeStatsNone only counts merge commits as merges. Their diff against
+	// the first parent is the whole merged branch, which would credit the
+	// author of the merge with lines other people wrote.
+	MergeStatsNone = "none"
+	// MergeStatsFirstParent counts the diff of merge commits against their
+	// first parent, like git show.
+	MergeStatsFirstParent = "first-parent"
+)
+
+// InterruptedError is returned by analyze when its context is cancelled
+// before every commit was analyzed.
+type InterruptedError struct {
+	Processed int
+	Found     int
+	Cause     error
+}
+
+func (e *InterruptedError) Error() string {
+	return fmt.Sprintf("interrupted after %d/%d commits: %s", e.Processed, e.Found, e.Cause)
+}
+
+func (e *InterruptedError) Unwrap() error {
+	return e.Cause
+}
+
+// analyze streams the commits from the source to a pool of workers computing
+// line stats and reduces the results into a summary as they arrive, so only
+// the handful of commits the report prints are kept alive. The reducer only
+// uses comparisons that fall back to the commit hash on ties, so the summary
+// doesn't depend on the order in which the workers finish.
+//
+// Cancelling the context stops the walk and the workers, and analyze returns
+// an *InterruptedError once every goroutine it started has exited.
+func analyze(parent context.Context, path string, source commitSource, opts analyzeOptions) (*Summary, error) {
+	jobs := opts.jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	if opts.diff == nil {
+		opts.diff = commitChanges
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	found := int64(0)
+	counts := &cacheCounts{}
+	producerDone := make(chan struct{})
+
+	pending := make(chan *object.Commit, jobs)
+	results := make(chan commitStats, jobs)
+	errs := make(chan error, jobs+1)
+
+	stopStats := opts.timings.Start(phaseStats)
+	go func() {
+		defer close(producerDone)
+		defer close(pending)
+		stopEnumerate := opts.timings.Start(phaseEnumerate)
+		defer stopEnumerate()
+		err := source(func(commit *object.Commit) error {
+			atomic.AddInt64(&found, 1)
+			opts.progress.found()
+			select {
+			case pending <- commit:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				errs <- err
+				cancel()
+			}
+			return
+		}
+		opts.progress.enumerated()
+	}()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := statsWorker(ctx, path, opts, counts, pending, results)
+			if err != nil {
+				errs <- err
+				cancel()
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	summary := NewSummary(!opts.has(CapChanges), opts.window)
+	summary.changes = opts.changes
+	summary.MergeStats = opts.mergeStats
+	if opts.listCommits {
+		summary.Fields |= fieldCommitList
+	}
+	reduce := func(result commitStats) {
+		if opts.authors != nil && !opts.authors[result.commit.Author.Email] {
+			result.coauthor = coauthoredBy(result.commit, opts.authors)
+		}
+		summary.add(result, opts.label)
+		if opts.commitLog != nil {
+			opts.commitLog.add(result, path, opts.authors, summary.has(fieldLineStats))
+		}
+	}
+	for _, result := range opts.restored {
+		reduce(result)
+	}
+	for result := range results {
+		reduce(result)
+		if opts.record != nil {
+			opts.record(result)
+		}
+		opts.progress.step()
+	}
+	<-producerDone
+	opts.progress.finish()
+	stopStats()
+	opts.timings.analyzed(summary.TotalCommits)
+	if opts.has(CapChanges) {
+		opts.logger.Logf(LevelVerbose, "%s: line stats of %s commits read from the cache, %s computed", opts.label,
+			FormatCount(int(atomic.LoadInt64(&counts.hits))), FormatCount(int(atomic.LoadInt64(&counts.misses))))
+	}
+
+	if err := parent.Err(); err != nil {
+		return nil, &InterruptedError{
+			Processed: int(summary.TotalCommits),
+			Found:     int(atomic.LoadInt64(&found)),
+			Cause:     context.Cause(parent),
+		}
+	}
+
+	select {
+	case err := <-errs:
+		return nil, err
+	default:
+	}
+
+	if opts.sample != nil {
+		summary.estimate(opts.sampleSeed)
+	}
+	summary.Finish()
+
+	return summary, nil
+}
+
+// statsWorker computes the line stats for every commit it receives. go-git's
+// repository storage isn't safe for concurrent reads, so each worker resolves
+// the commits through its own handle on the repository, caching the objects
+// in opts.objects. Without CapChanges commits are passed through without
+// stats.
+func statsWorker(ctx context.Context, path string, opts analyzeOptions, counts *cacheCounts, pending <-chan *object.Commit, results chan<- commitStats) error {
+	var repo *git.Repository
+	if opts.has(CapChanges) {
+		var err error
+		repo, err = openRoot(path, opts.objects)
+		if err != nil {
+			return err
+		}
+	}
+
+	for commit := range pending {
+		result := commitStats{commit: commit, merge: commit.NumParents() > 1}
+		result.skipLines = result.merge && opts.mergeStats != MergeStatsFirstParent
+
+		result.unsampled = opts.has(CapChanges) && !result.skipLines && opts.sample != nil && !opts.sample[commit.Hash]
+		if opts.has(CapChanges) && !result.skipLines && !result.unsampled {
+			err := computeLineStats(ctx, repo, opts, counts, &result)
+			if reason := oversized(err); reason != "" {
+				opts.logger.Logf(LevelVerbose, "%s: left %s out of the line stats, it %s", opts.label, commit.Hash, reason)
+				result = commitStats{commit: commit, merge: result.merge, oversized: reason}
+			} else if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				if opts.strict {
+					return fmt.Errorf("unable to compute the stats of commit %s: %w", commit.Hash, err)
+				}
+				result = commitStats{commit: commit, merge: result.merge, statsErr: err}
+			}
+		}
+
+		select {
+		case results <- result:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// computeLineStats fills in the line stats of the result's commit, reusing
+// cached stats when available.
+func computeLineStats(ctx context.Context, repo *git.Repository, opts analyzeOptions, counts *cacheCounts, result *commitStats) error {
+	cache := opts.cache
+	hash := result.commit.Hash
+	if cached, ok := cache.get(hash); ok {
+		atomic.AddInt64(&counts.hits, 1)
+		result.additions = cached.Additions
+		result.deletions = cached.Deletions
+		result.files = cached.Files
+		return nil
+	}
+
+	atomic.AddInt64(&counts.misses, 1)
+	local, err := repo.CommitObject(hash)
+	if err != nil {
+		return err
+	}
+
+	commitCtx, cancel := opts.limits.withTimeout(ctx)
+	defer cancel()
+	result.files, err = commitLineStats(commitCtx, local, opts.diff, opts.filter, opts.limits, opts.budget)
+	if err != nil {
+		return opts.limits.timedOut(ctx, commitCtx, err)
+	}
+
+	for _, stat := range result.files {
+		result.additions += stat.Additions
+		result.deletions += stat.Deletions
+	}
+
+	_ = cache.put(hash, &cachedStats{
+		Hash:      hash.String(),
+		Additions: result.additions,
+		Deletions: result.deletions,
+		Files:     result.files,
+	})
+
+	return nil
+}
+
+// commitBefore orders commits by author time, falling back to the
+// lexicographically smaller hash so that commits made in the same second
+// still have a stable order.
+func commitBefore(a, b *Commit) bool {
+	return timeHashBefore(a.Author.When, a.Hash, b.Author.When, b.Hash)
+}
+
+func timeHashBefore(aWhen time.Time, aHash string, bWhen time.Time, bHash string) bool {
+	if !aWhen.Equal(bWhen) {
+		return aWhen.Before(bWhen)
+	}
+	return aHash < bHash
+}
+
+func NewSummary(fast bool, window AnalysisWindow) *Summary {
+	summary := &Summary{
+		Window: window,
+		ByDay:  make(map[string]*dayActivity),
+		files:  make(map[string]*FileActivity),
+
+		identities: make(map[string]*identityActivity),
+	}
+	if !fast {
+		summary.Fields |= fieldLineStats
+	}
+
+	return summary
+}
+
+// when returns the commit's author time in the summary's time zone.
+func (s *Summary) when(commit *Commit) time.Time {
+	return commit.Author.When.In(s.Window.Location)
+}
+
+// mostActiveDay returns the day with the most commits, preferring the earlier
+// day on ties so the pick doesn't depend on map iteration order.
+func (s *Summary) mostActiveDay() *dayActivity {
+	var mostDay *dayActivity
+	for _, byDay := range s.ByDay {
+		if mostDay == nil || byDay.Count > mostDay.Count ||
+			(byDay.Count == mostDay.Count && timeHashBefore(byDay.First, byDay.Hash, mostDay.First, mostDay.Hash)) {
+			mostDay = byDay
+		}
+	}
+
+	return mostDay
+}
+
+// add merges the stats of a single commit, found in the given repository,
+// into the summary.
+func (s *Summary) add(result commitStats, repo string) {
+	commit := newCommit(result.commit)
+	s.TotalCommits++
+
+	s.considerEarliest(commit)
+	s.considerLatest(commit)
+	s.considerFirstLast(commit)
+	if result.merge {
+		s.MergeCommits++
+	}
+	identity := s.identity(result.identity())
+	identity.commits++
+	identity.repos[repo] = true
+	when := s.when(commit)
+	day := &dayActivity{Count: 1, First: when, Last: when, Hash: commit.Hash}
+
+	if result.statsErr != nil {
+		s.StatsErrors = append(s.StatsErrors, CommitError{Hash: commit.Hash, Err: result.statsErr})
+	} else if result.oversized != "" {
+		s.Oversized = append(s.Oversized, CommitError{Hash: commit.Hash, Err: &oversizedError{reason: result.oversized}})
+	} else if result.unsampled {
+		s.unsampled++
+	} else if s.has(fieldLineStats) && !result.skipLines {
+		if result.merge {
+			s.MergeAdditions += result.additions
+			s.MergeDeletions += result.deletions
+		}
+		s.statsCommits++
+		s.additionCount += result.additions
+		s.deletionCount += result.deletions
+		day.Additions, day.Deletions = result.additions, result.deletions
+		identity.additions += result.additions
+		identity.deletions += result.deletions
+		s.considerLargest(commit, result.size())
+		s.considerCleanest(commit, result.deletions)
+		s.addSize(result.size())
+		for _, file := range result.files {
+			s.addFile(file.Name, FileActivity{Commits: 1, Additions: file.Additions, Deletions: file.Deletions})
+		}
+		if s.changes != nil {
+			s.changes.add(result.commit.Hash, result.changeRecord)
+		}
+		if result.size() == 0 {
+			s.EmptyCommits++
+		} else {
+			s.considerSmallest(commit, result.size())
+			s.considerWidest(commit, len(result.files))
+			if len(result.files) == 1 {
+				s.SingleFileCommits++
+			}
+		}
+	}
+
+	if s.has(fieldCommitList) {
+		s.Commits = append(s.Commits, s.listed(commit, result, repo))
+	}
+
+	// ByDay
+	s.ByHour[when.Hour()]++
+	s.addDay(s.Window.dayKey(when), day)
+	identity.days[s.Window.dayKey(when)] = true
+}
+
+// Merge folds another summary, e.g. of a different repository, into this one.
+// Like add, the result doesn't depend on the order summaries are merged in.
+func (s *Summary) Merge(other *Summary) {
+	// Listed commits are collected the same way in every repository.
+	s.Fields |= other.Fields & fieldCommitList
+	// So are the change records, into the same index.
+	if s.changes == nil {
+		s.changes = other.changes
+	}
+	// Corruption is reported even for repositories without a matched commit.
+	s.CorruptSkipped = append(s.CorruptSkipped, other.CorruptSkipped...)
+	// So are the commits only co-authored, left out of the totals.
+	s.mergeCoAuthored(other.CoAuthored)
+	if other.TotalCommits == 0 {
+		return
+	}
+
+	s.TotalCommits += other.TotalCommits
+	s.additionCount += other.additionCount
+	s.deletionCount += other.deletionCount
+	s.statsCommits += other.statsCommits
+	s.StatsErrors = append(s.StatsErrors, other.StatsErrors...)
+	s.Oversized = append(s.Oversized, other.Oversized...)
+	s.mergeSampling(other.Sampling)
+	s.UnreachableSkipped += other.UnreachableSkipped
+	s.MergeCommits += other.MergeCommits
+	s.MergeAdditions += other.MergeAdditions
+	s.MergeDeletions += other.MergeDeletions
+	for _, branch := range other.Branches {
+		i := sort.SearchStrings(s.Branches, branch)
+		if i == len(s.Branches) || s.Branches[i] != branch {
+			s.Branches = append(s.Branches[:i], append([]string{branch}, s.Branches[i:]...)...)
+		}
+	}
+
+	s.considerEarliest(other.Earliest)
+	s.considerLatest(other.Latest)
+	s.considerFirstLast(other.FirstOfYear)
+	s.considerFirstLast(other.LastOfYear)
+	s.EmptyCommits += other.EmptyCommits
+	s.SingleFileCommits += other.SingleFileCommits
+	s.mergeSizes(other.CommitSizes)
+	s.Commits = append(s.Commits, other.Commits...)
+	if s.has(fieldLineStats) && other.statsCommits > 0 {
+		s.considerLargest(other.Largest, other.largestSize)
+		if other.Smallest != nil {
+			s.considerSmallest(other.Smallest, other.smallestSize)
+		}
+		if other.Widest != nil {
+			s.considerWidest(other.Widest, other.WidestFiles)
+		}
+		s.considerCleanest(other.Cleanest, other.CleanestDeletions)
+	}
+
+	for path, file := range other.files {
+		s.addFile(path, *file)
+	}
+	for email, identity := range other.identities {
+		s.mergeIdentity(email, identity)
+	}
+	for hour, count := range other.ByHour {
+		s.ByHour[hour] += count
+	}
+	for day, activity := range other.ByDay {
+		s.addDay(day, activity)
+	}
+}
+
+// Finish computes the stats that can only be derived once every commit has
+// been added. An empty summary is left zeroed.
+func (s *Summary) Finish() {
+	if s.statsCommits > 0 && s.has(fieldLineStats) {
+		s.AverageAdditions = float64(s.additionCount) / float64(s.statsCommits)
+		s.AverageDeletions = float64(s.deletionCount) / float64(s.statsCommits)
+	}
+	s.finishRepos()
+
+	sort.Slice(s.Commits, func(i, j int) bool {
+		a, b := s.Commits[i], s.Commits[j]
+		if a.Hash == b.Hash {
+			return a.Repo < b.Repo
+		}
+		return timeHashBefore(a.When, a.Hash, b.When, b.Hash)
+	})
+	sort.Slice(s.StatsErrors, func(i, j int) bool {
+		return s.StatsErrors[i].Hash < s.StatsErrors[j].Hash
+	})
+	sort.Slice(s.Oversized, func(i, j int) bool {
+		return s.Oversized[i].Hash < s.Oversized[j].Hash
+	})
+	sort.Slice(s.CorruptSkipped, func(i, j int) bool {
+		return s.CorruptSkipped[i].Hash < s.CorruptSkipped[j].Hash
+	})
+}
+
+func (s *Summary) considerEarliest(commit *Commit) {
+	if s.Earliest == nil {
+		s.Earliest = commit
+		return
+	}
+
+	whenInt := timeToInt(s.when(commit))
+	earliestTime := timeToInt(s.when(s.Earliest))
+	if whenInt < earliestTime || (whenInt == earliestTime && commitBefore(commit, s.Earliest)) {
+		s.Earliest = commit
+	}
+}
+
+func (s *Summary) considerLatest(commit *Commit) {
+	if s.Latest == nil {
+		s.Latest = commit
+		return
+	}
+
+	whenInt := timeToInt(s.when(commit))
+	latestTime := timeToInt(s.when(s.Latest))
+	if whenInt > latestTime || (whenInt == latestTime && commitBefore(commit, s.Latest)) {
+		s.Latest = commit
+	}
+}
+
+func (s *Summary) considerFirstLast(commit *Commit) {
+	if s.FirstOfYear == nil || commitBefore(commit, s.FirstOfYear) {
+		s.FirstOfYear = commit
+	}
+	if s.LastOfYear == nil || commitBefore(s.LastOfYear, commit) {
+		s.LastOfYear = commit
+	}
+}
+
+func (s *Summary) considerLargest(commit *Commit, size int64) {
+	if s.Largest == nil || size > s.largestSize || (size == s.largestSize && commitBefore(commit, s.Largest)) {
+		s.Largest = commit
+		s.largestSize = size
+	}
+}
+
+func (s *Summary) considerSmallest(commit *Commit, size int64) {
+	if s.Smallest == nil || size < s.smallestSize || (size == s.smallestSize && commitBefore(commit, s.Smallest)) {
+		s.Smallest = commit
+		s.smallestSize = size
+	}
+}
+
+// CommitsOn returns the number of commits made on the day, in the window's
+// time zone.
+func (s *Summary) CommitsOn(day time.Time) int {
+	activity, ok := s.ByDay[s.Window.dayKey(day)]
+	if !ok {
+		return 0
+	}
+
+	return activity.Count
+}
+
+// TotalAdditions returns the lines added by the commits with line stats.
+func (s *Summary) TotalAdditions() int64 {
+	return s.additionCount
+}
+
+// TotalDeletions returns the lines deleted by the commits with line stats.
+func (s *Summary) TotalDeletions() int64 {
+	return s.deletionCount
+}
+
+// ActiveDays returns the number of days with at least one commit.
+func (s *Summary) ActiveDays() int {
+	return len(s.ByDay)
+}
+
+// LongestStreak returns the most consecutive days with commits.
+func (s *Summary) LongestStreak() int {
+	_, longest := s.longestStreak()
+	return longest
+}
+
+// longestStreak returns the first day of the most consecutive days with
+// commits and their number, the earliest streak on ties.
+func (s *Summary) longestStreak() (time.Time, int) {
+	var start, longestStart time.Time
+	longest, streak := 0, 0
+	for day := s.Window.Start; day.Before(s.Window.End); day = day.AddDate(0, 0, 1) {
+		if _, ok := s.ByDay[s.Window.dayKey(day)]; !ok {
+			streak = 0
+			continue
+		}
+		if streak == 0 {
+			start = day
+		}
+		streak++
+		if streak > longest {
+			longest, longestStart = streak, start
+		}
+	}
+
+	return longestStart, longest
+}
+
+// BusiestHour returns the hour of the day with the most commits and their
+// number, the earliest hour on a tie. The count is zero without commits.
+func (s *Summary) BusiestHour() (int, int) {
+	busiest := 0
+	for hour, count := range s.ByHour {
+		if count > s.ByHour[busiest] {
+			busiest = hour
+		}
+	}
+
+	return busiest, s.ByHour[busiest]
+}
+
+// activeShare returns the percentage of the window's days with commits.
+func (s *Summary) activeShare() float64 {
+	days := s.Window.days()
+	if days == 0 {
+		return 0
+	}
+
+	return float64(s.ActiveDays()) * 100 / float64(days)
+}
+
+func (s *Summary) addDay(day string, activity *dayActivity) {
+	byDay, ok := s.ByDay[day]
+	if !ok {
+		copied := *activity
+		s.ByDay[day] = &copied
+		return
+	}
+
+	byDay.Count += activity.Count
+	byDay.Additions += activity.Additions
+	byDay.Deletions += activity.Deletions
+	if timeHashBefore(activity.First, activity.Hash, byDay.First, byDay.Hash) {
+		byDay.First = activity.First
+		byDay.Hash = activity.Hash
+	}
+	if activity.Last.After(byDay.Last) {
+		byDay.Last = activity.Last
+	}
+}
@@ -0,0 +1,226 @@
+package wrapped
+
+import (
+	"context"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// syntheticCommit returns the stats of a commit made by the email at when,
+// its hash derived from the seed.
+func syntheticCommit(seed string, email string, when time.Time, additions, deletions int64) commitStats {
+	commit := &object.Commit{
+		Hash:    plumbing.Hash(sha1.Sum([]byte(seed))),
+		Author:  object.Signature{Name: email, Email: email, When: when},
+		Message: "commit " + seed,
+	}
+	files := []fileStats{{Name: fmt.Sprintf("file%d.go", additions%3), Additions: additions, Deletions: deletions}}
+
+	return commitStats{commit: commit, changeRecord: changeRecord{additions: additions, deletions: deletions, files: files}}
+}
+
+// tiedCommits are commits tying on every pick the summary makes: the same
+// instant in different time zones, the same time of the day on different
+// days, days with as many commits and commits of the same size.
+func tiedCommits() []commitStats {
+	berlin := time.FixedZone("CET", 60*60)
+	newYork := time.FixedZone("EST", -5*60*60)
+	instant := time.Date(2023, time.March, 14, 6, 30, 0, 0, time.UTC)
+
+	return []commitStats{
+		syntheticCommit("a", "dev@example.com", instant, 10, 2),
+		syntheticCommit("b", "dev@example.com", instant.In(berlin), 10, 2),
+		syntheticCommit("c", "dev@example.com", instant.In(newYork), 10, 2),
+		syntheticCommit("d", "dev@example.com", instant.AddDate(0, 0, 7), 10, 2),
+		syntheticCommit("e", "dev@example.com", instant.AddDate(0, 0, 7).Add(15*time.Hour), 10, 2),
+		syntheticCommit("f", "dev@example.com", instant.AddDate(0, 0, 7).Add(15*time.Hour).In(berlin), 10, 2),
+		syntheticCommit("g", "other@example.com", instant.AddDate(0, 1, 0).Add(15*time.Hour), 1, 1),
+		syntheticCommit("h", "other@example.com", instant.AddDate(0, 1, 0).Add(16*time.Hour).In(newYork), 1, 1),
+		syntheticCommit("i", "other@example.com", instant.AddDate(0, 1, 0).Add(17*time.Hour), 1, 1),
+	}
+}
+
+// renderShuffled adds the commits in a shuffled order, half of them through
+// a summary merged in, and renders the summary in the format.
+func renderShuffled(t *testing.T, commits []commitStats, seed int64, format string) string {
+	shuffled := append([]commitStats{}, commits...)
+	rand.New(rand.NewSource(seed)).Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	window := NewYearWindow(2023, time.UTC)
+	summary, other := NewSummary(false, window), NewSummary(false, window)
+	for i, commit := range shuffled {
+		if i%2 == 0 {
+			summary.add(commit, "repo")
+		} else {
+			other.add(commit, "repo")
+		}
+	}
+	if seed%2 == 0 {
+		summary, other = other, summary
+	}
+	summary.Merge(other)
+	summary.Finish()
+
+	output, err := Render(format, summary, RenderOptions{Top: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return output
+}
+
+func TestSummaryShuffledDeterministic(t *testing.T) {
+	commits := tiedCommits()
+	for _, format := range []string{"text", "json"} {
+		t.Run(format, func(t *testing.T) {
+			want := renderShuffled(t, commits, 0, format)
+			for seed := int64(1); seed < 20; seed++ {
+				if got := renderShuffled(t, commits, seed, format); got != want {
+					t.Fatalf("seed %d rendered differently:\n%s\nwant:\n%s", seed, got, want)
+				}
+			}
+		})
+	}
+}
+
+// waitGoroutines waits for the goroutines to get back to the baseline,
+// failing the test when they don't within a second.
+func waitGoroutines(t *testing.T, baseline int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines left running, want %d:\n%s", runtime.NumGoroutine(), baseline, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAnalyzeCancelledLeaksNoGoroutines(t *testing.T) {
+	repo := newFixture(t)
+	repo.history("dev@example.com", time.Date(2023, time.January, 1, 12, 0, 0, 0, time.UTC), time.Hour, 200)
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	walked := 0
+	source := func(yield func(*object.Commit) error) error {
+		return walkCommits(ctx, repo.repo, time.Time{}, nopLogger{}, skipCorrupt, func(commit *object.Commit) error {
+			if walked++; walked == 50 {
+				cancel()
+			}
+			return yield(commit)
+		})
+	}
+	_, err := analyze(ctx, repo.dir, source, analyzeOptions{
+		jobs:         4,
+		capabilities: CapChanges,
+		objects:      newObjectCaches(0),
+		window:       NewYearWindow(2023, time.UTC),
+		logger:       nopLogger{},
+	})
+
+	var interrupted *InterruptedError
+	if !errors.As(err, &interrupted) {
+		t.Fatalf("got %v, want an *InterruptedError", err)
+	}
+	if interrupted.Processed >= 200 {
+		t.Errorf("processed %d commits, want the analysis stopped early", interrupted.Processed)
+	}
+	waitGoroutines(t, baseline)
+}
+
+// benchmarkDayCommits is the size of the synthetic year of BenchmarkByDay.
+const benchmarkDayCommits = 50000
+
+// BenchmarkByDay adds a year of synthetic commits to a summary, which keeps a
+// record of every day, next to also keeping the commits of every day like
+// the summary used to. live-B/op is the heap still in use once every commit
+// was added.
+func BenchmarkByDay(b *testing.B) {
+	window := NewYearWindow(2023, time.UTC)
+	step := window.End.Sub(window.Start) / benchmarkDayCommits
+	commits := func() []commitStats {
+		commits := make([]commitStats, benchmarkDayCommits)
+		for i := range commits {
+			commits[i] = syntheticCommit(fmt.Sprint(i), "dev@example.com", window.Start.Add(time.Duration(i)*step), int64(i%50), int64(i%7))
+		}
+
+		return commits
+	}
+
+	b.Run("records", func(b *testing.B) {
+		b.ReportAllocs()
+		live := int64(0)
+		for i := 0; i < b.N; i++ {
+			baseline := liveHeap()
+			summary := NewSummary(false, window)
+			for _, commit := range commits() {
+				summary.add(commit, "repo")
+			}
+			live += liveHeap() - baseline
+			runtime.KeepAlive(summary)
+		}
+		b.ReportMetric(float64(live)/float64(b.N), "live-B/op")
+	})
+	b.Run("commits", func(b *testing.B) {
+		b.ReportAllocs()
+		live := int64(0)
+		for i := 0; i < b.N; i++ {
+			baseline := liveHeap()
+			summary := NewSummary(false, window)
+			byDay := make(map[string][]*object.Commit)
+			for _, commit := range commits() {
+				summary.add(commit, "repo")
+				day := window.dayKey(commit.commit.Author.When)
+				byDay[day] = append(byDay[day], commit.commit)
+			}
+			live += liveHeap() - baseline
+			runtime.KeepAlive(summary)
+			runtime.KeepAlive(byDay)
+		}
+		b.ReportMetric(float64(live)/float64(b.N), "live-B/op")
+	})
+}
+
+func TestByDayFirstLast(t *testing.T) {
+	morning := time.Date(2023, time.May, 2, 8, 15, 0, 0, time.UTC)
+	evening := morning.Add(11 * time.Hour)
+	window := NewYearWindow(2023, time.UTC)
+	summary, other := NewSummary(false, window), NewSummary(false, window)
+	summary.add(syntheticCommit("noon", "dev@example.com", morning.Add(4*time.Hour), 1, 0), "repo")
+	other.add(syntheticCommit("evening", "dev@example.com", evening, 1, 0), "other")
+	other.add(syntheticCommit("morning", "dev@example.com", morning, 1, 0), "other")
+	summary.Merge(other)
+
+	day := summary.ByDay[window.dayKey(morning)]
+	if day == nil {
+		t.Fatal("no activity on the day")
+	}
+	if day.Count != 3 || !day.First.Equal(morning) || !day.Last.Equal(evening) {
+		t.Errorf("day = %d commits from %v to %v, want 3 from %v to %v", day.Count, day.First, day.Last, morning, evening)
+	}
+	if want := syntheticCommit("morning", "dev@example.com", morning, 1, 0).commit.Hash.String(); day.Hash != want {
+		t.Errorf("day.Hash = %s, want the first commit %s", day.Hash, want)
+	}
+	if span := day.span(); span != "from 08:15 to 19:15" {
+		t.Errorf("day.span() = %q, want from 08:15 to 19:15", span)
+	}
+	summary.Stats = StatSet{StatActiveDays: true}
+	if report := buildOutput(summary, RenderOptions{}); !strings.Contains(report, ": 3, from 08:15 to 19:15\n") {
+		t.Errorf("got the report:\n%s\nwant the busiest day's span", report)
+	}
+}
@@ -0,0 +1,205 @@
+package wrapped
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"strings"
+)
+
+// pseudonymLength is how many hexadecimal characters a pseudonym has.
+const pseudonymLength = 8
+
+// Anonymizer makes a summary shareable without leaking who made the commits
+// or what they changed. People and repositories are replaced by
+// pseudonyms, file paths are redacted to their depth and extension and
+// commit messages are stripped, while every number and date is kept.
+//
+// Pseudonyms are keyed by the seed, the same seed gives the same pseudonyms
+// across runs and without it they can't be matched back to the emails.
+type Anonymizer struct {
+	seed []byte
+}
+
+// NewAnonymizer returns an anonymizer deriving its pseudonyms from the seed.
+func NewAnonymizer(seed string) *Anonymizer {
+	return &Anonymizer{seed: []byte(seed)}
+}
+
+// Pseudonym returns the short hash standing in for the value.
+func (a *Anonymizer) Pseudonym(value string) string {
+	mac := hmac.New(sha256.New, a.seed)
+	mac.Write([]byte(value))
+
+	return hex.EncodeToString(mac.Sum(nil))[:pseudonymLength]
+}
+
+// Apply anonymizes the summary in place, so every renderer shows the
+// anonymized stats. Stat lines are kept as the plugins wrote them.
+func (a *Anonymizer) Apply(s *Summary) {
+	s.Earliest = a.commit(s.Earliest)
+	s.Latest = a.commit(s.Latest)
+	s.FirstOfYear = a.commit(s.FirstOfYear)
+	s.LastOfYear = a.commit(s.LastOfYear)
+	s.Largest = a.commit(s.Largest)
+	s.Smallest = a.commit(s.Smallest)
+	s.Widest = a.commit(s.Widest)
+	s.Cleanest = a.commit(s.Cleanest)
+
+	if s.Metadata != nil {
+		s.Metadata = s.Metadata.anonymize(a, s.Selection.Authors)
+	}
+	s.Selection.ExcludedAuthors = a.pseudonyms(s.Selection.ExcludedAuthors)
+	if s.Selection.Authors != nil {
+		authors := make(map[string]bool, len(s.Selection.Authors))
+		for email := range s.Selection.Authors {
+			authors[a.Pseudonym(email)] = true
+		}
+		s.Selection.Authors = authors
+	}
+
+	// Files redacted to the same path stay apart, keyed by their pseudonym.
+	files := make(map[string]*FileActivity, len(s.files))
+	for name, file := range s.files {
+		file.Path = redactPath(name)
+		files[a.Pseudonym(name)] = file
+	}
+	s.files = files
+
+	for i := range s.Commits {
+		commit := &s.Commits[i]
+		if commit.Repo != "" {
+			commit.Repo = a.Pseudonym(commit.Repo)
+		}
+		commit.Email = a.Pseudonym(commit.Email)
+		commit.Name = commit.Email
+		commit.Subject = ""
+		commit.Message = ""
+		for j := range commit.Files {
+			commit.Files[j].Path = redactPath(commit.Files[j].Path)
+		}
+	}
+
+	for i := range s.Repos {
+		pseudonym := a.Pseudonym(s.Repos[i].Path)
+		s.Repos[i].Name, s.Repos[i].Path = pseudonym, pseudonym
+	}
+
+	identities := make(map[string]*identityActivity, len(s.identities))
+	for email, identity := range s.identities {
+		repos := make(map[string]bool, len(identity.repos))
+		for repo := range identity.repos {
+			repos[a.Pseudonym(repo)] = true
+		}
+		identity.repos = repos
+		identities[a.Pseudonym(email)] = identity
+	}
+	s.identities = identities
+
+	if s.Team != nil {
+		team := *s.Team
+		team.NewContributors = make([]IdentityCount, 0, len(s.Team.NewContributors))
+		for _, contributor := range s.Team.NewContributors {
+			pseudonym := a.Pseudonym(contributor.Email)
+			team.NewContributors = append(team.NewContributors, IdentityCount{Email: pseudonym, Name: pseudonym, Commits: contributor.Commits})
+		}
+		s.Team = &team
+	}
+
+	// Ticket keys come out of the commit messages, so they're replaced too.
+	if s.Tickets != nil {
+		tickets := *s.Tickets
+		tickets.Tickets = make([]TicketCount, 0, len(s.Tickets.Tickets))
+		for _, ticket := range s.Tickets.Tickets {
+			tickets.Tickets = append(tickets.Tickets, TicketCount{Key: a.Pseudonym(ticket.Key), Commits: ticket.Commits})
+		}
+		s.Tickets = &tickets
+	}
+
+	if s.Fixes != nil && s.Fixes.File != "" {
+		s.Fixes.File = redactPath(s.Fixes.File)
+	}
+	if s.Spotlight != nil {
+		s.Spotlight.Path = redactPath(s.Spotlight.Path)
+	}
+	// Files redacted to the same path stay apart, named by their pseudonym.
+	if s.CoChanges != nil {
+		for i := range s.CoChanges.Files {
+			file := &s.CoChanges.Files[i]
+			file.Label = redactPath(file.Path)
+			file.Path = a.Pseudonym(file.Path)
+		}
+	}
+	if s.Ownership != nil {
+		for i := range s.Ownership.Largest {
+			s.Ownership.Largest[i].Path = redactPath(s.Ownership.Largest[i].Path)
+		}
+	}
+
+	neighbors := make([]Neighbor, 0, len(s.Neighbors))
+	for _, neighbor := range s.Neighbors {
+		pseudonym := a.Pseudonym(neighbor.Email)
+		neighbor.Name, neighbor.Email = pseudonym, pseudonym
+		neighbors = append(neighbors, neighbor)
+	}
+	s.Neighbors = neighbors
+
+	if s.Trailers != nil {
+		trailers := *s.Trailers
+		trailers.Reviewers = make([]TrailerValue, 0, len(s.Trailers.Reviewers))
+		for _, reviewer := range s.Trailers.Reviewers {
+			trailers.Reviewers = append(trailers.Reviewers, TrailerValue{Value: a.Pseudonym(reviewer.Value), Commits: reviewer.Commits})
+		}
+		s.Trailers = &trailers
+	}
+
+	s.Anonymized = true
+}
+
+// ApplyLog anonymizes the commits of the log in place the way Apply does the
+// listed commits.
+func (a *Anonymizer) ApplyLog(l *CommitLog) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.records {
+		record := &l.records[i]
+		record.Repo = a.Pseudonym(record.Repo)
+		record.AuthorEmail = a.Pseudonym(record.AuthorEmail)
+		record.Subject = ""
+		if record.MatchedIdentity != nil {
+			pseudonym := a.Pseudonym(*record.MatchedIdentity)
+			record.MatchedIdentity = &pseudonym
+		}
+	}
+}
+
+// commit returns an anonymized copy, since the same commit can be called out
+// more than once.
+func (a *Anonymizer) commit(commit *Commit) *Commit {
+	if commit == nil {
+		return nil
+	}
+
+	pseudonym := a.Pseudonym(commit.Author.Email)
+	return &Commit{
+		Hash:   commit.Hash,
+		Author: Signature{Name: pseudonym, Email: pseudonym, When: commit.Author.When},
+	}
+}
+
+// redactPath keeps the depth and the extension of the path, e.g.
+// ***/***/***.go. Both sides of a rename are redacted.
+func redactPath(name string) string {
+	if from, to, renamed := strings.Cut(name, " => "); renamed {
+		return redactPath(from) + " => " + redactPath(to)
+	}
+
+	parts := strings.Split(name, "/")
+	for i := range parts[:len(parts)-1] {
+		parts[i] = "***"
+	}
+	parts[len(parts)-1] = "***" + path.Ext(parts[len(parts)-1])
+
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,150 @@
+package wrapped
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// bannerMinWidth is the narrowest terminal the banner is drawn on, narrower
+// ones get its plain text.
+const bannerMinWidth = 60
+
+// bannerFont draws the characters of the banner with box-drawing characters,
+// every glyph being as many lines as the font is tall and its lines as wide
+// as each other.
+type bannerFont map[rune][]string
+
+// bannerFonts are the fonts of the banner, the largest first.
+var bannerFonts = []bannerFont{
+	{
+		'0': {"┏━━━┓", "┃   ┃", "┃   ┃", "┃   ┃", "┗━━━┛"},
+		'1': {" ━┓  ", "  ┃  ", "  ┃  ", "  ┃  ", " ━┻━ "},
+		'2': {"━━━━┓", "    ┃", "┏━━━┛", "┃    ", "┗━━━━"},
+		'3': {"━━━━┓", "    ┃", " ━━━┫", "    ┃", "━━━━┛"},
+		'4': {"┃   ┃", "┃   ┃", "┗━━━┫", "    ┃", "    ┃"},
+		'5': {"┏━━━━", "┃    ", "┗━━━┓", "    ┃", "━━━━┛"},
+		'6': {"┏━━━━", "┃    ", "┣━━━┓", "┃   ┃", "┗━━━┛"},
+		'7': {"━━━━┓", "    ┃", "    ┃", "    ┃", "    ┃"},
+		'8': {"┏━━━┓", "┃   ┃", "┣━━━┫", "┃   ┃", "┗━━━┛"},
+		'9': {"┏━━━┓", "┃   ┃", "┗━━━┫", "    ┃", "━━━━┛"},
+		'-': {"     ", "     ", " ━━━ ", "     ", "     "},
+		',': {"  ", "  ", "  ", " ┓", " ┛"},
+	},
+	{
+		'0': {"┌─┐", "│ │", "└─┘"},
+		'1': {"╶┐ ", " │ ", "╶┴╴"},
+		'2': {"╶─┐", "┌─┘", "└─╴"},
+		'3': {"╶─┐", " ─┤", "╶─┘"},
+		'4': {"╷ ╷", "└─┤", "  ╵"},
+		'5': {"┌─╴", "└─┐", "╶─┘"},
+		'6': {"┌─╴", "├─┐", "└─┘"},
+		'7': {"╶─┐", "  │", "  ╵"},
+		'8': {"┌─┐", "├─┤", "└─┘"},
+		'9': {"┌─┐", "└─┤", "╶─┘"},
+		'-': {"   ", "╶─╴", "   "},
+		',': {" ", " ", "╯"},
+	},
+}
+
+// draw returns the lines drawing the text, a space between its glyphs.
+func (f bannerFont) draw(text string) []string {
+	lines := make([]string, len(f['0']))
+	for i, char := range []rune(text) {
+		for line, glyph := range f[char] {
+			if i > 0 {
+				lines[line] += " "
+			}
+			lines[line] += glyph
+		}
+	}
+
+	return lines
+}
+
+// bannerBlock is a drawn text with its caption under it, both centered.
+type bannerBlock struct {
+	lines []string
+	width int
+}
+
+func newBannerBlock(font bannerFont, text string, caption string) bannerBlock {
+	lines := append(font.draw(text), caption)
+	width := max(utf8.RuneCountInString(lines[0]), utf8.RuneCountInString(caption))
+	for i, line := range lines {
+		lines[i] = strings.Repeat(" ", (width-utf8.RuneCountInString(line))/2) + line
+	}
+
+	return bannerBlock{lines: lines, width: width}
+}
+
+// bannerGap is the space between the blocks drawn side by side.
+const bannerGap = "    "
+
+// banner returns the intro of the text report, the year and the number of
+// commits drawn large and centered in a terminal of the width. It's drawn in
+// the largest font fitting, side by side or else one above the other, and
+// falls back to a line of plain text when the width is under 60 columns or
+// even the smallest font doesn't fit.
+func (s *Summary) banner(width int) string {
+	year := s.bannerYear()
+	commits := FormatCount(int(s.TotalCommits))
+	caption := "commits"
+	if s.TotalCommits == 1 {
+		caption = "commit"
+	}
+	plain := fmt.Sprintf("%s · %s %s\n", year, commits, caption)
+	if width < bannerMinWidth {
+		return plain
+	}
+
+	for _, font := range bannerFonts {
+		left := newBannerBlock(font, year, "git-wrapped")
+		right := newBannerBlock(font, commits, caption)
+
+		if left.width+len(bannerGap)+right.width <= width {
+			lines := make([]string, len(left.lines))
+			for i := range lines {
+				lines[i] = padRight(left.lines[i], left.width) + bannerGap + right.lines[i]
+			}
+			return centerLines(lines, left.width+len(bannerGap)+right.width, width)
+		}
+		if max(left.width, right.width) <= width {
+			return centerLines(left.lines, left.width, width) + "\n" + centerLines(right.lines, right.width, width)
+		}
+	}
+
+	return plain
+}
+
+// bannerYear returns the year of the window, or the years it spans like
+// 2023-2024 when it isn't a single one.
+func (s *Summary) bannerYear() string {
+	first, last := s.Window.Start.Year(), s.Window.LastDay().Year()
+	if first == last {
+		return fmt.Sprint(first)
+	}
+
+	return fmt.Sprintf("%d-%d", first, last)
+}
+
+// centerLines returns the lines of a block as wide as blockWidth centered in
+// the width, each ending with a newline and without trailing spaces.
+func centerLines(lines []string, blockWidth int, width int) string {
+	indent := strings.Repeat(" ", (width-blockWidth)/2)
+	builder := strings.Builder{}
+	for _, line := range lines {
+		line = strings.TrimRight(line, " ")
+		if line != "" {
+			builder.WriteString(indent + line)
+		}
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+// padRight pads the line with spaces to the width.
+func padRight(line string, width int) string {
+	return line + strings.Repeat(" ", width-utf8.RuneCountInString(line))
+}
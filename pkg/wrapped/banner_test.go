@@ -0,0 +1,65 @@
+package wrapped
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var update = flag.Bool("update", false, "Rewrite the golden files of the tests with their output")
+
+// checkGolden compares the output to the golden file in testdata, rewriting
+// it with -update.
+func checkGolden(t *testing.T, name string, output string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(output), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unable to read the golden file, run the test with -update to write it: %v", err)
+	}
+	if output != string(golden) {
+		t.Errorf("got:\n%s\nwant the content of %s:\n%s", output, path, golden)
+	}
+}
+
+func TestBannerWidths(t *testing.T) {
+	summary := NewSummary(true, NewYearWindow(2023, time.UTC))
+	summary.TotalCommits = 1234
+
+	for _, width := range []int{40, 80, 120} {
+		t.Run(fmt.Sprint(width), func(t *testing.T) {
+			banner := summary.banner(width)
+			for _, line := range strings.Split(strings.TrimSuffix(banner, "\n"), "\n") {
+				if utf8.RuneCountInString(line) > width {
+					t.Errorf("%q is wider than %d columns", line, width)
+				}
+			}
+			checkGolden(t, fmt.Sprintf("banner/width-%d.golden", width), banner)
+		})
+	}
+}
+
+func TestBannerSingleCommit(t *testing.T) {
+	summary := NewSummary(true, NewYearWindow(2023, time.UTC))
+	summary.TotalCommits = 1
+
+	if got, want := summary.banner(40), "2023 · 1 commit\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,115 @@
+package wrapped
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"strings"
+)
+
+// originHead is the default branch of the origin remote, as cloning set it.
+const originHead = plumbing.ReferenceName("refs/remotes/origin/HEAD")
+
+// wellKnownBranches are the branches taken for the default one when neither
+// HEAD nor origin/HEAD point at an existing branch, the better-known first.
+var wellKnownBranches = []plumbing.ReferenceName{plumbing.NewBranchReferenceName("main"), plumbing.NewBranchReferenceName("master")}
+
+// errNoDefaultBranch is returned for a repository without any of the
+// candidates of the default branch, like an empty one.
+var errNoDefaultBranch = errors.New("none of HEAD, origin/HEAD, main and master point at a commit, the repository is empty or the branch to analyze has to be picked")
+
+// EmptyRepoError is returned for a repository without a single commit yet,
+// like a freshly initialized one.
+type EmptyRepoError struct {
+	path string
+}
+
+func (e *EmptyRepoError) Error() string {
+	return fmt.Sprintf("the repository at %s has no commits yet", e.path)
+}
+
+// hasCommits returns whether any ref of the repository points at an object,
+// none of them do before the first commit, HEAD pointing at an unborn branch.
+func hasCommits(repo *git.Repository) (bool, error) {
+	refs, err := repo.References()
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.HashReference {
+			found = true
+			return storer.ErrStop
+		}
+		return nil
+	})
+
+	return found, err
+}
+
+// defaultBranch returns the name and the tip of the branch the code of the
+// repository is read from: the branch, when set, with any revision git
+// resolves. Otherwise it's the branch HEAD points at when it exists, the one
+// origin/HEAD points at, main or master, and last the commit a detached HEAD
+// is at, which a bare mirror's stale HEAD or a CI checkout fall back on.
+func defaultBranch(repo *git.Repository, branch string) (string, *object.Commit, error) {
+	if branch != "" {
+		commit, err := resolveRevision(repo, branch)
+		if err != nil {
+			return "", nil, err
+		}
+		return branch, commit, nil
+	}
+
+	candidates := make([]plumbing.ReferenceName, 0)
+	for _, symbolic := range []plumbing.ReferenceName{plumbing.HEAD, originHead} {
+		ref, err := repo.Storer.Reference(symbolic)
+		if err == nil && ref.Type() == plumbing.SymbolicReference {
+			candidates = append(candidates, ref.Target())
+		}
+	}
+	candidates = append(candidates, wellKnownBranches...)
+	for _, name := range candidates {
+		ref, err := repo.Reference(name, true)
+		if err != nil {
+			continue
+		}
+		commit, err := peelToCommit(repo, ref.Hash())
+		if err != nil {
+			return "", nil, err
+		}
+		if commit != nil {
+			return name.Short(), commit, nil
+		}
+	}
+
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err == nil && head.Type() == plumbing.HashReference {
+		commit, err := peelToCommit(repo, head.Hash())
+		if err != nil {
+			return "", nil, err
+		}
+		if commit != nil {
+			return fmt.Sprintf("HEAD (detached at %s)", commit.Hash.String()[:shortHashLength]), commit, nil
+		}
+	}
+
+	return "", nil, errNoDefaultBranch
+}
+
+// branchesSentence names the branches the code was read from, e.g. "Branch:
+// main", empty when none are known.
+func (s *Summary) branchesSentence() string {
+	switch len(s.Branches) {
+	case 0:
+		return ""
+	case 1:
+		return "Branch: " + s.Branches[0]
+	default:
+		return "Branches: " + strings.Join(s.Branches, ", ")
+	}
+}
@@ -0,0 +1,147 @@
+package wrapped
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/go-git/go-git/v5/plumbing"
+	"os"
+	"path/filepath"
+)
+
+// statsCacheVersion is part of the cache directory layout. Bump it whenever
+// cachedStats changes shape so older entries are never decoded.
+const statsCacheVersion = "v2"
+
+// cachedStats is the on-disk representation of a single commit's line stats.
+type cachedStats struct {
+	Hash      string      `json:"hash"`
+	Additions int64       `json:"additions"`
+	Deletions int64       `json:"deletions"`
+	Files     []fileStats `json:"files"`
+}
+
+// statsCache persists per-commit line stats between runs. Entries are stored
+// one file per commit and written through a rename, so concurrent runs against
+// the same cache can only ever observe complete entries. A nil cache is valid
+// and caches nothing.
+type statsCache struct {
+	dir string
+}
+
+// DefaultCacheDir returns the user level cache directory for the tool, e.g.
+// ~/.cache/git-wrapped on Linux.
+func DefaultCacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(base, "git-wrapped"), nil
+}
+
+// repoCacheDir returns the directory holding the cache for the repository at
+// repoPath. Repositories are told apart by a fingerprint of their absolute path.
+func repoCacheDir(baseDir string, repoPath string) (string, error) {
+	absPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(absPath))
+	return filepath.Join(baseDir, hex.EncodeToString(sum[:8])), nil
+}
+
+// openStatsCache opens the cache of the repository at repoPath. Stats depend
+// on the path filter, so each filter gets its own set of entries.
+func openStatsCache(baseDir string, repoPath string, filter PathFilter) (*statsCache, error) {
+	dir, err := repoCacheDir(baseDir, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dir = filepath.Join(dir, statsCacheVersion, filter.key())
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &statsCache{dir: dir}, nil
+}
+
+// ClearStatsCache removes every cached entry, of any version, for the
+// repository containing repoPath.
+func ClearStatsCache(baseDir string, repoPath string) (string, error) {
+	root, err := FindRepoRoot(repoPath)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := repoCacheDir(baseDir, root)
+	if err != nil {
+		return "", err
+	}
+
+	return dir, os.RemoveAll(dir)
+}
+
+func (c *statsCache) entryPath(hash plumbing.Hash) string {
+	name := hash.String()
+	return filepath.Join(c.dir, name[:2], name[2:]+".json")
+}
+
+// get returns the cached stats for the commit, if present. Unreadable entries
+// are treated as misses and get overwritten by the next put.
+func (c *statsCache) get(hash plumbing.Hash) (*cachedStats, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(c.entryPath(hash))
+	if err != nil {
+		return nil, false
+	}
+
+	entry := &cachedStats{}
+	err = json.Unmarshal(data, entry)
+	if err != nil || entry.Hash != hash.String() {
+		return nil, false
+	}
+
+	return entry, true
+}
+
+// put stores the stats for the commit. Failing to write the cache never fails
+// an analysis, so errors are only returned for the caller's information.
+func (c *statsCache) put(hash plumbing.Hash, entry *cachedStats) error {
+	if c == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	path := c.entryPath(hash)
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
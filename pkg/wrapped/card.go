@@ -0,0 +1,304 @@
+package wrapped
+
+import (
+	"bytes"
+	"fmt"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"sync"
+)
+
+const (
+	// CardLandscape is the 1200x630 card of link previews.
+	CardLandscape = "landscape"
+	// CardSquare is the 1080x1080 card of Instagram and Mastodon posts.
+	CardSquare = "square"
+)
+
+// CardLayouts returns the layouts the image cards can be rendered in.
+func CardLayouts() []string {
+	return []string{CardLandscape, CardSquare}
+}
+
+// cardLayout is the size of a card and of its type, in pixels.
+type cardLayout struct {
+	Width  int
+	Height int
+	Margin int
+	// Stats is how many of the headline stats the card shows.
+	Stats int
+	// Title, Subtitle, Value, Label and Footer are the sizes of the type.
+	Title    float64
+	Subtitle float64
+	Value    float64
+	Label    float64
+	Footer   float64
+}
+
+// cardLayouts are the layouts of the cards, keyed by their name.
+var cardLayouts = map[string]cardLayout{
+	CardLandscape: {Width: 1200, Height: 630, Margin: 60, Stats: 4, Title: 56, Subtitle: 28, Value: 60, Label: 24, Footer: 18},
+	CardSquare:    {Width: 1080, Height: 1080, Margin: 90, Stats: 5, Title: 84, Subtitle: 36, Value: 80, Label: 34, Footer: 24},
+}
+
+// cardTextureOpacity is how opaque the heatmap is behind the square card's
+// stats, low enough for them to stay readable.
+const cardTextureOpacity = 0.18
+
+// cardFonts are the embedded Go fonts the cards are written with, parsed once
+// on first use.
+var cardFonts = sync.OnceValues(func() ([2]*opentype.Font, error) {
+	regular, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return [2]*opentype.Font{}, err
+	}
+	bold, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		return [2]*opentype.Font{}, err
+	}
+
+	return [2]*opentype.Font{regular, bold}, nil
+})
+
+// cardStat is a headline stat of the cards, its value written large over
+// its label.
+type cardStat struct {
+	Value string
+	Label string
+}
+
+// cardStats returns the headline stats of the cards, the most telling first.
+func cardStats(summary *Summary) []cardStat {
+	days := func(n int) string {
+		if n == 1 {
+			return "1 day"
+		}
+		return fmt.Sprintf("%d days", n)
+	}
+
+	stats := []cardStat{{Value: FormatCount(int(summary.TotalCommits)), Label: "commits"}}
+	if summary.shows(StatActiveDays) {
+		stats = append(stats, cardStat{Value: FormatCount(summary.ActiveDays()), Label: "active days"})
+	}
+	stats = append(stats, cardStat{Value: days(summary.LongestStreak()), Label: "longest streak"})
+	if summary.has(fieldLineStats) && summary.shows(StatLines) {
+		stats = append(stats, cardStat{
+			Value: FormatCount(int(summary.TotalAdditions() + summary.TotalDeletions())),
+			Label: "lines changed",
+		})
+	} else if hour, count := summary.BusiestHour(); count > 0 {
+		stats = append(stats, cardStat{Value: fmt.Sprintf("%02d:00", hour), Label: "busiest hour"})
+	}
+	if busiest := summary.mostActiveDay(); busiest != nil && summary.shows(StatActiveDays) {
+		stats = append(stats, cardStat{
+			Value: busiest.First.Format(yearDayLayout),
+			Label: "busiest day, " + commitCount(busiest.Count),
+		})
+	}
+
+	return stats
+}
+
+// RenderCard draws the report as a PNG image card to share. The landscape
+// card has a row of headline stats above the heatmap, the square one stacks
+// more of them in larger type over the heatmap, faded into a background
+// texture. The card is drawn in the colors of the theme, DefaultTheme's when
+// it's nil.
+func RenderCard(summary *Summary, layout string, theme *Theme) ([]byte, error) {
+	size, ok := cardLayouts[layout]
+	if !ok {
+		return nil, fmt.Errorf("unknown card layout %q", layout)
+	}
+	canvas, err := newCard(size, RenderOptions{Theme: theme}.theme())
+	if err != nil {
+		return nil, err
+	}
+
+	stats := cardStats(summary)
+	if len(stats) > size.Stats {
+		stats = stats[:size.Stats]
+	}
+	if layout == CardSquare {
+		canvas.squareCard(summary, stats)
+	} else {
+		canvas.landscapeCard(summary, stats)
+	}
+
+	output := bytes.Buffer{}
+	if err := png.Encode(&output, canvas.img); err != nil {
+		return nil, err
+	}
+
+	return output.Bytes(), nil
+}
+
+// card is the image a card is drawn on, with the faces of its type and the
+// colors of its theme.
+type card struct {
+	img    *image.RGBA
+	layout cardLayout
+	theme  Theme
+	muted  color.RGBA
+	heat   []color.RGBA
+	title  font.Face
+	sub    font.Face
+	value  font.Face
+	label  font.Face
+	footer font.Face
+}
+
+// newCard returns a card of the layout filled with the theme's background.
+func newCard(layout cardLayout, theme Theme) (*card, error) {
+	fonts, err := cardFonts()
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the fonts. [err=%s]", err.Error())
+	}
+
+	c := &card{
+		img:    image.NewRGBA(image.Rect(0, 0, layout.Width, layout.Height)),
+		layout: layout,
+		theme:  theme,
+		muted:  theme.muted(),
+		heat:   theme.heat(),
+	}
+	faces := []struct {
+		face  *font.Face
+		font  *opentype.Font
+		point float64
+	}{
+		{&c.title, fonts[1], layout.Title},
+		{&c.sub, fonts[0], layout.Subtitle},
+		{&c.value, fonts[1], layout.Value},
+		{&c.label, fonts[0], layout.Label},
+		{&c.footer, fonts[0], layout.Footer},
+	}
+	for _, face := range faces {
+		*face.face, err = opentype.NewFace(face.font, &opentype.FaceOptions{Size: face.point, DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			return nil, fmt.Errorf("unable to load a %.0fpt font. [err=%s]", face.point, err.Error())
+		}
+	}
+	draw.Draw(c.img, c.img.Bounds(), image.NewUniform(theme.Background), image.Point{}, draw.Src)
+
+	return c, nil
+}
+
+// landscapeCard lays the stats out in a row between the title and the
+// heatmap.
+func (c *card) landscapeCard(summary *Summary, stats []cardStat) {
+	margin, width := c.layout.Margin, c.layout.Width-2*c.layout.Margin
+	c.header(summary, 110, 155)
+
+	column := width / c.layout.Stats
+	for i, stat := range stats {
+		x := margin + i*column
+		c.text(c.value, x, 290, stat.Value, c.theme.Text, column-20)
+		c.text(c.label, x, 330, stat.Label, c.muted, column-20)
+	}
+
+	heatmap := image.Rect(margin, 390, margin+width, c.layout.Height-margin-20)
+	c.heatmap(summary, heatmap, 1, false)
+	c.footerText(summary)
+}
+
+// squareCard stacks the stats under the title, over the heatmap tiled across
+// the whole card as a faded texture.
+func (c *card) squareCard(summary *Summary, stats []cardStat) {
+	c.heatmap(summary, c.img.Bounds(), cardTextureOpacity, true)
+	c.header(summary, 190, 250)
+
+	width := c.layout.Width - 2*c.layout.Margin
+	for i, stat := range stats {
+		y := 380 + i*130
+		c.text(c.value, c.layout.Margin, y, stat.Value, c.theme.Text, width)
+		c.text(c.label, c.layout.Margin, y+44, stat.Label, c.muted, width)
+	}
+	c.footerText(summary)
+}
+
+// header writes the title and the window of the card on the baselines.
+func (c *card) header(summary *Summary, title int, window int) {
+	width := c.layout.Width - 2*c.layout.Margin
+	c.text(c.title, c.layout.Margin, title, "git-wrapped", c.theme.Accent, width)
+	c.text(c.sub, c.layout.Margin, window, fontText(summary.Window.String()), c.muted, width)
+}
+
+// footerText writes the generator in the bottom right corner.
+func (c *card) footerText(summary *Summary) {
+	width := c.layout.Width - 2*c.layout.Margin
+	generator := fitText(fontText(summary.Generator), float64(width), c.measure(c.footer))
+	x := c.layout.Width - c.layout.Margin - int(c.measure(c.footer)(generator))
+	c.text(c.footer, x, c.layout.Height-c.layout.Margin/2, generator, c.muted, width)
+}
+
+// measure returns the width of text written with the face, in pixels.
+func (c *card) measure(face font.Face) func(string) float64 {
+	return func(text string) float64 {
+		return float64(font.MeasureString(face, text).Ceil())
+	}
+}
+
+// text writes the text with its baseline at y, shortened with an ellipsis
+// to fit the width.
+func (c *card) text(face font.Face, x int, y int, text string, ink color.Color, width int) {
+	drawer := font.Drawer{Dst: c.img, Src: image.NewUniform(ink), Face: face, Dot: fixed.P(x, y)}
+	drawer.DrawString(fitText(text, float64(width), c.measure(face)))
+}
+
+// cellSize returns the size of the heatmap's square cells, as large as a
+// column per week of the window and a row per weekday fit in the bounds. A
+// texture has cells large enough for the weeks to cover the whole width.
+func cellSize(summary *Summary, bounds image.Rectangle, texture bool) int {
+	offset := (int(summary.Window.Start.Weekday()) + 6) % 7
+	weeks := (offset + summary.Window.days() + 6) / 7
+	if texture {
+		return (bounds.Dx() + weeks - 1) / weeks
+	}
+
+	cell := bounds.Dx() / weeks
+	if rows := bounds.Dy() / 7; cell > rows {
+		cell = rows
+	}
+	if cell < 1 {
+		cell = 1
+	}
+
+	return cell
+}
+
+// heatmap draws the commits per day like Heatmap, one column per week
+// starting on Monday and one row per weekday, blended in with the opacity.
+// As a texture the weeks are repeated down the bounds rather than drawn once.
+func (c *card) heatmap(summary *Summary, bounds image.Rectangle, opacity float64, texture bool) {
+	window := summary.Window
+	cell := cellSize(summary, bounds, texture)
+	most := 0
+	if busiest := summary.mostActiveDay(); busiest != nil {
+		most = busiest.Count
+	}
+
+	gap := cell / 6
+	mask := image.NewUniform(color.Alpha{A: uint8(opacity * 255)})
+	repeats := 1
+	if texture {
+		repeats = (bounds.Dy() + 7*cell - 1) / (7 * cell)
+	}
+	i := (int(window.Start.Weekday()) + 6) % 7
+	for day := window.Start; day.Before(window.End); day = day.AddDate(0, 0, 1) {
+		ink := image.NewUniform(c.heat[heatLevel(summary.CommitsOn(day), most, len(c.heat))])
+		for repeat := 0; repeat < repeats; repeat++ {
+			x := bounds.Min.X + i/7*cell
+			y := bounds.Min.Y + (repeat*7+i%7)*cell
+			square := image.Rect(x, y, x+cell-gap, y+cell-gap).Intersect(bounds)
+			draw.DrawMask(c.img, square, ink, image.Point{}, mask, image.Point{}, draw.Over)
+		}
+		i++
+	}
+}
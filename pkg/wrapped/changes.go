@@ -0,0 +1,131 @@
+package wrapped
+
+import (
+	"context"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"sync"
+)
+
+// changeRecord is what a commit changed, computed once from its diff: the
+// files kept by the filter with their line stats. Every stat derived from the
+// diffs reads it, none diffs the commit again.
+type changeRecord struct {
+	additions int64
+	deletions int64
+	files     []fileStats
+}
+
+// differ diffs the tree of a commit against its first parent.
+type differ func(ctx context.Context, commit *object.Commit) (object.Changes, error)
+
+// changeIndex keeps the files every commit diffed during a run changed, by
+// hash, across the repositories of the run. The analysis adds the change
+// records of the author's commits, and the stats walking the window again
+// read them and add the other authors' commits they diff, so no commit is
+// diffed twice. The other authors' commits only keep the tracked files, the
+// author's ones, which bounds the memory by them.
+type changeIndex struct {
+	diff differ
+
+	mu      sync.Mutex
+	changed map[plumbing.Hash][]string
+}
+
+// newChangeIndex returns the index of a run with the options, nil when none
+// of its stats needs it.
+func newChangeIndex(opts Options) *changeIndex {
+	if opts.capabilities()&CapChangeIndex == 0 {
+		return nil
+	}
+
+	return &changeIndex{diff: opts.diff, changed: make(map[plumbing.Hash][]string)}
+}
+
+// add keeps the files of the commit's change record, renamed files under
+// both of their paths.
+func (i *changeIndex) add(hash plumbing.Hash, record changeRecord) {
+	changed := make([]string, 0, len(record.files))
+	for _, file := range record.files {
+		changed = append(changed, renameSides(file.Name)...)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.changed[hash] = changed
+}
+
+// lookup returns the files the commit changed, false when it wasn't diffed.
+func (i *changeIndex) lookup(hash plumbing.Hash) ([]string, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	changed, ok := i.changed[hash]
+
+	return changed, ok
+}
+
+// trackedChanges returns the tracked files the commit changed, diffing it
+// when it wasn't yet and keeping them for the next walk.
+func (i *changeIndex) trackedChanges(ctx context.Context, commit *object.Commit, tracked map[string]int) ([]string, error) {
+	changed, ok := i.lookup(commit.Hash)
+	if !ok {
+		diff := i.diff
+		if diff == nil {
+			diff = commitChanges
+		}
+		changes, err := diff(ctx, commit)
+		if err != nil {
+			return nil, err
+		}
+		changed = make([]string, 0)
+		for _, change := range changes {
+			for _, name := range []string{change.From.Name, change.To.Name} {
+				if tracked[name] > 0 {
+					changed = append(changed, name)
+				}
+			}
+		}
+
+		i.mu.Lock()
+		i.changed[commit.Hash] = changed
+		i.mu.Unlock()
+	}
+
+	seen := make(map[string]bool, len(changed))
+	kept := make([]string, 0, len(changed))
+	for _, name := range changed {
+		if tracked[name] > 0 && !seen[name] {
+			seen[name] = true
+			kept = append(kept, name)
+		}
+	}
+
+	return kept, nil
+}
+
+// changeIndex returns the index of the summary's run, starting one for the
+// walks when the analysis didn't keep it.
+func (s *Summary) changeIndex() *changeIndex {
+	if s.changes == nil {
+		s.changes = &changeIndex{changed: make(map[plumbing.Hash][]string)}
+	}
+
+	return s.changes
+}
+
+// changesPath reports whether the commit changed the tracked file at path,
+// from the index when the commit was diffed and comparing the trees
+// otherwise.
+func (i *changeIndex) changesPath(commit *object.Commit, path string) (bool, error) {
+	changed, ok := i.lookup(commit.Hash)
+	if !ok {
+		return changesPath(commit, path)
+	}
+	for _, name := range changed {
+		if name == path {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
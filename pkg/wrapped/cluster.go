@@ -0,0 +1,230 @@
+package wrapped
+
+import (
+	"fmt"
+	"golang.org/x/text/unicode/norm"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// IdentityCluster are the identities ClusterIdentities takes for the same
+// author, the one with the most commits first.
+type IdentityCluster struct {
+	Identities []IdentityCount
+	// Links are why the identities were clustered, one per pair joining
+	// them.
+	Links []ClusterLink
+}
+
+// ClusterLink joins two identities of a cluster.
+type ClusterLink struct {
+	From   string
+	To     string
+	Reason string
+}
+
+// Commits returns the commits of every identity of the cluster.
+func (c IdentityCluster) Commits() int {
+	commits := 0
+	for _, identity := range c.Identities {
+		commits += identity.Commits
+	}
+
+	return commits
+}
+
+// genericLocalParts are the local parts of emails shared by everyone on their
+// domain rather than naming someone, never clustered on.
+var genericLocalParts = map[string]bool{
+	"admin": true, "bot": true, "build": true, "ci": true, "dev": true, "git": true, "github": true, "info": true,
+	"mail": true, "noreply": true, "no-reply": true, "root": true, "support": true, "team": true, "test": true, "user": true,
+}
+
+// minClusterPrefix is the shortest abbreviation of a given name, like Rob of
+// Robert, that clusters names.
+const minClusterPrefix = 3
+
+// ClusterIdentities groups the identities of the same author that no mailmap
+// joins, like Rob King <rob@old.com> and Robert King <rking@new.com>. Two
+// identities are clustered when their names are the same once case,
+// diacritics and punctuation are ignored, when they're the same up to initials
+// and abbreviations of the given names, or when their emails have the same
+// local part on different domains. It's conservative: names need a given name
+// and a surname, a name abbreviating several others clusters with none, and
+// generic local parts like admin or ci are never matched. The identities the
+// selection excludes are left out. Why identities were joined, and which names
+// were too ambiguous to, is logged verbosely. Clusters are returned most
+// commits first.
+func ClusterIdentities(identities []IdentityCount, selection Selection, logger Logger) []IdentityCluster {
+	candidates := make([]IdentityCount, 0, len(identities))
+	for _, identity := range identities {
+		if !selection.Excludes(identity.Email) {
+			candidates = append(candidates, identity)
+		}
+	}
+
+	parents := make([]int, len(candidates))
+	for i := range parents {
+		parents[i] = i
+	}
+	var root func(i int) int
+	root = func(i int) int {
+		if parents[i] != i {
+			parents[i] = root(parents[i])
+		}
+		return parents[i]
+	}
+	links := make([]ClusterLink, 0)
+	join := func(i int, j int, reason string) {
+		if root(i) == root(j) {
+			return
+		}
+		links = append(links, ClusterLink{From: candidates[i].Email, To: candidates[j].Email, Reason: reason})
+		parents[root(i)] = root(j)
+	}
+
+	names := make([][]string, len(candidates))
+	for i, identity := range candidates {
+		names[i] = nameTokens(identity.Name)
+	}
+	for i := range candidates {
+		abbreviated := make([]int, 0)
+		for j := range candidates {
+			if i == j || len(names[i]) < 2 {
+				continue
+			}
+			if strings.Join(names[i], " ") == strings.Join(names[j], " ") {
+				if i < j {
+					join(i, j, fmt.Sprintf("the names %q and %q are the same", candidates[i].Name, candidates[j].Name))
+				}
+				continue
+			}
+			if abbreviates(names[i], names[j]) {
+				abbreviated = append(abbreviated, j)
+			}
+		}
+
+		distinct := make(map[string]bool)
+		for _, j := range abbreviated {
+			distinct[strings.Join(names[j], " ")] = true
+		}
+		if len(distinct) > 1 {
+			logger.Logf(LevelVerbose, "Not clustering %s <%s>, the name abbreviates %d different names", candidates[i].Name, candidates[i].Email, len(distinct))
+			continue
+		}
+		for _, j := range abbreviated {
+			join(i, j, fmt.Sprintf("the name %q abbreviates %q", candidates[i].Name, candidates[j].Name))
+		}
+	}
+
+	for i := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			local, domain := emailParts(candidates[i].Email)
+			otherLocal, otherDomain := emailParts(candidates[j].Email)
+			if local != otherLocal || domain == otherDomain || len(local) < minClusterPrefix || genericLocalParts[local] {
+				continue
+			}
+			join(i, j, fmt.Sprintf("the emails share the local part %q", local))
+		}
+	}
+
+	byRoot := make(map[int]*IdentityCluster)
+	for i, identity := range candidates {
+		cluster, ok := byRoot[root(i)]
+		if !ok {
+			cluster = &IdentityCluster{}
+			byRoot[root(i)] = cluster
+		}
+		cluster.Identities = append(cluster.Identities, identity)
+	}
+	clusters := make([]IdentityCluster, 0)
+	for _, cluster := range byRoot {
+		if len(cluster.Identities) < 2 {
+			continue
+		}
+		emails := make(map[string]bool, len(cluster.Identities))
+		for _, identity := range cluster.Identities {
+			emails[identity.Email] = true
+		}
+		for _, link := range links {
+			if emails[link.From] {
+				cluster.Links = append(cluster.Links, link)
+			}
+		}
+		sort.Slice(cluster.Identities, func(i, j int) bool {
+			if cluster.Identities[i].Commits != cluster.Identities[j].Commits {
+				return cluster.Identities[i].Commits > cluster.Identities[j].Commits
+			}
+			return cluster.Identities[i].Email < cluster.Identities[j].Email
+		})
+		clusters = append(clusters, *cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Commits() != clusters[j].Commits() {
+			return clusters[i].Commits() > clusters[j].Commits()
+		}
+		return clusters[i].Identities[0].Email < clusters[j].Identities[0].Email
+	})
+
+	for _, cluster := range clusters {
+		for _, link := range cluster.Links {
+			logger.Logf(LevelVerbose, "Clustering %s with %s: %s", link.From, link.To, link.Reason)
+		}
+	}
+
+	return clusters
+}
+
+// nameTokens returns the words of the name lowercased, without diacritics
+// and punctuation, e.g. "jose", "o", "neill" for José O'Neill.
+func nameTokens(name string) []string {
+	stripped := strings.Builder{}
+	for _, char := range norm.NFD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, char):
+		case unicode.IsLetter(char) || unicode.IsDigit(char):
+			stripped.WriteRune(unicode.ToLower(char))
+		default:
+			stripped.WriteRune(' ')
+		}
+	}
+
+	return strings.Fields(stripped.String())
+}
+
+// abbreviates reports whether the name is the other one with some of the
+// given names shortened to their initial or a prefix of at least 3 letters,
+// like R. King or Rob King of Robert King. The surnames have to be the same.
+func abbreviates(name []string, other []string) bool {
+	if len(name) != len(other) || name[len(name)-1] != other[len(other)-1] {
+		return false
+	}
+
+	shortened := false
+	for i, word := range name[:len(name)-1] {
+		switch {
+		case word == other[i]:
+		case len(word) < len(other[i]) && strings.HasPrefix(other[i], word) && (len(word) == 1 || len(word) >= minClusterPrefix):
+			shortened = true
+		default:
+			return false
+		}
+	}
+
+	return shortened
+}
+
+// emailParts returns the local part of the email lowercased, without a +tag
+// or the user id of a GitHub noreply email, and its domain.
+func emailParts(email string) (string, string) {
+	local, domain, _ := strings.Cut(strings.ToLower(email), "@")
+	if domain == "users.noreply.github.com" {
+		if _, user, ok := strings.Cut(local, "+"); ok {
+			return user, domain
+		}
+	}
+	local, _, _ = strings.Cut(local, "+")
+
+	return local, domain
+}
@@ -0,0 +1,88 @@
+package wrapped
+
+import (
+	"fmt"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// How the commits co-authored by one of the authors of a selection count,
+// see Selection.Coauthors.
+const (
+	// CoauthorsSeparate counts them in a bucket of their own, next to the
+	// commits of the authors.
+	CoauthorsSeparate = "separate"
+	// CoauthorsFull counts them towards every stat like the commits of the
+	// authors.
+	CoauthorsFull = "full"
+)
+
+// CoAuthored are the commits credited to the authors of the selection
+// through a Co-authored-by trailer rather than their author, like the squash
+// merges of their pull requests by someone else.
+type CoAuthored struct {
+	Commits int
+	// Counted is whether they count towards every stat. Otherwise they're
+	// only counted here.
+	Counted bool
+}
+
+// coauthorLine matches a Co-authored-by line anywhere in a message, even in
+// the bullet list of a squash merge, and captures its value.
+var coauthorLine = regexp.MustCompile(`(?i)^[\s*-]*co-authored-by[ \t]*:[ \t]*(.+)$`)
+
+// coauthorEmails returns the emails of the Co-authored-by lines of the
+// message, in the order they're in. Unlike parseTrailers it reads every line,
+// since squashing folds the trailers of the squashed commits into the body.
+func coauthorEmails(message string) []string {
+	emails := make([]string, 0)
+	for _, line := range strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n") {
+		match := coauthorLine.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		address, err := mail.ParseAddress(match[1])
+		if err != nil {
+			continue
+		}
+		emails = append(emails, address.Address)
+	}
+
+	return emails
+}
+
+// coauthoredBy returns the first email of the Co-authored-by lines of the
+// commit that's one of the authors, empty when none is.
+func coauthoredBy(commit *object.Commit, authors map[string]bool) string {
+	for _, email := range coauthorEmails(commit.Message) {
+		if authors[email] {
+			return email
+		}
+	}
+
+	return ""
+}
+
+// mergeCoAuthored folds the co-authored commits of another summary into this
+// one.
+func (s *Summary) mergeCoAuthored(other *CoAuthored) {
+	if other == nil {
+		return
+	}
+	if s.CoAuthored == nil {
+		s.CoAuthored = &CoAuthored{Counted: other.Counted}
+	}
+	s.CoAuthored.Commits += other.Commits
+}
+
+// sentence describes the co-authored commits, e.g. "12 more commits credited
+// through Co-authored-by trailers, not counted above".
+func (c *CoAuthored) sentence() string {
+	if c.Counted {
+		return fmt.Sprintf("%s of the commits credited through Co-authored-by trailers, counted like the others", FormatCount(c.Commits))
+	}
+
+	return fmt.Sprintf("%s credited through Co-authored-by trailers, not counted in the other stats", FormatCountOf(c.Commits, "more commit", "more commits"))
+}
@@ -0,0 +1,173 @@
+package wrapped
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CoChangeGraph is the files the author changed the most and how often they
+// changed them together, the clusters of the codebase they worked in.
+type CoChangeGraph struct {
+	// Files are the nodes of the graph, sorted by their path.
+	Files []CoChangeFile
+	// Edges connect the files changed together, sorted by their files.
+	Edges []CoChange
+}
+
+// CoChangeFile is a file of the co-change graph.
+type CoChangeFile struct {
+	Path string
+	// Label is the shortest end of the path telling the file apart from the
+	// others of the graph, e.g. wrapped/analyze.go.
+	Label string
+	// Commits are the author's commits changing the file.
+	Commits int
+}
+
+// CoChange is an edge of the co-change graph, From and To being indexes in
+// its Files with From < To.
+type CoChange struct {
+	From int
+	To   int
+	// Commits are the author's commits changing both files.
+	Commits int
+}
+
+// FindCoChanges builds the graph of the files most of the listed commits
+// changed, at most files of them, connecting the ones changed together by at
+// least minCommits commits. Renamed files count under their new path. The
+// commits must have been collected with Options.ListCommits and line stats.
+func (s *Summary) FindCoChanges(files int, minCommits int) error {
+	if !s.has(fieldCommitList) || !s.has(fieldLineStats) {
+		return errors.New("the co-change graph needs the commits collected with Options.ListCommits and line stats")
+	}
+
+	changed := make([][]string, 0, len(s.Commits))
+	counts := make(map[string]int)
+	for _, commit := range s.Commits {
+		seen := make(map[string]bool, len(commit.Files))
+		paths := make([]string, 0, len(commit.Files))
+		for _, file := range commit.Files {
+			sides := renameSides(file.Path)
+			path := sides[len(sides)-1]
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+				counts[path]++
+			}
+		}
+		changed = append(changed, paths)
+	}
+
+	top := make([]string, 0, len(counts))
+	for path := range counts {
+		top = append(top, path)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if counts[top[i]] != counts[top[j]] {
+			return counts[top[i]] > counts[top[j]]
+		}
+		return top[i] < top[j]
+	})
+	if len(top) > files {
+		top = top[:files]
+	}
+	sort.Strings(top)
+
+	graph := &CoChangeGraph{Files: make([]CoChangeFile, 0, len(top))}
+	index := make(map[string]int, len(top))
+	for i, path := range top {
+		index[path] = i
+		graph.Files = append(graph.Files, CoChangeFile{Path: path, Label: shortestLabel(path, top), Commits: counts[path]})
+	}
+
+	together := make(map[[2]int]int)
+	for _, paths := range changed {
+		nodes := make([]int, 0, len(paths))
+		for _, path := range paths {
+			if i, ok := index[path]; ok {
+				nodes = append(nodes, i)
+			}
+		}
+		sort.Ints(nodes)
+		for i := range nodes {
+			for _, to := range nodes[i+1:] {
+				together[[2]int{nodes[i], to}]++
+			}
+		}
+	}
+	for pair, commits := range together {
+		if commits >= minCommits {
+			graph.Edges = append(graph.Edges, CoChange{From: pair[0], To: pair[1], Commits: commits})
+		}
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From < graph.Edges[j].From
+		}
+		return graph.Edges[i].To < graph.Edges[j].To
+	})
+	s.CoChanges = graph
+
+	return nil
+}
+
+// shortestLabel returns the last directories of the path none of the other
+// paths end with, at least its file name.
+func shortestLabel(path string, paths []string) string {
+	parts := strings.Split(path, "/")
+	for depth := 1; depth < len(parts); depth++ {
+		suffix := strings.Join(parts[len(parts)-depth:], "/")
+		unique := true
+		for _, other := range paths {
+			if other != path && (other == suffix || strings.HasSuffix(other, "/"+suffix)) {
+				unique = false
+				break
+			}
+		}
+		if unique {
+			return suffix
+		}
+	}
+
+	return path
+}
+
+// DOT writes the graph in the DOT language of graphviz, e.g. for
+// `dot -Tsvg`. Nodes are labeled with their short path and their commits,
+// edges weighted by the commits changing both files and drawn thicker the
+// more there are. The output only depends on the graph, so it can be
+// compared across runs.
+func (g *CoChangeGraph) DOT() string {
+	most := 1
+	for _, edge := range g.Edges {
+		if edge.Commits > most {
+			most = edge.Commits
+		}
+	}
+
+	builder := &strings.Builder{}
+	builder.WriteString("graph cochanges {\n")
+	builder.WriteString("\tgraph [overlap=false, splines=true];\n")
+	builder.WriteString("\tnode [shape=box, style=rounded];\n")
+	for _, file := range g.Files {
+		fmt.Fprintf(builder, "\t%s [label=%s, tooltip=%s, commits=%d];\n",
+			dotQuote(file.Path), dotQuote(fmt.Sprintf("%s\n%s", file.Label, commitCount(file.Commits))), dotQuote(file.Path), file.Commits)
+	}
+	for _, edge := range g.Edges {
+		width := 1 + 4*float64(edge.Commits-1)/float64(most)
+		fmt.Fprintf(builder, "\t%s -- %s [weight=%d, label=%d, penwidth=%.1f];\n",
+			dotQuote(g.Files[edge.From].Path), dotQuote(g.Files[edge.To].Path), edge.Commits, edge.Commits, width)
+	}
+	builder.WriteString("}\n")
+
+	return builder.String()
+}
+
+// dotQuote returns the DOT string of the text, its quotes and backslashes
+// escaped and newlines as line breaks.
+func dotQuote(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(text) + `"`
+}
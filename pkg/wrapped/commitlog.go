@@ -0,0 +1,119 @@
+package wrapped
+
+import (
+	"bufio"
+	_ "embed"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed commit.schema.json
+var commitSchema string
+
+// CommitJSONSchema returns the JSON Schema of a line of the commit log.
+func CommitJSONSchema() string {
+	return commitSchema
+}
+
+// CommitRecord is a line of the commit log, a single analyzed commit. Line
+// stats are null when they weren't computed, like ListedCommit's.
+type CommitRecord struct {
+	SchemaVersion int    `json:"schema_version"`
+	Repo          string `json:"repo"`
+	Hash          string `json:"hash"`
+	Parents       int    `json:"parents"`
+	AuthorEmail   string `json:"author_email"`
+	// AuthorTime is RFC 3339 with the offset the commit was made at.
+	AuthorTime   string `json:"author_time"`
+	Subject      string `json:"subject"`
+	Additions    *int64 `json:"additions"`
+	Deletions    *int64 `json:"deletions"`
+	FilesChanged *int   `json:"files_changed"`
+	IsMerge      bool   `json:"is_merge"`
+	// MatchedIdentity is the email of the selection the commit was picked
+	// by, null when every author's commits are.
+	MatchedIdentity *string `json:"matched_identity"`
+	// CoAuthored is set when the commit was picked by a Co-authored-by line
+	// naming MatchedIdentity rather than its author.
+	CoAuthored bool `json:"co_authored,omitempty"`
+
+	when time.Time
+}
+
+// CommitLog records every analyzed commit as the analysis reduces it, to be
+// exported as JSON Lines. Only the few fields of CommitRecord are kept, not
+// the commits nor the files they changed, so it doesn't need
+// Options.ListCommits. It's safe to share between repositories analyzed at
+// once.
+type CommitLog struct {
+	mu      sync.Mutex
+	records []CommitRecord
+}
+
+// NewCommitLog returns an empty commit log.
+func NewCommitLog() *CommitLog {
+	return &CommitLog{}
+}
+
+// add records the commit of the result, found in the repository at path.
+func (l *CommitLog) add(result commitStats, path string, authors map[string]bool, lineStats bool) {
+	commit := result.commit
+	subject, _, _ := strings.Cut(strings.TrimSpace(commit.Message), "\n")
+	record := CommitRecord{
+		SchemaVersion: SchemaVersion,
+		Repo:          path,
+		Hash:          commit.Hash.String(),
+		Parents:       commit.NumParents(),
+		AuthorEmail:   commit.Author.Email,
+		AuthorTime:    commit.Author.When.Format(time.RFC3339),
+		Subject:       strings.TrimSpace(subject),
+		IsMerge:       result.merge,
+		when:          commit.Author.When,
+	}
+	if lineStats && result.statsErr == nil && !result.skipLines {
+		additions, deletions, files := result.additions, result.deletions, len(result.files)
+		record.Additions, record.Deletions, record.FilesChanged = &additions, &deletions, &files
+	}
+	if authors != nil {
+		email := result.identity()
+		record.MatchedIdentity = &email
+		record.CoAuthored = result.coauthor != ""
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, record)
+}
+
+// Len returns the number of recorded commits.
+func (l *CommitLog) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.records)
+}
+
+// Write writes a JSON object per recorded commit and line, in chronological
+// order of their author time, ties broken by the hash.
+func (l *CommitLog) Write(w io.Writer) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sort.Slice(l.records, func(i, j int) bool {
+		return timeHashBefore(l.records[i].when, l.records[i].Hash, l.records[j].when, l.records[j].Hash)
+	})
+
+	buffered := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buffered)
+	encoder.SetEscapeHTML(false)
+	for i := range l.records {
+		if err := encoder.Encode(&l.records[i]); err != nil {
+			return err
+		}
+	}
+
+	return buffered.Flush()
+}
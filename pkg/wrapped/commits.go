@@ -0,0 +1,201 @@
+package wrapped
+
+import (
+	"fmt"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"strings"
+	"time"
+)
+
+// Commit is a commit the report calls out, copied out of the repository so
+// the summary doesn't hold on to it.
+type Commit struct {
+	// Hash is the full hexadecimal hash.
+	Hash    string
+	Author  Signature
+	Message string
+}
+
+// sameCommit returns whether both are the same commit, like the first and the
+// last of a year with a single one.
+func sameCommit(a *Commit, b *Commit) bool {
+	return a != nil && b != nil && a.Hash == b.Hash
+}
+
+// Signature is who made a commit and when, in their own time zone.
+type Signature struct {
+	Name  string
+	Email string
+	When  time.Time
+}
+
+func newCommit(commit *object.Commit) *Commit {
+	return &Commit{
+		Hash: commit.Hash.String(),
+		Author: Signature{
+			Name:  commit.Author.Name,
+			Email: commit.Author.Email,
+			When:  commit.Author.When,
+		},
+		Message: commit.Message,
+	}
+}
+
+// ListedCommit is a matched commit as listed by --list-commits.
+type ListedCommit struct {
+	// Repo is the path of the repository the commit was found in.
+	Repo string
+	Hash string
+	When time.Time
+	// AuthorWhen is When in the author's own time zone.
+	AuthorWhen time.Time
+	Name       string
+	Email      string
+	Subject    string
+	// Message is the whole commit message, Subject its first line.
+	Message string
+	Merge   bool
+	// HasLineStats is false when the commit's line stats weren't computed,
+	// in fast mode, for merges that don't count towards them or when
+	// computing them failed.
+	HasLineStats bool
+	Additions    int64
+	Deletions    int64
+	// Files are the files counted by the line stats, empty without them.
+	Files []FileChange
+}
+
+// FileChange is the line stats of a file changed by a listed commit.
+type FileChange struct {
+	// Path is old => new for renamed files.
+	Path      string
+	Additions int64
+	Deletions int64
+	// Change is how the commit changed the file, one of the Change
+	// constants.
+	Change string
+}
+
+// The ways a commit can change a file.
+const (
+	ChangeAdded    = "added"
+	ChangeDeleted  = "deleted"
+	ChangeModified = "modified"
+	ChangeRenamed  = "renamed"
+)
+
+// shortHashLength is how many characters of the hash are listed, git's
+// default abbreviation.
+const shortHashLength = 7
+
+// listed returns the listing of a single commit.
+func (s *Summary) listed(commit *Commit, result commitStats, repo string) ListedCommit {
+	subject, _, _ := strings.Cut(strings.TrimSpace(commit.Message), "\n")
+	listed := ListedCommit{
+		Repo:       repo,
+		Hash:       commit.Hash,
+		When:       s.when(commit),
+		AuthorWhen: commit.Author.When,
+		Name:       commit.Author.Name,
+		Email:      commit.Author.Email,
+		Subject:    strings.TrimSpace(subject),
+		Message:    commit.Message,
+		Merge:      result.merge,
+	}
+	if s.has(fieldLineStats) && result.statsErr == nil && !result.skipLines {
+		listed.HasLineStats = true
+		listed.Additions = result.additions
+		listed.Deletions = result.deletions
+		for _, file := range result.files {
+			listed.Files = append(listed.Files, FileChange{Path: file.Name, Additions: file.Additions, Deletions: file.Deletions, Change: file.Change})
+		}
+	}
+
+	return listed
+}
+
+// commitListRenderers turn the commits of a summary into the listing, keyed
+// by --format.
+var commitListRenderers = map[string]func(*Summary) (string, error){
+	"text": func(summary *Summary) (string, error) {
+		return buildCommitList(summary), nil
+	},
+	"json": buildJSONCommitList,
+}
+
+// RenderCommits lists the commits collected by the summary, see
+// Options.ListCommits.
+func RenderCommits(format string, summary *Summary) (string, error) {
+	render, ok := commitListRenderers[format]
+	if !ok {
+		return "", fmt.Errorf("unknown output format %q", format)
+	}
+
+	return render(summary)
+}
+
+// buildCommitList prints one line per commit, in the same order and with the
+// same fields as git log --date=iso-local --format='%h %ad %ae %s' run in the
+// window's time zone. The repository is only printed when the commits come
+// from more than one.
+func buildCommitList(summary *Summary) string {
+	repos := make(map[string]bool)
+	for _, commit := range summary.Commits {
+		repos[commit.Repo] = true
+	}
+
+	builder := strings.Builder{}
+	for _, commit := range summary.Commits {
+		if len(repos) > 1 {
+			builder.WriteString(commit.Repo + " ")
+		}
+		builder.WriteString(fmt.Sprintf("%s %s %s %s", commit.Hash[:shortHashLength], commit.When.Format("2006-01-02 15:04:05 -0700"), commit.Email, commit.Subject))
+		if commit.HasLineStats {
+			builder.WriteString(fmt.Sprintf(" +%d/-%d", commit.Additions, commit.Deletions))
+		}
+		builder.WriteString("\n")
+	}
+
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+type jsonListedCommit struct {
+	Repository string    `json:"repository"`
+	Hash       string    `json:"hash"`
+	When       time.Time `json:"when"`
+	Email      string    `json:"email"`
+	Subject    string    `json:"subject"`
+	Additions  *int64    `json:"additions,omitempty"`
+	Deletions  *int64    `json:"deletions,omitempty"`
+}
+
+type jsonCommitList struct {
+	Generator string             `json:"generator,omitempty"`
+	Window    jsonWindow         `json:"window"`
+	Commits   []jsonListedCommit `json:"commits"`
+}
+
+func buildJSONCommitList(summary *Summary) (string, error) {
+	output := jsonCommitList{
+		Generator: summary.Generator,
+		Window:    newJSONWindow(summary.Window),
+		Commits:   make([]jsonListedCommit, 0, len(summary.Commits)),
+	}
+	for i := range summary.Commits {
+		commit := &summary.Commits[i]
+		listed := jsonListedCommit{
+			Repository: commit.Repo,
+			Hash:       commit.Hash,
+			When:       commit.When,
+			Email:      commit.Email,
+			Subject:    commit.Subject,
+		}
+		if commit.HasLineStats {
+			listed.Additions = &commit.Additions
+			listed.Deletions = &commit.Deletions
+		}
+		output.Commits = append(output.Commits, listed)
+	}
+
+	return marshalJSON(output)
+}
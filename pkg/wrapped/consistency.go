@@ -0,0 +1,125 @@
+package wrapped
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// sparkLevels are the bars of the sparkline, from the fewest commits to the
+// most. Weeks without commits are drawn as heatLevels[0].
+var sparkLevels = []string{"▁", "▂", "▃", "▄", "▅", "▆", "▇", "█"}
+
+// WeeklyCommits returns the commits of every week of the window, weeks
+// counted in 7 day steps from its start. The days left over at the end are
+// folded into the last week rather than making up a short one, which would
+// look like a quiet week.
+func (s *Summary) WeeklyCommits() []int {
+	weeks := s.Window.days() / 7
+	if weeks == 0 {
+		weeks = 1
+	}
+
+	counts := make([]int, weeks)
+	i := 0
+	for day := s.Window.Start; day.Before(s.Window.End); day = day.AddDate(0, 0, 1) {
+		week := i / 7
+		if week >= weeks {
+			week = weeks - 1
+		}
+		counts[week] += s.CommitsOn(day)
+		i++
+	}
+
+	return counts
+}
+
+// Consistency scores how evenly the commits are spread over the weeks of the
+// window, from 0 when every commit was made in a single week to 100 when
+// every week has as many. ok is false for windows shorter than two weeks and
+// fewer than two commits, whose spread says nothing.
+//
+// The score is based on the Gini coefficient of the weekly counts. With the n
+// counts x sorted ascending and numbered from 1,
+//
+//	G = 2 * Σ i*x_i / (n * Σ x_i) - (n+1) / n
+//
+// which is 0 for equal counts and at most (n-1)/n, when one week holds every
+// commit. G is scaled by n/(n-1) so the extremes are 0 and 1 whatever the
+// number of weeks, and the score is 100 * (1 - G) rounded. Committing evenly
+// in half of the weeks and never in the others scores about 50.
+//
+// Unlike the coefficient of variation, the Gini coefficient is bounded, so a
+// single huge week can't push the score of an otherwise steady year far below
+// one with a few idle months. Few commits score low even when they're
+// evenly spread, since n weeks can't all have commits with fewer than n.
+func (s *Summary) Consistency() (score int, ok bool) {
+	counts := s.WeeklyCommits()
+	n := len(counts)
+	if n < 2 || s.TotalCommits < 2 {
+		return 0, false
+	}
+
+	sort.Ints(counts)
+	weighted, total := 0, 0
+	for i, count := range counts {
+		weighted += (i + 1) * count
+		total += count
+	}
+	if total == 0 {
+		return 0, false
+	}
+	gini := 2*float64(weighted)/(float64(n)*float64(total)) - float64(n+1)/float64(n)
+	normalized := gini * float64(n) / float64(n-1)
+
+	return int(math.Round(100 * (1 - normalized))), true
+}
+
+// consistencyPhrase names the cadence of the score.
+func consistencyPhrase(score int) string {
+	switch {
+	case score >= 80:
+		return "Steady shipper"
+	case score >= 60:
+		return "Reliable rhythm"
+	case score >= 40:
+		return "Burst builder"
+	default:
+		return "Deadline sprinter"
+	}
+}
+
+// Sparkline draws the counts as bars relative to the largest one.
+func Sparkline(counts []int) string {
+	most := 0
+	for _, count := range counts {
+		if count > most {
+			most = count
+		}
+	}
+
+	builder := strings.Builder{}
+	for _, count := range counts {
+		if count == 0 {
+			builder.WriteString(heatLevels[0])
+			continue
+		}
+		builder.WriteString(sparkLevels[(count*len(sparkLevels)-1)/most])
+	}
+
+	return builder.String()
+}
+
+// cadenceSentence is the weekly sparkline followed by the consistency score,
+// e.g. "▁▃█▂ 72/100, Reliable rhythm". The score is left out for windows too
+// short to have one.
+func (s *Summary) cadenceSentence() string {
+	sparkline := Sparkline(s.WeeklyCommits())
+	score, ok := s.Consistency()
+	if !ok {
+		return sparkline
+	}
+
+	return fmt.Sprintf("%s %d/100, %s", sparkline, score, consistencyPhrase(score))
+}
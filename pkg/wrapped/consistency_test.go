@@ -0,0 +1,75 @@
+package wrapped
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// summaryOf returns the summary of 2023 with a commit at noon of every day.
+func summaryOf(days []time.Time) *Summary {
+	summary := NewSummary(true, NewYearWindow(2023, time.UTC))
+	for i, day := range days {
+		summary.add(syntheticCommit(fmt.Sprint(i), "dev@example.com", day.Add(12*time.Hour), 0, 0), "repo")
+	}
+	summary.Finish()
+
+	return summary
+}
+
+// everyDays returns the days of 2023 step days apart, from the first, each
+// repeated times.
+func everyDays(first int, step int, times int) []time.Time {
+	days := make([]time.Time, 0)
+	for day := time.Date(2023, time.January, 1+first, 0, 0, 0, 0, time.UTC); day.Year() == 2023; day = day.AddDate(0, 0, step) {
+		for i := 0; i < times; i++ {
+			days = append(days, day)
+		}
+	}
+
+	return days
+}
+
+func TestConsistency(t *testing.T) {
+	burst := make([]time.Time, 0)
+	for i := 0; i < 60; i++ {
+		burst = append(burst, time.Date(2023, time.November, 20+i%5, 0, 0, 0, 0, time.UTC))
+	}
+
+	for _, tt := range []struct {
+		name     string
+		days     []time.Time
+		min, max int
+		phrase   string
+	}{
+		{name: "uniform daily", days: everyDays(0, 1, 1), min: 100, max: 100, phrase: "Steady shipper"},
+		{name: "uniform weekly", days: everyDays(2, 7, 3), min: 98, max: 100, phrase: "Steady shipper"},
+		{name: "every other week", days: everyDays(0, 14, 2), min: 48, max: 52, phrase: "Burst builder"},
+		{name: "single week", days: burst, min: 0, max: 0, phrase: "Deadline sprinter"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			score, ok := summaryOf(tt.days).Consistency()
+			if !ok {
+				t.Fatal("got no score")
+			}
+			if score < tt.min || score > tt.max {
+				t.Errorf("got %d, want between %d and %d", score, tt.min, tt.max)
+			}
+			if phrase := consistencyPhrase(score); phrase != tt.phrase {
+				t.Errorf("got %q for %d, want %q", phrase, score, tt.phrase)
+			}
+		})
+	}
+}
+
+func TestConsistencyTooFewCommits(t *testing.T) {
+	if score, ok := summaryOf(everyDays(0, 400, 1)).Consistency(); ok {
+		t.Errorf("got %d for a single commit, want no score", score)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got, want := Sparkline([]int{0, 1, 2, 4, 8}), heatLevels[0]+"▁▂▄█"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,234 @@
+package wrapped
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// digestTopFiles caps the files of a digest, which is meant to be posted
+// as it is.
+const digestTopFiles = 3
+
+// Digest is a week or a month of an author's commits next to the one
+// before, for RenderDigest.
+type Digest struct {
+	// Period is the length of both windows, PeriodWeek or PeriodMonth.
+	Period  string
+	Current *Summary
+	// Previous is the period before, an empty summary when there were no
+	// commits in it.
+	Previous *Summary
+}
+
+// digestRenderers turn a digest into its report, keyed by --format.
+var digestRenderers = map[string]func(digest Digest, opts RenderOptions) (string, error){
+	"text": func(digest Digest, opts RenderOptions) (string, error) {
+		return buildDigest(digest, opts), nil
+	},
+	"markdown": func(digest Digest, opts RenderOptions) (string, error) {
+		return buildMarkdownDigest(digest, opts), nil
+	},
+	"json": buildJSONDigest,
+}
+
+// DigestFormats returns the formats RenderDigest supports, sorted.
+func DigestFormats() []string {
+	formats := make([]string, 0, len(digestRenderers))
+	for format := range digestRenderers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+
+	return formats
+}
+
+// RenderDigest renders the digest, short enough to post in a standup: the
+// commits, the lines, the busiest day and the top files, with the change
+// from the period before. The stats of a whole year, like the streaks and
+// the heatmap, are left out.
+func RenderDigest(format string, digest Digest, opts RenderOptions) (string, error) {
+	render, ok := digestRenderers[format]
+	if !ok {
+		return "", fmt.Errorf("unknown output format %q", format)
+	}
+
+	return render(digest, opts)
+}
+
+// digestStat is a stat of the digest with its value in the period before.
+type digestStat struct {
+	label    string
+	value    string
+	current  int64
+	previous int64
+}
+
+// change describes the stat against the period before, e.g. "+4 vs the
+// week before".
+func (s digestStat) change(period string) string {
+	if s.current == s.previous {
+		return "same as the " + period + " before"
+	}
+
+	return fmt.Sprintf("%+d vs the %s before", s.current-s.previous, period)
+}
+
+// digestStats returns the stats of the digest the summaries show, the line
+// stats only when they were computed.
+func digestStats(digest Digest) []digestStat {
+	current, previous := digest.Current, digest.Previous
+	stats := []digestStat{{label: "🧮 Commits", value: FormatCount(int(current.TotalCommits)), current: current.TotalCommits, previous: previous.TotalCommits}}
+	if current.has(fieldLineStats) && current.shows(StatLines) {
+		stats = append(stats, digestStat{
+			label:    "✏️ Lines changed" + current.estimated(),
+			value:    fmt.Sprintf("+%d/-%d", current.TotalAdditions(), current.TotalDeletions()),
+			current:  current.TotalAdditions() + current.TotalDeletions(),
+			previous: previous.TotalAdditions() + previous.TotalDeletions(),
+		})
+	}
+	if current.shows(StatActiveDays) {
+		stats = append(stats, digestStat{
+			label:    "📅 Active days",
+			value:    fmt.Sprintf("%d of %d", current.ActiveDays(), current.Window.days()),
+			current:  int64(current.ActiveDays()),
+			previous: int64(previous.ActiveDays()),
+		})
+	}
+
+	return stats
+}
+
+// digestTitle names the period, e.g. "Weekly digest".
+func digestTitle(digest Digest) string {
+	if digest.Period == PeriodMonth {
+		return "Monthly digest"
+	}
+
+	return "Weekly digest"
+}
+
+// busiestDaySentence describes the day with the most commits, e.g. "Tue Mar
+// 5, 4 commits", empty without commits or with the active days disabled.
+func busiestDaySentence(summary *Summary) string {
+	busiest := summary.mostActiveDay()
+	if busiest == nil || !summary.shows(StatActiveDays) {
+		return ""
+	}
+
+	return busiest.First.Format("Mon "+yearDayLayout) + ", " + commitCount(busiest.Count)
+}
+
+// digestFiles returns the most changed files the digest lists.
+func digestFiles(summary *Summary, opts RenderOptions) []FileActivity {
+	top := opts.Limit(SectionFiles)
+	if top > digestTopFiles {
+		top = digestTopFiles
+	}
+	if !summary.has(fieldLineStats) || !summary.shows(StatFiles) || top <= 0 {
+		return nil
+	}
+
+	files := summary.TopFiles()
+	if len(files) > top {
+		files = files[:top]
+	}
+
+	return files
+}
+
+func buildDigest(digest Digest, opts RenderOptions) string {
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("🗞️ %s: %s\n", digestTitle(digest), digest.Current.Window))
+	for _, stat := range digestStats(digest) {
+		builder.WriteString(fmt.Sprintf("%s: %s (%s)\n", stat.label, stat.value, stat.change(digest.Period)))
+	}
+	if busiest := busiestDaySentence(digest.Current); busiest != "" {
+		builder.WriteString(fmt.Sprintf("🏔️ Busiest day: %s\n", busiest))
+	}
+	if files := digestFiles(digest.Current, opts); len(files) > 0 {
+		builder.WriteString(fmt.Sprintf("📂 Top files%s:\n", digest.Current.estimated()))
+		for i, file := range files {
+			builder.WriteString(fmt.Sprintf("%3d. %s: %s in %s\n", i+1, file.Path, opts.lineStats(file.Additions, file.Deletions), commitCount(file.Commits)))
+		}
+	}
+
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func buildMarkdownDigest(digest Digest, opts RenderOptions) string {
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("## 🗞️ %s\n\n%s\n\n", digestTitle(digest), digest.Current.Window))
+	for _, stat := range digestStats(digest) {
+		builder.WriteString(fmt.Sprintf("- **%s**: %s (%s)\n", stat.label, stat.value, stat.change(digest.Period)))
+	}
+	if busiest := busiestDaySentence(digest.Current); busiest != "" {
+		builder.WriteString(fmt.Sprintf("- **🏔️ Busiest day**: %s\n", busiest))
+	}
+	if files := digestFiles(digest.Current, opts); len(files) > 0 {
+		builder.WriteString(fmt.Sprintf("\n**📂 Top files%s**\n\n", digest.Current.estimated()))
+		for i, file := range files {
+			builder.WriteString(fmt.Sprintf("%d. `%s`: +%d/-%d in %s\n", i+1, file.Path, file.Additions, file.Deletions, commitCount(file.Commits)))
+		}
+	}
+
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+type jsonDigest struct {
+	Generator string          `json:"generator,omitempty"`
+	Period    string          `json:"period"`
+	Window    jsonWindow      `json:"window"`
+	Current   jsonDigestStats `json:"current"`
+	// Previous are the stats of the period before, in PreviousWindow.
+	PreviousWindow jsonWindow      `json:"previous_window"`
+	Previous       jsonDigestStats `json:"previous"`
+	Change         jsonDigestStats `json:"change"`
+	// BusiestDay is left out without commits.
+	BusiestDay *jsonDay   `json:"busiest_day,omitempty"`
+	Files      []jsonFile `json:"files,omitempty"`
+}
+
+// jsonDigestStats are the stats there is a change of. The line stats and
+// the active days are left out when they're not shown.
+type jsonDigestStats struct {
+	Commits    int64  `json:"commits"`
+	Additions  *int64 `json:"additions,omitempty"`
+	Deletions  *int64 `json:"deletions,omitempty"`
+	ActiveDays *int64 `json:"active_days,omitempty"`
+}
+
+func buildJSONDigest(digest Digest, opts RenderOptions) (string, error) {
+	current, previous := digest.Current, digest.Previous
+	output := jsonDigest{
+		Generator:      current.Generator,
+		Period:         digest.Period,
+		Window:         newJSONWindow(current.Window),
+		Current:        jsonDigestStats{Commits: current.TotalCommits},
+		PreviousWindow: newJSONWindow(previous.Window),
+		Previous:       jsonDigestStats{Commits: previous.TotalCommits},
+		Change:         jsonDigestStats{Commits: current.TotalCommits - previous.TotalCommits},
+	}
+	if current.has(fieldLineStats) && current.shows(StatLines) {
+		additions, deletions := current.TotalAdditions(), current.TotalDeletions()
+		previousAdditions, previousDeletions := previous.TotalAdditions(), previous.TotalDeletions()
+		changeAdditions, changeDeletions := additions-previousAdditions, deletions-previousDeletions
+		output.Current.Additions, output.Current.Deletions = &additions, &deletions
+		output.Previous.Additions, output.Previous.Deletions = &previousAdditions, &previousDeletions
+		output.Change.Additions, output.Change.Deletions = &changeAdditions, &changeDeletions
+	}
+	if current.shows(StatActiveDays) {
+		days, previousDays := int64(current.ActiveDays()), int64(previous.ActiveDays())
+		change := days - previousDays
+		output.Current.ActiveDays, output.Previous.ActiveDays, output.Change.ActiveDays = &days, &previousDays, &change
+		if busiest := current.mostActiveDay(); busiest != nil {
+			output.BusiestDay = &jsonDay{Date: busiest.First.Format(time.DateOnly), Commits: busiest.Count}
+		}
+	}
+	for _, file := range digestFiles(current, opts) {
+		output.Files = append(output.Files, jsonFile{Path: file.Path, Commits: file.Commits, Additions: file.Additions, Deletions: file.Deletions})
+	}
+
+	return marshalJSON(output)
+}
@@ -0,0 +1,139 @@
+package wrapped
+
+import (
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FindRepoRoot returns the root of the repository containing dir, walking up
+// the parent directories looking for a .git the way git does. The .git of a
+// linked worktree is a file pointing at its git directory, the worktree is
+// still the root. A bare repository is its own root. Like with git, GIT_DIR
+// and GIT_WORK_TREE replace the lookup for the current directory and the
+// directories of the work tree, see gitEnvRepo. The error is a
+// *RepoOpenError naming dir.
+func FindRepoRoot(dir string) (string, error) {
+	start, err := filepath.Abs(dir)
+	if err != nil {
+		return "", &RepoOpenError{path: dir, err: err}
+	}
+	if _, err := os.Stat(start); err != nil {
+		return "", &RepoOpenError{path: dir, err: err}
+	}
+
+	if gitDir, root := gitEnvRepo(); gitDir != "" && usesGitEnv(start, root) {
+		return root, nil
+	}
+	if isBareRepo(start) {
+		return start, nil
+	}
+
+	for current := start; ; {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current, nil
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", &RepoOpenError{path: dir, err: fmt.Errorf("no git repository found from %s up to %s", start, current)}
+		}
+		current = parent
+	}
+}
+
+// isBareRepo reports whether dir looks like the git directory of a bare
+// repository.
+func isBareRepo(dir string) bool {
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// gitEnvRepo returns the git directory GIT_DIR points at and the root of the
+// repository it stands for: GIT_WORK_TREE when it's set, else the git
+// directory itself, or its parent when it's a .git directory. Both are empty
+// without GIT_DIR.
+func gitEnvRepo() (string, string) {
+	gitDir := os.Getenv("GIT_DIR")
+	if gitDir == "" {
+		return "", ""
+	}
+	gitDir, err := filepath.Abs(gitDir)
+	if err != nil {
+		return "", ""
+	}
+
+	root := gitDir
+	if workTree := os.Getenv("GIT_WORK_TREE"); workTree != "" {
+		if abs, err := filepath.Abs(workTree); err == nil {
+			root = abs
+		}
+	} else if filepath.Base(gitDir) == ".git" {
+		root = filepath.Dir(gitDir)
+	}
+
+	return gitDir, root
+}
+
+// usesGitEnv reports whether the repository of dir is the one of GIT_DIR,
+// which is when dir is the current directory or inside the root.
+func usesGitEnv(dir string, root string) bool {
+	if cwd, err := os.Getwd(); err == nil && cwd == dir {
+		return true
+	}
+	rel, err := filepath.Rel(root, dir)
+
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// openRepo opens the repository containing path, returning its root. Errors
+// are *RepoOpenErrors.
+func openRepo(path string) (string, *git.Repository, error) {
+	return openRepoWith(path, nil)
+}
+
+// openRepoWith is openRepo with the object cache of the handle taken from the
+// objects, go-git's default one when nil.
+func openRepoWith(path string, objects *objectCaches) (string, *git.Repository, error) {
+	root, err := FindRepoRoot(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	repo, err := openRoot(root, objects)
+	if err != nil {
+		return "", nil, &RepoOpenError{path: path, err: err}
+	}
+
+	return root, repo, nil
+}
+
+// openRoot opens the repository at a root returned by FindRepoRoot. The root
+// GIT_DIR stands for is opened from the git directory, and the git directory
+// of a linked worktree shares the objects and refs of its main one through
+// its commondir. The storage of the handle caches its objects in one of the
+// objects when set.
+func openRoot(root string, objects *objectCaches) (*git.Repository, error) {
+	path := root
+	if gitDir, envRoot := gitEnvRepo(); gitDir != "" && root == envRoot {
+		path = gitDir
+	}
+
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{DetectDotGit: !isBareRepo(path), EnableDotGitCommonDir: true})
+	if err != nil || objects == nil {
+		return repo, err
+	}
+	if storage, ok := repo.Storer.(*filesystem.Storage); ok {
+		repo.Storer = filesystem.NewStorage(storage.Filesystem(), objects.handle())
+	}
+
+	return repo, nil
+}
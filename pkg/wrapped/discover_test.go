@@ -0,0 +1,174 @@
+package wrapped
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// discoveryFixture returns a repository of two commits with a nested
+// directory.
+func discoveryFixture(t *testing.T) *fixture {
+	repo := newFixture(t)
+	start := time.Date(2023, time.April, 1, 9, 0, 0, 0, time.UTC)
+	repo.commit("dev@example.com", start, map[string]string{"src/pkg/main.go": "package main\n"})
+	repo.commit("dev@example.com", start.Add(time.Hour), map[string]string{"README.md": "readme\n"})
+
+	return repo
+}
+
+// linkWorktree links a worktree of the repository checked out at dir on a
+// new branch, the way git worktree add lays it out: its .git is a file
+// pointing at a git directory in the repository's, whose commondir points
+// back at the repository's own.
+func (f *fixture) linkWorktree(dir string, branch string) {
+	f.t.Helper()
+
+	head, err := f.repo.Head()
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	if err := f.repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), head.Hash())); err != nil {
+		f.t.Fatal(err)
+	}
+
+	gitDir := filepath.Join(f.dir, ".git", "worktrees", filepath.Base(dir))
+	files := map[string]string{
+		filepath.Join(gitDir, "HEAD"):      "ref: refs/heads/" + branch + "\n",
+		filepath.Join(gitDir, "commondir"): "../..\n",
+		filepath.Join(gitDir, "gitdir"):    filepath.Join(dir, ".git") + "\n",
+		filepath.Join(dir, ".git"):         "gitdir: " + gitDir + "\n",
+		filepath.Join(dir, "sub", "x.txt"): "x\n",
+	}
+	for path, contents := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			f.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			f.t.Fatal(err)
+		}
+	}
+}
+
+// copyBare copies the git directory of the repository to dir, a bare
+// repository of the same commits.
+func (f *fixture) copyBare(dir string) {
+	f.t.Helper()
+
+	gitDir := filepath.Join(f.dir, ".git")
+	err := filepath.WalkDir(gitDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(gitDir, path)
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return os.MkdirAll(filepath.Join(dir, rel), 0o755)
+		}
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, rel), contents, 0o644)
+	})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// chdir changes the working directory for the rest of the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(previous); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// realPath resolves the symlinks of the path, like the temporary directories
+// of some systems.
+func realPath(t *testing.T, path string) string {
+	t.Helper()
+
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return real
+}
+
+func TestDiscoverRepo(t *testing.T) {
+	repo := discoveryFixture(t)
+	worktree := filepath.Join(t.TempDir(), "feature")
+	repo.linkWorktree(worktree, "feature")
+	bare := filepath.Join(t.TempDir(), "bare.git")
+	repo.copyBare(bare)
+
+	for _, tt := range []struct {
+		name   string
+		path   string
+		gitDir string
+		root   string
+	}{
+		{name: "root", path: repo.dir, root: repo.dir},
+		{name: "nested cwd", path: filepath.Join(repo.dir, "src", "pkg"), root: repo.dir},
+		{name: "linked worktree", path: worktree, root: worktree},
+		{name: "nested in a linked worktree", path: filepath.Join(worktree, "sub"), root: worktree},
+		{name: "bare", path: bare, root: bare},
+		{name: "GIT_DIR", path: ".", gitDir: bare, root: bare},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.gitDir != "" {
+				t.Setenv("GIT_DIR", tt.gitDir)
+				chdir(t, t.TempDir())
+			}
+
+			root, err := FindRepoRoot(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if realPath(t, root) != realPath(t, tt.root) {
+				t.Errorf("got the root %s, want %s", root, tt.root)
+			}
+
+			opened, err := Open(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			summary, err := opened.Analyze(context.Background(), Options{Selection: Selection{Window: NewYearWindow(2023, time.UTC)}, Quiet: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if summary.TotalCommits != 2 {
+				t.Errorf("got %d commits, want 2", summary.TotalCommits)
+			}
+		})
+	}
+}
+
+func TestDiscoverNoRepo(t *testing.T) {
+	dir := t.TempDir()
+	_, err := FindRepoRoot(dir)
+	openErr := &RepoOpenError{}
+	if !errors.As(err, &openErr) {
+		t.Errorf("got %v from %s, want a *RepoOpenError", err, dir)
+	}
+}
@@ -0,0 +1,35 @@
+// Package wrapped computes the stats of the commits an author made during a
+// year, and renders them into the report.
+//
+// A repository is opened with Open and analyzed with Repo.Analyze, picking
+// the commits through Options.Selection:
+//
+//	repo, err := wrapped.Open(".")
+//	if err != nil {
+//		return err
+//	}
+//	summary, err := repo.Analyze(ctx, wrapped.Options{
+//		Selection: wrapped.Selection{
+//			Window:  wrapped.NewYearWindow(2023, time.UTC),
+//			Authors: map[string]bool{"me@example.com": true},
+//		},
+//		Jobs:  runtime.GOMAXPROCS(0),
+//		Quiet: true,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//
+// The summary is then rendered by the Renderer of one of the Formats:
+//
+//	renderer, err := wrapped.NewRenderer("markdown", wrapped.RenderOptions{Top: wrapped.DefaultTop})
+//	if err != nil {
+//		return err
+//	}
+//	report, err := renderer.Render(summary)
+//
+// AnalyzeRepos analyzes several repositories at once, their summaries are
+// combined with Summary.Merge and Summary.Finish. The summary only exposes
+// plain values, commits are copied into Commit, so nothing of the underlying
+// git library leaks into the API.
+package wrapped
@@ -0,0 +1,45 @@
+package wrapped
+
+import "sort"
+
+// FileActivity is how much a single file was changed by the matched commits.
+type FileActivity struct {
+	Path      string
+	Commits   int
+	Additions int64
+	Deletions int64
+}
+
+func (f FileActivity) size() int64 {
+	return f.Additions + f.Deletions
+}
+
+// addFile counts the changes a commit made to a file.
+func (s *Summary) addFile(path string, activity FileActivity) {
+	file, ok := s.files[path]
+	if !ok {
+		file = &FileActivity{Path: path}
+		s.files[path] = file
+	}
+
+	file.Commits += activity.Commits
+	file.Additions += activity.Additions
+	file.Deletions += activity.Deletions
+}
+
+// TopFiles ranks the changed files by the lines added and deleted, falling
+// back to the path on ties.
+func (s *Summary) TopFiles() []FileActivity {
+	files := make([]FileActivity, 0, len(s.files))
+	for _, file := range s.files {
+		files = append(files, *file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].size() != files[j].size() {
+			return files[i].size() > files[j].size()
+		}
+		return files[i].Path < files[j].Path
+	})
+
+	return files
+}
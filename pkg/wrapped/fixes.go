@@ -0,0 +1,151 @@
+package wrapped
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultFixKeywords and DefaultFeatureKeywords are the words classifying
+// the subjects of commits that don't follow conventional commits.
+var (
+	DefaultFixKeywords     = []string{"fix", "fixes", "fixed", "bug", "bugfix", "hotfix", "patch"}
+	DefaultFeatureKeywords = []string{"feat", "feature", "add", "adds", "added", "implement", "introduce"}
+)
+
+// conventionalSubject matches the type of a conventional commit subject,
+// e.g. fix of fix(cache)!: evict on resize.
+var conventionalSubject = regexp.MustCompile(`^([A-Za-z]+)(\([^)]*\))?!?:\s`)
+
+// FixStats are the fix and feature commits of the author and where the fixes
+// went.
+type FixStats struct {
+	Fixes    int
+	Features int
+	// Month is the first day of the month with the most fixes, MonthFixes.
+	Month      time.Time
+	MonthFixes int
+	// File is the file changed by the most fixes, FileFixes, empty without
+	// line stats.
+	File      string
+	FileFixes int
+}
+
+// KeywordPattern returns the pattern matching any of the keywords as a whole
+// word, ignoring case.
+func KeywordPattern(keywords []string) (*regexp.Regexp, error) {
+	quoted := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		keyword = strings.TrimSpace(keyword)
+		if keyword == "" {
+			return nil, errors.New("keywords can't be empty")
+		}
+		quoted = append(quoted, regexp.QuoteMeta(keyword))
+	}
+
+	return regexp.Compile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)
+}
+
+// classify tells the fixes and the features apart by the type of
+// conventional commit subjects, or by the keywords anywhere in other
+// subjects. Fixes win over features when both match.
+func classify(subject string, fixes *regexp.Regexp, features *regexp.Regexp) (fix bool, feature bool) {
+	if match := conventionalSubject.FindStringSubmatch(subject); match != nil {
+		subject = match[1]
+		whole := func(pattern *regexp.Regexp) bool {
+			found := pattern.FindString(subject)
+			return found != "" && len(found) == len(subject)
+		}
+		if whole(fixes) {
+			return true, false
+		}
+		return false, whole(features)
+	}
+	if fixes.MatchString(subject) {
+		return true, false
+	}
+
+	return false, features.MatchString(subject)
+}
+
+// CountFixes classifies the listed commits but merges into fixes and
+// features, counting the fixes of every month and every file. Ties go to the
+// earliest month and the first path. The commits must have been collected with
+// Options.ListCommits.
+func (s *Summary) CountFixes(fixes *regexp.Regexp, features *regexp.Regexp) error {
+	if !s.has(fieldCommitList) {
+		return errors.New("fixes need the commits collected with Options.ListCommits")
+	}
+
+	stats := &FixStats{}
+	months := make(map[time.Time]int)
+	files := make(map[string]int)
+	for _, commit := range s.Commits {
+		if commit.Merge {
+			continue
+		}
+		fix, feature := classify(commit.Subject, fixes, features)
+		if feature {
+			stats.Features++
+		}
+		if !fix {
+			continue
+		}
+		stats.Fixes++
+		months[time.Date(commit.When.Year(), commit.When.Month(), 1, 0, 0, 0, 0, commit.When.Location())]++
+		for _, file := range commit.Files {
+			sides := renameSides(file.Path)
+			files[sides[len(sides)-1]]++
+		}
+	}
+	for month, count := range months {
+		if count > stats.MonthFixes || (count == stats.MonthFixes && month.Before(stats.Month)) {
+			stats.Month, stats.MonthFixes = month, count
+		}
+	}
+	for file, count := range files {
+		if count > stats.FileFixes || (count == stats.FileFixes && file < stats.File) {
+			stats.File, stats.FileFixes = file, count
+		}
+	}
+	s.Fixes = stats
+
+	return nil
+}
+
+// sentence describes the ratio, e.g. "23 fixes to 41 features, 0.56 per
+// feature, the most in March with 7".
+func (f *FixStats) sentence() string {
+	fixes, features := "fixes", "features"
+	if f.Fixes == 1 {
+		fixes = "fix"
+	}
+	if f.Features == 1 {
+		features = "feature"
+	}
+
+	var sentence string
+	if f.Features == 0 {
+		sentence = fmt.Sprintf("%d %s and no features", f.Fixes, fixes)
+	} else {
+		sentence = fmt.Sprintf("%d %s to %d %s, %.2f per feature", f.Fixes, fixes, f.Features, features, float64(f.Fixes)/float64(f.Features))
+	}
+	if f.MonthFixes > 0 {
+		sentence += fmt.Sprintf(", the most in %s with %d", f.Month.Format("January"), f.MonthFixes)
+	}
+
+	return sentence
+}
+
+// fileSentence calls out the file needing the most fixes, e.g.
+// "pkg/cache/lru.go was fixed 11 times — maybe 2025 is its year".
+func (f *FixStats) fileSentence(window AnalysisWindow) string {
+	times := "times"
+	if f.FileFixes == 1 {
+		times = "time"
+	}
+
+	return fmt.Sprintf("%s was fixed %d %s — maybe %d is its year", f.File, f.FileFixes, times, window.LastDay().Year()+1)
+}
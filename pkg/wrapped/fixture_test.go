@@ -0,0 +1,160 @@
+package wrapped
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// fixture is a repository built commit by commit for a test.
+type fixture struct {
+	t    testing.TB
+	dir  string
+	repo *git.Repository
+}
+
+func newFixture(t testing.TB) *fixture {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("unable to init the fixture: %v", err)
+	}
+
+	return &fixture{t: t, dir: dir, repo: repo}
+}
+
+// commit writes the files, removing the ones with empty contents, and commits
+// every change as the email at when.
+func (f *fixture) commit(email string, when time.Time, files map[string]string) plumbing.Hash {
+	f.t.Helper()
+
+	worktree, err := f.repo.Worktree()
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	for name, contents := range files {
+		path := filepath.Join(f.dir, name)
+		if contents == "" {
+			if _, err := worktree.Remove(name); err != nil {
+				f.t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			f.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			f.t.Fatal(err)
+		}
+		if _, err := worktree.Add(name); err != nil {
+			f.t.Fatal(err)
+		}
+	}
+
+	signature := &object.Signature{Name: email, Email: email, When: when}
+	hash, err := worktree.Commit("change "+when.Format(time.RFC3339), &git.CommitOptions{Author: signature, Committer: signature, AllowEmptyCommits: true})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	return hash
+}
+
+// analyze runs the analysis of every author's commits in the year of 2023,
+// in UTC.
+func (f *fixture) analyze(opts Options) (*Summary, error) {
+	f.t.Helper()
+
+	repo, err := Open(f.dir)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	if opts.Selection.Window.Start.IsZero() {
+		opts.Selection.Window = NewYearWindow(2023, time.UTC)
+	}
+	opts.Quiet = true
+
+	return repo.Analyze(context.Background(), opts)
+}
+
+// history writes a linear history of commits made every interval from start
+// straight into the storage, each changing a single file, and points HEAD's
+// branch at the last one. It's much faster than commit for the large
+// histories of benchmarks.
+func (f *fixture) history(email string, start time.Time, interval time.Duration, commits int) {
+	f.t.Helper()
+
+	storer := f.repo.Storer
+	var parent []plumbing.Hash
+	for i := 0; i < commits; i++ {
+		when := start.Add(time.Duration(i) * interval)
+		blob := storer.NewEncodedObject()
+		blob.SetType(plumbing.BlobObject)
+		writer, err := blob.Writer()
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		fmt.Fprintf(writer, "%d\n", i)
+		writer.Close()
+		blobHash, err := storer.SetEncodedObject(blob)
+		if err != nil {
+			f.t.Fatal(err)
+		}
+
+		tree := &object.Tree{Entries: []object.TreeEntry{{Name: fmt.Sprintf("file%d.txt", i%10), Mode: filemode.Regular, Hash: blobHash}}}
+		treeHash := f.store(tree)
+		signature := object.Signature{Name: email, Email: email, When: when}
+		commit := &object.Commit{Author: signature, Committer: signature, Message: fmt.Sprintf("commit %d\n", i), TreeHash: treeHash, ParentHashes: parent}
+		parent = []plumbing.Hash{f.store(commit)}
+	}
+
+	if err := storer.SetReference(plumbing.NewHashReference(plumbing.Master, parent[0])); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// store encodes the object into the storage, returning its hash.
+func (f *fixture) store(object interface {
+	Encode(plumbing.EncodedObject) error
+}) plumbing.Hash {
+	f.t.Helper()
+
+	encoded := f.repo.Storer.NewEncodedObject()
+	if err := object.Encode(encoded); err != nil {
+		f.t.Fatal(err)
+	}
+	hash, err := f.repo.Storer.SetEncodedObject(encoded)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	return hash
+}
+
+// listedSummary returns a summary of 2023 listing commits by dev@example.com
+// with the messages, the merges last.
+func listedSummary(messages []string, merges int) *Summary {
+	summary := NewSummary(true, NewYearWindow(2023, time.UTC))
+	summary.Fields |= fieldCommitList
+	summary.Selection.Authors = map[string]bool{"dev@example.com": true}
+	for i, message := range messages {
+		summary.Commits = append(summary.Commits, ListedCommit{
+			Hash:    plumbing.Hash(sha1.Sum([]byte(fmt.Sprint(i)))).String(),
+			Message: message,
+			Merge:   i >= len(messages)-merges,
+		})
+	}
+
+	return summary
+}
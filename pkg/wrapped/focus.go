@@ -0,0 +1,51 @@
+package wrapped
+
+import "fmt"
+
+// considerWidest keeps the commit changing the most files, the earliest on
+// ties.
+func (s *Summary) considerWidest(commit *Commit, files int) {
+	if s.Widest == nil || files > s.WidestFiles || (files == s.WidestFiles && commitBefore(commit, s.Widest)) {
+		s.Widest = commit
+		s.WidestFiles = files
+	}
+}
+
+// focusCommits returns the commits the focus stat is taken over, the ones
+// with line stats that changed any line counted by them.
+func (s *Summary) focusCommits() int64 {
+	return s.statsCommits - s.EmptyCommits
+}
+
+// SingleFileShare returns the percentage of the commits changing lines that
+// changed exactly one.
+func (s *Summary) SingleFileShare() float64 {
+	if s.focusCommits() == 0 {
+		return 0
+	}
+
+	return float64(s.SingleFileCommits) * 100 / float64(s.focusCommits())
+}
+
+// focusSentence describes the share of single-file commits, e.g. "62% of your
+// commits touched a single file, 38% spanned several".
+func (s *Summary) focusSentence() string {
+	single := roundHalfUp(s.SingleFileShare(), 0)
+
+	return fmt.Sprintf("%.0f%% of your commits touched a single file, %.0f%% spanned several", single, 100-single)
+}
+
+// widestSentence calls out the commit changing the most files, e.g. "touched
+// 214 files — "Run gofmt" on Mar 2".
+func (s *Summary) widestSentence() string {
+	files := "files"
+	if s.WidestFiles == 1 {
+		files = "file"
+	}
+	sentence := fmt.Sprintf("touched %d %s", s.WidestFiles, files)
+	if subject := commitSubject(s.Widest); subject != "" {
+		sentence += fmt.Sprintf(" — %q", subject)
+	}
+
+	return sentence + " on " + s.when(s.Widest).Format(yearDayLayout)
+}
@@ -0,0 +1,43 @@
+package wrapped
+
+import (
+	"fmt"
+	"strings"
+)
+
+// heatLevels are the cells of the heatmap, from no commits to the most.
+var heatLevels = []string{"·", "░", "▒", "▓", "█"}
+
+// Heatmap draws the commits per day of the window as a calendar, one column
+// per week and one row per weekday.
+func Heatmap(summary *Summary) string {
+	window := summary.Window
+	most := 0
+	for day := window.Start; day.Before(window.End); day = day.AddDate(0, 0, 1) {
+		if count := summary.CommitsOn(day); count > most {
+			most = count
+		}
+	}
+
+	// Weeks start on Monday, days before the window are left blank.
+	rows := make([]strings.Builder, 7)
+	offset := (int(window.Start.Weekday()) + 6) % 7
+	for i := 0; i < offset; i++ {
+		rows[i].WriteString(" ")
+	}
+	for day := window.Start; day.Before(window.End); day = day.AddDate(0, 0, 1) {
+		level := 0
+		if count := summary.CommitsOn(day); count > 0 {
+			level = 1 + (count-1)*(len(heatLevels)-2)/most
+		}
+		rows[(int(day.Weekday())+6)%7].WriteString(heatLevels[level])
+	}
+
+	builder := strings.Builder{}
+	for i, name := range []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"} {
+		builder.WriteString(fmt.Sprintf("%s %s\n", name, rows[i].String()))
+	}
+	builder.WriteString(fmt.Sprintf("\n%s none  %s most (%s)", heatLevels[0], heatLevels[len(heatLevels)-1], commitCount(most)))
+
+	return builder.String()
+}
@@ -0,0 +1,175 @@
+package wrapped
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// The typical values the stats are scored against as highlights, a stat
+// scoring the higher the further above them it is.
+const (
+	typicalAdditions = 40
+	typicalDeletions = 20
+	// typicalActiveShare is the percentage of the days of the window with
+	// commits.
+	typicalActiveShare = 40
+	// typicalWidestFiles is the files the broadest change touched.
+	typicalWidestFiles = 10
+	// typicalMergeShare, typicalFixShare and typicalAfterHoursShare are the
+	// percentages of the commits being merges, fixes and made after hours.
+	typicalMergeShare      = 10
+	typicalFixShare        = 30
+	typicalAfterHoursShare = 15
+	// typicalAfterHoursRun is the days of the longest after-hours run.
+	typicalAfterHoursRun = 2
+	typicalReleases      = 4
+	// typicalSurvivalShare is the percentage of the added lines still alive.
+	typicalSurvivalShare = 50
+	// typicalKickoffDays is how many days into the window the first commit
+	// typically is.
+	typicalKickoffDays = 14
+	// typicalEarliestMinute and typicalLatestMinute are the minutes of the
+	// day the earliest and the latest commit typically are at, and
+	// lateNightMinutes how much later the latest scores the highest.
+	typicalEarliestMinute = 7 * 60
+	typicalLatestMinute   = 20 * 60
+	lateNightMinutes      = 4 * 60
+)
+
+// magnitudeScore scores a stat by how far above its typical value it is,
+// from 0 at or under it towards 1, e.g. 0.5 for twice the typical value.
+func magnitudeScore(value float64, typical float64) float64 {
+	if typical <= 0 || value <= typical {
+		return 0
+	}
+
+	return 1 - typical/value
+}
+
+// clampScore keeps the score between 0 and 1.
+func clampScore(score float64) float64 {
+	return math.Max(0, math.Min(1, score))
+}
+
+// kickoffScore scores the first commit of the window by how soon into it it
+// was, 1 for the very start.
+func (s *Summary) kickoffScore() float64 {
+	days := s.when(s.FirstOfYear).Sub(s.Window.Start).Hours() / 24
+	return clampScore(1 - days/typicalKickoffDays)
+}
+
+// earliestScore scores the earliest commit of the day by how long before 7
+// it was made, 1 for midnight.
+func (s *Summary) earliestScore() float64 {
+	when := s.when(s.Earliest)
+	return clampScore(float64(typicalEarliestMinute-(when.Hour()*60+when.Minute())) / typicalEarliestMinute)
+}
+
+// latestScore scores the latest commit of the day by how long after 20 it was
+// made, 1 for midnight.
+func (s *Summary) latestScore() float64 {
+	when := s.when(s.Latest)
+	return clampScore(float64(when.Hour()*60+when.Minute()-typicalLatestMinute) / lateNightMinutes)
+}
+
+// commitsPerActiveDay returns the average commits of the days with any.
+func (s *Summary) commitsPerActiveDay() float64 {
+	if s.ActiveDays() == 0 {
+		return 0
+	}
+
+	return float64(s.TotalCommits) / float64(s.ActiveDays())
+}
+
+// historyScore scores the historical rank, the record of many years the
+// highest and any rank of a single year 0.
+func historyScore(history *History) float64 {
+	if len(history.Years) < 2 {
+		return 0
+	}
+
+	return (1 - 1/float64(len(history.Years))) / float64(history.Rank())
+}
+
+// fixScore scores the fixes by how much of the fix and feature commits they
+// were.
+func fixScore(fixes *FixStats) float64 {
+	if fixes.Fixes+fixes.Features == 0 {
+		return 0
+	}
+
+	return magnitudeScore(float64(fixes.Fixes)*100/float64(fixes.Fixes+fixes.Features), typicalFixShare)
+}
+
+// highlights picks opts.Highlights of the scored report rows at random, the
+// higher a row scores the likelier it's picked. The draw is seeded by
+// opts.HighlightSeed, picking the same rows of the same report for the same
+// seed, and they're returned in the order of the report.
+func highlights(summary *Summary, opts RenderOptions) []reportRow {
+	type candidate struct {
+		row   reportRow
+		index int
+		key   float64
+	}
+
+	random := seededRand(opts.HighlightSeed)
+	candidates := make([]candidate, 0)
+	for i, row := range reportRows(summary) {
+		if row.Score <= 0 {
+			continue
+		}
+		// The rows with the largest u^(1/score), u drawn uniformly, are a
+		// sample weighted by the scores.
+		candidates = append(candidates, candidate{row: row, index: i, key: math.Pow(random.Float64(), 1/row.Score)})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].key > candidates[j].key
+	})
+	if len(candidates) > opts.Highlights {
+		candidates = candidates[:opts.Highlights]
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].index < candidates[j].index
+	})
+
+	rows := make([]reportRow, 0, len(candidates))
+	for _, candidate := range candidates {
+		rows = append(rows, candidate.row)
+	}
+
+	return rows
+}
+
+// buildHighlightsOutput renders the text report of the highlights, in place of
+// every stat.
+func buildHighlightsOutput(summary *Summary, opts RenderOptions) string {
+	builder := strings.Builder{}
+	if opts.BannerWidth > 0 {
+		builder.WriteString(opts.paint(opts.theme().Accent, summary.banner(opts.BannerWidth)) + "\n")
+	}
+	builder.WriteString(fmt.Sprintf("📆 %s\n", summary.Window))
+	if branches := summary.branchesSentence(); branches != "" {
+		builder.WriteString("🌿 " + branches + "\n")
+	}
+
+	rows := highlights(summary, opts)
+	if len(rows) == 0 {
+		builder.WriteString("✨ Highlights: nothing stood out\n")
+		writeFooter(&builder, summary, opts)
+		return builder.String()
+	}
+	builder.WriteString("✨ Highlights:\n")
+	for _, row := range rows {
+		value := row.Value
+		if row.Hash != "" {
+			value += " (" + row.Hash + ")"
+		}
+		builder.WriteString(fmt.Sprintf("  %s: %s\n", row.Label, value))
+	}
+	writeFooter(&builder, summary, opts)
+
+	return builder.String()
+}
@@ -0,0 +1,167 @@
+package wrapped
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// historyBarWidth is how many blocks the year with the most commits is drawn
+// with in the per-year chart.
+const historyBarWidth = 20
+
+// History is the author's commits in every year of the repositories'
+// history, to rank the window's year against the others.
+type History struct {
+	// Year is the year of the window.
+	Year int
+	// Years are the years with commits, oldest first.
+	Years []YearCount
+}
+
+// YearCount is the number of commits in a single year.
+type YearCount struct {
+	Year    int
+	Commits int
+}
+
+// FindHistory counts the commits of the selection's authors in every year of
+// the history, with the merges left out the way the selection does. Only the
+// commit times are read, no diffs are computed, so it stays fast however long
+// the history is. Years are taken in the window's time zone. Repositories that
+// can't be opened are skipped, the analysis already reported them.
+func FindHistory(ctx context.Context, paths []string, selection Selection) (*History, error) {
+	location := selection.Window.Location
+	year := selection.Window.Start.Year()
+	selection.Window = AnalysisWindow{
+		Start:    time.Time{},
+		End:      time.Date(9999, 1, 1, 0, 0, 0, 0, location),
+		Location: location,
+	}
+
+	counts := make(map[int]int)
+	for _, path := range paths {
+		_, repo, err := openRepo(path)
+		if err != nil {
+			continue
+		}
+		_, err = findRelevantCommits(ctx, repo, selection, nopLogger{}, skipCorrupt, func(commit *object.Commit) error {
+			counts[commit.Author.When.In(location).Year()]++
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to walk the history of %s. [err=%s]", path, err.Error())
+		}
+	}
+
+	history := &History{Year: year, Years: make([]YearCount, 0, len(counts))}
+	for year, commits := range counts {
+		history.Years = append(history.Years, YearCount{Year: year, Commits: commits})
+	}
+	sort.Slice(history.Years, func(i, j int) bool {
+		return history.Years[i].Year < history.Years[j].Year
+	})
+
+	return history, nil
+}
+
+// Commits returns the commits of the year, zero when it has none.
+func (h *History) Commits(year int) int {
+	for _, count := range h.Years {
+		if count.Year == year {
+			return count.Commits
+		}
+	}
+
+	return 0
+}
+
+// Rank returns the place of the window's year among the years with commits,
+// 1 for the most active. Years with as many commits share their place.
+func (h *History) Rank() int {
+	commits := h.Commits(h.Year)
+	rank := 1
+	for _, count := range h.Years {
+		if count.Commits > commits {
+			rank++
+		}
+	}
+
+	return rank
+}
+
+// Record returns the year with the most commits, the earliest of them on
+// ties.
+func (h *History) Record() YearCount {
+	record := YearCount{}
+	for _, count := range h.Years {
+		if count.Commits > record.Commits {
+			record = count
+		}
+	}
+
+	return record
+}
+
+// sentence ranks the year, e.g. "2023 was your 2nd most active year out of 7;
+// your record is 2019 with 611 commits".
+func (h *History) sentence() string {
+	record := h.Record()
+	switch {
+	case len(h.Years) < 2:
+		return fmt.Sprintf("%d is your only year with commits so far", h.Year)
+	case h.Rank() == 1 && record.Year == h.Year:
+		return fmt.Sprintf("%d was your most active year out of %d, a record with %s", h.Year, len(h.Years), commitCount(record.Commits))
+	case h.Rank() == 1:
+		return fmt.Sprintf("%d tied your most active year out of %d, %d with %s", h.Year, len(h.Years), record.Year, commitCount(record.Commits))
+	default:
+		return fmt.Sprintf("%d was your %s most active year out of %d; your record is %d with %s", h.Year, ordinal(h.Rank()), len(h.Years), record.Year, commitCount(record.Commits))
+	}
+}
+
+// ordinal returns the number with its English suffix, e.g. 2nd or 11th.
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+
+	return strconv.Itoa(n) + suffix
+}
+
+// Chart draws a bar per year relative to the record, the window's year
+// marked, e.g.
+//
+//	2022 ██████████           311
+//	2023 ████████████████████ 611 ◀
+func (h *History) Chart() string {
+	most := h.Record().Commits
+	width := 0
+	for _, count := range h.Years {
+		if digits := len(FormatCount(count.Commits)); digits > width {
+			width = digits
+		}
+	}
+
+	lines := make([]string, 0, len(h.Years))
+	for _, count := range h.Years {
+		blocks := (count.Commits*historyBarWidth + most - 1) / most
+		line := fmt.Sprintf("%d %-*s %*s", count.Year, historyBarWidth, strings.Repeat("█", blocks), width, FormatCount(count.Commits))
+		if count.Year == h.Year {
+			line += " ◀"
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
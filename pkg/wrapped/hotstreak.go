@@ -0,0 +1,117 @@
+package wrapped
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// hotStreakWeeks is the length of a hot streak in weeks.
+	hotStreakWeeks = 4
+	// trailingWeeks is how many weeks before a streak make the usual pace it
+	// is compared to, and minTrailingWeeks how many of them there must be.
+	trailingWeeks    = 8
+	minTrailingWeeks = 4
+	// minStreakCommits is the fewest commits a streak must have, so going
+	// from 1 commit to 3 isn't a hot streak.
+	minStreakCommits = 8
+	// minMultiplier is the least increase over the usual pace that counts
+	// as a hot streak.
+	minMultiplier = 1.5
+	// maxMultiplier caps the increase, after weeks of no commits any pace
+	// would be infinitely faster.
+	maxMultiplier = 10
+)
+
+// HotStreak is the 4 weeks the author committed the most above their usual
+// pace.
+type HotStreak struct {
+	// Start is the first day of the streak and End the day after its last.
+	Start time.Time
+	End   time.Time
+	// Commits were made in the streak.
+	Commits int
+	// Usual is the average of weekly commits in the weeks before it.
+	Usual float64
+	// Multiplier is the weekly commits of the streak over Usual, at most
+	// maxMultiplier, Capped when it's more.
+	Multiplier float64
+	Capped     bool
+}
+
+// HotStreak slides a 4 week window over the weekly commits and compares each
+// one with the average of the 8 weeks before it, returning the window with
+// the largest increase. Windows need at least 4 weeks before them, so the
+// first month of the window can't be a streak, and at least 8 commits, and
+// the increase must be 1.5× or more. A window after weeks without any commits
+// is capped at 10×. ok is false when no window qualifies.
+func (s *Summary) HotStreak() (streak HotStreak, ok bool) {
+	weeks := s.WeeklyCommits()
+	for i := minTrailingWeeks; i+hotStreakWeeks <= len(weeks); i++ {
+		commits := 0
+		for _, count := range weeks[i : i+hotStreakWeeks] {
+			commits += count
+		}
+		if commits < minStreakCommits {
+			continue
+		}
+
+		first := i - trailingWeeks
+		if first < 0 {
+			first = 0
+		}
+		trailing := 0
+		for _, count := range weeks[first:i] {
+			trailing += count
+		}
+		usual := float64(trailing) / float64(i-first)
+		pace := float64(commits) / hotStreakWeeks
+		multiplier, capped := float64(maxMultiplier), true
+		if usual > 0 && pace/usual <= maxMultiplier {
+			multiplier, capped = pace/usual, false
+		}
+		if multiplier < minMultiplier || (ok && multiplier <= streak.Multiplier) {
+			continue
+		}
+
+		end := s.Window.Start.AddDate(0, 0, 7*(i+hotStreakWeeks))
+		// The last week holds the days left over.
+		if i+hotStreakWeeks == len(weeks) {
+			end = s.Window.End
+		}
+		streak = HotStreak{Start: s.Window.Start.AddDate(0, 0, 7*i), End: end, Commits: commits, Usual: usual, Multiplier: multiplier, Capped: capped}
+		ok = true
+	}
+
+	return streak, ok
+}
+
+// LastDay returns the last day of the streak.
+func (h HotStreak) LastDay() time.Time {
+	return h.End.AddDate(0, 0, -1)
+}
+
+// when names the part of the month the streak is centered on, e.g. Late
+// September.
+func (h HotStreak) when() string {
+	middle := h.Start.Add(h.End.Sub(h.Start) / 2)
+	switch {
+	case middle.Day() <= 10:
+		return "Early " + middle.Month().String()
+	case middle.Day() <= 20:
+		return "Mid " + middle.Month().String()
+	default:
+		return "Late " + middle.Month().String()
+	}
+}
+
+// sentence describes the streak, e.g. "Late September you shipped 3.4× your
+// usual pace (Sep 11 – Oct 8, 27 commits)".
+func (h HotStreak) sentence() string {
+	pace := fmt.Sprintf("%.1f×", roundHalfUp(h.Multiplier, 1))
+	if h.Capped {
+		pace = fmt.Sprintf("more than %d×", maxMultiplier)
+	}
+
+	return fmt.Sprintf("%s you shipped %s your usual pace (%s – %s, %s)", h.when(), pace, h.Start.Format(yearDayLayout), h.LastDay().Format(yearDayLayout), commitCount(h.Commits))
+}
@@ -0,0 +1,213 @@
+package wrapped
+
+import (
+	"html/template"
+	"strings"
+)
+
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>git-wrapped {{.Window}}</title>
+<style>
+body { font-family: sans-serif; max-width: 48em; margin: 2em auto; }
+td { padding: 0.2em 1em 0.2em 0; vertical-align: top; }
+code { font-size: 0.9em; }
+{{.Style}}</style>
+</head>
+<body>
+<h1>{{if .Highlights}}✨ git-wrapped highlights{{else}}🎁 git-wrapped{{end}}</h1>
+<p class="muted">{{.Window}}{{if .Branches}} · 🌿 {{.Branches}}{{end}}</p>
+<table>
+{{- range .Rows}}
+<tr><td>{{.Label}}</td><td>{{if .Hash}}<code>{{.Hash}}</code> {{end}}{{.Value}}</td></tr>
+{{- end}}
+</table>
+{{- if .Files}}
+<h2>📂 Most changed files</h2>
+<ol>
+{{- range .Files}}
+<li><code>{{.Path}}</code>: <span class="additions">+{{.Additions}}</span>/<span class="deletions">-{{.Deletions}}</span></li>
+{{- end}}
+</ol>
+{{- end}}
+{{- if .Tickets}}
+<h2>🎫 Top tickets</h2>
+<ol>
+{{- range .Tickets}}
+<li>{{if .URL}}<a href="{{.URL}}">{{.Label}}</a>{{else}}{{.Label}}{{end}}: {{.Value}}</li>
+{{- end}}
+</ol>
+{{- end}}
+{{- if .Identities}}
+<h2>🪪 Identities</h2>
+<ul>
+{{- range .Identities}}
+<li>{{.Label}}: {{.Value}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Repos}}
+<h2>🗂️ Repositories</h2>
+<p>{{.Home}}</p>
+<ol>
+{{- range .Repos}}
+<li>{{.Label}}: {{.Value}}</li>
+{{- end}}
+</ol>
+{{- end}}
+{{- if .Spotlight}}
+<h2>🎯 File spotlight</h2>
+<p>{{.Spotlight}}</p>
+{{- end}}
+{{- if .History}}
+<h2>📜 Commits per year</h2>
+<pre>{{.History}}</pre>
+{{- end}}
+{{- if .NetLines}}
+<h2>📐 Net lines per month</h2>
+<pre>{{.NetLines}}</pre>
+{{- end}}
+{{- if .Sizes}}
+<h2>📊 Commit sizes</h2>
+<pre>{{.Sizes}}</pre>
+{{- end}}
+{{- if .Owned}}
+<h2>👑 Largest owned files</h2>
+<ol>
+{{- range .Owned}}
+<li>{{.Label}}: {{.Value}}</li>
+{{- end}}
+</ol>
+{{- end}}
+{{- if .Neighbors}}
+<h2>🏘️ Code neighbors</h2>
+<ol>
+{{- range .Neighbors}}
+<li>{{.Label}}: {{.Value}}</li>
+{{- end}}
+</ol>
+{{- end}}
+{{- if .NewContributors}}
+<h2>🌱 New contributors</h2>
+<ol>
+{{- range .NewContributors}}
+<li>{{.Label}}: {{.Value}}</li>
+{{- end}}
+</ol>
+{{- end}}
+{{- if .Achievements}}
+<h2>🏅 Achievements unlocked</h2>
+<ul>
+{{- range .Achievements}}
+<li>{{.Emoji}} <strong>{{.Name}}</strong> ({{.Rule}}): {{.Evidence}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Heatmap}}
+<h2>🗓️ Team activity</h2>
+<pre>{{.Heatmap}}</pre>
+{{- end}}
+{{- if .Footer}}
+<footer class="muted">
+{{- range .Footer}}
+<small>{{.Label}}: {{.Value}}</small><br>
+{{- end}}
+</footer>
+{{- end}}
+</body>
+</html>`))
+
+// htmlReportData is what the HTML report is rendered from.
+type htmlReportData struct {
+	Style           template.CSS
+	Window          AnalysisWindow
+	Branches        string
+	Highlights      bool
+	Rows            []reportRow
+	Files           []FileActivity
+	Tickets         []reportRow
+	Identities      []reportRow
+	Home            string
+	Repos           []reportRow
+	Spotlight       string
+	History         string
+	NetLines        string
+	Sizes           string
+	Owned           []reportRow
+	Neighbors       []reportRow
+	NewContributors []reportRow
+	Achievements    []Achievement
+	Heatmap         string
+	// Footer tells how the report was computed.
+	Footer []reportRow
+}
+
+// buildHTMLOutput renders the report as a standalone HTML page, e.g. for
+// emails and the server. With opts.Highlights the page only has the
+// highlights.
+func buildHTMLOutput(summary *Summary, opts RenderOptions) (string, error) {
+	builder := strings.Builder{}
+	style := template.CSS("")
+	if opts.Theme != nil {
+		style = template.CSS(opts.Theme.css())
+	}
+	if opts.Highlights > 0 {
+		err := htmlReport.Execute(&builder, htmlReportData{Style: style, Window: summary.Window, Branches: summary.branchesSentence(), Highlights: true, Rows: highlights(summary, opts), Footer: footerRows(summary, opts)})
+		if err != nil {
+			return "", err
+		}
+		return builder.String(), nil
+	}
+
+	identities := make([]reportRow, 0)
+	shown := shownIdentities(summary, opts)
+	for _, identity := range shown {
+		identities = append(identities, reportRow{Label: identity.Email, Value: identity.sentence(summary.has(fieldLineStats), identityRepos(shown))})
+	}
+	repos := make([]reportRow, 0)
+	for _, repo := range shownRepos(summary, opts) {
+		repos = append(repos, reportRow{Label: repo.Name, Value: repo.sentence(summary.has(fieldLineStats))})
+	}
+	contributors := make([]reportRow, 0)
+	for _, contributor := range shownNewContributors(summary, opts) {
+		contributors = append(contributors, reportRow{Label: contributor.Name + " <" + contributor.Email + ">", Value: commitCount(contributor.Commits)})
+	}
+	owned := make([]reportRow, 0)
+	if summary.Ownership != nil {
+		for _, file := range summary.Ownership.Largest {
+			owned = append(owned, reportRow{Label: file.Path, Value: file.sentence()})
+		}
+	}
+	neighbors := make([]reportRow, 0)
+	for _, neighbor := range topNeighbors(summary) {
+		neighbors = append(neighbors, reportRow{Label: neighbor.Name + " <" + neighbor.Email + ">", Value: neighbor.sentence()})
+	}
+	spotlight := ""
+	if summary.Spotlight != nil {
+		spotlight = summary.Spotlight.sentence()
+	}
+	history := ""
+	if summary.History != nil {
+		history = summary.History.Chart()
+	}
+	netLines := ""
+	if summary.has(fieldLineStats) && summary.shows(StatNetLines) {
+		netLines = summary.NetLinesChart()
+	}
+	sizes := ""
+	if summary.shows(StatCommitSizes) {
+		sizes = summary.SizeHistogram()
+	}
+	heatmap := ""
+	if summary.Team != nil {
+		heatmap = Heatmap(summary)
+	}
+	err := htmlReport.Execute(&builder, htmlReportData{style, summary.Window, summary.branchesSentence(), false, reportRows(summary), shownFiles(summary, opts), ticketRows(summary, opts), identities, summary.homeSentence(), repos, spotlight, history, netLines, sizes, owned, neighbors, contributors, summary.Achievements(), heatmap, footerRows(summary, opts)})
+	if err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
@@ -0,0 +1,183 @@
+package wrapped
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"sort"
+	"strings"
+)
+
+// IdentityCount is the number of commits an author email made in the window.
+type IdentityCount struct {
+	Email   string
+	Name    string
+	Commits int
+}
+
+// CountIdentities walks the year's commits in every repository, by any
+// author, and counts the commits made under each email. It's a second pass
+// over history, so it only runs when diagnosing which emails to use. The
+// counts are sorted by commits, most first. Repositories that can't be opened
+// are skipped, unless none of them can.
+func CountIdentities(ctx context.Context, paths []string, selection Selection) ([]IdentityCount, error) {
+	counts := make(map[string]*IdentityCount)
+	selection.Authors = nil
+
+	var openErr error
+	opened := 0
+	for _, path := range paths {
+		_, repo, err := openRepo(path)
+		if err != nil {
+			openErr = err
+			continue
+		}
+		opened++
+
+		_, err = findRelevantCommits(ctx, repo, selection, nopLogger{}, skipCorrupt, func(commit *object.Commit) error {
+			count, ok := counts[commit.Author.Email]
+			if !ok {
+				count = &IdentityCount{Email: commit.Author.Email, Name: commit.Author.Name}
+				counts[commit.Author.Email] = count
+			}
+			count.Commits++
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opened == 0 {
+		return nil, openErr
+	}
+
+	sorted := make([]IdentityCount, 0, len(counts))
+	for _, count := range counts {
+		sorted = append(sorted, *count)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Commits != sorted[j].Commits {
+			return sorted[i].Commits > sorted[j].Commits
+		}
+		return sorted[i].Email < sorted[j].Email
+	})
+
+	return sorted, nil
+}
+
+// IdentityActivity is how much of the summary was committed under a single
+// email of the author.
+type IdentityActivity struct {
+	Email   string
+	Commits int64
+	// Additions and Deletions are only counted with line stats.
+	Additions  int64
+	Deletions  int64
+	ActiveDays int
+	// Repos are the repositories the email committed to, sorted.
+	Repos []string
+}
+
+// identityActivity accumulates an IdentityActivity while commits are added.
+type identityActivity struct {
+	commits   int64
+	additions int64
+	deletions int64
+	days      map[string]bool
+	repos     map[string]bool
+}
+
+func (s *Summary) identity(email string) *identityActivity {
+	identity, ok := s.identities[email]
+	if !ok {
+		identity = &identityActivity{days: make(map[string]bool), repos: make(map[string]bool)}
+		s.identities[email] = identity
+	}
+
+	return identity
+}
+
+func (s *Summary) mergeIdentity(email string, other *identityActivity) {
+	identity := s.identity(email)
+	identity.commits += other.commits
+	identity.additions += other.additions
+	identity.deletions += other.deletions
+	for day := range other.days {
+		identity.days[day] = true
+	}
+	for repo := range other.repos {
+		identity.repos[repo] = true
+	}
+}
+
+// Identities returns the activity of every email the commits were matched
+// by, most commits first.
+func (s *Summary) Identities() []IdentityActivity {
+	identities := make([]IdentityActivity, 0, len(s.identities))
+	for email, identity := range s.identities {
+		repos := make([]string, 0, len(identity.repos))
+		for repo := range identity.repos {
+			repos = append(repos, repo)
+		}
+		sort.Strings(repos)
+		identities = append(identities, IdentityActivity{
+			Email:      email,
+			Commits:    identity.commits,
+			Additions:  identity.additions,
+			Deletions:  identity.deletions,
+			ActiveDays: len(identity.days),
+			Repos:      repos,
+		})
+	}
+	sort.Slice(identities, func(i, j int) bool {
+		if identities[i].Commits != identities[j].Commits {
+			return identities[i].Commits > identities[j].Commits
+		}
+		return identities[i].Email < identities[j].Email
+	})
+
+	return identities
+}
+
+// shownIdentities returns the identities the report breaks the activity down
+// by, none when only one of them matched.
+func shownIdentities(summary *Summary, opts RenderOptions) []IdentityActivity {
+	if !opts.Identities || len(summary.identities) < 2 {
+		return nil
+	}
+
+	return summary.Identities()
+}
+
+// identityRepos reports whether the repositories of the identities are
+// worth listing, only when the commits come from more than one.
+func identityRepos(identities []IdentityActivity) bool {
+	repos := make(map[string]bool)
+	for _, identity := range identities {
+		for _, repo := range identity.Repos {
+			repos[repo] = true
+		}
+	}
+
+	return len(repos) > 1
+}
+
+// sentence describes the activity of the identity, e.g. "12 commits,
+// +340/-20, 9 active days".
+func (i IdentityActivity) sentence(lineStats bool, repos bool) string {
+	sentence := commitCount(int(i.Commits))
+	if lineStats {
+		sentence += fmt.Sprintf(", +%d/-%d", i.Additions, i.Deletions)
+	}
+	if i.ActiveDays == 1 {
+		sentence += ", 1 active day"
+	} else {
+		sentence += fmt.Sprintf(", %d active days", i.ActiveDays)
+	}
+	if repos {
+		sentence += " in " + strings.Join(i.Repos, ", ")
+	}
+
+	return sentence
+}
@@ -0,0 +1,234 @@
+package wrapped
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// lockfiles are dependency lock files, which are generated and would otherwise
+// dwarf the lines people actually wrote.
+var lockfiles = map[string]bool{
+	"Cargo.lock":        true,
+	"Gemfile.lock":      true,
+	"Pipfile.lock":      true,
+	"Podfile.lock":      true,
+	"composer.lock":     true,
+	"flake.lock":        true,
+	"go.sum":            true,
+	"mix.lock":          true,
+	"package-lock.json": true,
+	"pnpm-lock.yaml":    true,
+	"poetry.lock":       true,
+	"yarn.lock":         true,
+}
+
+// PathFilter decides which changed files count towards the line stats.
+type PathFilter struct {
+	// Excluded are glob patterns (see path.Match) matched against the whole
+	// path, the file name and every leading directory, so "vendor",
+	// "*.pb.go" and "docs/*.md" all work as expected.
+	Excluded []string
+	// ExcludeLockfiles skips dependency lock files.
+	ExcludeLockfiles bool
+}
+
+// skips reports whether the file at name is left out of the line stats. The
+// empty name, used for the missing side of an insertion or deletion, is never
+// skipped.
+func (f PathFilter) skips(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	if f.ExcludeLockfiles && lockfiles[path.Base(name)] {
+		return true
+	}
+
+	for _, pattern := range f.Excluded {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, path.Base(name)); matched {
+			return true
+		}
+		for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			if matched, _ := path.Match(pattern, dir); matched {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// key identifies the filter settings, so stats computed under one filter are
+// never reused under another.
+func (f PathFilter) key() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%t\x00%s", f.ExcludeLockfiles, strings.Join(f.Excluded, "\x00"))))
+	return hex.EncodeToString(sum[:4])
+}
+
+// commitLineStats computes the per-file line stats of a commit against its
+// first parent, or the empty tree for root commits. It diffs the trees with
+// diff and only counts changed lines, rather than going through
+// commit.Stats() which renders the whole patch, and never reads the contents
+// of files skipped by the filter or binary files. The counts match commit.Stats() for the files
+// that are kept. An *oversizedError is returned as soon as the commit goes
+// over the limits. The files are read once the budget has room for them.
+func commitLineStats(ctx context.Context, commit *object.Commit, diff differ, filter PathFilter, limits CommitLimits, budget *memoryBudget) ([]fileStats, error) {
+	changes, err := diff(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+	if err := limits.checkChangedFiles(len(changes)); err != nil {
+		return nil, err
+	}
+
+	stats := make([]fileStats, 0, len(changes))
+	for _, change := range changes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if filter.skips(change.From.Name) || filter.skips(change.To.Name) {
+			continue
+		}
+
+		stat, ok, err := changeLineStats(ctx, change, limits, budget)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			stats = append(stats, stat)
+		}
+	}
+
+	return stats, nil
+}
+
+// commitChanges diffs the tree of the commit against its first parent, or
+// the empty tree for root commits, detecting renames.
+func commitChanges(ctx context.Context, commit *object.Commit) (object.Changes, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	parentTree := &object.Tree{}
+	if commit.NumParents() != 0 {
+		parent, err := firstParent(commit)
+		if err != nil {
+			return nil, err
+		}
+
+		parentTree, err = parent.Tree()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return object.DiffTreeWithOptions(ctx, parentTree, tree, object.DefaultDiffTreeOptions)
+}
+
+// changeLineStats counts the lines added and removed by a single change. Like
+// commit.Stats(), submodules, binary files and changes without any content
+// produce no stats. The diff gives up once the context is done, returning
+// its error.
+func changeLineStats(ctx context.Context, change *object.Change, limits CommitLimits, budget *memoryBudget) (fileStats, bool, error) {
+	from, to, err := change.Files()
+	if err != nil || (from == nil && to == nil) {
+		return fileStats{}, false, err
+	}
+	size := int64(0)
+	for _, file := range []*object.File{from, to} {
+		if file == nil {
+			continue
+		}
+		if err := limits.checkBlobSize(file.Size); err != nil {
+			return fileStats{}, false, err
+		}
+		size += file.Size
+	}
+	budget.acquire(size)
+	defer budget.release(size)
+
+	fromContent, binary, err := textContent(from)
+	if err != nil || binary {
+		return fileStats{}, false, err
+	}
+	toContent, binary, err := textContent(to)
+	if err != nil || binary {
+		return fileStats{}, false, err
+	}
+
+	stat := fileStats{}
+	switch {
+	case from == nil:
+		stat.Name, stat.Change = change.To.Name, ChangeAdded
+	case to == nil:
+		stat.Name, stat.Change = change.From.Name, ChangeDeleted
+	case change.From.Name != change.To.Name:
+		stat.Name, stat.Change = fmt.Sprintf("%s => %s", change.From.Name, change.To.Name), ChangeRenamed
+	default:
+		stat.Name, stat.Change = change.From.Name, ChangeModified
+	}
+
+	changed := false
+	stat.Additions, stat.Deletions, changed = countLineChanges(ctx, fromContent, toContent)
+	if err := ctx.Err(); err != nil {
+		return fileStats{}, false, err
+	}
+
+	return stat, changed, nil
+}
+
+func textContent(f *object.File) (string, bool, error) {
+	if f == nil {
+		return "", false, nil
+	}
+
+	binary, err := f.IsBinary()
+	if err != nil || binary {
+		return "", binary, err
+	}
+
+	content, err := f.Contents()
+	return content, false, err
+}
+
+// countLineChanges runs the same line oriented diff go-git uses for patches,
+// but counts the lines of each chunk instead of turning them back into text.
+// Every rune of a line mode diff stands for one whole line. The final result
+// reports whether the diff had any chunks at all. Past the deadline of the
+// context the diff is cut short, the counts then being rough.
+func countLineChanges(ctx context.Context, from, to string) (int64, int64, bool) {
+	dmp := diffmatchpatch.New()
+	// go-git uses an hour so big files are never reported as a single chunk.
+	dmp.DiffTimeout = time.Hour
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < dmp.DiffTimeout {
+		dmp.DiffTimeout = max(time.Until(deadline), time.Nanosecond)
+	}
+	fromRunes, toRunes, _ := dmp.DiffLinesToRunes(from, to)
+	diffs := dmp.DiffMainRunes(fromRunes, toRunes, false)
+
+	additions := int64(0)
+	deletions := int64(0)
+	for _, d := range diffs {
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			additions += int64(utf8.RuneCountInString(d.Text))
+		case diffmatchpatch.DiffDelete:
+			deletions += int64(utf8.RuneCountInString(d.Text))
+		}
+	}
+
+	return additions, deletions, len(diffs) > 0
+}
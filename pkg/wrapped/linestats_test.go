@@ -0,0 +1,317 @@
+package wrapped
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// numberedLines returns the lines from to to, prefixed with the prefix.
+func numberedLines(prefix string, from, to int) string {
+	builder := strings.Builder{}
+	for i := from; i < to; i++ {
+		fmt.Fprintf(&builder, "%s %d\n", prefix, i)
+	}
+
+	return builder.String()
+}
+
+// lineStatsFixture is a history adding, editing, growing, shrinking and
+// deleting text files, returning its commits oldest first.
+func lineStatsFixture(t testing.TB) (*fixture, []*object.Commit) {
+	repo := newFixture(t)
+	start := time.Date(2023, time.June, 1, 9, 0, 0, 0, time.UTC)
+	steps := []map[string]string{
+		{"main.go": numberedLines("main", 0, 200), "util.go": numberedLines("util", 0, 50)},
+		{"main.go": numberedLines("main", 0, 100) + numberedLines("edited", 100, 150) + numberedLines("main", 150, 200)},
+		{"util.go": numberedLines("util", 0, 300), "docs/readme.md": numberedLines("doc", 0, 20)},
+		{"main.go": numberedLines("main", 50, 200)},
+		{"util.go": "", "docs/readme.md": numberedLines("doc", 0, 10) + numberedLines("more", 0, 30)},
+	}
+	for i := 0; i < 30; i++ {
+		steps = append(steps, map[string]string{"main.go": numberedLines(fmt.Sprintf("round%d", i%3), 0, 150+i*10)})
+	}
+
+	commits := make([]*object.Commit, 0, len(steps))
+	for i, files := range steps {
+		hash := repo.commit("dev@example.com", start.Add(time.Duration(i)*time.Hour), files)
+		commit, err := repo.repo.CommitObject(hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		commits = append(commits, commit)
+	}
+
+	return repo, commits
+}
+
+func TestCommitLineStatsMatchStats(t *testing.T) {
+	_, commits := lineStatsFixture(t)
+	for _, commit := range commits {
+		files, err := commitLineStats(context.Background(), commit, commitChanges, PathFilter{}, CommitLimits{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[string][2]int64)
+		for _, file := range files {
+			got[file.Name] = [2]int64{file.Additions, file.Deletions}
+		}
+
+		stats, err := commit.Stats()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stats) != len(got) {
+			t.Errorf("%s: got %d files, Stats() has %d", commit.Hash, len(got), len(stats))
+		}
+		for _, stat := range stats {
+			if want := [2]int64{int64(stat.Addition), int64(stat.Deletion)}; got[stat.Name] != want {
+				t.Errorf("%s: got +%d/-%d for %s, Stats() has +%d/-%d", commit.Hash, got[stat.Name][0], got[stat.Name][1], stat.Name, want[0], want[1])
+			}
+		}
+	}
+}
+
+// BenchmarkCommitLineStats counts the lines of the fixture's commits from
+// their tree diff, next to commit.Stats() rendering the whole patch.
+func BenchmarkCommitLineStats(b *testing.B) {
+	_, commits := lineStatsFixture(b)
+
+	b.Run("tree-diff", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, commit := range commits {
+				if _, err := commitLineStats(context.Background(), commit, commitChanges, PathFilter{}, CommitLimits{}, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("patch", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, commit := range commits {
+				if _, err := commit.Stats(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+// commitModes commits the tree of HEAD with every file made executable, a
+// commit changing nothing but modes.
+func (f *fixture) commitModes(email string, when time.Time) plumbing.Hash {
+	f.t.Helper()
+
+	head, err := f.repo.Head()
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	parent, err := f.repo.CommitObject(head.Hash())
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	tree, err := parent.Tree()
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	entries := make([]object.TreeEntry, 0, len(tree.Entries))
+	for _, entry := range tree.Entries {
+		entry.Mode = filemode.Executable
+		entries = append(entries, entry)
+	}
+	signature := object.Signature{Name: email, Email: email, When: when}
+	hash := f.store(&object.Commit{Author: signature, Committer: signature, Message: "make executable\n", TreeHash: f.store(&object.Tree{Entries: entries}), ParentHashes: []plumbing.Hash{parent.Hash}})
+	if err := f.repo.Storer.SetReference(plumbing.NewHashReference(head.Name(), hash)); err != nil {
+		f.t.Fatal(err)
+	}
+
+	return hash
+}
+
+func TestCommitLineStatsRootEmptyAndModes(t *testing.T) {
+	repo := newFixture(t)
+	start := time.Date(2023, time.June, 1, 9, 0, 0, 0, time.UTC)
+	root := repo.commit("dev@example.com", start, map[string]string{"run.sh": "a\nb\nc\n"})
+	edit := repo.commit("dev@example.com", start.Add(time.Hour), map[string]string{"run.sh": "a\nb\nc\nd\n"})
+	empty := repo.commit("dev@example.com", start.Add(2*time.Hour), nil)
+	modes := repo.commitModes("dev@example.com", start.Add(3*time.Hour))
+
+	for _, tt := range []struct {
+		name      string
+		hash      plumbing.Hash
+		files     int
+		additions int64
+	}{
+		{name: "root", hash: root, files: 1, additions: 3},
+		{name: "edit", hash: edit, files: 1, additions: 1},
+		{name: "empty", hash: empty},
+		// Like Stats(), the file is listed without any lines.
+		{name: "modes", hash: modes, files: 1},
+	} {
+		commit, err := repo.repo.CommitObject(tt.hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files, err := commitLineStats(context.Background(), commit, commitChanges, PathFilter{}, CommitLimits{}, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		additions, deletions := int64(0), int64(0)
+		for _, file := range files {
+			additions += file.Additions
+			deletions += file.Deletions
+		}
+		if len(files) != tt.files || additions != tt.additions || deletions != 0 {
+			t.Errorf("%s: got %d files +%d/-%d, want %d files +%d/-0", tt.name, len(files), additions, deletions, tt.files, tt.additions)
+		}
+	}
+
+	summary, err := repo.analyze(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.TotalCommits != 4 || summary.additionCount != 4 || summary.EmptyCommits != 2 {
+		t.Errorf("got %d commits, %d additions and %d empty commits, want 4, 4 and 2", summary.TotalCommits, summary.additionCount, summary.EmptyCommits)
+	}
+	if summary.Largest == nil || summary.Largest.Hash != root.String() {
+		t.Errorf("got the largest commit %v, want the root commit %s", summary.Largest, root)
+	}
+	if summary.Smallest == nil || summary.Smallest.Hash != edit.String() {
+		t.Errorf("got the smallest commit %v, want %s skipping the empty ones", summary.Smallest, edit)
+	}
+}
+
+// countDiffs returns a differ counting the diffs of every commit, and a
+// function returning the counts since it was last called.
+func countDiffs() (differ, func() map[plumbing.Hash]int) {
+	var mu sync.Mutex
+	counts := make(map[plumbing.Hash]int)
+	diff := func(ctx context.Context, commit *object.Commit) (object.Changes, error) {
+		mu.Lock()
+		counts[commit.Hash]++
+		mu.Unlock()
+
+		return commitChanges(ctx, commit)
+	}
+
+	return diff, func() map[plumbing.Hash]int {
+		mu.Lock()
+		defer mu.Unlock()
+
+		seen := make(map[plumbing.Hash]int, len(counts))
+		for hash, count := range counts {
+			seen[hash] = count
+		}
+		clear(counts)
+
+		return seen
+	}
+}
+
+func TestAnalyzeDiffsEveryCommitOnce(t *testing.T) {
+	repo := newFixture(t)
+	const commits = 30
+	repo.history("me@example.com", time.Date(2023, time.March, 1, 9, 0, 0, 0, time.UTC), 24*time.Hour, commits)
+	diff, diffs := countDiffs()
+
+	summary, err := repo.analyze(Options{Jobs: 4, diff: diff})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.TotalCommits != commits {
+		t.Fatalf("TotalCommits = %d, want %d", summary.TotalCommits, commits)
+	}
+	counts := diffs()
+	if len(counts) != commits {
+		t.Errorf("diffed %d commits, want %d", len(counts), commits)
+	}
+	for hash, count := range counts {
+		if count != 1 {
+			t.Errorf("commit %s diffed %d times, want once", hash, count)
+		}
+	}
+
+	if _, err := repo.analyze(Options{Jobs: 4, Fast: true, diff: diff}); err != nil {
+		t.Fatal(err)
+	}
+	if counts := diffs(); len(counts) != 0 {
+		t.Errorf("--fast diffed %d commits, want none", len(counts))
+	}
+
+	cacheDir := t.TempDir()
+	for run := 0; run < 2; run++ {
+		if _, err := repo.analyze(Options{Jobs: 4, CacheDir: cacheDir, diff: diff}); err != nil {
+			t.Fatal(err)
+		}
+		want := commits
+		if run > 0 {
+			want = 0
+		}
+		if counts := diffs(); len(counts) != want {
+			t.Errorf("run %d with the cache diffed %d commits, want %d", run+1, len(counts), want)
+		}
+	}
+}
+
+func TestDeepStatsDiffEveryCommitOnce(t *testing.T) {
+	repo := newFixture(t)
+	day := func(d int) time.Time { return time.Date(2023, time.March, d, 9, 0, 0, 0, time.UTC) }
+	repo.commit("me@example.com", day(1), map[string]string{"a.go": "a\n", "b.go": "b\n"})
+	repo.commit("other@example.com", day(2), map[string]string{"a.go": "a\nother\n", "c.go": "c\n"})
+	repo.commit("me@example.com", day(3), map[string]string{"a.go": "a\nother\nme\n"})
+	repo.commit("other@example.com", day(4), map[string]string{"b.go": "b\nother\n"})
+	repo.commit("dependabot[bot]@example.com", day(5), map[string]string{"c.go": "c\nbot\n"})
+	repo.commit("me@example.com", day(6), map[string]string{"b.go": "b\nother\nme\n"})
+	diff, diffs := countDiffs()
+
+	selection := Selection{Authors: map[string]bool{"me@example.com": true}, Window: NewYearWindow(2023, time.UTC)}
+	summary, err := repo.analyze(Options{Selection: selection, Jobs: 4, Capabilities: StatSet(nil).Needs(), diff: diff})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	paths := []string{repo.dir}
+	spotlight, err := FindSpotlight(ctx, paths, selection, summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	neighbors, err := FindNeighbors(ctx, paths, selection, summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownership, err := FindOwnership(ctx, paths, selection, summary, OwnershipAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spotlight == nil || spotlight.Path != "a.go" || spotlight.Others != 1 {
+		t.Errorf("got the spotlight %+v, want a.go with 1 other author", spotlight)
+	}
+	if len(neighbors) != 1 || neighbors[0].Email != "other@example.com" || neighbors[0].SharedFiles != 2 {
+		t.Errorf("got the neighbors %+v, want other@example.com sharing 2 files", neighbors)
+	}
+	if ownership.Files != 2 || ownership.Touched != 2 {
+		t.Errorf("got %d of %d files owned, want 2 of 2", ownership.Files, ownership.Touched)
+	}
+
+	// The bot's commit is only walked by the ownership, which keeps every
+	// author's commits.
+	counts := diffs()
+	if len(counts) != 6 {
+		t.Errorf("diffed %d commits, want all 6", len(counts))
+	}
+	for hash, count := range counts {
+		if count != 1 {
+			t.Errorf("commit %s diffed %d times, want once", hash, count)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package wrapped
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// LogLevel is how much a Logger reports.
+type LogLevel int
+
+const (
+	// LevelQuiet logs nothing, which is the default.
+	LevelQuiet LogLevel = iota
+	// LevelVerbose logs the configuration and the counts of every stage.
+	LevelVerbose
+	// LevelDebug also logs every ref walked and other per item details.
+	LevelDebug
+)
+
+// Logger receives the diagnostics of the analysis. Library consumers can plug
+// their own, the analysis never writes them anywhere else.
+type Logger interface {
+	Logf(level LogLevel, format string, args ...interface{})
+}
+
+// nopLogger drops every message, it's used when Options.Logger is nil.
+type nopLogger struct{}
+
+func (nopLogger) Logf(LogLevel, string, ...interface{}) {}
+
+// writerLogger writes the messages up to its level as lines, safe for use by
+// concurrent analyses.
+type writerLogger struct {
+	mu    sync.Mutex
+	out   io.Writer
+	level LogLevel
+}
+
+// NewLogger returns a Logger writing the messages up to level to out, a
+// LevelQuiet logger writes nothing.
+func NewLogger(out io.Writer, level LogLevel) Logger {
+	if level <= LevelQuiet {
+		return nopLogger{}
+	}
+
+	return &writerLogger{out: out, level: level}
+}
+
+func (l *writerLogger) Logf(level LogLevel, format string, args ...interface{}) {
+	if level > l.level {
+		return
+	}
+
+	prefix := "verbose"
+	if level == LevelDebug {
+		prefix = "debug"
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.out, "[%s] %s\n", prefix, strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+}
+
+// orNop returns the logger, or one dropping every message when it's nil.
+func orNop(logger Logger) Logger {
+	if logger == nil {
+		return nopLogger{}
+	}
+
+	return logger
+}
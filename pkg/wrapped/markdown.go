@@ -0,0 +1,297 @@
+package wrapped
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// markdownEscaper escapes the characters that would break out of a table
+// cell or start inline markup.
+var markdownEscaper = strings.NewReplacer("|", "\\|", "`", "\\`", "*", "\\*", "_", "\\_", "<", "&lt;", ">", "&gt;")
+
+// reportRow is a single stat of the tabular reports.
+type reportRow struct {
+	Label string
+	Value string
+	// Hash is the short hash of the commit the stat is about, if any.
+	Hash string
+	// URL is where the label links to, if anywhere.
+	URL string
+	// Score is how interesting the stat is as one of the highlights, 0 for
+	// the ones that never are, see highlights.
+	Score float64
+}
+
+// reportRows returns the stats shown by the markdown and HTML reports, with
+// values left unescaped. They're the candidates of the highlights too, scored
+// by fact.
+func reportRows(summary *Summary) []reportRow {
+	rows := make([]reportRow, 0)
+	row := func(label string, value string) {
+		rows = append(rows, reportRow{Label: label, Value: value})
+	}
+	fact := func(label string, value string, score float64) {
+		rows = append(rows, reportRow{Label: label, Value: value, Score: score})
+	}
+	commitRow := func(label string, commit *Commit, score float64) {
+		rows = append(rows, reportRow{
+			Label: label,
+			Value: strings.TrimSpace(summary.when(commit).Format("2006-01-02 15:04") + " " + commitSubject(commit)),
+			Hash:  commit.Hash[:shortHashLength],
+			Score: score,
+		})
+	}
+
+	row("🧮 Total commits", fmt.Sprint(summary.TotalCommits))
+	if summary.Sampling != nil && summary.has(fieldLineStats) {
+		row("🧪 Sample", summary.Sampling.sentence())
+	}
+	if summary.CoAuthored != nil {
+		row("🤝 Co-authored", summary.CoAuthored.sentence())
+	}
+	// A year with a single commit would call it out twice in a row.
+	switch {
+	case !summary.shows(StatKickoff):
+	case sameCommit(summary.FirstOfYear, summary.LastOfYear):
+		commitRow("🚀 Kicked off and signed off the year", summary.FirstOfYear, summary.kickoffScore())
+	case summary.Earliest != nil:
+		commitRow("🚀 Kicked off the year", summary.FirstOfYear, summary.kickoffScore())
+		commitRow("🏁 Signed off", summary.LastOfYear, 0)
+	}
+	switch {
+	case !summary.shows(StatEarlyLate):
+	case sameCommit(summary.Earliest, summary.Latest):
+		commitRow("🌅 Earliest riser and latest night", summary.Earliest, math.Max(summary.earliestScore(), summary.latestScore()))
+	case summary.Earliest != nil:
+		commitRow("🌅 Earliest riser", summary.Earliest, summary.earliestScore())
+		commitRow("🌃 Latest night", summary.Latest, summary.latestScore())
+	}
+	if summary.has(fieldLineStats) && summary.shows(StatLines) {
+		fact("🟢 Additions"+summary.estimated(), fmt.Sprintf("%d (%.1f per commit)", summary.TotalAdditions(), roundHalfUp(summary.AverageAdditions, 1)), magnitudeScore(summary.AverageAdditions, typicalAdditions))
+		fact("🔴 Deletions"+summary.estimated(), fmt.Sprintf("%d (%.1f per commit)", summary.TotalDeletions(), roundHalfUp(summary.AverageDeletions, 1)), magnitudeScore(summary.AverageDeletions, typicalDeletions))
+		if summary.EmptyCommits > 0 {
+			fact("🫙 Empty commits"+summary.estimated(), fmt.Sprint(summary.EmptyCommits), magnitudeScore(float64(summary.EmptyCommits), 1))
+		}
+		if len(summary.Oversized) > 0 {
+			row("🐘 Oversized commits", summary.oversizedSentence())
+		}
+	}
+	if summary.shows(StatActiveDays) {
+		fact("📅 Active days", fmt.Sprintf("%d of %d (%.1f%%)", summary.ActiveDays(), summary.Window.days(), roundHalfUp(summary.activeShare(), 1)), magnitudeScore(summary.activeShare(), typicalActiveShare))
+		if mostDay := summary.mostActiveDay(); mostDay != nil {
+			most := fmt.Sprintf("%d on %s", mostDay.Count, mostDay.First.Format(time.DateOnly))
+			if span := mostDay.span(); span != "" {
+				most += ", " + span
+			}
+			fact("🏔️ Most commits per day", most, magnitudeScore(float64(mostDay.Count), summary.commitsPerActiveDay()))
+		}
+	}
+	if summary.shows(StatCadence) {
+		row("📈 Weekly cadence", summary.cadenceSentence())
+	}
+	if streak, ok := summary.HotStreak(); ok && summary.shows(StatHotStreak) {
+		fact("🔥 Hot streak", streak.sentence(), magnitudeScore(streak.Multiplier, minMultiplier))
+	}
+	if summary.History != nil {
+		fact("🏆 Historical rank", summary.History.sentence(), historyScore(summary.History))
+	}
+	if summary.Widest != nil && summary.shows(StatFocus) {
+		fact("🔬 Focus"+summary.estimated(), summary.focusSentence(), math.Abs(summary.SingleFileShare()-50)/100)
+		rows = append(rows, reportRow{Label: "🌐 Broadest change" + summary.estimated(), Value: summary.widestSentence(), Hash: summary.Widest.Hash[:shortHashLength], Score: magnitudeScore(float64(summary.WidestFiles), typicalWidestFiles)})
+	}
+	if summary.has(fieldLineStats) && summary.shows(StatNetLines) {
+		row("📐 Net lines"+summary.estimated(), summary.netLinesSentence())
+	}
+	if sentence := summary.sizeSentence(); sentence != "" && summary.shows(StatCommitSizes) {
+		row("📊 Commit sizes"+summary.estimated(), sentence)
+	}
+	if summary.MergeCommits > 0 && summary.shows(StatMerges) {
+		fact("🔀 Merge commits", fmt.Sprintf("%d (%s)", summary.MergeCommits, mergeNote(summary)), magnitudeScore(float64(summary.MergeCommits)*100/float64(summary.TotalCommits), typicalMergeShare))
+	}
+	if summary.Team != nil {
+		row("👥 Contributors", summary.Team.sentence())
+		if summary.has(fieldLineStats) && summary.shows(StatLines) {
+			row("📏 Total lines"+summary.estimated(), fmt.Sprintf("+%d/-%d", summary.TotalAdditions(), summary.TotalDeletions()))
+		}
+	}
+	for _, line := range summary.StatLines {
+		row(line.Label, line.Value)
+	}
+	for _, reviews := range summary.Reviews {
+		row("🔃 "+reviews.Forge, reviews.sentence())
+	}
+	if summary.Tickets != nil {
+		row("🎫 Tickets", summary.Tickets.sentence())
+		row("🤠 Ticket-less commits", summary.Tickets.ticketlessSentence())
+	}
+	if summary.Trailers != nil {
+		for _, trailer := range summary.Trailers.Trailers {
+			row("🏷️ "+trailer.Key, trailer.sentence(summary.Trailers.Commits))
+		}
+		if reviewer := summary.Trailers.topReviewerSentence(); reviewer != "" {
+			row("🧐 Most reviewed by", reviewer)
+		}
+	}
+	if summary.Fixes != nil {
+		fact("🐛 Fixes vs features", summary.Fixes.sentence(), fixScore(summary.Fixes))
+		if summary.Fixes.File != "" {
+			row("🩺 Most fixed file", summary.Fixes.fileSentence(summary.Window))
+		}
+	}
+	if pattern := summary.WorkPattern; pattern != nil && pattern.Commits > 0 {
+		row("🕘 Work hours", pattern.insideSentence())
+		fact("🌙 After hours", pattern.afterHoursSentence(), magnitudeScore(float64(pattern.Commits-pattern.Inside)*100/float64(pattern.Commits), typicalAfterHoursShare))
+		if pattern.LongestRun > 0 {
+			fact("🦉 Longest after-hours run", pattern.runSentence(), magnitudeScore(float64(pattern.LongestRun), typicalAfterHoursRun))
+		}
+	}
+	if summary.Releases != nil && summary.Releases.Tags > 0 {
+		fact("📦 Releases", summary.Releases.sentence(), magnitudeScore(float64(summary.Releases.Tags), typicalReleases))
+	}
+	if summary.Signoffs != nil {
+		row("✍️ Signed off", summary.Signoffs.sentence())
+	}
+	if summary.Survival != nil {
+		fact("🌱 Code survival", summary.Survival.sentence(), magnitudeScore(summary.Survival.Share(), typicalSurvivalShare))
+	}
+	if summary.Ownership != nil {
+		row("👑 Owned files", summary.Ownership.sentence())
+	}
+
+	return rows
+}
+
+// shownFiles returns the most changed files the report lists.
+func shownFiles(summary *Summary, opts RenderOptions) []FileActivity {
+	top := opts.Limit(SectionFiles)
+	if !summary.has(fieldLineStats) || !summary.shows(StatFiles) || top <= 0 {
+		return nil
+	}
+
+	files := summary.TopFiles()
+	if len(files) > top {
+		files = files[:top]
+	}
+
+	return files
+}
+
+// ticketRows returns the top tickets as rows, their label linking to the
+// ticket when opts.JiraURL is set.
+func ticketRows(summary *Summary, opts RenderOptions) []reportRow {
+	rows := make([]reportRow, 0)
+	for _, ticket := range shownTickets(summary, opts) {
+		rows = append(rows, reportRow{Label: ticket.Key, Value: commitCount(ticket.Commits), URL: ticketURL(opts.JiraURL, ticket.Key)})
+	}
+
+	return rows
+}
+
+// buildMarkdownOutput renders the report as a markdown table, e.g. for the
+// summary of a GitHub Actions run. With opts.Highlights the table only has the
+// highlights.
+func buildMarkdownOutput(summary *Summary, opts RenderOptions) string {
+	builder := strings.Builder{}
+	rows := reportRows(summary)
+	if opts.Highlights > 0 {
+		rows = highlights(summary, opts)
+		builder.WriteString(fmt.Sprintf("## ✨ git-wrapped highlights %s\n\n", summary.Window))
+	} else {
+		builder.WriteString(fmt.Sprintf("## 🎁 git-wrapped %s\n\n", summary.Window))
+	}
+	if branches := summary.branchesSentence(); branches != "" {
+		builder.WriteString("🌿 " + markdownEscaper.Replace(branches) + "\n\n")
+	}
+	builder.WriteString("| | |\n|---|---|\n")
+	for _, row := range rows {
+		value := markdownEscaper.Replace(row.Value)
+		if row.Hash != "" {
+			value = "`" + row.Hash + "` " + value
+		}
+		builder.WriteString(fmt.Sprintf("| %s | %s |\n", row.Label, value))
+	}
+	if opts.Highlights > 0 {
+		return builder.String()
+	}
+
+	if files := shownFiles(summary, opts); len(files) > 0 {
+		builder.WriteString("\n### 📂 Most changed files\n\n")
+		for i, file := range files {
+			builder.WriteString(fmt.Sprintf("%d. `%s`: +%d/-%d\n", i+1, strings.ReplaceAll(file.Path, "`", "'"), file.Additions, file.Deletions))
+		}
+	}
+	if tickets := ticketRows(summary, opts); len(tickets) > 0 {
+		builder.WriteString("\n### 🎫 Top tickets\n\n")
+		for i, ticket := range tickets {
+			label := markdownEscaper.Replace(ticket.Label)
+			if ticket.URL != "" {
+				label = fmt.Sprintf("[%s](%s)", label, ticket.URL)
+			}
+			builder.WriteString(fmt.Sprintf("%d. %s: %s\n", i+1, label, ticket.Value))
+		}
+	}
+	if identities := shownIdentities(summary, opts); len(identities) > 0 {
+		builder.WriteString("\n### 🪪 Identities\n\n")
+		repos := identityRepos(identities)
+		for _, identity := range identities {
+			builder.WriteString(fmt.Sprintf("- %s: %s\n", markdownEscaper.Replace(identity.Email), markdownEscaper.Replace(identity.sentence(summary.has(fieldLineStats), repos))))
+		}
+	}
+	if repos := shownRepos(summary, opts); len(repos) > 0 {
+		builder.WriteString("\n### 🗂️ Repositories\n\n" + markdownEscaper.Replace(summary.homeSentence()) + "\n\n")
+		builder.WriteString("| Repository | Commits | Lines | Active days | Busiest day |\n|---|---|---|---|---|\n")
+		for _, repo := range repos {
+			builder.WriteString(fmt.Sprintf("| %s | %s | %s | %d | %s |\n", markdownEscaper.Replace(repo.Name), FormatCount(int(repo.Commits)), repo.lines(summary.has(fieldLineStats)), repo.ActiveDays, repo.busiest()))
+		}
+	}
+	if summary.Spotlight != nil {
+		builder.WriteString("\n### 🎯 File spotlight\n\n" + markdownEscaper.Replace(summary.Spotlight.sentence()) + "\n")
+	}
+	if summary.History != nil {
+		builder.WriteString("\n### 📜 Commits per year\n\n```\n" + summary.History.Chart() + "\n```\n")
+	}
+	if summary.has(fieldLineStats) && summary.shows(StatNetLines) {
+		builder.WriteString("\n### 📐 Net lines per month\n\n```\n" + summary.NetLinesChart() + "\n```\n")
+	}
+	if histogram := summary.SizeHistogram(); histogram != "" && summary.shows(StatCommitSizes) {
+		builder.WriteString("\n### 📊 Commit sizes\n\n```\n" + histogram + "\n```\n")
+	}
+	if summary.Ownership != nil && len(summary.Ownership.Largest) > 0 {
+		builder.WriteString("\n### 👑 Largest owned files\n\n")
+		for i, file := range summary.Ownership.Largest {
+			builder.WriteString(fmt.Sprintf("%d. %s: %s\n", i+1, markdownEscaper.Replace(file.Path), file.sentence()))
+		}
+	}
+	if neighbors := topNeighbors(summary); len(neighbors) > 0 {
+		builder.WriteString("\n### 🏘️ Code neighbors\n\n")
+		for i, neighbor := range neighbors {
+			builder.WriteString(fmt.Sprintf("%d. %s: %s\n", i+1, markdownEscaper.Replace(neighbor.Name+" <"+neighbor.Email+">"), neighbor.sentence()))
+		}
+	}
+	if contributors := shownNewContributors(summary, opts); len(contributors) > 0 {
+		builder.WriteString("\n### 🌱 New contributors\n\n")
+		for i, contributor := range contributors {
+			builder.WriteString(fmt.Sprintf("%d. %s: %s\n", i+1, markdownEscaper.Replace(contributor.Name+" <"+contributor.Email+">"), commitCount(contributor.Commits)))
+		}
+	}
+	if achievements := summary.Achievements(); len(achievements) > 0 {
+		builder.WriteString("\n### 🏅 Achievements unlocked\n\n")
+		for _, achievement := range achievements {
+			builder.WriteString(fmt.Sprintf("- %s **%s** (%s): %s\n", achievement.Emoji, achievement.Name, achievement.Rule, markdownEscaper.Replace(achievement.Evidence)))
+		}
+	}
+	if summary.Team != nil {
+		builder.WriteString("\n### 🗓️ Team activity\n\n```\n" + Heatmap(summary) + "\n```\n")
+	}
+	if footer := footerRows(summary, opts); len(footer) > 0 {
+		parts := make([]string, 0, len(footer))
+		for _, row := range footer {
+			parts = append(parts, row.Label+": "+markdownEscaper.Replace(row.Value))
+		}
+		builder.WriteString("\n---\n\n<sub>" + strings.Join(parts, " · ") + "</sub>\n")
+	}
+
+	return strings.TrimSuffix(builder.String(), "\n")
+}
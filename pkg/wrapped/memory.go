@@ -0,0 +1,84 @@
+package wrapped
+
+import (
+	"fmt"
+	"sync"
+)
+
+// memoryBudget throttles the diffs computed at once by the size of the files
+// they read, shared by the workers of every repository analyzed together.
+// A worker waiting for the budget stops taking commits, which in turn blocks
+// the walk producing them. A nil *memoryBudget is valid and throttles
+// nothing.
+type memoryBudget struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	// max is the most bytes in flight, 0 for no bound.
+	max      int64
+	inFlight int64
+	peak     int64
+}
+
+func newMemoryBudget(max int64) *memoryBudget {
+	budget := &memoryBudget{max: max}
+	budget.cond = sync.NewCond(&budget.mu)
+
+	return budget
+}
+
+// acquire waits until the size fits in the budget, then takes it. A size
+// larger than the whole budget is let through once nothing else is in flight,
+// so no file is too large to ever be diffed.
+func (b *memoryBudget) acquire(size int64) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.max > 0 && b.inFlight > 0 && b.inFlight+size > b.max {
+		b.cond.Wait()
+	}
+	b.inFlight += size
+	b.peak = max(b.peak, b.inFlight)
+}
+
+// release gives the size taken by acquire back.
+func (b *memoryBudget) release(size int64) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.inFlight -= size
+	b.cond.Broadcast()
+}
+
+// peakInFlight returns the most bytes that were in flight at once.
+func (b *memoryBudget) peakInFlight() int64 {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.peak
+}
+
+// FormatBytes formats a number of bytes with the largest binary unit keeping
+// it at 1 or more, e.g. 1.5 GiB.
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	value, prefix := float64(bytes)/unit, 0
+	for value >= unit && prefix < len("KMGTPE")-1 {
+		value /= unit
+		prefix++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", value, "KMGTPE"[prefix])
+}
@@ -0,0 +1,168 @@
+package wrapped
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Metadata are how a report was computed beyond what its summary already
+// keeps, like the generator, the window and the selection, so a shared
+// report tells how it came to be.
+type Metadata struct {
+	GeneratedAt time.Time
+	// Filter is the path filter of the line stats.
+	Filter PathFilter
+	// Command is the command line generating the same report, empty when
+	// unknown. It's left out of anonymized reports.
+	Command string
+	// IdentitiesHash stands for the authors once anonymized, set by
+	// Anonymizer.Apply.
+	IdentitiesHash string
+}
+
+// anonymize replaces what would tell the authors and the paths apart in the
+// metadata.
+func (m Metadata) anonymize(a *Anonymizer, authors map[string]bool) *Metadata {
+	m.Command = ""
+	if authors != nil {
+		m.IdentitiesHash = a.Pseudonym(strings.Join(sortedEmails(authors), ","))
+	}
+	m.Filter.Excluded = a.pseudonyms(m.Filter.Excluded)
+
+	return &m
+}
+
+// pseudonyms returns the pseudonyms of the values, in the same order.
+func (a *Anonymizer) pseudonyms(values []string) []string {
+	if values == nil {
+		return nil
+	}
+
+	pseudonyms := make([]string, 0, len(values))
+	for _, value := range values {
+		pseudonyms = append(pseudonyms, a.Pseudonym(value))
+	}
+
+	return pseudonyms
+}
+
+// sortedEmails returns the emails of the set, sorted.
+func sortedEmails(emails map[string]bool) []string {
+	sorted := make([]string, 0, len(emails))
+	for email := range emails {
+		sorted = append(sorted, email)
+	}
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+// Where the commits of a selection were walked from, see Summary.refsWalked.
+const (
+	refsAll         = "refs"
+	refsRange       = "range"
+	refsUnreachable = "unreachable"
+)
+
+// refsWalked returns where the commits were walked from, and the revision
+// range when it's one.
+func (s *Summary) refsWalked() (string, string) {
+	switch {
+	case s.Selection.Range != nil:
+		return refsRange, s.Selection.Range.String()
+	case s.Selection.IncludeUnreachable:
+		return refsUnreachable, ""
+	default:
+		return refsAll, ""
+	}
+}
+
+// refsSentence describes where the commits were walked from, e.g. "every
+// branch, tag and HEAD".
+func (s *Summary) refsSentence() string {
+	switch mode, revisions := s.refsWalked(); mode {
+	case refsRange:
+		return "the commits of " + revisions
+	case refsUnreachable:
+		return "every commit of the object store, reachable or not"
+	default:
+		return "every branch, tag and HEAD"
+	}
+}
+
+// identitiesSentence names the authors, e.g. "me@example.com, me@work.com",
+// or their hash once anonymized.
+func (s *Summary) identitiesSentence() string {
+	switch {
+	case s.Selection.Authors == nil:
+		return "every author"
+	case s.Metadata != nil && s.Metadata.IdentitiesHash != "" && len(s.Selection.Authors) == 1:
+		return s.Metadata.IdentitiesHash + ", the hash of 1 email"
+	case s.Metadata != nil && s.Metadata.IdentitiesHash != "":
+		return fmt.Sprintf("%s, the hash of %s emails", s.Metadata.IdentitiesHash, FormatCount(len(s.Selection.Authors)))
+	default:
+		return strings.Join(sortedEmails(s.Selection.Authors), ", ")
+	}
+}
+
+// filtersSentence lists what was left out of the analysis, e.g. "merges
+// counted without line stats, lock files excluded, paths vendor excluded".
+func (s *Summary) filtersSentence() string {
+	filters := make([]string, 0)
+	switch {
+	case s.Selection.ExcludeMerges:
+		filters = append(filters, "merges excluded")
+	case s.MergeStats == MergeStatsFirstParent:
+		filters = append(filters, "merges counted with their first-parent diff")
+	default:
+		filters = append(filters, "merges counted without line stats")
+	}
+
+	if s.Metadata.Filter.ExcludeLockfiles {
+		filters = append(filters, "lock files excluded")
+	}
+	if len(s.Metadata.Filter.Excluded) > 0 {
+		filters = append(filters, "paths "+strings.Join(s.Metadata.Filter.Excluded, ", ")+" excluded")
+	}
+	if len(s.Selection.ExcludedAuthors) > 0 {
+		filters = append(filters, "authors "+strings.Join(s.Selection.ExcludedAuthors, ", ")+" excluded")
+	}
+
+	return strings.Join(filters, ", ")
+}
+
+// footerRows are the rows of the footer of the reports, telling how they were
+// computed, none without Metadata or when opts leaves the footer out.
+func footerRows(summary *Summary, opts RenderOptions) []reportRow {
+	if summary.Metadata == nil || !opts.Footer {
+		return nil
+	}
+
+	generated := summary.Metadata.GeneratedAt.UTC().Format("2006-01-02 15:04 MST")
+	if summary.Generator != "" {
+		generated += " by " + summary.Generator
+	}
+
+	return []reportRow{
+		{Label: "Generated", Value: generated},
+		{Label: "Window", Value: summary.Window.String()},
+		{Label: "Refs", Value: summary.refsSentence()},
+		{Label: "Identities", Value: summary.identitiesSentence()},
+		{Label: "Filters", Value: summary.filtersSentence()},
+	}
+}
+
+// writeFooter ends the text report with the footer rows.
+func writeFooter(builder *strings.Builder, summary *Summary, opts RenderOptions) {
+	rows := footerRows(summary, opts)
+	if len(rows) == 0 {
+		return
+	}
+
+	builder.WriteString("ℹ️ How this was computed:\n")
+	for _, row := range rows {
+		builder.WriteString(fmt.Sprintf("  %s: %s\n", row.Label, row.Value))
+	}
+}
@@ -0,0 +1,186 @@
+package wrapped
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"sort"
+	"strings"
+)
+
+// Neighbor is another author who changed the files the author changed in
+// the window, a code neighbor.
+type Neighbor struct {
+	Name  string
+	Email string
+	// SharedFiles is the number of the author's files the neighbor changed.
+	SharedFiles int
+	// SharedChanges weighs the overlap, see FindNeighbors.
+	SharedChanges int
+}
+
+// shownNeighbors is how many code neighbors the reports list.
+const shownNeighbors = 3
+
+// FindNeighbors walks every commit of the window again, by any author, to
+// find who else changed the files counted in the summary's line stats, the
+// changes of every commit read from its index when it was diffed already. For
+// every shared file the neighbor is credited with the fewer of both their
+// commits to it, so a file the author touched once doesn't make everyone who
+// keeps changing it a neighbor. Only the author's files are tracked, which
+// keeps the memory bounded by them, and merges and bots are left out. The
+// neighbors are returned with the most shared changes first.
+func FindNeighbors(ctx context.Context, paths []string, selection Selection, summary *Summary) ([]Neighbor, error) {
+	if !summary.has(fieldLineStats) {
+		return nil, errors.New("code neighbors need the line stats")
+	}
+
+	mine := summary.touchedFiles()
+	theirs := make(map[string]map[string]int)
+	names := make(map[string]string)
+	authors := selection.Authors
+	keep := func(commit *object.Commit) bool {
+		return !authors[commit.Author.Email] && !isBot(commit.Author.Name, commit.Author.Email)
+	}
+	err := walkTrackedChanges(ctx, paths, selection, summary.changeIndex(), mine, keep, func(_ string, commit *object.Commit, changed []string) error {
+		if len(changed) == 0 {
+			return nil
+		}
+		files, ok := theirs[commit.Author.Email]
+		if !ok {
+			files = make(map[string]int)
+			theirs[commit.Author.Email] = files
+			names[commit.Author.Email] = commit.Author.Name
+		}
+		for _, name := range changed {
+			files[name]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	neighbors := make([]Neighbor, 0, len(theirs))
+	for email, files := range theirs {
+		neighbor := Neighbor{Name: names[email], Email: email, SharedFiles: len(files)}
+		for name, commits := range files {
+			if commits < mine[name] {
+				neighbor.SharedChanges += commits
+			} else {
+				neighbor.SharedChanges += mine[name]
+			}
+		}
+		neighbors = append(neighbors, neighbor)
+	}
+	sort.Slice(neighbors, func(i, j int) bool {
+		if neighbors[i].SharedChanges != neighbors[j].SharedChanges {
+			return neighbors[i].SharedChanges > neighbors[j].SharedChanges
+		}
+		return neighbors[i].Email < neighbors[j].Email
+	})
+
+	return neighbors, nil
+}
+
+// walkTrackedChanges walks every commit of the window in the repositories, by
+// any author and merges left out, calling fn with the tracked files each one
+// changed. Only the commits keep accepts are passed to fn, their changes read
+// from the index or diffed into it. When a repository can't be opened it's
+// skipped, the analysis already reported it.
+func walkTrackedChanges(ctx context.Context, paths []string, selection Selection, changes *changeIndex, tracked map[string]int, keep func(*object.Commit) bool, fn func(path string, commit *object.Commit, changed []string) error) error {
+	selection.Authors = nil
+	for _, path := range paths {
+		_, repo, err := openRepo(path)
+		if err != nil {
+			continue
+		}
+
+		_, err = findRelevantCommits(ctx, repo, selection, nopLogger{}, skipCorrupt, func(commit *object.Commit) error {
+			if commit.NumParents() > 1 || !keep(commit) {
+				return nil
+			}
+			changed, err := changes.trackedChanges(ctx, commit, tracked)
+			if errors.Is(err, errShallowCommit) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return fn(path, commit, changed)
+		})
+		if err != nil {
+			return fmt.Errorf("unable to walk the commits of %s. [err=%s]", path, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// touchedFiles returns the commits of the author to every file of the line
+// stats, renamed files under both of their paths.
+func (s *Summary) touchedFiles() map[string]int {
+	touched := make(map[string]int, len(s.files))
+	for name, file := range s.files {
+		for _, side := range renameSides(name) {
+			touched[side] += file.Commits
+		}
+	}
+
+	return touched
+}
+
+// renameSides returns both paths of a renamed file's key, e.g. a.go => b.go,
+// or the path itself.
+func renameSides(name string) []string {
+	if from, to, renamed := strings.Cut(name, " => "); renamed {
+		return []string{from, to}
+	}
+
+	return []string{name}
+}
+
+// knownBots are automation accounts that don't follow the [bot] or -bot
+// naming.
+var knownBots = []string{"dependabot", "renovate", "github-actions"}
+
+// isBot reports whether the identity belongs to an automation account, like
+// dependabot[bot] or a ci-bot.
+func isBot(name string, email string) bool {
+	local, _, _ := strings.Cut(strings.ToLower(email), "@")
+	for _, value := range []string{strings.ToLower(name), local} {
+		if strings.Contains(value, "[bot]") || strings.HasSuffix(value, "-bot") || strings.HasSuffix(value, "_bot") {
+			return true
+		}
+		for _, bot := range knownBots {
+			if strings.HasPrefix(value, bot) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// sentence describes the overlap, e.g. "9 shared changes in 4 files".
+func (n Neighbor) sentence() string {
+	changes, files := "changes", "files"
+	if n.SharedChanges == 1 {
+		changes = "change"
+	}
+	if n.SharedFiles == 1 {
+		files = "file"
+	}
+
+	return fmt.Sprintf("%d shared %s in %d %s", n.SharedChanges, changes, n.SharedFiles, files)
+}
+
+// topNeighbors returns the neighbors the reports list.
+func topNeighbors(summary *Summary) []Neighbor {
+	if len(summary.Neighbors) > shownNeighbors {
+		return summary.Neighbors[:shownNeighbors]
+	}
+
+	return summary.Neighbors
+}
@@ -0,0 +1,134 @@
+package wrapped
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// shrinkLevels are the bars of the months deleting more lines than they
+// added, hanging down from the zero line so they stand out from the growth.
+var shrinkLevels = []string{"▔", "▀", "█"}
+
+// MonthNet is the lines added minus the lines deleted in a month.
+type MonthNet struct {
+	// Month is the first day of the month, in the window's time zone.
+	Month time.Time
+	Net   int64
+}
+
+// MonthlyNetLines returns the net lines of every calendar month the window
+// touches, in order, which is twelve for a year.
+func (s *Summary) MonthlyNetLines() []MonthNet {
+	start := s.Window.Start.In(s.Window.Location)
+	first := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, s.Window.Location)
+	last := s.Window.LastDay().In(s.Window.Location)
+	count := monthIndex(first, last) + 1
+
+	months := make([]MonthNet, count)
+	for i := range months {
+		months[i].Month = first.AddDate(0, i, 0)
+	}
+	for _, day := range s.ByDay {
+		if i := monthIndex(first, day.First.In(s.Window.Location)); i >= 0 && i < count {
+			months[i].Net += day.Additions - day.Deletions
+		}
+	}
+
+	return months
+}
+
+// monthIndex returns how many months t is after the month of first.
+func monthIndex(first time.Time, t time.Time) int {
+	return (t.Year()-first.Year())*12 + int(t.Month()) - int(first.Month())
+}
+
+// averageNet returns the footprint growth per month. The footprint is the
+// running sum of the net lines, so the slope of a straight line through its
+// start and end is the mean of the monthly net lines.
+func averageNet(months []MonthNet) float64 {
+	if len(months) == 0 {
+		return 0
+	}
+
+	total := int64(0)
+	for _, month := range months {
+		total += month.Net
+	}
+
+	return float64(total) / float64(len(months))
+}
+
+// netLinesSentence is the slope verdict, e.g. "your footprint in the codebase
+// grew by ~2,100 lines/month on average".
+func (s *Summary) netLinesSentence() string {
+	average := averageNet(s.MonthlyNetLines())
+	rounded := roundSignificant(math.Abs(average), 2)
+	switch {
+	case rounded == 0:
+		return "your footprint in the codebase held steady"
+	case average > 0:
+		return fmt.Sprintf("your footprint in the codebase grew by ~%s/month on average", lineCount(int(rounded)))
+	default:
+		return fmt.Sprintf("your footprint in the codebase shrank by ~%s/month on average, a refactoring badge of honor", lineCount(int(rounded)))
+	}
+}
+
+// roundSignificant rounds the value to the given number of significant
+// digits, e.g. 2,149 to 2,100 with two.
+func roundSignificant(value float64, digits int) int64 {
+	if value < 1 {
+		return int64(math.Round(value))
+	}
+	scale := math.Pow(10, math.Floor(math.Log10(value))-float64(digits-1))
+	if scale < 1 {
+		scale = 1
+	}
+
+	return int64(math.Round(value/scale) * scale)
+}
+
+// NetLinesChart draws the monthly net lines as a trendline relative to the
+// largest month, growth as bars on the first row and shrinking months as bars
+// hanging from the zero line on the second, above the initials of the months.
+// The second row is left out when no month shrank.
+func (s *Summary) NetLinesChart() string {
+	months := s.MonthlyNetLines()
+	most := int64(0)
+	shrank := false
+	for _, month := range months {
+		if abs := month.Net; abs < 0 {
+			abs = -abs
+			shrank = true
+			if abs > most {
+				most = abs
+			}
+		} else if abs > most {
+			most = abs
+		}
+	}
+
+	grew, shrinking, initials := strings.Builder{}, strings.Builder{}, strings.Builder{}
+	for _, month := range months {
+		initials.WriteString(month.Month.Format("Jan")[:1])
+		switch {
+		case month.Net > 0:
+			grew.WriteString(sparkLevels[(month.Net*int64(len(sparkLevels))-1)/most])
+			shrinking.WriteString(" ")
+		case month.Net < 0:
+			grew.WriteString(" ")
+			shrinking.WriteString(shrinkLevels[(-month.Net*int64(len(shrinkLevels))-1)/most])
+		default:
+			grew.WriteString(heatLevels[0])
+			shrinking.WriteString(" ")
+		}
+	}
+
+	rows := []string{grew.String()}
+	if shrank {
+		rows = append(rows, strings.TrimRight(shrinking.String(), " "))
+	}
+
+	return strings.Join(append(rows, initials.String()), "\n")
+}
@@ -0,0 +1,32 @@
+package wrapped
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNetLinesSentence(t *testing.T) {
+	tests := []struct {
+		name      string
+		additions int64
+		deletions int64
+		want      string
+	}{
+		{name: "steady", want: "your footprint in the codebase held steady"},
+		{name: "one line", additions: 12, want: "your footprint in the codebase grew by ~1 line/month on average"},
+		{name: "lines", additions: 25788, want: "your footprint in the codebase grew by ~2,100 lines/month on average"},
+		{name: "shrank one line", deletions: 12, want: "your footprint in the codebase shrank by ~1 line/month on average, a refactoring badge of honor"},
+		{name: "shrank", additions: 10, deletions: 370, want: "your footprint in the codebase shrank by ~30 lines/month on average, a refactoring badge of honor"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			summary := NewSummary(false, NewYearWindow(2023, time.UTC))
+			summary.add(syntheticCommit(test.name, "dev@example.com", time.Date(2023, time.June, 1, 12, 0, 0, 0, time.UTC), test.additions, test.deletions), "repo")
+			summary.Finish()
+
+			if got := summary.netLinesSentence(); got != test.want {
+				t.Errorf("netLinesSentence() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package wrapped
+
+import (
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"sync/atomic"
+)
+
+// objectCaches hand out the object caches of the handles the analysis opens
+// on a repository, counting how often they had the objects read. Every handle
+// shares a single cache of the size when it's set, so the trees a worker
+// read are there for the others, and gets a go-git default cache of its own
+// otherwise.
+type objectCaches struct {
+	size   int64
+	shared *cache.ObjectLRU
+	hits   int64
+	misses int64
+	// sharedHits are the hits on objects another handle read, which a cache
+	// per handle would have missed.
+	sharedHits int64
+}
+
+func newObjectCaches(size int64) *objectCaches {
+	caches := &objectCaches{size: size}
+	if size > 0 {
+		caches.shared = cache.NewObjectLRU(cache.FileSize(size))
+	}
+
+	return caches
+}
+
+// handle returns the object cache of a new handle on the repository.
+func (c *objectCaches) handle() cache.Object {
+	if c.shared == nil {
+		return &countedObjectCache{Object: cache.NewObjectLRUDefault(), caches: c}
+	}
+
+	return &sharedObjectCache{caches: c}
+}
+
+// countedObjectCache is the object cache of a single handle, counting its
+// hits and misses in the caches it comes from.
+type countedObjectCache struct {
+	cache.Object
+	caches *objectCaches
+}
+
+func (c *countedObjectCache) Get(hash plumbing.Hash) (plumbing.EncodedObject, bool) {
+	object, ok := c.Object.Get(hash)
+	c.caches.count(ok, false)
+
+	return object, ok
+}
+
+// sharedObjectCache is the view of a single handle on the shared cache.
+// go-git puts the loose objects it reads into the cache before writing their
+// contents, and hashes them lazily, so an object is only shared once the
+// handle comes back to the cache, done with it. A handle is only ever used
+// by one goroutine at a time.
+type sharedObjectCache struct {
+	caches *objectCaches
+	// pending is the object put last, not shared yet.
+	pending plumbing.EncodedObject
+}
+
+// sharedObject is an object of the shared cache with the handle that read
+// it.
+type sharedObject struct {
+	plumbing.EncodedObject
+	reader *sharedObjectCache
+}
+
+// publish shares the pending object.
+func (c *sharedObjectCache) publish() {
+	if c.pending == nil {
+		return
+	}
+
+	c.caches.shared.Put(&sharedObject{EncodedObject: c.pending, reader: c})
+	c.pending = nil
+}
+
+func (c *sharedObjectCache) Put(object plumbing.EncodedObject) {
+	c.publish()
+	c.pending = object
+}
+
+func (c *sharedObjectCache) Get(hash plumbing.Hash) (plumbing.EncodedObject, bool) {
+	c.publish()
+	cached, ok := c.caches.shared.Get(hash)
+	if !ok {
+		c.caches.count(false, false)
+		return nil, false
+	}
+
+	object := cached.(*sharedObject)
+	c.caches.count(true, object.reader != c)
+	return object.EncodedObject, true
+}
+
+// Clear forgets the pending object, leaving the shared ones to the other
+// handles.
+func (c *sharedObjectCache) Clear() {
+	c.pending = nil
+}
+
+// count records a read of the caches, a hit or a miss, and whether the hit
+// was on an object another handle read.
+func (c *objectCaches) count(hit bool, shared bool) {
+	switch {
+	case !hit:
+		atomic.AddInt64(&c.misses, 1)
+	case shared:
+		atomic.AddInt64(&c.hits, 1)
+		atomic.AddInt64(&c.sharedHits, 1)
+	default:
+		atomic.AddInt64(&c.hits, 1)
+	}
+}
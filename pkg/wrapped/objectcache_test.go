@@ -0,0 +1,70 @@
+package wrapped
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// TestObjectCachesConcurrentWorkers runs the stats workers on the object
+// caches of the repository at once, meant for -race.
+func TestObjectCachesConcurrentWorkers(t *testing.T) {
+	repo := newFixture(t)
+	start := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 40; i++ {
+		repo.commit("dev@example.com", start.Add(time.Duration(i)*time.Hour), map[string]string{
+			fmt.Sprintf("dir%d/file.txt", i%4): strings.Repeat(fmt.Sprintf("line %d\n", i), i+1),
+		})
+	}
+
+	for _, size := range []int64{0, 1 << 20} {
+		timings := NewTimings(true)
+		summary, err := repo.analyze(Options{Jobs: 4, ObjectCacheSize: size, Timings: timings})
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if summary.TotalCommits != 40 {
+			t.Errorf("size %d: got %d commits, want 40", size, summary.TotalCommits)
+		}
+		if got, want := summary.TotalAdditions(), int64(40*41/2); got != want {
+			t.Errorf("size %d: got %d additions, want %d", size, got, want)
+		}
+
+		report := &bytes.Buffer{}
+		timings.Report(report)
+		want := "per handle"
+		if size > 0 {
+			want = "1.0 MiB shared"
+		}
+		if !strings.Contains(report.String(), want) {
+			t.Errorf("size %d: timings %q, want the object cache %q", size, report.String(), want)
+		}
+	}
+}
+
+func TestSharedObjectCache(t *testing.T) {
+	caches := newObjectCaches(1 << 20)
+	reader, other := caches.handle(), caches.handle()
+	object := &plumbing.MemoryObject{}
+	object.SetType(plumbing.BlobObject)
+	object.Write([]byte("contents\n"))
+	hash := object.Hash()
+
+	reader.Put(object)
+	if _, ok := other.Get(hash); ok {
+		t.Fatal("the object was shared before its handle was done with it")
+	}
+	if got, ok := reader.Get(hash); !ok || got != object {
+		t.Fatalf("Get on the reading handle = %v, %t, want the object", got, ok)
+	}
+	if got, ok := other.Get(hash); !ok || got != object {
+		t.Fatalf("Get on another handle = %v, %t, want the object", got, ok)
+	}
+	if caches.hits != 2 || caches.sharedHits != 1 || caches.misses != 1 {
+		t.Errorf("hits %d, shared hits %d, misses %d, want 2, 1 and 1", caches.hits, caches.sharedHits, caches.misses)
+	}
+}
@@ -0,0 +1,1158 @@
+package wrapped
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// renderers turn a summary into the report, keyed by --format.
+var renderers = map[string]func(*Summary, RenderOptions) (string, error){
+	"text": func(summary *Summary, opts RenderOptions) (string, error) {
+		return buildOutput(summary, opts), nil
+	},
+	"json":         buildJSONOutput,
+	"html":         buildHTMLOutput,
+	FormatShortlog: buildShortlog,
+	"markdown": func(summary *Summary, opts RenderOptions) (string, error) {
+		return buildMarkdownOutput(summary, opts), nil
+	},
+}
+
+// SectionFiles is the ranked list of the most changed files.
+const SectionFiles = "files"
+
+// DefaultTop is how many items ranked lists show unless told otherwise.
+const DefaultTop = 5
+
+// RenderOptions control how much of the report is displayed. They only
+// affect the text report, the json one always includes the full lists.
+type RenderOptions struct {
+	// Top is how many items every ranked list shows, 0 hides them.
+	Top int
+	// SectionTop overrides Top for single sections, keyed by their name.
+	SectionTop map[string]int
+	// Identities breaks the activity down by the emails of the author, when
+	// the commits were matched by more than one.
+	Identities bool
+	// JiraURL is the base URL of the Jira the top tickets link to in the
+	// markdown and HTML reports, e.g. https://acme.atlassian.net.
+	JiraURL string
+	// BannerWidth is the width of the terminal the text report is printed
+	// to, its intro drawing the year and the commits large is fitted to it.
+	// 0 leaves the intro out.
+	BannerWidth int
+	// Theme is the palette of the HTML report and, with ANSI, of the text
+	// report. nil leaves them uncolored, the image cards are drawn with
+	// DefaultTheme.
+	Theme *Theme
+	// ANSI colors the text report with the escapes of a terminal.
+	ANSI bool
+	// Highlights is how many of the most interesting stats the text,
+	// markdown and HTML reports are cut down to, and the json one lists
+	// under highlights. 0 shows every stat.
+	Highlights int
+	// HighlightSeed draws the highlights, the same seed picking the same
+	// ones of a report.
+	HighlightSeed string
+	// Footer ends the text, markdown and HTML reports with how they were
+	// computed, when the summary has Metadata.
+	Footer bool
+}
+
+// theme returns the palette to draw with, DefaultTheme when none is set.
+func (o RenderOptions) theme() Theme {
+	if o.Theme == nil {
+		return DefaultTheme()
+	}
+
+	return *o.Theme
+}
+
+// paint colors the text for a terminal with the 24-bit ANSI escapes of the
+// ink, leaving it as is unless the text report is themed.
+func (o RenderOptions) paint(ink color.RGBA, text string) string {
+	if !o.ANSI || o.Theme == nil {
+		return text
+	}
+
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm%s\x1b[0m", ink.R, ink.G, ink.B, text)
+}
+
+// Limit returns how many items the ranked list of the section shows.
+func (o RenderOptions) Limit(section string) int {
+	if top, ok := o.SectionTop[section]; ok {
+		return top
+	}
+
+	return o.Top
+}
+
+// Formats returns the formats there is a renderer for, sorted.
+func Formats() []string {
+	formats := make([]string, 0, len(renderers))
+	for format := range renderers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+
+	return formats
+}
+
+// Renderer turns a summary into a report.
+type Renderer interface {
+	Render(summary *Summary) ([]byte, error)
+}
+
+// formatRenderer renders one of the formats with fixed options.
+type formatRenderer struct {
+	render func(*Summary, RenderOptions) (string, error)
+	opts   RenderOptions
+}
+
+func (r formatRenderer) Render(summary *Summary) ([]byte, error) {
+	output, err := r.render(summary, r.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(output), nil
+}
+
+// NewRenderer returns the renderer of the format, one of Formats.
+func NewRenderer(format string, opts RenderOptions) (Renderer, error) {
+	render, ok := renderers[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+
+	return formatRenderer{render: render, opts: opts}, nil
+}
+
+// Render renders the summary in the format, see NewRenderer.
+func Render(format string, summary *Summary, opts RenderOptions) (string, error) {
+	renderer, err := NewRenderer(format, opts)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := renderer.Render(summary)
+	return string(output), err
+}
+
+// roundHalfUp rounds x to the given number of decimals, rounding halves away
+// from zero rather than to even.
+func roundHalfUp(x float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Floor(x*scale+0.5) / scale
+}
+
+// commitText is the hash and message of a commit the text report calls
+// out, the message is left out when it's empty, like once anonymized.
+func commitText(commit *Commit) string {
+	message := strings.TrimSpace(commit.Message)
+	if message == "" {
+		return commit.Hash
+	}
+
+	return commit.Hash + " -- " + message
+}
+
+// yearDayLayout is how the first and the last commit of the year are dated.
+const yearDayLayout = "Jan 2"
+
+// commitSubject returns the first line of the commit message.
+func commitSubject(commit *Commit) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(commit.Message), "\n")
+	return strings.TrimSpace(subject)
+}
+
+// subjectText is like commitText with only the subject of the message.
+func subjectText(commit *Commit) string {
+	subject := commitSubject(commit)
+	if subject == "" {
+		return commit.Hash
+	}
+
+	return commit.Hash + " -- " + subject
+}
+
+func buildOutput(summary *Summary, opts RenderOptions) string {
+	if opts.Highlights > 0 {
+		return buildHighlightsOutput(summary, opts)
+	}
+
+	mostDay := summary.mostActiveDay()
+
+	builder := strings.Builder{}
+	theme := opts.theme()
+
+	if opts.BannerWidth > 0 {
+		builder.WriteString(opts.paint(theme.Accent, summary.banner(opts.BannerWidth)) + "\n")
+	}
+	builder.WriteString(fmt.Sprintf("📆 %s\n", summary.Window))
+	if branches := summary.branchesSentence(); branches != "" {
+		builder.WriteString("🌿 " + branches + "\n")
+	}
+	builder.WriteString(fmt.Sprintf("🧮 Total commit count: %d\n", summary.TotalCommits))
+	if summary.Sampling != nil && summary.has(fieldLineStats) {
+		builder.WriteString(fmt.Sprintf("🧪 Sample: %s\n", summary.Sampling.sentence()))
+	}
+	if summary.CoAuthored != nil {
+		builder.WriteString(fmt.Sprintf("🤝 Co-authored: %s\n", summary.CoAuthored.sentence()))
+	}
+	switch {
+	case !summary.shows(StatKickoff):
+	case sameCommit(summary.FirstOfYear, summary.LastOfYear):
+		builder.WriteString(fmt.Sprintf("🚀 You kicked off and signed off the year on %s with %s\n", summary.when(summary.FirstOfYear).Format(yearDayLayout), subjectText(summary.FirstOfYear)))
+	default:
+		builder.WriteString(fmt.Sprintf("🚀 You kicked off the year on %s with %s\n", summary.when(summary.FirstOfYear).Format(yearDayLayout), subjectText(summary.FirstOfYear)))
+		builder.WriteString(fmt.Sprintf("🏁 You signed off on %s with %s\n", summary.when(summary.LastOfYear).Format(yearDayLayout), subjectText(summary.LastOfYear)))
+	}
+	switch {
+	case !summary.shows(StatEarlyLate):
+	case sameCommit(summary.Earliest, summary.Latest):
+		builder.WriteString(fmt.Sprintf("🌅 Earliest riser and latest night(%v): %s\n", summary.when(summary.Earliest), commitText(summary.Earliest)))
+	default:
+		builder.WriteString(fmt.Sprintf("🌅 Earliest riser(%v): %s\n", summary.when(summary.Earliest), commitText(summary.Earliest)))
+		builder.WriteString(fmt.Sprintf("🌃 Latest night(%v): %s\n", summary.when(summary.Latest), commitText(summary.Latest)))
+	}
+	if summary.has(fieldLineStats) && summary.shows(StatLines) {
+		builder.WriteString(fmt.Sprintf("🟢 Average additions%s: %s\n", summary.estimated(), opts.paint(theme.Addition, fmt.Sprintf("%.1f", roundHalfUp(summary.AverageAdditions, 1)))))
+		builder.WriteString(fmt.Sprintf("🔴 Average deletions%s: %s\n", summary.estimated(), opts.paint(theme.Deletion, fmt.Sprintf("%.1f", roundHalfUp(summary.AverageDeletions, 1)))))
+		if summary.EmptyCommits > 0 {
+			builder.WriteString(fmt.Sprintf("🫙 Empty commits%s: %d\n", summary.estimated(), summary.EmptyCommits))
+		}
+		if len(summary.Oversized) > 0 {
+			builder.WriteString(fmt.Sprintf("🐘 Oversized commits: %s\n", summary.oversizedSentence()))
+		}
+	}
+	if summary.shows(StatActiveDays) {
+		builder.WriteString(fmt.Sprintf("📅 Active days: %d of %d (%.1f%%)\n", summary.ActiveDays(), summary.Window.days(), roundHalfUp(summary.activeShare(), 1)))
+		if mostDay != nil {
+			most := fmt.Sprintf("%d", mostDay.Count)
+			if span := mostDay.span(); span != "" {
+				most += ", " + span
+			}
+			builder.WriteString(fmt.Sprintf("🏔️ Most commits per day(%v): %s\n", mostDay.First, most))
+		}
+	}
+	if summary.shows(StatCadence) {
+		builder.WriteString(fmt.Sprintf("📈 Weekly cadence: %s\n", summary.cadenceSentence()))
+	}
+	if streak, ok := summary.HotStreak(); ok && summary.shows(StatHotStreak) {
+		builder.WriteString(fmt.Sprintf("🔥 %s\n", streak.sentence()))
+	}
+	if summary.History != nil {
+		builder.WriteString(fmt.Sprintf("🏆 Historical rank: %s\n", summary.History.sentence()))
+		builder.WriteString("📜 Commits per year:\n" + summary.History.Chart() + "\n")
+	}
+	if summary.Widest != nil && summary.shows(StatFocus) {
+		builder.WriteString(fmt.Sprintf("🔬 Focus%s: %s\n", summary.estimated(), summary.focusSentence()))
+		builder.WriteString(fmt.Sprintf("🌐 Broadest change%s: %s (%s)\n", summary.estimated(), summary.widestSentence(), summary.Widest.Hash))
+	}
+	if summary.has(fieldLineStats) && summary.shows(StatNetLines) {
+		builder.WriteString(fmt.Sprintf("📐 Net lines%s: %s\n", summary.estimated(), summary.netLinesSentence()))
+		for _, line := range strings.Split(summary.NetLinesChart(), "\n") {
+			builder.WriteString("  " + line + "\n")
+		}
+	}
+	if histogram := summary.SizeHistogram(); histogram != "" && summary.shows(StatCommitSizes) {
+		builder.WriteString(fmt.Sprintf("📊 Commit sizes%s: %s\n", summary.estimated(), summary.sizeSentence()))
+		for _, line := range strings.Split(histogram, "\n") {
+			builder.WriteString("  " + line + "\n")
+		}
+	}
+	if summary.MergeCommits > 0 && summary.shows(StatMerges) {
+		builder.WriteString(fmt.Sprintf("🔀 Merge commits: %d (%s)\n", summary.MergeCommits, mergeNote(summary)))
+	}
+	if summary.Team != nil {
+		builder.WriteString(fmt.Sprintf("👥 Contributors: %s\n", summary.Team.sentence()))
+		if summary.has(fieldLineStats) && summary.shows(StatLines) {
+			builder.WriteString(fmt.Sprintf("📏 Total lines%s: %s\n", summary.estimated(), opts.lineStats(summary.TotalAdditions(), summary.TotalDeletions())))
+		}
+	}
+	for _, line := range summary.StatLines {
+		builder.WriteString(fmt.Sprintf("%s: %s\n", line.Label, line.Value))
+	}
+	for _, reviews := range summary.Reviews {
+		builder.WriteString(fmt.Sprintf("🔃 %s: %s\n", reviews.Forge, reviews.sentence()))
+	}
+	if summary.Tickets != nil {
+		builder.WriteString(fmt.Sprintf("🎫 Tickets: %s\n", summary.Tickets.sentence()))
+		builder.WriteString(fmt.Sprintf("🤠 Ticket-less commits: %s\n", summary.Tickets.ticketlessSentence()))
+	}
+	if summary.Trailers != nil {
+		for _, trailer := range summary.Trailers.Trailers {
+			builder.WriteString(fmt.Sprintf("🏷️ %s: %s\n", trailer.Key, trailer.sentence(summary.Trailers.Commits)))
+		}
+		if reviewer := summary.Trailers.topReviewerSentence(); reviewer != "" {
+			builder.WriteString(fmt.Sprintf("🧐 Most reviewed by: %s\n", reviewer))
+		}
+	}
+	if summary.Fixes != nil {
+		builder.WriteString(fmt.Sprintf("🐛 Fixes vs features: %s\n", summary.Fixes.sentence()))
+		if summary.Fixes.File != "" {
+			builder.WriteString(fmt.Sprintf("🩺 Most fixed file: %s\n", summary.Fixes.fileSentence(summary.Window)))
+		}
+	}
+	if pattern := summary.WorkPattern; pattern != nil && pattern.Commits > 0 {
+		builder.WriteString(fmt.Sprintf("🕘 Work hours: %s\n", pattern.insideSentence()))
+		builder.WriteString(fmt.Sprintf("🌙 After hours: %s\n", pattern.afterHoursSentence()))
+		if pattern.LongestRun > 0 {
+			builder.WriteString(fmt.Sprintf("🦉 Longest after-hours run: %s\n", pattern.runSentence()))
+		}
+	}
+	if summary.Releases != nil && summary.Releases.Tags > 0 {
+		builder.WriteString(fmt.Sprintf("📦 %s\n", summary.Releases.sentence()))
+	}
+	if summary.Signoffs != nil {
+		builder.WriteString(fmt.Sprintf("✍️ Signed off: %s\n", summary.Signoffs.sentence()))
+	}
+	if summary.has(fieldLineStats) {
+		writeTopFiles(&builder, summary, opts)
+	}
+	if tickets := shownTickets(summary, opts); len(tickets) > 0 {
+		builder.WriteString("🎫 Top tickets:\n")
+		for i, ticket := range tickets {
+			builder.WriteString(fmt.Sprintf("%3d. %s: %s\n", i+1, ticket.Key, commitCount(ticket.Commits)))
+		}
+	}
+	if identities := shownIdentities(summary, opts); len(identities) > 0 {
+		builder.WriteString("🪪 Identities:\n")
+		repos := identityRepos(identities)
+		for _, identity := range identities {
+			builder.WriteString(fmt.Sprintf("  %s: %s\n", identity.Email, identity.sentence(summary.has(fieldLineStats), repos)))
+		}
+	}
+	if repos := shownRepos(summary, opts); len(repos) > 0 {
+		builder.WriteString(fmt.Sprintf("🏠 %s\n", summary.homeSentence()))
+		builder.WriteString("🗂️ Repositories:\n")
+		for i, repo := range repos {
+			builder.WriteString(fmt.Sprintf("%3d. %s: %s\n", i+1, repo.Name, repo.sentence(summary.has(fieldLineStats))))
+		}
+	}
+	if summary.Spotlight != nil {
+		builder.WriteString(fmt.Sprintf("🎯 %s\n", summary.Spotlight.sentence()))
+	}
+	if summary.Survival != nil {
+		builder.WriteString(fmt.Sprintf("🌱 %s\n", summary.Survival.sentence()))
+	}
+	if summary.Ownership != nil {
+		builder.WriteString(fmt.Sprintf("👑 Owned files: %s\n", summary.Ownership.sentence()))
+		if len(summary.Ownership.Largest) > 0 {
+			builder.WriteString("👑 Largest owned files:\n")
+			for i, file := range summary.Ownership.Largest {
+				builder.WriteString(fmt.Sprintf("%3d. %s: %s\n", i+1, file.Path, file.sentence()))
+			}
+		}
+	}
+	if neighbors := topNeighbors(summary); len(neighbors) > 0 {
+		builder.WriteString("🏘️ Code neighbors:\n")
+		for i, neighbor := range neighbors {
+			builder.WriteString(fmt.Sprintf("%3d. %s <%s>: %s\n", i+1, neighbor.Name, neighbor.Email, neighbor.sentence()))
+		}
+	}
+	if contributors := shownNewContributors(summary, opts); len(contributors) > 0 {
+		builder.WriteString("🌱 New contributors:\n")
+		for i, contributor := range contributors {
+			builder.WriteString(fmt.Sprintf("%3d. %s <%s>: %s\n", i+1, contributor.Name, contributor.Email, commitCount(contributor.Commits)))
+		}
+	}
+	if achievements := summary.Achievements(); len(achievements) > 0 {
+		builder.WriteString("🏅 Achievements unlocked:\n")
+		for _, achievement := range achievements {
+			builder.WriteString(fmt.Sprintf("  %s %s\n", achievement.Emoji, achievement.sentence()))
+		}
+	}
+	if summary.Team != nil {
+		builder.WriteString("🗓️ Team activity:\n")
+		builder.WriteString(Heatmap(summary) + "\n")
+	}
+	writeFooter(&builder, summary, opts)
+
+	return builder.String()
+}
+
+// writeTopFiles lists the first files the options show, leaving the section
+// out when there are none to list.
+func writeTopFiles(builder *strings.Builder, summary *Summary, opts RenderOptions) {
+	if !summary.shows(StatFiles) {
+		return
+	}
+	files := summary.TopFiles()
+	top := opts.Limit(SectionFiles)
+	if top <= 0 || len(files) == 0 {
+		return
+	}
+	if len(files) > top {
+		files = files[:top]
+	}
+
+	builder.WriteString(fmt.Sprintf("📂 Most changed files%s:\n", summary.estimated()))
+	for i, file := range files {
+		builder.WriteString(fmt.Sprintf("%3d. %s: %s in %s\n", i+1, file.Path, opts.lineStats(file.Additions, file.Deletions), commitCount(file.Commits)))
+	}
+}
+
+// lineStats returns the added and deleted lines like +12/-3, colored when the
+// text report is.
+func (o RenderOptions) lineStats(additions int64, deletions int64) string {
+	theme := o.theme()
+	return o.paint(theme.Addition, fmt.Sprintf("+%d", additions)) + "/" + o.paint(theme.Deletion, fmt.Sprintf("-%d", deletions))
+}
+
+// mergeNote explains how the merge commits affected the line stats.
+func mergeNote(summary *Summary) string {
+	if summary.MergeStats != MergeStatsFirstParent || !summary.has(fieldLineStats) {
+		return "not counted towards line stats"
+	}
+
+	return fmt.Sprintf("+%d/-%d lines against their first parent included in line stats", summary.MergeAdditions, summary.MergeDeletions)
+}
+
+// jsonSample is the sample the line stats were estimated from.
+type jsonSample struct {
+	Sampled int64  `json:"sampled"`
+	Commits int64  `json:"commits"`
+	Seed    string `json:"seed,omitempty"`
+	// Estimated are the fields of the report that are estimates.
+	Estimated []string `json:"estimated"`
+}
+
+// jsonOversized is a commit left out of the line stats for going over the
+// limits.
+type jsonOversized struct {
+	Hash   string `json:"hash"`
+	Reason string `json:"reason"`
+}
+
+// jsonCoAuthored are the commits credited through Co-authored-by lines.
+type jsonCoAuthored struct {
+	Commits int  `json:"commits"`
+	Counted bool `json:"counted"`
+}
+
+// jsonHighlights are the stats picked as the highlights, by the seed.
+type jsonHighlights struct {
+	Seed  string     `json:"seed"`
+	Facts []jsonFact `json:"facts"`
+}
+
+// jsonFact is a stat as the other reports show it, with how interesting it
+// scored.
+type jsonFact struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+	// Hash is the short hash of the commit the stat is about, if any.
+	Hash  string  `json:"hash,omitempty"`
+	Score float64 `json:"score"`
+}
+
+type jsonMerges struct {
+	Commits   int64  `json:"commits"`
+	Policy    string `json:"policy"`
+	Additions *int64 `json:"additions,omitempty"`
+	Deletions *int64 `json:"deletions,omitempty"`
+}
+
+type jsonReviews struct {
+	Forge    string `json:"forge"`
+	Opened   int    `json:"opened"`
+	Merged   int    `json:"merged"`
+	Reviewed int    `json:"reviewed"`
+	Approved int    `json:"approved,omitempty"`
+	// MedianTimeToMergeHours is left out when nothing was merged.
+	MedianTimeToMergeHours *float64 `json:"median_time_to_merge_hours,omitempty"`
+}
+
+type jsonIdentity struct {
+	Email      string   `json:"email"`
+	Commits    int64    `json:"commits"`
+	Additions  *int64   `json:"additions,omitempty"`
+	Deletions  *int64   `json:"deletions,omitempty"`
+	ActiveDays int      `json:"active_days"`
+	Repos      []string `json:"repos"`
+}
+
+// jsonTeam lists every new contributor, --top only limits the other
+// reports.
+type jsonTeam struct {
+	Contributors    int               `json:"contributors"`
+	NewContributors []jsonContributor `json:"new_contributors"`
+	Additions       *int64            `json:"additions,omitempty"`
+	Deletions       *int64            `json:"deletions,omitempty"`
+	CommitsPerDay   map[string]int    `json:"commits_per_day"`
+}
+
+type jsonContributor struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Commits int    `json:"commits"`
+}
+
+// jsonTickets lists every referenced ticket, --top only limits the other
+// reports.
+type jsonTickets struct {
+	Tickets           []jsonTicket `json:"tickets"`
+	Ticketless        int          `json:"ticketless"`
+	TicketlessPercent float64      `json:"ticketless_percent"`
+}
+
+type jsonTicket struct {
+	Key     string `json:"key"`
+	Commits int    `json:"commits"`
+}
+
+// jsonTrailers lists every reviewer, --top only limits the other reports.
+type jsonTrailers struct {
+	Trailers []jsonTrailer `json:"trailers"`
+	// Reviewers is left out unless Reviewed-by was counted.
+	Reviewers []jsonReviewer `json:"reviewers,omitempty"`
+}
+
+type jsonTrailer struct {
+	Key     string  `json:"key"`
+	Commits int     `json:"commits"`
+	Percent float64 `json:"percent"`
+}
+
+type jsonReviewer struct {
+	Reviewer string `json:"reviewer"`
+	Commits  int    `json:"commits"`
+}
+
+type jsonConsistency struct {
+	Score         int    `json:"score"`
+	Phrase        string `json:"phrase"`
+	WeeklyCommits []int  `json:"weekly_commits"`
+}
+
+type jsonHotStreak struct {
+	Start        string  `json:"start"`
+	End          string  `json:"end"`
+	Commits      int     `json:"commits"`
+	UsualPerWeek float64 `json:"usual_per_week"`
+	Multiplier   float64 `json:"multiplier"`
+	Capped       bool    `json:"capped"`
+}
+
+type jsonRepo struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Commits int64  `json:"commits"`
+	// Additions and Deletions are left out without line stats.
+	Additions      *int64 `json:"additions,omitempty"`
+	Deletions      *int64 `json:"deletions,omitempty"`
+	ActiveDays     int    `json:"active_days"`
+	BusiestDay     string `json:"busiest_day"`
+	BusiestCommits int    `json:"busiest_commits"`
+}
+
+type jsonHistory struct {
+	Year          int `json:"year"`
+	Rank          int `json:"rank"`
+	RecordYear    int `json:"record_year"`
+	RecordCommits int `json:"record_commits"`
+	// Years are the commits of every year with commits, keyed by the year.
+	Years map[string]int `json:"years"`
+}
+
+type jsonFixes struct {
+	Fixes      int    `json:"fixes"`
+	Features   int    `json:"features"`
+	Month      string `json:"month,omitempty"`
+	MonthFixes int    `json:"month_fixes"`
+	File       string `json:"file,omitempty"`
+	FileFixes  int    `json:"file_fixes"`
+}
+
+type jsonWorkPattern struct {
+	WorkHours string   `json:"work_hours"`
+	WorkDays  []string `json:"work_days"`
+	// TimeZone is left out when every commit stays in its own time zone.
+	TimeZone       string  `json:"time_zone,omitempty"`
+	Commits        int     `json:"commits"`
+	Inside         int     `json:"inside"`
+	InsidePercent  float64 `json:"inside_percent"`
+	AfterHours     int     `json:"after_hours"`
+	LateNight      int     `json:"late_night"`
+	LongestRunDays int     `json:"longest_run_days"`
+	// LongestRunStart is left out when no commit was after hours.
+	LongestRunStart string `json:"longest_run_start,omitempty"`
+}
+
+type jsonReleases struct {
+	Tags     int           `json:"tags"`
+	Releases []jsonRelease `json:"releases"`
+}
+
+type jsonRelease struct {
+	Tag     string    `json:"tag"`
+	When    time.Time `json:"when"`
+	Commits int       `json:"commits"`
+}
+
+// jsonSignoffs lists every commit without a sign-off, the other reports
+// only the first.
+type jsonSignoffs struct {
+	Commits   int      `json:"commits"`
+	SignedOff int      `json:"signed_off"`
+	Percent   float64  `json:"percent"`
+	Unsigned  []string `json:"unsigned"`
+}
+
+type jsonFocus struct {
+	SingleFileCommits int64       `json:"single_file_commits"`
+	MultiFileCommits  int64       `json:"multi_file_commits"`
+	SingleFilePercent float64     `json:"single_file_percent"`
+	Widest            *jsonCommit `json:"widest"`
+	WidestFiles       int         `json:"widest_files"`
+}
+
+type jsonAchievement struct {
+	Emoji    string `json:"emoji"`
+	Name     string `json:"name"`
+	Rule     string `json:"rule"`
+	Evidence string `json:"evidence"`
+}
+
+type jsonSpotlight struct {
+	Path         string `json:"path"`
+	Commits      int    `json:"commits"`
+	Additions    int64  `json:"additions"`
+	Deletions    int64  `json:"deletions"`
+	Month        string `json:"month,omitempty"`
+	MonthCommits int    `json:"month_commits"`
+	Others       int    `json:"others"`
+}
+
+type jsonMonthNet struct {
+	Month string `json:"month"`
+	Net   int64  `json:"net"`
+}
+
+type jsonSizeBucket struct {
+	Label string `json:"label"`
+	// Max is left out for the last bucket, which has no limit.
+	Max     *int64  `json:"max,omitempty"`
+	Commits int     `json:"commits"`
+	Percent float64 `json:"percent"`
+}
+
+type jsonSurvival struct {
+	Added   int64   `json:"added"`
+	Alive   int64   `json:"alive"`
+	Percent float64 `json:"percent"`
+}
+
+type jsonOwnership struct {
+	Window  string          `json:"window"`
+	Files   int             `json:"files"`
+	Touched int             `json:"touched"`
+	Largest []jsonOwnedFile `json:"largest"`
+}
+
+type jsonOwnedFile struct {
+	Path  string `json:"path"`
+	Lines int    `json:"lines"`
+}
+
+type jsonNeighbor struct {
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	SharedFiles   int    `json:"shared_files"`
+	SharedChanges int    `json:"shared_changes"`
+}
+
+type jsonStatLine struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+type jsonCommit struct {
+	Hash    string    `json:"hash"`
+	When    time.Time `json:"when"`
+	Message string    `json:"message"`
+}
+
+type jsonFile struct {
+	Path      string `json:"path"`
+	Commits   int    `json:"commits"`
+	Additions int64  `json:"additions"`
+	Deletions int64  `json:"deletions"`
+}
+
+// jsonMetadata are how the report was computed.
+type jsonMetadata struct {
+	Generator   string     `json:"generator,omitempty"`
+	GeneratedAt string     `json:"generated_at"`
+	Window      jsonWindow `json:"window"`
+	Refs        jsonRefs   `json:"refs"`
+	// Identities are left out for the commits of every author, and for
+	// IdentitiesHash once anonymized.
+	Identities       []string `json:"identities,omitempty"`
+	IdentitiesHash   string   `json:"identities_hash,omitempty"`
+	ExcludedAuthors  []string `json:"excluded_authors"`
+	ExcludedPaths    []string `json:"excluded_paths"`
+	ExcludeLockfiles bool     `json:"exclude_lockfiles"`
+	ExcludeMerges    bool     `json:"exclude_merges"`
+	MergeStats       string   `json:"merge_stats"`
+	// Command is left out when unknown and once anonymized.
+	Command string `json:"command,omitempty"`
+	// Stats are left out when every stat is shown.
+	Stats []string `json:"stats,omitempty"`
+}
+
+type jsonRefs struct {
+	Walked string `json:"walked"`
+	Range  string `json:"range,omitempty"`
+}
+
+func newJSONMetadata(summary *Summary) *jsonMetadata {
+	if summary.Metadata == nil {
+		return nil
+	}
+
+	walked, revisions := summary.refsWalked()
+	metadata := &jsonMetadata{
+		Generator:        summary.Generator,
+		GeneratedAt:      summary.Metadata.GeneratedAt.UTC().Format(time.RFC3339),
+		Window:           newJSONWindow(summary.Window),
+		Refs:             jsonRefs{Walked: walked, Range: revisions},
+		IdentitiesHash:   summary.Metadata.IdentitiesHash,
+		ExcludedAuthors:  append(make([]string, 0), summary.Selection.ExcludedAuthors...),
+		ExcludedPaths:    append(make([]string, 0), summary.Metadata.Filter.Excluded...),
+		ExcludeLockfiles: summary.Metadata.Filter.ExcludeLockfiles,
+		ExcludeMerges:    summary.Selection.ExcludeMerges,
+		MergeStats:       summary.MergeStats,
+		Command:          summary.Metadata.Command,
+	}
+	if summary.Stats != nil {
+		metadata.Stats = summary.Stats.IDs()
+	}
+	if summary.Selection.Authors != nil && metadata.IdentitiesHash == "" {
+		metadata.Identities = sortedEmails(summary.Selection.Authors)
+	}
+
+	return metadata
+}
+
+type jsonWindow struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	TimeZone string `json:"time_zone"`
+}
+
+func newJSONWindow(window AnalysisWindow) jsonWindow {
+	return jsonWindow{
+		Start:    window.Start.Format(time.DateOnly),
+		End:      window.LastDay().Format(time.DateOnly),
+		TimeZone: window.Location.String(),
+	}
+}
+
+type jsonActiveDays struct {
+	Days    int     `json:"days"`
+	Of      int     `json:"of"`
+	Percent float64 `json:"percent"`
+}
+
+type jsonDay struct {
+	Date    string `json:"date"`
+	Commits int    `json:"commits"`
+}
+
+// SchemaVersion is the schema_version of the json report and of the commit
+// log. Bump it, and report.schema.json and commit.schema.json with it,
+// whenever jsonOutput or CommitRecord changes shape, keeping a copy of the
+// new report schema in testdata for the compatibility tests.
+const SchemaVersion = 31
+
+//go:embed report.schema.json
+var reportSchema string
+
+// JSONSchema returns the JSON Schema of the json report.
+func JSONSchema() string {
+	return reportSchema
+}
+
+// jsonOutput is the structure of the json report. Line based stats are left
+// out entirely when they weren't computed rather than reported as zero.
+type jsonOutput struct {
+	SchemaVersion int    `json:"schema_version"`
+	Generator     string `json:"generator,omitempty"`
+	// Metadata is left out when the summary doesn't know how it was
+	// computed.
+	Metadata *jsonMetadata `json:"metadata,omitempty"`
+	Window   jsonWindow    `json:"window"`
+	// Branches are left out when the summary doesn't know them.
+	Branches     []string `json:"branches,omitempty"`
+	TotalCommits int64    `json:"total_commits"`
+	// Sample is only set when the line stats were estimated from a sample.
+	Sample *jsonSample `json:"sample,omitempty"`
+	// CoAuthored is only set with --attribute-coauthors.
+	CoAuthored *jsonCoAuthored `json:"co_authored,omitempty"`
+	// Highlights is only set with --highlights.
+	Highlights       *jsonHighlights `json:"highlights,omitempty"`
+	Earliest         *jsonCommit     `json:"earliest,omitempty"`
+	Latest           *jsonCommit     `json:"latest,omitempty"`
+	FirstOfYear      *jsonCommit     `json:"first_of_year,omitempty"`
+	LastOfYear       *jsonCommit     `json:"last_of_year,omitempty"`
+	Largest          *jsonCommit     `json:"largest,omitempty"`
+	Smallest         *jsonCommit     `json:"smallest,omitempty"`
+	AverageAdditions *float64        `json:"average_additions,omitempty"`
+	AverageDeletions *float64        `json:"average_deletions,omitempty"`
+	EmptyCommits     *int64          `json:"empty_commits,omitempty"`
+	// OversizedCommits is only set when commits went over the limits.
+	OversizedCommits []jsonOversized `json:"oversized_commits,omitempty"`
+	ActiveDays       jsonActiveDays  `json:"active_days"`
+	MostActiveDay    *jsonDay        `json:"most_active_day,omitempty"`
+	// Consistency is left out for windows shorter than two weeks and fewer
+	// than two commits.
+	Consistency *jsonConsistency `json:"consistency,omitempty"`
+	// HotStreak is left out when no 4 weeks stand out.
+	HotStreak *jsonHotStreak `json:"hot_streak,omitempty"`
+	// Achievements are left out when none was unlocked.
+	Achievements []jsonAchievement `json:"achievements,omitempty"`
+	// HistoricalRank is only set with --historical-rank.
+	HistoricalRank *jsonHistory `json:"historical_rank,omitempty"`
+	// Focus is left out without line stats or when no commit changed files.
+	Focus *jsonFocus `json:"focus,omitempty"`
+	// CommitSizes is left out without line stats.
+	CommitSizes []jsonSizeBucket `json:"commit_sizes,omitempty"`
+	// MonthlyNetLines is left out without line stats.
+	MonthlyNetLines []jsonMonthNet   `json:"monthly_net_lines,omitempty"`
+	Merges          *jsonMerges      `json:"merges,omitempty"`
+	Stats           []jsonStatLine   `json:"stats,omitempty"`
+	Reviews         []jsonReviews    `json:"reviews,omitempty"`
+	Tickets         *jsonTickets     `json:"tickets,omitempty"`
+	Trailers        *jsonTrailers    `json:"trailers,omitempty"`
+	Signoffs        *jsonSignoffs    `json:"signoffs,omitempty"`
+	Releases        *jsonReleases    `json:"releases,omitempty"`
+	Fixes           *jsonFixes       `json:"fixes,omitempty"`
+	WorkPattern     *jsonWorkPattern `json:"work_pattern,omitempty"`
+	Identities      []jsonIdentity   `json:"identities,omitempty"`
+	// Repos is left out unless the commits span several repositories.
+	Repos []jsonRepo `json:"repos,omitempty"`
+	// Neighbors lists every code neighbor, the other reports only the top.
+	Neighbors []jsonNeighbor `json:"neighbors,omitempty"`
+	Ownership *jsonOwnership `json:"ownership,omitempty"`
+	Survival  *jsonSurvival  `json:"survival,omitempty"`
+	Spotlight *jsonSpotlight `json:"spotlight,omitempty"`
+	Team      *jsonTeam      `json:"team,omitempty"`
+	// Files ranks every changed file, --top only limits the text report.
+	Files []jsonFile `json:"files,omitempty"`
+}
+
+func newJSONCommit(summary *Summary, commit *Commit) *jsonCommit {
+	if commit == nil {
+		return nil
+	}
+
+	return &jsonCommit{
+		Hash:    commit.Hash,
+		When:    summary.when(commit),
+		Message: strings.TrimSpace(commit.Message),
+	}
+}
+
+func buildJSONOutput(summary *Summary, opts RenderOptions) (string, error) {
+	output := jsonOutput{
+		SchemaVersion: SchemaVersion,
+		Generator:     summary.Generator,
+		Metadata:      newJSONMetadata(summary),
+		Window:        newJSONWindow(summary.Window),
+		Branches:      summary.Branches,
+		ActiveDays: jsonActiveDays{
+			Days:    summary.ActiveDays(),
+			Of:      summary.Window.days(),
+			Percent: roundHalfUp(summary.activeShare(), 1),
+		},
+		TotalCommits: summary.TotalCommits,
+		Earliest:     newJSONCommit(summary, summary.Earliest),
+		Latest:       newJSONCommit(summary, summary.Latest),
+		FirstOfYear:  newJSONCommit(summary, summary.FirstOfYear),
+		LastOfYear:   newJSONCommit(summary, summary.LastOfYear),
+	}
+
+	if summary.has(fieldLineStats) {
+		output.Largest = newJSONCommit(summary, summary.Largest)
+		output.Smallest = newJSONCommit(summary, summary.Smallest)
+		output.AverageAdditions = &summary.AverageAdditions
+		output.AverageDeletions = &summary.AverageDeletions
+		output.EmptyCommits = &summary.EmptyCommits
+		for _, oversized := range summary.Oversized {
+			output.OversizedCommits = append(output.OversizedCommits, jsonOversized{Hash: oversized.Hash, Reason: oversized.Err.Error()})
+		}
+		if summary.shows(StatFiles) {
+			output.Files = make([]jsonFile, 0, len(summary.files))
+			for _, file := range summary.TopFiles() {
+				output.Files = append(output.Files, jsonFile{Path: file.Path, Commits: file.Commits, Additions: file.Additions, Deletions: file.Deletions})
+			}
+		}
+	}
+
+	if mostDay := summary.mostActiveDay(); mostDay != nil && summary.shows(StatActiveDays) {
+		output.MostActiveDay = &jsonDay{
+			Date:    mostDay.First.Format(time.DateOnly),
+			Commits: mostDay.Count,
+		}
+	}
+
+	if score, ok := summary.Consistency(); ok && summary.shows(StatCadence) {
+		output.Consistency = &jsonConsistency{Score: score, Phrase: consistencyPhrase(score), WeeklyCommits: summary.WeeklyCommits()}
+	}
+	if history := summary.History; history != nil {
+		record := history.Record()
+		output.HistoricalRank = &jsonHistory{Year: history.Year, Rank: history.Rank(), RecordYear: record.Year, RecordCommits: record.Commits, Years: make(map[string]int, len(history.Years))}
+		for _, count := range history.Years {
+			output.HistoricalRank.Years[strconv.Itoa(count.Year)] = count.Commits
+		}
+	}
+	if streak, ok := summary.HotStreak(); ok && summary.shows(StatHotStreak) {
+		output.HotStreak = &jsonHotStreak{
+			Start:        streak.Start.Format(time.DateOnly),
+			End:          streak.LastDay().Format(time.DateOnly),
+			Commits:      streak.Commits,
+			UsualPerWeek: roundHalfUp(streak.Usual, 1),
+			Multiplier:   roundHalfUp(streak.Multiplier, 1),
+			Capped:       streak.Capped,
+		}
+	}
+	for _, achievement := range summary.Achievements() {
+		output.Achievements = append(output.Achievements, jsonAchievement{Emoji: achievement.Emoji, Name: achievement.Name, Rule: achievement.Rule, Evidence: achievement.Evidence})
+	}
+
+	for _, line := range summary.StatLines {
+		output.Stats = append(output.Stats, jsonStatLine{Label: line.Label, Value: line.Value})
+	}
+	for _, reviews := range summary.Reviews {
+		entry := jsonReviews{Forge: reviews.Forge, Opened: reviews.Opened, Merged: reviews.Merged, Reviewed: reviews.Reviewed, Approved: reviews.Approved}
+		if reviews.Merged > 0 {
+			hours := roundHalfUp(reviews.MedianTimeToMerge.Hours(), 1)
+			entry.MedianTimeToMergeHours = &hours
+		}
+		output.Reviews = append(output.Reviews, entry)
+	}
+	if summary.Tickets != nil {
+		output.Tickets = &jsonTickets{
+			Tickets:           make([]jsonTicket, 0, len(summary.Tickets.Tickets)),
+			Ticketless:        summary.Tickets.Ticketless,
+			TicketlessPercent: roundHalfUp(summary.Tickets.TicketlessShare(), 1),
+		}
+		for _, ticket := range summary.Tickets.Tickets {
+			output.Tickets.Tickets = append(output.Tickets.Tickets, jsonTicket{Key: ticket.Key, Commits: ticket.Commits})
+		}
+	}
+	if summary.Trailers != nil {
+		output.Trailers = &jsonTrailers{Trailers: make([]jsonTrailer, 0, len(summary.Trailers.Trailers))}
+		for _, trailer := range summary.Trailers.Trailers {
+			output.Trailers.Trailers = append(output.Trailers.Trailers, jsonTrailer{
+				Key:     trailer.Key,
+				Commits: trailer.Commits,
+				Percent: roundHalfUp(percent(trailer.Commits, summary.Trailers.Commits), 1),
+			})
+		}
+		for _, reviewer := range summary.Trailers.Reviewers {
+			output.Trailers.Reviewers = append(output.Trailers.Reviewers, jsonReviewer{Reviewer: reviewer.Value, Commits: reviewer.Commits})
+		}
+	}
+
+	// Like the files, the identities are listed whether or not the other
+	// reports break the activity down by them.
+	if len(summary.identities) > 1 {
+		for _, identity := range summary.Identities() {
+			entry := jsonIdentity{Email: identity.Email, Commits: identity.Commits, ActiveDays: identity.ActiveDays, Repos: identity.Repos}
+			if summary.has(fieldLineStats) {
+				additions, deletions := identity.Additions, identity.Deletions
+				entry.Additions, entry.Deletions = &additions, &deletions
+			}
+			output.Identities = append(output.Identities, entry)
+		}
+	}
+
+	if len(summary.Repos) > 1 {
+		for _, repo := range summary.Repos {
+			entry := jsonRepo{Name: repo.Name, Path: repo.Path, Commits: repo.Commits, ActiveDays: repo.ActiveDays, BusiestDay: repo.BusiestDay.Format(time.DateOnly), BusiestCommits: repo.BusiestCommits}
+			if summary.has(fieldLineStats) {
+				additions, deletions := repo.Additions, repo.Deletions
+				entry.Additions, entry.Deletions = &additions, &deletions
+			}
+			output.Repos = append(output.Repos, entry)
+		}
+	}
+	if fixes := summary.Fixes; fixes != nil {
+		output.Fixes = &jsonFixes{Fixes: fixes.Fixes, Features: fixes.Features, MonthFixes: fixes.MonthFixes, File: fixes.File, FileFixes: fixes.FileFixes}
+		if fixes.MonthFixes > 0 {
+			output.Fixes.Month = fixes.Month.Format("2006-01")
+		}
+	}
+	if pattern := summary.WorkPattern; pattern != nil {
+		output.WorkPattern = &jsonWorkPattern{
+			WorkHours:      formatClock(pattern.Hours.Start) + "-" + formatClock(pattern.Hours.End),
+			WorkDays:       pattern.Hours.DayNames(),
+			Commits:        pattern.Commits,
+			Inside:         pattern.Inside,
+			InsidePercent:  roundHalfUp(pattern.InsideShare(), 1),
+			AfterHours:     pattern.Commits - pattern.Inside,
+			LateNight:      pattern.LateNight,
+			LongestRunDays: pattern.LongestRun,
+		}
+		if pattern.Location != nil {
+			output.WorkPattern.TimeZone = pattern.Location.String()
+		}
+		if pattern.LongestRun > 0 {
+			output.WorkPattern.LongestRunStart = pattern.RunStart.Format(time.DateOnly)
+		}
+	}
+	if summary.Releases != nil {
+		output.Releases = &jsonReleases{Tags: summary.Releases.Tags, Releases: make([]jsonRelease, 0, len(summary.Releases.Releases))}
+		for _, release := range summary.Releases.Releases {
+			output.Releases.Releases = append(output.Releases.Releases, jsonRelease{Tag: release.Tag, When: release.When.In(summary.Window.Location), Commits: release.Commits})
+		}
+	}
+	if summary.Signoffs != nil {
+		output.Signoffs = &jsonSignoffs{Commits: summary.Signoffs.Commits, SignedOff: summary.Signoffs.SignedOff, Percent: roundHalfUp(summary.Signoffs.Share(), 1), Unsigned: summary.Signoffs.Unsigned}
+	}
+	if summary.Widest != nil && summary.shows(StatFocus) {
+		output.Focus = &jsonFocus{
+			SingleFileCommits: summary.SingleFileCommits,
+			MultiFileCommits:  summary.focusCommits() - summary.SingleFileCommits,
+			SingleFilePercent: roundHalfUp(summary.SingleFileShare(), 1),
+			Widest:            newJSONCommit(summary, summary.Widest),
+			WidestFiles:       summary.WidestFiles,
+		}
+	}
+	if summary.has(fieldLineStats) {
+		for _, month := range summary.MonthlyNetLines() {
+			output.MonthlyNetLines = append(output.MonthlyNetLines, jsonMonthNet{Month: month.Month.Format("2006-01"), Net: month.Net})
+		}
+	}
+	if total, _ := summary.sizeCommits(); total > 0 {
+		for i, bucket := range sizeBuckets {
+			entry := jsonSizeBucket{Label: bucket.Label, Commits: summary.CommitSizes[i], Percent: roundHalfUp(percent(summary.CommitSizes[i], total), 1)}
+			if bucket.Max >= 0 {
+				max := bucket.Max
+				entry.Max = &max
+			}
+			output.CommitSizes = append(output.CommitSizes, entry)
+		}
+	}
+	if spotlight := summary.Spotlight; spotlight != nil {
+		output.Spotlight = &jsonSpotlight{Path: spotlight.Path, Commits: spotlight.Commits, Additions: spotlight.Additions, Deletions: spotlight.Deletions, MonthCommits: spotlight.MonthCommits, Others: spotlight.Others}
+		if spotlight.MonthCommits > 0 {
+			output.Spotlight.Month = spotlight.Month.Format("2006-01")
+		}
+	}
+	if summary.Survival != nil {
+		output.Survival = &jsonSurvival{Added: summary.Survival.Added, Alive: summary.Survival.Alive, Percent: roundHalfUp(summary.Survival.Share(), 1)}
+	}
+	if summary.Ownership != nil {
+		output.Ownership = &jsonOwnership{Window: summary.Ownership.Window, Files: summary.Ownership.Files, Touched: summary.Ownership.Touched, Largest: make([]jsonOwnedFile, 0, len(summary.Ownership.Largest))}
+		for _, file := range summary.Ownership.Largest {
+			output.Ownership.Largest = append(output.Ownership.Largest, jsonOwnedFile{Path: file.Path, Lines: file.Lines})
+		}
+	}
+	for _, neighbor := range summary.Neighbors {
+		output.Neighbors = append(output.Neighbors, jsonNeighbor{Name: neighbor.Name, Email: neighbor.Email, SharedFiles: neighbor.SharedFiles, SharedChanges: neighbor.SharedChanges})
+	}
+
+	if summary.Team != nil {
+		output.Team = &jsonTeam{
+			Contributors:    summary.Team.Contributors,
+			NewContributors: make([]jsonContributor, 0, len(summary.Team.NewContributors)),
+			CommitsPerDay:   make(map[string]int, len(summary.ByDay)),
+		}
+		for _, contributor := range summary.Team.NewContributors {
+			output.Team.NewContributors = append(output.Team.NewContributors, jsonContributor{Name: contributor.Name, Email: contributor.Email, Commits: contributor.Commits})
+		}
+		if summary.has(fieldLineStats) {
+			additions, deletions := summary.TotalAdditions(), summary.TotalDeletions()
+			output.Team.Additions, output.Team.Deletions = &additions, &deletions
+		}
+		for day, activity := range summary.ByDay {
+			output.Team.CommitsPerDay[day] = activity.Count
+		}
+	}
+
+	if summary.MergeCommits > 0 {
+		output.Merges = &jsonMerges{Commits: summary.MergeCommits, Policy: summary.MergeStats}
+		if summary.MergeStats == MergeStatsFirstParent && summary.has(fieldLineStats) {
+			output.Merges.Additions = &summary.MergeAdditions
+			output.Merges.Deletions = &summary.MergeDeletions
+		}
+	}
+
+	if summary.Sampling != nil && summary.has(fieldLineStats) {
+		output.Sample = &jsonSample{
+			Sampled:   summary.Sampling.Sampled,
+			Commits:   summary.Sampling.Commits,
+			Seed:      summary.Sampling.Seed,
+			Estimated: output.estimatedFields(),
+		}
+	}
+	if summary.CoAuthored != nil {
+		output.CoAuthored = &jsonCoAuthored{Commits: summary.CoAuthored.Commits, Counted: summary.CoAuthored.Counted}
+	}
+	if opts.Highlights > 0 {
+		output.Highlights = &jsonHighlights{Seed: opts.HighlightSeed, Facts: make([]jsonFact, 0)}
+		for _, row := range highlights(summary, opts) {
+			output.Highlights.Facts = append(output.Highlights.Facts, jsonFact{Label: row.Label, Value: row.Value, Hash: row.Hash, Score: roundHalfUp(row.Score, 2)})
+		}
+	}
+
+	return marshalJSON(output)
+}
+
+// estimatedFields returns the fields of the report derived from the line
+// stats, the ones a sample estimates, with [] standing for every item of a
+// list.
+func (o *jsonOutput) estimatedFields() []string {
+	fields := make([]string, 0)
+	add := func(set bool, field string) {
+		if set {
+			fields = append(fields, field)
+		}
+	}
+	add(o.Largest != nil, "largest")
+	add(o.Smallest != nil, "smallest")
+	add(o.AverageAdditions != nil, "average_additions")
+	add(o.AverageDeletions != nil, "average_deletions")
+	add(o.EmptyCommits != nil, "empty_commits")
+	add(o.Focus != nil, "focus")
+	add(len(o.CommitSizes) > 0, "commit_sizes")
+	add(len(o.MonthlyNetLines) > 0, "monthly_net_lines")
+	add(o.Merges != nil && o.Merges.Additions != nil, "merges.additions")
+	add(o.Merges != nil && o.Merges.Deletions != nil, "merges.deletions")
+	add(len(o.Identities) > 0 && o.Identities[0].Additions != nil, "identities[].additions")
+	add(len(o.Identities) > 0 && o.Identities[0].Deletions != nil, "identities[].deletions")
+	add(len(o.Repos) > 0 && o.Repos[0].Additions != nil, "repos[].additions")
+	add(len(o.Repos) > 0 && o.Repos[0].Deletions != nil, "repos[].deletions")
+	add(o.Team != nil && o.Team.Additions != nil, "team.additions")
+	add(o.Team != nil && o.Team.Deletions != nil, "team.deletions")
+	add(len(o.Files) > 0, "files")
+
+	return fields
+}
+
+// marshalJSON indents the value without escaping the <, > and & characters
+// that show up in commit messages.
+func marshalJSON(value interface{}) (string, error) {
+	builder := strings.Builder{}
+	encoder := json.NewEncoder(&builder)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(value)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(builder.String(), "\n"), nil
+}
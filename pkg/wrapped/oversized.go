@@ -0,0 +1,91 @@
+package wrapped
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CommitLimits bound the line stats of a single commit, so one pathological
+// commit, like a generated file of gigabytes, can't stall the analysis. The
+// commits going over them are left out of the line stats and listed in
+// Summary.Oversized instead. A zero limit is no limit. Commits whose stats
+// are cached are never oversized, their stats cost nothing to read.
+type CommitLimits struct {
+	// MaxChangedFiles is the most files the tree diff of a commit can
+	// change, before any file is read.
+	MaxChangedFiles int
+	// MaxBlobSize is the most bytes either side of a changed file can be.
+	MaxBlobSize int64
+	// Timeout is the longest the line stats of a commit can take.
+	Timeout time.Duration
+}
+
+// oversizedError is why a commit went over the CommitLimits.
+type oversizedError struct {
+	reason string
+}
+
+func (e *oversizedError) Error() string {
+	return e.reason
+}
+
+// checkChangedFiles returns an *oversizedError when the commit changes more
+// files than allowed.
+func (l CommitLimits) checkChangedFiles(changed int) error {
+	if l.MaxChangedFiles > 0 && changed > l.MaxChangedFiles {
+		return &oversizedError{reason: fmt.Sprintf("changes %s files, more than %s", FormatCount(changed), FormatCount(l.MaxChangedFiles))}
+	}
+
+	return nil
+}
+
+// checkBlobSize returns an *oversizedError when a changed file is larger than
+// allowed. The reason doesn't name it, to be shared anonymized.
+func (l CommitLimits) checkBlobSize(size int64) error {
+	if l.MaxBlobSize > 0 && size > l.MaxBlobSize {
+		return &oversizedError{reason: fmt.Sprintf("changes a file of %s bytes, more than %s", FormatCount(int(size)), FormatCount(int(l.MaxBlobSize)))}
+	}
+
+	return nil
+}
+
+// withTimeout returns the context the line stats of a single commit are
+// computed under.
+func (l CommitLimits) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if l.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, l.Timeout)
+}
+
+// timedOut returns an *oversizedError when the line stats of the commit ran
+// out of its time, the context of the whole analysis still being alive, and
+// err otherwise. go-git reports the cancelled diff of a tree as an error of
+// its own, hence checking the contexts rather than err.
+func (l CommitLimits) timedOut(ctx context.Context, commitCtx context.Context, err error) error {
+	if commitCtx.Err() != nil && ctx.Err() == nil {
+		return &oversizedError{reason: fmt.Sprintf("took longer than %s", l.Timeout)}
+	}
+
+	return err
+}
+
+// oversized returns why the commit was left out of the line stats for going
+// over the limits, empty when the error is another one.
+func oversized(err error) string {
+	var oversizedErr *oversizedError
+	if errors.As(err, &oversizedErr) {
+		return oversizedErr.reason
+	}
+
+	return ""
+}
+
+// oversizedSentence counts the oversized commits, e.g. "3 left out of the
+// line stats".
+func (s *Summary) oversizedSentence() string {
+	return fmt.Sprintf("%s left out of the line stats", FormatCount(len(s.Oversized)))
+}
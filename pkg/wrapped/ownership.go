@@ -0,0 +1,180 @@
+package wrapped
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"sort"
+	"time"
+)
+
+const (
+	// OwnershipAll counts the commits to the files over their whole history.
+	OwnershipAll = "all"
+	// OwnershipYear only counts the commits of the window.
+	OwnershipYear = "year"
+)
+
+// OwnershipWindows lists the histories ownership can be counted over.
+func OwnershipWindows() []string {
+	return []string{OwnershipAll, OwnershipYear}
+}
+
+// shownOwnedFiles is how many of the largest owned files the reports list.
+const shownOwnedFiles = 3
+
+// Ownership is how many of the files the author changed they're the top
+// committer of.
+type Ownership struct {
+	// Window is the history the commits were counted over, OwnershipAll or
+	// OwnershipYear.
+	Window string
+	// Files is the number of owned files, Touched the number of files the
+	// author changed in the window.
+	Files   int
+	Touched int
+	// Largest are the owned files with the most lines at the end of the
+	// window, most first. Files deleted by then are left out.
+	Largest []OwnedFile
+}
+
+// OwnedFile is a file the author is the top committer of.
+type OwnedFile struct {
+	Path  string
+	Lines int
+}
+
+// FindOwnership counts, for every file changed by the author in the window,
+// the commits every author made to it, over the whole history up to the end
+// of the window or only over the window. The author owns the files nobody
+// made more commits to, counting all of their emails together, so ties go
+// to the author. Only the author's files are tracked, which keeps the memory
+// bounded by them, and the commits already diffed are read from the
+// summary's change index. The owned files are then sized by their lines as
+// HEAD had them at the end of the window, in the repository holding the most.
+func FindOwnership(ctx context.Context, paths []string, selection Selection, summary *Summary, window string) (*Ownership, error) {
+	if !summary.has(fieldLineStats) {
+		return nil, errors.New("ownership needs the line stats")
+	}
+	if window == OwnershipAll {
+		selection.Window.Start = time.Time{}
+	}
+
+	touched := summary.touchedFiles()
+	commits := make(map[string]map[string]int, len(touched))
+	keep := func(*object.Commit) bool { return true }
+	err := walkTrackedChanges(ctx, paths, selection, summary.changeIndex(), touched, keep, func(_ string, commit *object.Commit, changed []string) error {
+		for _, name := range changed {
+			authors, ok := commits[name]
+			if !ok {
+				authors = make(map[string]int)
+				commits[name] = authors
+			}
+			authors[commit.Author.Email]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ownership := &Ownership{Window: window, Touched: len(touched)}
+	owned := make([]string, 0)
+	for name, authors := range commits {
+		mine, most := 0, 0
+		for email, count := range authors {
+			if selection.Authors[email] {
+				mine += count
+			} else if count > most {
+				most = count
+			}
+		}
+		if mine > 0 && mine >= most {
+			owned = append(owned, name)
+		}
+	}
+	ownership.Files = len(owned)
+
+	lines := make(map[string]int, len(owned))
+	for _, path := range paths {
+		_, repo, err := openRepo(path)
+		if err != nil {
+			continue
+		}
+		end, err := windowEnd(repo, selection.Branch, selection.Window)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find the end of the window in %s. [err=%s]", path, err.Error())
+		}
+		if end == nil {
+			continue
+		}
+		tree, err := end.Tree()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the tree of %s. [err=%s]", end.Hash, err.Error())
+		}
+		for _, name := range owned {
+			count, ok := fileLines(tree, name)
+			if ok && count > lines[name] {
+				lines[name] = count
+			}
+		}
+	}
+	for name, count := range lines {
+		ownership.Largest = append(ownership.Largest, OwnedFile{Path: name, Lines: count})
+	}
+	sort.Slice(ownership.Largest, func(i, j int) bool {
+		if ownership.Largest[i].Lines != ownership.Largest[j].Lines {
+			return ownership.Largest[i].Lines > ownership.Largest[j].Lines
+		}
+		return ownership.Largest[i].Path < ownership.Largest[j].Path
+	})
+	if len(ownership.Largest) > shownOwnedFiles {
+		ownership.Largest = ownership.Largest[:shownOwnedFiles]
+	}
+
+	return ownership, nil
+}
+
+// fileLines counts the lines of the file in the tree, ok is false when it
+// isn't in the tree or is binary.
+func fileLines(tree *object.Tree, name string) (int, bool) {
+	file, err := tree.File(name)
+	if err != nil {
+		return 0, false
+	}
+	if binary, err := file.IsBinary(); err != nil || binary {
+		return 0, false
+	}
+	contents, err := file.Contents()
+	if err != nil {
+		return 0, false
+	}
+	count := bytes.Count([]byte(contents), []byte("\n"))
+	if contents != "" && contents[len(contents)-1] != '\n' {
+		count++
+	}
+
+	return count, true
+}
+
+// sentence describes the owned files, e.g. "12 of the 40 files you changed
+// have you as their top committer".
+func (o *Ownership) sentence() string {
+	history := "over their whole history"
+	if o.Window == OwnershipYear {
+		history = "this year"
+	}
+
+	return fmt.Sprintf("%d of the %d files you changed have you as their top committer %s", o.Files, o.Touched, history)
+}
+
+// sentence describes the size of the file, e.g. "1204 lines".
+func (f OwnedFile) sentence() string {
+	if f.Lines == 1 {
+		return "1 line"
+	}
+
+	return fmt.Sprintf("%d lines", f.Lines)
+}
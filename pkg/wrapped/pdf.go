@@ -0,0 +1,303 @@
+package wrapped
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// pdfPageSizes are the page sizes of --pdf-page, keyed by their name.
+var pdfPageSizes = map[string]string{"a4": "A4", "letter": "Letter"}
+
+// PDFPageSizes returns the page sizes the PDF report can be laid out on.
+func PDFPageSizes() []string {
+	return []string{"a4", "letter"}
+}
+
+const (
+	// pdfFont is the family the Go fonts are embedded as, so the report
+	// renders the same in every viewer.
+	pdfFont = "go"
+	// pdfMargin is the margin of the page in millimeters.
+	pdfMargin = 15.0
+	// pdfRows is how many of the headline stats fit on the page next to the
+	// charts.
+	pdfRows = 18
+	// pdfRowHeight is the height of a headline stat in millimeters.
+	pdfRowHeight = 6.0
+)
+
+// pdfHeatColors are the cells of the heatmap, from no commits to the most,
+// the way heatLevels are drawn in text.
+var pdfHeatColors = [][3]int{{235, 237, 240}, {155, 233, 168}, {64, 196, 99}, {48, 161, 78}, {33, 110, 57}}
+
+// fontGlyphs reports whether the embedded Go font has a glyph for a rune,
+// parsed once on first use.
+var fontGlyphs = sync.OnceValue(func() func(rune) bool {
+	font, err := sfnt.Parse(goregular.TTF)
+	if err != nil {
+		return func(r rune) bool { return r < unicode.MaxASCII }
+	}
+	buffer := &sfnt.Buffer{}
+	return func(r rune) bool {
+		index, err := font.GlyphIndex(buffer, r)
+		return err == nil && index != 0
+	}
+})
+
+// RenderPDF lays the report out on a single page: the headline stats, a bar
+// per month and the heatmap of the window. The charts are drawn rather than
+// written with block characters, emoji are left out of the labels and any
+// other character the embedded font lacks is dropped, so nothing renders as a
+// missing glyph.
+func RenderPDF(summary *Summary, pageSize string) ([]byte, error) {
+	size, ok := pdfPageSizes[pageSize]
+	if !ok {
+		return nil, fmt.Errorf("unknown page size %q", pageSize)
+	}
+
+	pdf := fpdf.New("P", "mm", size, "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddUTF8FontFromBytes(pdfFont, "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes(pdfFont, "B", gobold.TTF)
+	pdf.SetTitle("git-wrapped "+summary.Window.String(), true)
+	pdf.SetCreator(summary.Generator, true)
+	pdf.SetCreationDate(summary.Window.End)
+	pdf.AddPage()
+	width, height := pdf.GetPageSize()
+	content := width - 2*pdfMargin
+
+	pdf.SetFont(pdfFont, "B", 22)
+	pdf.CellFormat(content, 10, "git-wrapped", "", 1, "L", false, 0, "")
+	pdf.SetFont(pdfFont, "", 11)
+	pdf.SetTextColor(90, 90, 90)
+	pdf.CellFormat(content, 6, fontText(summary.Window.String()), "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(4)
+
+	pdfHeading(pdf, content, "Headline stats")
+	labelWidth := content * 0.32
+	for i, row := range reportRows(summary) {
+		if i == pdfRows {
+			break
+		}
+		value := row.Value
+		if row.Hash != "" {
+			value = row.Hash + " " + value
+		}
+		pdf.SetFont(pdfFont, "B", 9)
+		pdf.CellFormat(labelWidth, pdfRowHeight, pdfFit(pdf, pdfLabel(row.Label), labelWidth-2), "", 0, "L", false, 0, "")
+		pdf.SetFont(pdfFont, "", 9)
+		pdf.CellFormat(content-labelWidth, pdfRowHeight, pdfFit(pdf, fontText(value), content-labelWidth), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	pdfHeading(pdf, content, "Commits per month")
+	pdfMonthChart(pdf, summary, content, 40)
+	pdf.Ln(4)
+
+	pdfHeading(pdf, content, "Activity")
+	pdfHeatmap(pdf, summary, content)
+
+	pdf.SetFont(pdfFont, "", 7)
+	pdf.SetTextColor(120, 120, 120)
+	pdf.SetXY(pdfMargin, height-pdfMargin)
+	pdf.CellFormat(content, 4, fontText(summary.Generator), "", 0, "R", false, 0, "")
+
+	output := bytes.Buffer{}
+	if err := pdf.Output(&output); err != nil {
+		return nil, err
+	}
+
+	return output.Bytes(), nil
+}
+
+// pdfHeading writes the title of a section.
+func pdfHeading(pdf *fpdf.Fpdf, width float64, title string) {
+	pdf.SetFont(pdfFont, "B", 13)
+	pdf.CellFormat(width, 8, title, "", 1, "L", false, 0, "")
+}
+
+// pdfMonthChart draws a bar per month of the window with its commits, the
+// busiest month filling the height.
+func pdfMonthChart(pdf *fpdf.Fpdf, summary *Summary, width float64, height float64) {
+	months := summary.monthlyCommits()
+	most := 1
+	for _, month := range months {
+		if month.Commits > most {
+			most = month.Commits
+		}
+	}
+
+	x, y := pdf.GetXY()
+	slot := width / float64(len(months))
+	bars := height - 10
+	pdf.SetFont(pdfFont, "", 7)
+	pdf.SetFillColor(64, 196, 99)
+	for i, month := range months {
+		left := x + float64(i)*slot
+		bar := bars * float64(month.Commits) / float64(most)
+		if bar > 0 {
+			pdf.Rect(left+slot*0.15, y+4+bars-bar, slot*0.7, bar, "F")
+		}
+		pdf.SetXY(left, y+bars-bar)
+		pdf.CellFormat(slot, 4, FormatCount(month.Commits), "", 0, "C", false, 0, "")
+		pdf.SetXY(left, y+4+bars+1)
+		pdf.CellFormat(slot, 4, month.Month.Format("Jan"), "", 0, "C", false, 0, "")
+	}
+	pdf.SetXY(x, y+height)
+}
+
+// pdfHeatmap draws the commits per day like Heatmap, one column per week
+// starting on Monday and one row per weekday.
+func pdfHeatmap(pdf *fpdf.Fpdf, summary *Summary, width float64) {
+	window := summary.Window
+	most := 0
+	for day := window.Start; day.Before(window.End); day = day.AddDate(0, 0, 1) {
+		if count := summary.CommitsOn(day); count > most {
+			most = count
+		}
+	}
+
+	offset := (int(window.Start.Weekday()) + 6) % 7
+	weeks := (offset + window.days() + 6) / 7
+	labels := 8.0
+	cell := (width - labels) / float64(weeks)
+	if cell > 4 {
+		cell = 4
+	}
+
+	x, y := pdf.GetXY()
+	pdf.SetFont(pdfFont, "", 6)
+	for i, name := range []string{"Mon", "Wed", "Fri"} {
+		pdf.SetXY(x, y+float64(2*i)*cell)
+		pdf.CellFormat(labels, cell, name, "", 0, "L", false, 0, "")
+	}
+	i := offset
+	for day := window.Start; day.Before(window.End); day = day.AddDate(0, 0, 1) {
+		color := pdfHeatColors[heatLevel(summary.CommitsOn(day), most, len(pdfHeatColors))]
+		pdf.SetFillColor(color[0], color[1], color[2])
+		pdf.Rect(x+labels+float64(i/7)*cell, y+float64(i%7)*cell, cell*0.85, cell*0.85, "F")
+		i++
+	}
+
+	pdf.SetXY(x, y+7*cell+2)
+	pdf.SetFont(pdfFont, "", 7)
+	pdf.CellFormat(width, 4, fmt.Sprintf("Lighter to darker, from no commits to the most in a day (%s)", commitCount(most)), "", 1, "L", false, 0, "")
+}
+
+// heatLevel returns which of the levels a day with count commits is drawn
+// with, 0 for none and the last for the most in a day.
+func heatLevel(count int, most int, levels int) int {
+	if count == 0 {
+		return 0
+	}
+
+	return 1 + (count-1)*(levels-2)/most
+}
+
+// monthCommits is the number of commits in a month of the window.
+type monthCommits struct {
+	Month   time.Time
+	Commits int
+}
+
+// monthlyCommits returns the commits of every month of the window.
+func (s *Summary) monthlyCommits() []monthCommits {
+	months := make([]monthCommits, 0, 12)
+	for day := s.Window.Start; day.Before(s.Window.End); day = day.AddDate(0, 0, 1) {
+		month := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		if len(months) == 0 || !months[len(months)-1].Month.Equal(month) {
+			months = append(months, monthCommits{Month: month})
+		}
+		months[len(months)-1].Commits += s.CommitsOn(day)
+	}
+
+	return months
+}
+
+// pdfLabel returns the label without its emoji, e.g. "Total commits" for
+// "🧮 Total commits".
+func pdfLabel(label string) string {
+	if first, rest, found := strings.Cut(label, " "); found && !pdfPlain(first) {
+		label = rest
+	}
+
+	return fontText(label)
+}
+
+// pdfPlain reports whether the text is made of letters, digits and
+// punctuation only.
+func pdfPlain(text string) bool {
+	for _, r := range text {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsPunct(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fontText drops the characters the embedded Go font has no glyph for, and
+// the words only made of the block characters of the text charts, which the
+// PDF and the cards draw instead.
+func fontText(text string) string {
+	has := fontGlyphs()
+	words := make([]string, 0)
+	for _, word := range strings.Fields(text) {
+		if isTextChart(word) {
+			continue
+		}
+		kept := strings.Map(func(r rune) rune {
+			if has(r) {
+				return r
+			}
+			return -1
+		}, word)
+		if kept != "" {
+			words = append(words, kept)
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// isTextChart reports whether the word is a sparkline or a row of the
+// heatmap.
+func isTextChart(word string) bool {
+	for _, r := range word {
+		if !strings.ContainsRune(strings.Join(sparkLevels, "")+strings.Join(heatLevels, "")+strings.Join(shrinkLevels, ""), r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// pdfFit shortens the text with an ellipsis until it fits the width.
+func pdfFit(pdf *fpdf.Fpdf, text string, width float64) string {
+	return fitText(text, width, pdf.GetStringWidth)
+}
+
+// fitText shortens the text with an ellipsis until its measure fits the
+// width.
+func fitText(text string, width float64, measure func(string) float64) string {
+	if measure(text) <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 && measure(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+
+	return strings.TrimSpace(string(runes)) + "…"
+}
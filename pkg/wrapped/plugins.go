@@ -0,0 +1,163 @@
+package wrapped
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StatLine is a stat a plugin adds to the report.
+type StatLine struct {
+	Label string
+	Value string
+}
+
+// CommitStats are the line stats of a commit observed by a plugin.
+type CommitStats struct {
+	Merge bool
+	// HasLineStats is false when the commit's line stats weren't computed,
+	// see ListedCommit.
+	HasLineStats bool
+	Additions    int64
+	Deletions    int64
+	Files        []FileChange
+}
+
+// StatPlugin computes a custom stat. It observes every matched commit in
+// chronological order, then returns the lines it adds to the report.
+type StatPlugin interface {
+	ObserveCommit(commit *Commit, stats CommitStats)
+	Finalize() []StatLine
+}
+
+// RunPlugins feeds the listed commits to every plugin, then adds their stat
+// lines to the summary in the order of the plugins. The commits must have
+// been collected with Options.ListCommits.
+func (s *Summary) RunPlugins(plugins []StatPlugin) error {
+	if len(plugins) == 0 {
+		return nil
+	}
+	if !s.has(fieldCommitList) {
+		return errors.New("stat plugins need the commits collected with Options.ListCommits")
+	}
+
+	for i := range s.Commits {
+		listed := &s.Commits[i]
+		commit := &Commit{
+			Hash:    listed.Hash,
+			Author:  Signature{Name: listed.Name, Email: listed.Email, When: listed.When},
+			Message: listed.Message,
+		}
+		stats := CommitStats{
+			Merge:        listed.Merge,
+			HasLineStats: listed.HasLineStats,
+			Additions:    listed.Additions,
+			Deletions:    listed.Deletions,
+			Files:        listed.Files,
+		}
+		for _, plugin := range plugins {
+			plugin.ObserveCommit(commit, stats)
+		}
+	}
+	for _, plugin := range plugins {
+		s.StatLines = append(s.StatLines, plugin.Finalize()...)
+	}
+
+	return nil
+}
+
+// builtinStats are the plugins shipped with the tool, created from the
+// argument following their name, e.g. tickets=PAY-.
+var builtinStats = map[string]func(arg string) (StatPlugin, error){
+	"tickets": newTicketsStat,
+	"weekend": func(arg string) (StatPlugin, error) {
+		if arg != "" {
+			return nil, fmt.Errorf("the weekend stat takes no argument, got %q", arg)
+		}
+		return &weekendStat{}, nil
+	},
+}
+
+// BuiltinStats returns the names of the built-in plugins, sorted.
+func BuiltinStats() []string {
+	names := make([]string, 0, len(builtinStats))
+	for name := range builtinStats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// NewBuiltinStat creates the built-in plugin described by spec, its name
+// optionally followed by = and its argument.
+func NewBuiltinStat(spec string) (StatPlugin, error) {
+	name, arg, _ := strings.Cut(spec, "=")
+	create, ok := builtinStats[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown stat %q, expected one of %s", name, strings.Join(BuiltinStats(), ", "))
+	}
+
+	return create(arg)
+}
+
+// ticketsStat counts the commits whose message mentions a ticket with the
+// prefix, e.g. PAY-123, the way CountTickets does.
+type ticketsStat struct {
+	prefix  string
+	counter *ticketCounter
+}
+
+func newTicketsStat(prefix string) (StatPlugin, error) {
+	if prefix == "" {
+		return nil, errors.New("the tickets stat needs the ticket prefix, e.g. tickets=PAY-")
+	}
+
+	pattern := regexp.MustCompile(`\b` + regexp.QuoteMeta(prefix) + `\d+\b`)
+	return &ticketsStat{prefix: prefix, counter: newTicketCounter([]*regexp.Regexp{pattern})}, nil
+}
+
+func (t *ticketsStat) ObserveCommit(commit *Commit, _ CommitStats) {
+	t.counter.observe(commit.Message)
+}
+
+func (t *ticketsStat) Finalize() []StatLine {
+	tickets := t.counter.finish()
+	mentioning := tickets.Commits - tickets.Ticketless
+	return []StatLine{{
+		Label: "🎫 Commits mentioning " + t.prefix,
+		Value: fmt.Sprintf("%d (%.1f%%), %d distinct tickets", mentioning, roundHalfUp(percent(mentioning, tickets.Commits), 1), len(tickets.Tickets)),
+	}}
+}
+
+// weekendStat counts the commits made on Saturdays and Sundays, in the
+// window's time zone.
+type weekendStat struct {
+	commits int
+	total   int
+}
+
+func (w *weekendStat) ObserveCommit(commit *Commit, _ CommitStats) {
+	w.total++
+	if day := commit.Author.When.Weekday(); day == time.Saturday || day == time.Sunday {
+		w.commits++
+	}
+}
+
+func (w *weekendStat) Finalize() []StatLine {
+	return []StatLine{{
+		Label: "🏖️ Weekend commits",
+		Value: fmt.Sprintf("%d (%.1f%%)", w.commits, roundHalfUp(percent(w.commits, w.total), 1)),
+	}}
+}
+
+func percent(part int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(part) * 100 / float64(total)
+}
@@ -0,0 +1,148 @@
+package wrapped
+
+import (
+	"fmt"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Timings accumulates how long each phase of a run took, summed over
+// every repository analyzed. A nil *Timings is valid and records nothing,
+// so the hooks cost nothing unless --timings is passed.
+type Timings struct {
+	mu      sync.Mutex
+	order   []string
+	phases  map[string]time.Duration
+	commits int64
+	// tuning are the effective settings of the stats workers, and the peak
+	// of the bytes diffed at once.
+	jobs      int
+	parallel  int
+	maxMemory int64
+	peak      int64
+	// objects are the object caches of the last repository, and the hits
+	// and misses of every repository's. objectSharedHits are the hits a
+	// cache per handle would have missed.
+	objectCacheSize  int64
+	objectHits       int64
+	objectMisses     int64
+	objectSharedHits int64
+}
+
+func NewTimings(enabled bool) *Timings {
+	if !enabled {
+		return nil
+	}
+
+	return &Timings{phases: make(map[string]time.Duration)}
+}
+
+// Start begins timing a phase, returning the function that ends it.
+func (t *Timings) Start(phase string) func() {
+	if t == nil {
+		return func() {}
+	}
+
+	began := time.Now()
+	return func() {
+		elapsed := time.Since(began)
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if _, ok := t.phases[phase]; !ok {
+			t.order = append(t.order, phase)
+		}
+		t.phases[phase] += elapsed
+	}
+}
+
+// analyzed records how many commits had their stats computed, which is used
+// to report throughput.
+func (t *Timings) analyzed(commits int64) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.commits += commits
+}
+
+// tuned records the settings the stats workers ran with and the most bytes
+// they diffed at once, keeping the largest of every run.
+func (t *Timings) tuned(jobs int, parallel int, maxMemory int64, peak int64) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.jobs, t.maxMemory = max(jobs, 1), maxMemory
+	t.parallel = max(t.parallel, parallel)
+	t.peak = max(t.peak, peak)
+}
+
+// objectsCached records how often the object caches of a repository had the
+// objects read.
+func (t *Timings) objectsCached(objects *objectCaches) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.objectCacheSize = objects.size
+	t.objectHits += atomic.LoadInt64(&objects.hits)
+	t.objectMisses += atomic.LoadInt64(&objects.misses)
+	t.objectSharedHits += atomic.LoadInt64(&objects.sharedHits)
+}
+
+// Report writes the phase breakdown to out. Enumerating commits and computing
+// their stats run concurrently, so those two phases overlap.
+func (t *Timings) Report(out io.Writer) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fmt.Fprintln(out, "Timings:")
+	for _, phase := range t.order {
+		elapsed := t.phases[phase]
+		line := fmt.Sprintf("  %-18s %10s", phase, elapsed.Round(time.Microsecond))
+		if phase == phaseStats && elapsed > 0 {
+			line += fmt.Sprintf("  (%s commits, %.1f commits/s)", FormatCount(int(t.commits)), float64(t.commits)/elapsed.Seconds())
+		}
+		fmt.Fprintln(out, line)
+	}
+	if t.jobs > 0 {
+		maxMemory := "unbounded"
+		if t.maxMemory > 0 {
+			maxMemory = FormatBytes(t.maxMemory)
+		}
+		fmt.Fprintf(out, "  workers            %d per repository, %d repositories at once, max memory %s, peak in flight %s\n", t.jobs, t.parallel, maxMemory, FormatBytes(t.peak))
+	}
+	if reads := t.objectHits + t.objectMisses; reads > 0 {
+		if t.objectCacheSize == 0 {
+			fmt.Fprintf(out, "  object cache       %s per handle, %s hits, %s misses (%.1f%% hits)\n", FormatBytes(int64(cache.DefaultMaxSize)),
+				FormatCount(int(t.objectHits)), FormatCount(int(t.objectMisses)), 100*float64(t.objectHits)/float64(reads))
+		} else {
+			// The hits on objects another handle read are the difference
+			// sharing the cache makes, they'd be misses with one per handle.
+			fmt.Fprintf(out, "  object cache       %s shared, %s hits, %s misses (%.1f%% hits, %.1f%% with a cache per handle, %s hits saved by sharing)\n", FormatBytes(t.objectCacheSize),
+				FormatCount(int(t.objectHits)), FormatCount(int(t.objectMisses)), 100*float64(t.objectHits)/float64(reads),
+				100*float64(t.objectHits-t.objectSharedHits)/float64(reads), FormatCount(int(t.objectSharedHits)))
+		}
+	}
+}
+
+const (
+	phaseOpen      = "open repository"
+	phaseEnumerate = "enumerate commits"
+	phaseStats     = "compute stats"
+	PhaseRender    = "render"
+)
@@ -0,0 +1,214 @@
+package wrapped
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	progressBarWidth = 30
+	// interactiveInterval throttles redraws of the progress bar.
+	interactiveInterval = 100 * time.Millisecond
+	// plainInterval spaces out progress lines when stderr isn't a terminal.
+	plainInterval = 10 * time.Second
+)
+
+// progressReporter writes analysis progress to stderr, drawing a progress bar
+// when stderr is a terminal and occasional plain lines otherwise. A nil
+// reporter is valid and reports nothing, which is how --quiet is implemented.
+//
+// Commits are found by the history walk while earlier ones are still being
+// analyzed, so the total keeps growing until the walk has finished.
+type progressReporter struct {
+	mu          sync.Mutex
+	out         io.Writer
+	label       string
+	interactive bool
+	total       int
+	complete    bool
+	done        int
+	start       time.Time
+	lastReport  time.Time
+}
+
+// newProgressReporter creates a reporter writing to out. Labelled reporters
+// prefix every line with the label and never draw a progress bar, since
+// several of them report at the same time when analyzing many repositories.
+func newProgressReporter(out *os.File, quiet bool, label string) *progressReporter {
+	if quiet {
+		return nil
+	}
+
+	now := time.Now()
+	return &progressReporter{
+		out:         out,
+		label:       label,
+		interactive: label == "" && isTerminal(out),
+		start:       now,
+		lastReport:  now,
+	}
+}
+
+// isTerminal reports whether the file is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// found records that one more matching commit was found.
+func (p *progressReporter) found() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total++
+}
+
+// enumerated announces that every matching commit has been found.
+func (p *progressReporter) enumerated() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.complete = true
+	p.clearLine()
+	fmt.Fprintf(p.out, "%sFound %s\n", p.prefix(), FormatCountOf(p.total, "matching commit", "matching commits"))
+}
+
+// step records that one more commit has been analyzed.
+func (p *progressReporter) step() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done++
+	now := time.Now()
+	interval := plainInterval
+	if p.interactive {
+		interval = interactiveInterval
+	}
+	if now.Sub(p.lastReport) < interval && !(p.complete && p.done == p.total) {
+		return
+	}
+	p.lastReport = now
+
+	if p.interactive {
+		fmt.Fprintf(p.out, "\r%s %s", p.bar(), p.status(now))
+	} else {
+		fmt.Fprintf(p.out, "%sProcessed %s\n", p.prefix(), p.status(now))
+	}
+}
+
+// finish ends the progress output, leaving the terminal on a fresh line.
+func (p *progressReporter) finish() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clearLine()
+}
+
+// result reports the outcome of a labelled analysis, so every repository ends
+// with a status line.
+func (p *progressReporter) result(summary *Summary, err error) {
+	if p == nil || p.label == "" {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		fmt.Fprintf(p.out, "%sFailed: %s\n", p.prefix(), err.Error())
+		return
+	}
+	fmt.Fprintf(p.out, "%sDone, %s commits analyzed\n", p.prefix(), FormatCount(int(summary.TotalCommits)))
+}
+
+func (p *progressReporter) prefix() string {
+	if p.label == "" {
+		return ""
+	}
+	return "[" + p.label + "] "
+}
+
+// clearLine erases the progress bar so other output starts on a clean line.
+func (p *progressReporter) clearLine() {
+	if p.interactive && p.done > 0 {
+		fmt.Fprintf(p.out, "\r%s\r", strings.Repeat(" ", progressBarWidth+60))
+	}
+}
+
+func (p *progressReporter) bar() string {
+	filled := 0
+	if p.complete && p.total > 0 {
+		filled = p.done * progressBarWidth / p.total
+	}
+
+	return "[" + strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled) + "]"
+}
+
+func (p *progressReporter) status(now time.Time) string {
+	if !p.complete {
+		return fmt.Sprintf("%s commits (%s found so far)", FormatCount(p.done), FormatCount(p.total))
+	}
+
+	status := fmt.Sprintf("%s/%s commits", FormatCount(p.done), FormatCount(p.total))
+	if p.done > 0 && p.done < p.total {
+		elapsed := now.Sub(p.start)
+		eta := elapsed / time.Duration(p.done) * time.Duration(p.total-p.done)
+		status += fmt.Sprintf(" (ETA %s)", eta.Round(time.Second))
+	}
+
+	return status
+}
+
+// FormatCount renders n with thousands separators, e.g. 8431 as "8,431".
+func FormatCount(n int) string {
+	if n < 0 {
+		return "-" + FormatCount(-n)
+	}
+	digits := strconv.Itoa(n)
+
+	builder := strings.Builder{}
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteRune(digit)
+	}
+
+	return builder.String()
+}
+
+// FormatCountOf renders n like FormatCount followed by the noun agreeing with
+// it, e.g. "1 commit" or "1,234 commits".
+func FormatCountOf(n int, singular string, plural string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+
+	return FormatCount(n) + " " + plural
+}
+
+// commitCount returns the number of commits with the noun agreeing.
+func commitCount(commits int) string {
+	return FormatCountOf(commits, "commit", "commits")
+}
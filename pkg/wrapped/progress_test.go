@@ -0,0 +1,53 @@
+package wrapped
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFormatCount(t *testing.T) {
+	for n, want := range map[int]string{0: "0", 7: "7", 999: "999", 1000: "1,000", 8431: "8,431", 1234567: "1,234,567", -1234: "-1,234"} {
+		if got := FormatCount(n); got != want {
+			t.Errorf("FormatCount(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestCountNouns(t *testing.T) {
+	tests := []struct {
+		name string
+		got  func(commits int) string
+		want []string
+	}{
+		{name: "count of", got: func(authors int) string {
+			return FormatCountOf(authors, "author", "authors")
+		}, want: []string{"0 authors", "1 author", "2 authors", "1,234 authors"}},
+		{name: "commits", got: commitCount, want: []string{"0 commits", "1 commit", "2 commits", "1,234 commits"}},
+		{name: "progress", got: func(commits int) string {
+			out := &bytes.Buffer{}
+			reporter := &progressReporter{out: out}
+			for i := 0; i < commits; i++ {
+				reporter.found()
+			}
+			reporter.enumerated()
+			return out.String()
+		}, want: []string{"Found 0 matching commits\n", "Found 1 matching commit\n", "Found 2 matching commits\n", "Found 1,234 matching commits\n"}},
+		{name: "co-authored", got: func(commits int) string {
+			return (&CoAuthored{Commits: commits}).sentence()
+		}, want: []string{
+			"0 more commits credited through Co-authored-by trailers, not counted in the other stats",
+			"1 more commit credited through Co-authored-by trailers, not counted in the other stats",
+			"2 more commits credited through Co-authored-by trailers, not counted in the other stats",
+			"1,234 more commits credited through Co-authored-by trailers, not counted in the other stats",
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i, n := range []int{0, 1, 2, 1234} {
+				if got := test.got(n); got != test.want[i] {
+					t.Errorf("%d = %q, want %q", n, got, test.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package wrapped
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"sort"
+	"time"
+)
+
+// ReleaseStats are the releases tagged in the window carrying the author's
+// commits.
+type ReleaseStats struct {
+	// Tags is the number of tags created in the window.
+	Tags int
+	// Releases are the tags shipping any of the author's commits, in the
+	// order they were created.
+	Releases []Release
+}
+
+// Release is a tag and the number of the author's commits it shipped first.
+type Release struct {
+	Tag     string
+	When    time.Time
+	Commits int
+}
+
+// releaseTag is a tag of a repository with the commit it points at.
+type releaseTag struct {
+	name   string
+	when   time.Time
+	commit *object.Commit
+}
+
+// FindReleases credits every listed commit to the first tag created in the
+// window that reaches it, going through the tags in the order they were
+// created, by the tagger date of annotated tags and the committer date of
+// the commit of lightweight ones. A commit is credited to a tag when the tag
+// reaches it and no earlier one does, so the ancestors of every tag are only
+// walked down to the commits an earlier tag already reached, which keeps the
+// walk linear in the history of the window. The walk stops at commits older
+// than the window, which can't be the author's. The commits must have been
+// collected with Options.ListCommits.
+func FindReleases(ctx context.Context, paths []string, summary *Summary) (*ReleaseStats, error) {
+	if !summary.has(fieldCommitList) {
+		return nil, errors.New("releases need the commits collected with Options.ListCommits")
+	}
+
+	mine := make(map[plumbing.Hash]bool, len(summary.Commits))
+	for _, commit := range summary.Commits {
+		mine[plumbing.NewHash(commit.Hash)] = true
+	}
+
+	stats := &ReleaseStats{Releases: make([]Release, 0)}
+	for _, path := range paths {
+		_, repo, err := openRepo(path)
+		if err != nil {
+			continue
+		}
+		tags, err := windowTags(repo, summary.Window)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the tags of %s. [err=%s]", path, err.Error())
+		}
+		stats.Tags += len(tags)
+
+		shallows, err := shallowCommits(repo)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the shallow commits of %s. [err=%s]", path, err.Error())
+		}
+
+		reached := make(map[plumbing.Hash]bool)
+		horizon := summary.Window.Start.Add(-walkSlack)
+		for _, tag := range tags {
+			commits, err := creditTag(ctx, repo, tag.commit, reached, mine, shallows, horizon)
+			if err != nil {
+				return nil, fmt.Errorf("unable to walk the tag %s of %s. [err=%s]", tag.name, path, err.Error())
+			}
+			if commits > 0 {
+				stats.Releases = append(stats.Releases, Release{Tag: tag.name, When: tag.when, Commits: commits})
+			}
+		}
+	}
+	sort.SliceStable(stats.Releases, func(i, j int) bool {
+		return stats.Releases[i].When.Before(stats.Releases[j].When)
+	})
+
+	return stats, nil
+}
+
+// windowTags returns the tags of the repository created in the window, in
+// the order they were created.
+func windowTags(repo *git.Repository, window AnalysisWindow) ([]releaseTag, error) {
+	refs, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+	defer refs.Close()
+
+	tags := make([]releaseTag, 0)
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		commit, err := peelToCommit(repo, ref.Hash())
+		if err != nil || commit == nil {
+			return err
+		}
+		when := commit.Committer.When
+		if tag, err := repo.TagObject(ref.Hash()); err == nil {
+			when = tag.Tagger.When
+		}
+		if window.contains(when) {
+			tags = append(tags, releaseTag{name: ref.Name().Short(), when: when, commit: commit})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if !tags[i].when.Equal(tags[j].when) {
+			return tags[i].when.Before(tags[j].when)
+		}
+		return tags[i].name < tags[j].name
+	})
+
+	return tags, nil
+}
+
+// creditTag walks the ancestors of the tag's commit not reached yet, marking
+// them reached, and returns how many of them are the author's. The parents of
+// the shallow commits weren't fetched, the walk stops at them.
+func creditTag(ctx context.Context, repo *git.Repository, tip *object.Commit, reached map[plumbing.Hash]bool, mine map[plumbing.Hash]bool, shallows map[plumbing.Hash]bool, horizon time.Time) (int, error) {
+	if reached[tip.Hash] {
+		return 0, nil
+	}
+	reached[tip.Hash] = true
+
+	commits := 0
+	queue := []*object.Commit{tip}
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		commit := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if mine[commit.Hash] {
+			commits++
+		}
+		if commit.Committer.When.Before(horizon) || shallows[commit.Hash] {
+			continue
+		}
+
+		for _, parentHash := range commit.ParentHashes {
+			if reached[parentHash] {
+				continue
+			}
+			reached[parentHash] = true
+			// The commits that can't be read were reported by the analysis.
+			parent, err := repo.CommitObject(parentHash)
+			if err != nil {
+				continue
+			}
+			queue = append(queue, parent)
+		}
+	}
+
+	return commits, nil
+}
+
+// biggest returns the release shipping the most of the author's commits, the
+// earliest on ties.
+func (r *ReleaseStats) biggest() Release {
+	biggest := r.Releases[0]
+	for _, release := range r.Releases[1:] {
+		if release.Commits > biggest.Commits {
+			biggest = release
+		}
+	}
+
+	return biggest
+}
+
+// sentence describes the releases, e.g. "Your work shipped in 9 releases,
+// the biggest being v3.2.0 (84 of your commits)".
+func (r *ReleaseStats) sentence() string {
+	if len(r.Releases) == 0 {
+		return fmt.Sprintf("None of the %d releases of the year shipped your commits yet", r.Tags)
+	}
+
+	releases := "releases"
+	if len(r.Releases) == 1 {
+		releases = "release"
+	}
+	biggest := r.biggest()
+
+	return fmt.Sprintf("Your work shipped in %d %s, the biggest being %s (%d of your commits)", len(r.Releases), releases, biggest.Tag, biggest.Commits)
+}
@@ -0,0 +1,225 @@
+package wrapped
+
+import (
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SectionRepos is the ranked list of the repositories with the most commits.
+const SectionRepos = "repos"
+
+// RepoActivity is the activity of the author in a single repository of the
+// summary.
+type RepoActivity struct {
+	// Name is the name of the origin remote, like acme/api, or else the
+	// directory of the repository. Repositories with the same name have the
+	// end of their path appended, e.g. api (work/api).
+	Name string
+	// Path is the path the repository was analyzed at.
+	Path    string
+	Commits int64
+	// Additions and Deletions are only counted with line stats.
+	Additions  int64
+	Deletions  int64
+	ActiveDays int
+	// BusiestDay is the day with the most commits to the repository,
+	// BusiestCommits of them.
+	BusiestDay     time.Time
+	BusiestCommits int
+
+	// dirs are the directories of the repository's root, outermost first,
+	// which tell repositories with the same name apart.
+	dirs []string
+}
+
+// MergeRepo merges the summary of the repository analyzed at path, like
+// Merge, and keeps its activity apart in Repos. Repositories without commits
+// are left out.
+func (s *Summary) MergeRepo(path string, other *Summary) {
+	s.Merge(other)
+	if other.TotalCommits == 0 {
+		return
+	}
+
+	root := path
+	name := ""
+	if found, repo, err := openRepo(path); err == nil {
+		root = found
+		name = remoteName(repo)
+	}
+	root, _ = filepath.Abs(root)
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(root), ".git")
+	}
+
+	activity := RepoActivity{
+		Name:       name,
+		Path:       path,
+		Commits:    other.TotalCommits,
+		Additions:  other.TotalAdditions(),
+		Deletions:  other.TotalDeletions(),
+		ActiveDays: other.ActiveDays(),
+		dirs:       strings.Split(strings.Trim(filepath.ToSlash(root), "/"), "/"),
+	}
+	if busiest := other.mostActiveDay(); busiest != nil {
+		activity.BusiestDay = busiest.First
+		activity.BusiestCommits = busiest.Count
+	}
+	s.Repos = append(s.Repos, activity)
+}
+
+// remoteName returns the humanized name of the origin remote, its path
+// without the host and the .git suffix, e.g. acme/api for
+// git@github.com:acme/api.git. It's empty without an origin remote.
+func remoteName(repo *git.Repository) string {
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	if err != nil || len(remote.Config().URLs) == 0 {
+		return ""
+	}
+
+	address := remote.Config().URLs[0]
+	var path string
+	if parsed, err := url.Parse(address); err == nil && parsed.Scheme != "" {
+		path = parsed.Path
+	} else if _, scpPath, found := strings.Cut(address, ":"); found {
+		// An scp-like address, e.g. git@github.com:acme/api.git.
+		path = scpPath
+	} else {
+		// A clone of a local repository has no owner to keep.
+		return strings.TrimSuffix(filepath.Base(address), ".git")
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+
+	// Forges nest repositories under their owner, keep both of them.
+	parts := strings.Split(path, "/")
+	if len(parts) > 2 {
+		parts = parts[len(parts)-2:]
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// finishRepos sorts the repositories by their commits, most first, and
+// appends the shortest end of their root telling them apart to repositories
+// sharing a name.
+func (s *Summary) finishRepos() {
+	sort.SliceStable(s.Repos, func(i, j int) bool {
+		if s.Repos[i].Commits != s.Repos[j].Commits {
+			return s.Repos[i].Commits > s.Repos[j].Commits
+		}
+		return s.Repos[i].Path < s.Repos[j].Path
+	})
+
+	byName := make(map[string][]int)
+	for i, repo := range s.Repos {
+		byName[repo.Name] = append(byName[repo.Name], i)
+	}
+	for name, indexes := range byName {
+		if len(indexes) < 2 {
+			continue
+		}
+		suffixes := make([]string, len(indexes))
+		for k, i := range indexes {
+			suffixes[k] = s.Repos[i].uniqueSuffix(s.Repos, indexes)
+		}
+		for k, i := range indexes {
+			s.Repos[i].Name = fmt.Sprintf("%s (%s)", name, suffixes[k])
+		}
+	}
+}
+
+// uniqueSuffix returns the shortest end of the repository's root that none of
+// the other repositories share, or the whole root when they all do.
+func (r RepoActivity) uniqueSuffix(repos []RepoActivity, indexes []int) string {
+	for depth := 1; depth < len(r.dirs); depth++ {
+		suffix := r.suffix(depth)
+		unique := true
+		for _, i := range indexes {
+			if repos[i].Path != r.Path && repos[i].suffix(depth) == suffix {
+				unique = false
+				break
+			}
+		}
+		if unique {
+			return suffix
+		}
+	}
+
+	return r.suffix(len(r.dirs))
+}
+
+// suffix returns the last depth directories of the repository's root.
+func (r RepoActivity) suffix(depth int) string {
+	if depth > len(r.dirs) {
+		depth = len(r.dirs)
+	}
+
+	return strings.Join(r.dirs[len(r.dirs)-depth:], "/")
+}
+
+// shownRepos returns the repositories the report lists, none when only one
+// of them has commits.
+func shownRepos(summary *Summary, opts RenderOptions) []RepoActivity {
+	top := opts.Limit(SectionRepos)
+	if len(summary.Repos) < 2 || top <= 0 {
+		return nil
+	}
+	if len(summary.Repos) > top {
+		return summary.Repos[:top]
+	}
+
+	return summary.Repos
+}
+
+// homeSentence calls out the repository with the most commits, e.g. "Most
+// of your year lived in acme/api (62% of your commits)". It's empty unless
+// the commits span several repositories.
+func (s *Summary) homeSentence() string {
+	if len(s.Repos) < 2 {
+		return ""
+	}
+
+	home := s.Repos[0]
+	share := roundHalfUp(percent(int(home.Commits), int(s.TotalCommits)), 0)
+	if home.Commits*2 > s.TotalCommits {
+		return fmt.Sprintf("Most of your year lived in %s (%.0f%% of your commits)", home.Name, share)
+	}
+
+	return fmt.Sprintf("Your busiest repository was %s (%.0f%% of your commits)", home.Name, share)
+}
+
+// sentence describes the activity in the repository, e.g. "120 commits,
+// +3,400/-1,200, 80 active days, busiest on Mar 3 (9 commits)".
+func (r RepoActivity) sentence(lineStats bool) string {
+	parts := []string{commitCount(int(r.Commits))}
+	if lineStats {
+		parts = append(parts, r.lines(lineStats))
+	}
+	days := "active days"
+	if r.ActiveDays == 1 {
+		days = "active day"
+	}
+	parts = append(parts, fmt.Sprintf("%d %s", r.ActiveDays, days), "busiest on "+r.busiest())
+
+	return strings.Join(parts, ", ")
+}
+
+// lines returns the lines added and deleted in the repository, e.g.
+// +3,400/-1,200, or n/a without line stats.
+func (r RepoActivity) lines(lineStats bool) string {
+	if !lineStats {
+		return "n/a"
+	}
+
+	return fmt.Sprintf("+%s/-%s", FormatCount(int(r.Additions)), FormatCount(int(r.Deletions)))
+}
+
+// busiest returns the busiest day of the repository, e.g. Mar 3 (9 commits).
+func (r RepoActivity) busiest() string {
+	return fmt.Sprintf("%s (%s)", r.BusiestDay.Format(yearDayLayout), commitCount(r.BusiestCommits))
+}
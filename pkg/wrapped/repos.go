@@ -0,0 +1,403 @@
+package wrapped
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// Options are the settings shared by the analysis of every repository.
+type Options struct {
+	// Selection decides which commits are analyzed.
+	Selection Selection
+	Jobs      int
+	// Fast computes none of the capabilities, leaving every line based stat
+	// out.
+	Fast bool
+	// Capabilities are computed on top of the change records unless Fast,
+	// see Capability and StatSet.Needs.
+	Capabilities Capability
+	Filter       PathFilter
+	// Limits bound the line stats of every commit, see CommitLimits.
+	Limits CommitLimits
+	// MaxMemory bounds the bytes of the files diffed at once, across every
+	// repository, estimated by their size. Workers wait for the diffs in
+	// flight to finish before going over it. 0 is no bound.
+	MaxMemory int64
+	// ObjectCacheSize is the bytes of the object cache shared by every handle
+	// the analysis opens on a repository, one per stats worker. 0 leaves
+	// every handle go-git's default cache.
+	ObjectCacheSize int64
+	// CountUnreachable counts the matching commits no ref reaches into
+	// Summary.UnreachableSkipped, which scans the whole object store next to
+	// the analysis. It's left out in fast mode.
+	CountUnreachable bool
+	// CacheDir is where commit stats are cached, empty when caching is off.
+	CacheDir string
+	Quiet    bool
+	Timings  *Timings
+	Strict   bool
+	// MergeStats is how merge commits count towards the line stats, either
+	// MergeStatsNone or MergeStatsFirstParent.
+	MergeStats string
+	// Sample is the number of commits the line stats are computed for when
+	// more commits would need them, the others' estimated from them, see
+	// Sampling. 0 computes them for every commit.
+	Sample int
+	// SampleSeed picks the Sample commits at random, the same commits for
+	// the same seed, rather than evenly spaced in time.
+	SampleSeed string
+	// ListCommits collects every matched commit into Summary.Commits, for
+	// listings and exports.
+	ListCommits bool
+	// Overrides replace settings for single repositories, keyed by the path
+	// they're analyzed at.
+	Overrides map[string]RepoOverride
+	// Logger receives the diagnostics of the analysis, nil logs nothing.
+	Logger Logger
+	// CommitLog records every analyzed commit when set, see CommitLog.
+	CommitLog *CommitLog
+	// State picks up where the last run left every repository when set, see
+	// State. It's left unused for a Sample, a Range of the selection and
+	// the unreachable commits, which aren't walked from the refs.
+	State *State
+	// diff is how the commits are diffed, commitChanges when nil.
+	diff differ
+}
+
+// capabilities returns what the analysis computes.
+func (o Options) capabilities() Capability {
+	if o.Fast {
+		return 0
+	}
+
+	return o.Capabilities | CapChanges
+}
+
+// RepoOverride replaces the authors or the path filter of a single
+// repository, leaving the shared settings in place when nil.
+type RepoOverride struct {
+	Authors map[string]bool
+	Filter  *PathFilter
+}
+
+// Repo is a repository opened for analysis.
+type Repo struct {
+	path string
+	root string
+}
+
+// Open opens the repository containing path, which can be any directory
+// of its work tree. A *RepoOpenError is returned when there is none.
+func Open(path string) (*Repo, error) {
+	root, _, err := openRepo(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Repo{path: path, root: root}, nil
+}
+
+// Root returns the top directory of the repository.
+func (r *Repo) Root() string {
+	return r.root
+}
+
+// Analyze computes the summary of the commits picked by opts.Selection.
+// Progress is reported on stderr unless opts.Quiet is set.
+func (r *Repo) Analyze(ctx context.Context, opts Options) (*Summary, error) {
+	samples, err := sampleRepos(ctx, []string{r.path}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	budget := newMemoryBudget(opts.MaxMemory)
+	result := analyzeRepo(ctx, r.path, opts, samples[0], budget, newChangeIndex(opts), false)
+	opts.Timings.tuned(opts.Jobs, 1, opts.MaxMemory, budget.peakInFlight())
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	result.Summary.Selection = opts.Selection
+
+	return result.Summary, nil
+}
+
+// RepoResult is the outcome of analyzing a single repository.
+type RepoResult struct {
+	Path    string
+	Summary *Summary
+	Err     error
+}
+
+// repoParallelism returns how many repositories are analyzed at once. Every
+// repository already runs jobs stats workers, so only as many repositories run
+// together as it takes to keep GOMAXPROCS busy.
+func repoParallelism(jobs int, repos int) int {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	parallel := runtime.GOMAXPROCS(0) / jobs
+	if parallel < 1 {
+		parallel = 1
+	}
+	if parallel > repos {
+		parallel = repos
+	}
+
+	return parallel
+}
+
+// AnalyzeRepos analyzes every repository with a bounded pool, returning the
+// results in the same order as paths no matter which finished first. A
+// failing repository doesn't stop the others, its error is kept in its result.
+func AnalyzeRepos(ctx context.Context, paths []string, opts Options) []RepoResult {
+	results := make([]RepoResult, len(paths))
+	samples, err := sampleRepos(ctx, paths, opts)
+	if err != nil {
+		for i, path := range paths {
+			results[i] = RepoResult{Path: path, Err: err}
+		}
+		return results
+	}
+	indexes := make(chan int)
+	budget := newMemoryBudget(opts.MaxMemory)
+	changes := newChangeIndex(opts)
+
+	parallel := repoParallelism(opts.Jobs, len(paths))
+	wg := sync.WaitGroup{}
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				results[index] = analyzeRepo(ctx, paths[index], opts, samples[index], budget, changes, len(paths) > 1)
+			}
+		}()
+	}
+
+	for i := range paths {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	opts.Timings.tuned(opts.Jobs, parallel, opts.MaxMemory, budget.peakInFlight())
+
+	return results
+}
+
+// sampleRepos returns the commits of the opts.Sample of every repository, all
+// nil without sampling.
+func sampleRepos(ctx context.Context, paths []string, opts Options) ([]map[plumbing.Hash]bool, error) {
+	if opts.Sample <= 0 || opts.Fast {
+		return make([]map[plumbing.Hash]bool, len(paths)), nil
+	}
+
+	samples, err := sampleCommits(ctx, paths, opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sample the commits. [err=%s]", err.Error())
+	}
+
+	return samples, nil
+}
+
+// analyzeRepo opens and analyzes a single repository, computing the line
+// stats of the sample's commits only when it's set, the diffs sharing the
+// budget with the other repositories analyzed at once, and their change
+// records the index when it's kept. When labelled, progress
+// lines are prefixed with the path so they can be told apart from the other
+// repositories being analyzed at the same time.
+func analyzeRepo(ctx context.Context, path string, opts Options, sample map[plumbing.Hash]bool, budget *memoryBudget, changes *changeIndex, labelled bool) RepoResult {
+	result := RepoResult{Path: path}
+	selection := opts.Selection
+	logger := orNop(opts.Logger)
+	if override, ok := opts.Overrides[path]; ok {
+		if override.Authors != nil {
+			selection.Authors = override.Authors
+		}
+		if override.Filter != nil {
+			opts.Filter = *override.Filter
+		}
+	}
+
+	objects := newObjectCaches(opts.ObjectCacheSize)
+	defer opts.Timings.objectsCached(objects)
+	stopOpen := opts.Timings.Start(phaseOpen)
+	root, repo, err := openRepoWith(path, objects)
+	stopOpen()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	logger.Logf(LevelVerbose, "%s: opened the repository at %s", path, root)
+	if found, err := hasCommits(repo); err == nil && !found {
+		result.Err = &EmptyRepoError{path: path}
+		return result
+	}
+	branch, _, err := defaultBranch(repo, selection.Branch)
+	if err != nil {
+		result.Err = fmt.Errorf("unable to find the branch of %s. [err=%s]", path, err.Error())
+		return result
+	}
+	logger.Logf(LevelVerbose, "%s: reading the code of %s", path, branch)
+
+	var cache *statsCache
+	if opts.CacheDir != "" {
+		cache, err = openStatsCache(opts.CacheDir, root, opts.Filter)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+	}
+
+	label := ""
+	if labelled {
+		label = path
+	}
+	progress := newProgressReporter(os.Stderr, opts.Quiet, label)
+
+	// The walk only yields reachable commits, remember them so the commits it
+	// left out can be counted afterwards.
+	reachable := make(map[plumbing.Hash]bool)
+	// The commits the walk can't read are skipped and reported, unless
+	// opts.Strict.
+	var corrupt []CommitError
+	skip := func(commitErr CommitError) error {
+		if opts.Strict {
+			return fmt.Errorf("unable to read commit %s: %w", commitErr.Hash, commitErr.Err)
+		}
+		corrupt = append(corrupt, commitErr)
+		return nil
+	}
+	var run *stateRun
+	var restored []commitStats
+	if opts.State != nil && (sample != nil || selection.Range != nil || selection.IncludeUnreachable) {
+		logger.Logf(LevelVerbose, "%s: not using the state, which only walks the refs of every commit", path)
+	} else if opts.State != nil {
+		run, err = opts.State.begin(ctx, repo, root, stateKey(selection, opts), selection.Window.Start, logger)
+		if err != nil {
+			result.Err = fmt.Errorf("unable to read the refs of %s. [err=%s]", path, err.Error())
+			return result
+		}
+		restored, err = run.restored()
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		for _, commit := range restored {
+			reachable[commit.commit.Hash] = true
+		}
+	}
+
+	var counts selectionCounts
+	source := func(yield func(*object.Commit) error) error {
+		fn := func(commit *object.Commit) error {
+			reachable[commit.Hash] = true
+			return yield(commit)
+		}
+		var err error
+		if run != nil {
+			counts, err = selectCommits(selection, run.walk(ctx, repo, selection.Window.Start, logger, skip), fn)
+		} else {
+			counts, err = findRelevantCommits(ctx, repo, selection, logger, skip, fn)
+		}
+		logger.Logf(LevelVerbose, "%s: %s", path, counts)
+		return err
+	}
+	var record func(commitStats)
+	if run != nil {
+		record = run.record
+	}
+
+	var unreachable chan int
+	countCtx, cancelCount := context.WithCancel(ctx)
+	defer cancelCount()
+	// The commits of the window a revision range leaves out are reachable,
+	// they'd be miscounted as unreachable.
+	if opts.CountUnreachable && !selection.IncludeUnreachable && selection.Range == nil && !opts.Fast {
+		unreachable = make(chan int, 1)
+		go func() {
+			unreachable <- countMatching(countCtx, root, selection, objects)
+		}()
+	}
+
+	result.Summary, result.Err = analyze(ctx, root, source, analyzeOptions{
+		jobs:         opts.Jobs,
+		cache:        cache,
+		progress:     progress,
+		capabilities: opts.capabilities(),
+		diff:         opts.diff,
+		changes:      changes,
+		filter:       opts.Filter,
+		limits:       opts.Limits,
+		budget:       budget,
+		objects:      objects,
+		restored:     restored,
+		record:       record,
+		timings:      opts.Timings,
+		window:       selection.Window,
+		strict:       opts.Strict,
+		mergeStats:   opts.MergeStats,
+		sample:       sample,
+		sampleSeed:   opts.SampleSeed,
+		listCommits:  opts.ListCommits,
+		commitLog:    opts.CommitLog,
+		authors:      selection.Authors,
+		logger:       logger,
+		label:        path,
+	})
+	progress.result(result.Summary, result.Err)
+	if result.Err == nil {
+		result.Summary.CorruptSkipped = corrupt
+		result.Summary.Branches = []string{branch}
+		coauthored := counts.coauthored
+		if run != nil {
+			opts.State.finish(run, counts.coauthored)
+			coauthored += run.priorCoAuthored()
+		}
+		if selection.Coauthors != "" && selection.Authors != nil {
+			result.Summary.CoAuthored = &CoAuthored{Commits: coauthored, Counted: selection.Coauthors == CoauthorsFull}
+		}
+	}
+
+	if unreachable != nil {
+		if result.Err != nil {
+			cancelCount()
+		}
+		matching := <-unreachable
+		if result.Err == nil && matching > len(reachable) {
+			result.Summary.UnreachableSkipped = matching - len(reachable)
+		}
+		logger.Logf(LevelVerbose, "%s: %s matching commits in the object store, %s reachable", path, FormatCount(matching), FormatCount(len(reachable)))
+	}
+
+	return result
+}
+
+// countMatching counts the matching commits in the whole object store,
+// reachable or not, through its own handle on the repository. It runs next
+// to the analysis, which is dominated by computing diffs, when
+// Options.CountUnreachable is set and there's no revision range. -1 is
+// returned when the count couldn't be completed.
+func countMatching(ctx context.Context, path string, selection Selection, objects *objectCaches) int {
+	repo, err := openRoot(path, objects)
+	if err != nil {
+		return -1
+	}
+
+	count := 0
+	selection.IncludeUnreachable = true
+	_, err = findRelevantCommits(ctx, repo, selection, nopLogger{}, skipCorrupt, func(*object.Commit) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		return -1
+	}
+
+	return count
+}
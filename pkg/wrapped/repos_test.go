@@ -0,0 +1,163 @@
+package wrapped
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// unreachableFixture returns a repository of three commits, the last of which
+// no ref reaches once the branch is reset to the second.
+func unreachableFixture(t *testing.T) *fixture {
+	repo := newFixture(t)
+	start := time.Date(2023, time.May, 1, 9, 0, 0, 0, time.UTC)
+	repo.commit("dev@example.com", start, map[string]string{"a.txt": "a\n"})
+	kept := repo.commit("dev@example.com", start.Add(time.Hour), map[string]string{"b.txt": "b\n"})
+	repo.commit("dev@example.com", start.Add(2*time.Hour), map[string]string{"c.txt": "c\n"})
+
+	head, err := repo.repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.repo.Storer.SetReference(plumbing.NewHashReference(head.Name(), kept)); err != nil {
+		t.Fatal(err)
+	}
+
+	return repo
+}
+
+func TestAnalyzeCountUnreachable(t *testing.T) {
+	repo := unreachableFixture(t)
+
+	for _, tt := range []struct {
+		name string
+		opts Options
+		want int
+	}{
+		{name: "default", opts: Options{}, want: 0},
+		{name: "counted", opts: Options{CountUnreachable: true}, want: 1},
+		{name: "fast", opts: Options{CountUnreachable: true, Fast: true}, want: 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := repo.analyze(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if summary.TotalCommits != 2 {
+				t.Errorf("got %d commits, want 2", summary.TotalCommits)
+			}
+			if summary.UnreachableSkipped != tt.want {
+				t.Errorf("got %d unreachable commits skipped, want %d", summary.UnreachableSkipped, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeRangeCountsNoUnreachable(t *testing.T) {
+	repo := newFixture(t)
+	start := time.Date(2023, time.May, 1, 9, 0, 0, 0, time.UTC)
+	from := repo.commit("dev@example.com", start, map[string]string{"a.txt": "a\n"})
+	repo.commit("dev@example.com", start.Add(time.Hour), map[string]string{"b.txt": "b\n"})
+	repo.commit("dev@example.com", start.Add(2*time.Hour), map[string]string{"c.txt": "c\n"})
+
+	summary, err := repo.analyze(Options{
+		Selection:        Selection{Range: &RevRange{From: from.String(), To: "HEAD"}},
+		CountUnreachable: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.TotalCommits != 2 {
+		t.Errorf("got %d commits, want 2", summary.TotalCommits)
+	}
+	if summary.UnreachableSkipped != 0 {
+		t.Errorf("got %d unreachable commits skipped, want the commit before the range left uncounted", summary.UnreachableSkipped)
+	}
+}
+
+func TestAnalyzeEmptyRepo(t *testing.T) {
+	repo := newFixture(t)
+
+	_, err := repo.analyze(Options{})
+	emptyErr := &EmptyRepoError{}
+	if !errors.As(err, &emptyErr) {
+		t.Fatalf("got %v, want an *EmptyRepoError", err)
+	}
+	if !strings.Contains(err.Error(), "has no commits yet") {
+		t.Errorf("got %q, want it to tell the repository has no commits yet", err)
+	}
+}
+
+func TestAnalyzeSingleCommit(t *testing.T) {
+	repo := newFixture(t)
+	repo.commit("dev@example.com", time.Date(2023, time.March, 1, 10, 0, 0, 0, time.UTC), map[string]string{"main.go": "a\nb\nc\n"})
+
+	summary, err := repo.analyze(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.TotalCommits != 1 || summary.ActiveDays() != 1 || summary.LongestStreak() != 1 {
+		t.Errorf("got %d commits on %d days, a streak of %d, want 1 of each", summary.TotalCommits, summary.ActiveDays(), summary.LongestStreak())
+	}
+	if summary.AverageAdditions != 3 || summary.AverageDeletions != 0 {
+		t.Errorf("got averages of +%g/-%g, want the commit's +3/-0", summary.AverageAdditions, summary.AverageDeletions)
+	}
+	if summary.Largest.Hash != summary.Smallest.Hash {
+		t.Errorf("got the largest commit %s and the smallest %s, want the single commit", summary.Largest.Hash, summary.Smallest.Hash)
+	}
+	if _, ok := summary.Consistency(); ok {
+		t.Error("got a consistency score for a single commit")
+	}
+
+	output, err := Render("text", summary, RenderOptions{Top: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The commit is called out once for the kick-off and the sign-off, and
+	// once for the earliest and the latest.
+	for _, want := range []string{"kicked off and signed off the year on Mar 1", "Earliest riser and latest night"} {
+		if strings.Count(output, want) != 1 {
+			t.Errorf("got:\n%s\nwant it to contain %q once", output, want)
+		}
+	}
+	if strings.Contains(output, "/100") {
+		t.Errorf("got:\n%s\nwant no consistency score", output)
+	}
+}
+
+func TestAnalyzeJobsMatchSerial(t *testing.T) {
+	repo := newFixture(t)
+	start := time.Date(2023, time.January, 2, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 40; i++ {
+		// The commits are made in pairs within the same second, so the ties
+		// are broken by hash whichever worker finishes first.
+		when := start.Add(time.Duration(i/2) * 31 * time.Hour)
+		email := []string{"dev@example.com", "ops@example.com", "dev@example.com"}[i%3]
+		files := map[string]string{fmt.Sprintf("pkg/file%d.go", i%7): numberedLines("line", 0, i+1)}
+		if i%5 == 0 {
+			files["README.md"] = numberedLines("readme", i, 2*i+1)
+		}
+		repo.commit(email, when, files)
+	}
+
+	serial, err := repo.analyze(Options{Jobs: 1, ListCommits: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, jobs := range []int{2, 8} {
+		parallel, err := repo.analyze(Options{Jobs: jobs, ListCommits: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(serial, parallel) {
+			want, _ := Render("json", serial, RenderOptions{})
+			got, _ := Render("json", parallel, RenderOptions{})
+			t.Errorf("--jobs %d got:\n%s\nwant the --jobs 1 summary:\n%s", jobs, got, want)
+		}
+	}
+}
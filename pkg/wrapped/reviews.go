@@ -0,0 +1,71 @@
+package wrapped
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ReviewStats are the code review stats of the author on a forge like
+// GitHub, added to the report next to the commit stats.
+type ReviewStats struct {
+	// Forge names where the stats come from, e.g. GitHub.
+	Forge string
+	// Noun is what the forge calls a change, e.g. PR or MR.
+	Noun     string
+	Opened   int
+	Merged   int
+	Reviewed int
+	// Approved counts the approvals of others' changes, on the forges that
+	// have them.
+	Approved int
+	// MedianTimeToMerge is the median time from opening to merging the
+	// merged changes, zero when none were merged.
+	MedianTimeToMerge time.Duration
+}
+
+// MedianDuration returns the median of the durations, zero when there are
+// none.
+func MedianDuration(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+
+	return sorted[middle]
+}
+
+// sentence summarizes the stats, e.g. "You opened 84 PRs (71 merged),
+// reviewed 132, median time-to-merge 18h".
+func (r ReviewStats) sentence() string {
+	sentence := fmt.Sprintf("You opened %d %ss (%d merged), reviewed %d", r.Opened, r.Noun, r.Merged, r.Reviewed)
+	if r.Approved > 0 {
+		sentence += fmt.Sprintf(", approved %d", r.Approved)
+	}
+	if r.Merged > 0 {
+		sentence += ", median time-to-merge " + formatMergeTime(r.MedianTimeToMerge)
+	}
+
+	return sentence
+}
+
+// formatMergeTime rounds the duration to the unit that reads best: minutes
+// under an hour, hours under three days and days beyond.
+func formatMergeTime(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Round(time.Minute)/time.Minute))
+	case d < 72*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Round(time.Hour)/time.Hour))
+	default:
+		return fmt.Sprintf("%.1fd", roundHalfUp(d.Hours()/24, 1))
+	}
+}
@@ -0,0 +1,97 @@
+package wrapped
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"strings"
+	"time"
+)
+
+// RevRange is a revision range like git log's v2.0..v3.0, the commits
+// reachable from To but not from From. Either can be any revision git
+// resolves, a branch, a tag, an abbreviated hash or an expression like
+// main~3, and is the branch of the selection when empty.
+type RevRange struct {
+	From string
+	To   string
+}
+
+// ParseRevRange parses a revision range, nil when the argument isn't one. A
+// side can be left out, like main.. for the commits the branch of the
+// selection has on top of main.
+func ParseRevRange(arg string) (*RevRange, error) {
+	if strings.Contains(arg, "...") {
+		return nil, errors.New("unable to walk a symmetric difference, expected a range like v2.0..v3.0")
+	}
+	from, to, ok := strings.Cut(arg, "..")
+	if !ok {
+		return nil, nil
+	}
+	if from == "" && to == "" {
+		return nil, errors.New("expected a range like v2.0..v3.0")
+	}
+
+	return &RevRange{From: from, To: to}, nil
+}
+
+func (r RevRange) String() string {
+	return r.From + ".." + r.To
+}
+
+// rangeSide returns the commit a side of a range points at, the tip of the
+// branch when it's left out.
+func rangeSide(repo *git.Repository, revision string, branch string) (*object.Commit, error) {
+	if revision == "" {
+		_, commit, err := defaultBranch(repo, branch)
+		return commit, err
+	}
+
+	return resolveRevision(repo, revision)
+}
+
+// resolveRevision returns the commit the revision points at, peeling tags.
+func resolveRevision(repo *git.Repository, revision string) (*object.Commit, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve the revision %q. [err=%s]", revision, err.Error())
+	}
+	commit, err := peelToCommit(repo, *hash)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve the revision %q. [err=%s]", revision, err.Error())
+	}
+	if commit == nil {
+		return nil, fmt.Errorf("unable to resolve the revision %q, it doesn't point at a commit", revision)
+	}
+
+	return commit, nil
+}
+
+// walkRange is walkCommits over the commits of the range instead of the ones
+// of the refs. The history reachable from its start is walked first, as far
+// back as since, to hide it from the walk from its end.
+func walkRange(ctx context.Context, repo *git.Repository, revisions RevRange, branch string, since time.Time, logger Logger, corrupt func(CommitError) error, fn func(*object.Commit) error) error {
+	from, err := rangeSide(repo, revisions.From, branch)
+	if err != nil {
+		return err
+	}
+	to, err := rangeSide(repo, revisions.To, branch)
+	if err != nil {
+		return err
+	}
+	logger.Logf(LevelDebug, "Walking %s from %s, hiding %s", revisions, to.Hash, from.Hash)
+
+	hidden := make(map[plumbing.Hash]bool)
+	err = walkFrom(ctx, repo, []*object.Commit{from}, nil, since, logger, skipCorrupt, func(commit *object.Commit) error {
+		hidden[commit.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return walkFrom(ctx, repo, []*object.Commit{to}, hidden, since, logger, corrupt, fn)
+}
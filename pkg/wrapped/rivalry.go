@@ -0,0 +1,202 @@
+package wrapped
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Contender is one of the authors compared by RenderRivalry, Label names the
+// author in the columns.
+type Contender struct {
+	Label   string
+	Summary *Summary
+}
+
+// rivalryRenderers turn two summaries into the comparison, keyed by --format.
+var rivalryRenderers = map[string]func(a Contender, b Contender) (string, error){
+	"text": func(a Contender, b Contender) (string, error) {
+		return buildRivalry(a, b), nil
+	},
+	"markdown": func(a Contender, b Contender) (string, error) {
+		return buildMarkdownRivalry(a, b), nil
+	},
+	"json": buildJSONRivalry,
+}
+
+// RivalryFormats returns the formats RenderRivalry supports, sorted.
+func RivalryFormats() []string {
+	formats := make([]string, 0, len(rivalryRenderers))
+	for format := range rivalryRenderers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+
+	return formats
+}
+
+// RenderRivalry renders the headline stats of two authors side by side, with
+// the difference of b to a. It's meant as a friendly rivalry, so commit
+// messages and files are left out.
+func RenderRivalry(format string, a Contender, b Contender) (string, error) {
+	render, ok := rivalryRenderers[format]
+	if !ok {
+		return "", fmt.Errorf("unknown output format %q", format)
+	}
+
+	return render(a, b)
+}
+
+// rivalryStat is a headline stat of both authors.
+type rivalryStat struct {
+	label string
+	a     int64
+	b     int64
+	// hour marks the busiest hour, an hour of the day rather than an amount,
+	// which has no difference. It's -1 without commits.
+	hour bool
+}
+
+func (s rivalryStat) values() (string, string, string) {
+	if s.hour {
+		return formatRivalryHour(s.a), formatRivalryHour(s.b), ""
+	}
+
+	return fmt.Sprint(s.a), fmt.Sprint(s.b), fmt.Sprintf("%+d", s.b-s.a)
+}
+
+func formatRivalryHour(hour int64) string {
+	if hour < 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%02d:00", hour)
+}
+
+// rivalryStats returns the stats both summaries have, the line stats only
+// when they were computed for both.
+func rivalryStats(a *Summary, b *Summary) []rivalryStat {
+	stats := []rivalryStat{{label: "🧮 Commits", a: a.TotalCommits, b: b.TotalCommits}}
+	if a.has(fieldLineStats) && b.has(fieldLineStats) {
+		stats = append(stats,
+			rivalryStat{label: "🟢 Lines added", a: a.TotalAdditions(), b: b.TotalAdditions()},
+			rivalryStat{label: "🔴 Lines deleted", a: a.TotalDeletions(), b: b.TotalDeletions()},
+		)
+	}
+
+	return append(stats,
+		rivalryStat{label: "📅 Active days", a: int64(a.ActiveDays()), b: int64(b.ActiveDays())},
+		rivalryStat{label: "🔥 Longest streak", a: int64(a.LongestStreak()), b: int64(b.LongestStreak())},
+		rivalryStat{label: "⏰ Busiest hour", a: busiestHour(a), b: busiestHour(b), hour: true},
+	)
+}
+
+// busiestHour returns the hour with the most commits, -1 without commits.
+func busiestHour(summary *Summary) int64 {
+	hour, commits := summary.BusiestHour()
+	if commits == 0 {
+		return -1
+	}
+
+	return int64(hour)
+}
+
+func buildRivalry(a Contender, b Contender) string {
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("🥊 %s\n", a.Summary.Window))
+	writer := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "\t%s\t%s\tdifference\n", a.Label, b.Label)
+	for _, stat := range rivalryStats(a.Summary, b.Summary) {
+		valueA, valueB, difference := stat.values()
+		if difference == "" {
+			// An empty last cell would still be padded.
+			fmt.Fprintf(writer, "%s\t%s\t%s\n", stat.label, valueA, valueB)
+			continue
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", stat.label, valueA, valueB, difference)
+	}
+	writer.Flush()
+
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func buildMarkdownRivalry(a Contender, b Contender) string {
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("## 🥊 git-wrapped %s\n\n", a.Summary.Window))
+	builder.WriteString(fmt.Sprintf("| | %s | %s | Difference |\n|---|---|---|---|\n", markdownEscaper.Replace(a.Label), markdownEscaper.Replace(b.Label)))
+	for _, stat := range rivalryStats(a.Summary, b.Summary) {
+		valueA, valueB, difference := stat.values()
+		builder.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", stat.label, valueA, valueB, difference))
+	}
+
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+type jsonRivalry struct {
+	Generator  string             `json:"generator,omitempty"`
+	Window     jsonWindow         `json:"window"`
+	A          jsonContender      `json:"a"`
+	B          jsonContender      `json:"b"`
+	Difference jsonRivalryChanges `json:"difference"`
+}
+
+type jsonContender struct {
+	Label string `json:"label"`
+	jsonRivalryChanges
+	// BusiestHour is left out without commits.
+	BusiestHour *int64 `json:"busiest_hour,omitempty"`
+}
+
+// jsonRivalryChanges are the stats there is a difference of. The line stats
+// are left out unless they were computed for both authors.
+type jsonRivalryChanges struct {
+	Commits       int64  `json:"commits"`
+	Additions     *int64 `json:"additions,omitempty"`
+	Deletions     *int64 `json:"deletions,omitempty"`
+	ActiveDays    int64  `json:"active_days"`
+	LongestStreak int64  `json:"longest_streak"`
+}
+
+func buildJSONRivalry(a Contender, b Contender) (string, error) {
+	lineStats := a.Summary.has(fieldLineStats) && b.Summary.has(fieldLineStats)
+	output := jsonRivalry{
+		Generator: a.Summary.Generator,
+		Window:    newJSONWindow(a.Summary.Window),
+		A:         newJSONContender(a, lineStats),
+		B:         newJSONContender(b, lineStats),
+	}
+	output.Difference = jsonRivalryChanges{
+		Commits:       output.B.Commits - output.A.Commits,
+		ActiveDays:    output.B.ActiveDays - output.A.ActiveDays,
+		LongestStreak: output.B.LongestStreak - output.A.LongestStreak,
+	}
+	if lineStats {
+		additions := b.Summary.TotalAdditions() - a.Summary.TotalAdditions()
+		deletions := b.Summary.TotalDeletions() - a.Summary.TotalDeletions()
+		output.Difference.Additions, output.Difference.Deletions = &additions, &deletions
+	}
+
+	return marshalJSON(output)
+}
+
+func newJSONContender(contender Contender, lineStats bool) jsonContender {
+	summary := contender.Summary
+	output := jsonContender{
+		Label: contender.Label,
+		jsonRivalryChanges: jsonRivalryChanges{
+			Commits:       summary.TotalCommits,
+			ActiveDays:    int64(summary.ActiveDays()),
+			LongestStreak: int64(summary.LongestStreak()),
+		},
+	}
+	if lineStats {
+		additions, deletions := summary.TotalAdditions(), summary.TotalDeletions()
+		output.Additions, output.Deletions = &additions, &deletions
+	}
+	if hour := busiestHour(summary); hour >= 0 {
+		output.BusiestHour = &hour
+	}
+
+	return output
+}
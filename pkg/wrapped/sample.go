@@ -0,0 +1,171 @@
+package wrapped
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Sampling is how the line stats were estimated from a sample of the commits,
+// see Options.Sample. Every line based stat of a sampled summary is scaled up
+// from the sampled commits to all of them, the commit counts and the time of
+// the commits stay exact.
+type Sampling struct {
+	// Sampled is the number of commits the line stats were computed for,
+	// out of the Commits they'd have been computed for without sampling.
+	Sampled int64
+	Commits int64
+	// Seed picked the commits at random, they're evenly spaced in time when
+	// it's empty.
+	Seed string
+}
+
+// sampledCommit is a commit the line stats could be computed for, a
+// candidate of the sample.
+type sampledCommit struct {
+	repo int
+	hash plumbing.Hash
+	when time.Time
+}
+
+// sampleCommits picks the opts.Sample commits of the repositories the line
+// stats are computed for, among the matched ones they'd be computed for
+// without sampling. They're evenly spaced in time across every repository,
+// or picked at random by opts.SampleSeed, the same commits for the same
+// seed. The picks of every repository are returned in the order of paths, all
+// nil when there are no more commits than opts.Sample and sampling is off.
+func sampleCommits(ctx context.Context, paths []string, opts Options) ([]map[plumbing.Hash]bool, error) {
+	candidates := make([]sampledCommit, 0)
+	for i, path := range paths {
+		_, repo, err := openRepo(path)
+		if err != nil {
+			// The analysis reports the repositories it can't open.
+			continue
+		}
+		selection := opts.Selection
+		if override, ok := opts.Overrides[path]; ok && override.Authors != nil {
+			selection.Authors = override.Authors
+		}
+		_, err = findRelevantCommits(ctx, repo, selection, nopLogger{}, skipCorrupt, func(commit *object.Commit) error {
+			if commit.NumParents() > 1 && opts.MergeStats != MergeStatsFirstParent {
+				return nil
+			}
+			candidates = append(candidates, sampledCommit{repo: i, hash: commit.Hash, when: commit.Author.When})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	picks := make([]map[plumbing.Hash]bool, len(paths))
+	if len(candidates) <= opts.Sample {
+		return picks, nil
+	}
+	for i := range picks {
+		picks[i] = make(map[plumbing.Hash]bool)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return timeHashBefore(candidates[i].when, candidates[i].hash.String(), candidates[j].when, candidates[j].hash.String())
+	})
+	for _, index := range sampleIndexes(len(candidates), opts.Sample, opts.SampleSeed) {
+		picks[candidates[index].repo][candidates[index].hash] = true
+	}
+
+	return picks, nil
+}
+
+// sampleIndexes returns the indexes of size picks out of count, evenly spaced
+// or drawn at random from the seed.
+func sampleIndexes(count int, size int, seed string) []int {
+	if seed == "" {
+		indexes := make([]int, size)
+		for i := range indexes {
+			indexes[i] = int((int64(i)*int64(count) + int64(count)/2) / int64(size))
+		}
+		return indexes
+	}
+
+	return seededRand(seed).Perm(count)[:size]
+}
+
+// seededRand returns a source of random numbers drawing the same numbers for
+// the same seed.
+func seededRand(seed string) *rand.Rand {
+	hash := fnv.New64a()
+	hash.Write([]byte(seed))
+	return rand.New(rand.NewSource(int64(hash.Sum64())))
+}
+
+// estimate scales the line stats of the sampled commits up to every commit
+// they were sampled from, once they've all been added.
+func (s *Summary) estimate(seed string) {
+	eligible := s.statsCommits + s.unsampled
+	s.Sampling = &Sampling{Sampled: s.statsCommits, Commits: eligible, Seed: seed}
+	if s.statsCommits == 0 {
+		return
+	}
+
+	factor := float64(eligible) / float64(s.statsCommits)
+	scale := func(value int64) int64 {
+		return int64(math.Round(float64(value) * factor))
+	}
+	s.additionCount, s.deletionCount = scale(s.additionCount), scale(s.deletionCount)
+	s.MergeAdditions, s.MergeDeletions = scale(s.MergeAdditions), scale(s.MergeDeletions)
+	s.EmptyCommits, s.SingleFileCommits = scale(s.EmptyCommits), scale(s.SingleFileCommits)
+	for i, count := range s.CommitSizes {
+		s.CommitSizes[i] = int(scale(int64(count)))
+	}
+	for _, day := range s.ByDay {
+		day.Additions, day.Deletions = scale(day.Additions), scale(day.Deletions)
+	}
+	for _, identity := range s.identities {
+		identity.additions, identity.deletions = scale(identity.additions), scale(identity.deletions)
+	}
+	for _, file := range s.files {
+		file.Commits = int(scale(int64(file.Commits)))
+		file.Additions, file.Deletions = scale(file.Additions), scale(file.Deletions)
+	}
+	s.statsCommits = eligible
+	s.unsampled = 0
+}
+
+// mergeSampling folds the sampling of another summary into this one.
+func (s *Summary) mergeSampling(other *Sampling) {
+	if other == nil {
+		return
+	}
+	if s.Sampling == nil {
+		s.Sampling = &Sampling{Seed: other.Seed}
+	}
+	s.Sampling.Sampled += other.Sampled
+	s.Sampling.Commits += other.Commits
+}
+
+// estimated returns the marker of the labels of the line based stats, which
+// are estimates of a sampled summary.
+func (s *Summary) estimated() string {
+	if s.Sampling == nil {
+		return ""
+	}
+
+	return " (estimated)"
+}
+
+// sentence describes the sample, e.g. "line stats estimated from 2,000 of
+// 48,211 commits, evenly spaced in time".
+func (s *Sampling) sentence() string {
+	picked := "evenly spaced in time"
+	if s.Seed != "" {
+		picked = fmt.Sprintf("picked at random from the seed %q", s.Seed)
+	}
+
+	return fmt.Sprintf("line stats estimated from %s of %s commits, %s", FormatCount(int(s.Sampled)), FormatCount(int(s.Commits)), picked)
+}
@@ -0,0 +1,61 @@
+package wrapped
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSampleSeedDeterministic(t *testing.T) {
+	repo := newFixture(t)
+	start := time.Date(2023, time.February, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 30; i++ {
+		repo.commit("dev@example.com", start.Add(time.Duration(i)*50*time.Hour), map[string]string{
+			fmt.Sprintf("file%d.txt", i%4): numberedLines("line", 0, 1+i*i%17),
+		})
+	}
+	opts := Options{
+		Selection:  Selection{Window: NewYearWindow(2023, time.UTC)},
+		Sample:     8,
+		SampleSeed: "preview",
+	}
+
+	first, err := sampleCommits(context.Background(), []string{repo.dir}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first[0]) != 8 {
+		t.Fatalf("sampled %d commits, want 8", len(first[0]))
+	}
+	again, err := sampleCommits(context.Background(), []string{repo.dir}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(first, again) {
+		t.Errorf("sampled %v, then %v from the same seed", first[0], again[0])
+	}
+	other := opts
+	other.SampleSeed = "another"
+	if picked, err := sampleCommits(context.Background(), []string{repo.dir}, other); err != nil || reflect.DeepEqual(first, picked) {
+		t.Errorf("sampled %v from another seed, want other commits than %v. [err=%v]", picked, first, err)
+	}
+
+	var serial *Summary
+	for _, jobs := range []int{1, 4, 1} {
+		opts.Jobs = jobs
+		summary, err := repo.analyze(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if summary.Sampling == nil || summary.Sampling.Sampled != 8 || summary.Sampling.Commits != 30 {
+			t.Fatalf("got the sampling %+v, want 8 of 30 commits", summary.Sampling)
+		}
+		if serial == nil {
+			serial = summary
+		} else if !reflect.DeepEqual(serial, summary) {
+			t.Errorf("--jobs %d estimated +%d/-%d, want the estimate of --jobs 1, +%d/-%d", jobs, summary.TotalAdditions(), summary.TotalDeletions(), serial.TotalAdditions(), serial.TotalDeletions())
+		}
+	}
+}
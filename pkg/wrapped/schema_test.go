@@ -0,0 +1,164 @@
+package wrapped
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// schemaNode is the part of a JSON Schema the tests look at.
+type schemaNode struct {
+	Type       interface{}            `json:"type"`
+	Ref        string                 `json:"$ref"`
+	Const      interface{}            `json:"const"`
+	Required   []string               `json:"required"`
+	Properties map[string]*schemaNode `json:"properties"`
+	Items      *schemaNode            `json:"items"`
+	Defs       map[string]*schemaNode `json:"$defs"`
+}
+
+func parseSchema(t *testing.T, schema string) *schemaNode {
+	t.Helper()
+
+	root := &schemaNode{}
+	if err := json.Unmarshal([]byte(schema), root); err != nil {
+		t.Fatal(err)
+	}
+
+	return root
+}
+
+// readSchemaVersion returns the report schema of the version kept in
+// testdata.
+func readSchemaVersion(t *testing.T, version int) string {
+	t.Helper()
+
+	schema, err := os.ReadFile(fmt.Sprintf("testdata/report.schema.v%d.json", version))
+	if err != nil {
+		t.Fatalf("unable to read the schema of version %d, add it to testdata when bumping SchemaVersion: %v", version, err)
+	}
+
+	return string(schema)
+}
+
+// shapes returns the type of every property path of the schema, like
+// "window.start", with "[]" standing for the items of an array.
+func (n *schemaNode) shapes(path string, shapes map[string]string) map[string]string {
+	shape := fmt.Sprint(n.Type)
+	if n.Ref != "" {
+		shape = n.Ref
+	}
+	shapes[path] = shape
+	for name, property := range n.Properties {
+		property.shapes(strings.TrimPrefix(path+"."+name, "."), shapes)
+	}
+	if n.Items != nil {
+		n.Items.shapes(path+"[]", shapes)
+	}
+	for name, def := range n.Defs {
+		def.shapes("$defs."+name, shapes)
+	}
+
+	return shapes
+}
+
+// TestReportSchemaGolden fails when the schema changes without a bump of
+// SchemaVersion, whose schema is kept in testdata.
+func TestReportSchemaGolden(t *testing.T) {
+	if golden := readSchemaVersion(t, SchemaVersion); golden != reportSchema {
+		t.Errorf("report.schema.json differs from testdata/report.schema.v%d.json, bump SchemaVersion for a new shape", SchemaVersion)
+	}
+	if schema := parseSchema(t, reportSchema); schema.Properties["schema_version"].Const != float64(SchemaVersion) {
+		t.Errorf("got schema_version %v in the schema, want %d", schema.Properties["schema_version"].Const, SchemaVersion)
+	}
+}
+
+// TestReportSchemaCompatible checks the schema only added to the one of the
+// previous version: every property it had is still there with its type.
+func TestReportSchemaCompatible(t *testing.T) {
+	previous := parseSchema(t, readSchemaVersion(t, SchemaVersion-1)).shapes("", make(map[string]string))
+	current := parseSchema(t, reportSchema).shapes("", make(map[string]string))
+
+	paths := make([]string, 0, len(previous))
+	for path := range previous {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		shape, ok := current[path]
+		switch {
+		case !ok:
+			t.Errorf("%s of version %d was removed", path, SchemaVersion-1)
+		case shape != previous[path]:
+			t.Errorf("%s changed from %s to %s since version %d", path, previous[path], shape, SchemaVersion-1)
+		}
+	}
+}
+
+// checkDocument fails for every key of the document the schema doesn't
+// have, and every required key the document lacks.
+func checkDocument(t *testing.T, root *schemaNode, node *schemaNode, path string, document interface{}) {
+	t.Helper()
+
+	for node.Ref != "" {
+		name := node.Ref[strings.LastIndex(node.Ref, "/")+1:]
+		if strings.HasPrefix(node.Ref, "#/$defs/") {
+			node = root.Defs[name]
+		} else {
+			node = root.Properties[name]
+		}
+	}
+
+	switch value := document.(type) {
+	case map[string]interface{}:
+		for _, name := range node.Required {
+			if _, ok := value[name]; !ok {
+				t.Errorf("%s lacks the required %s", path, name)
+			}
+		}
+		for name, property := range value {
+			schema, ok := node.Properties[name]
+			if !ok {
+				t.Errorf("%s.%s isn't in the schema", path, name)
+				continue
+			}
+			checkDocument(t, root, schema, path+"."+name, property)
+		}
+	case []interface{}:
+		for i, item := range value {
+			if node.Items == nil {
+				t.Errorf("%s is an array the schema has no items for", path)
+				return
+			}
+			checkDocument(t, root, node.Items, fmt.Sprintf("%s[%d]", path, i), item)
+		}
+	}
+}
+
+// TestJSONReportMatchesSchema fails when the json report emits a field the
+// schema doesn't describe, which needs a new schema version.
+func TestJSONReportMatchesSchema(t *testing.T) {
+	for _, fast := range []bool{false, true} {
+		summary := NewSummary(fast, NewYearWindow(2023, time.UTC))
+		for _, commit := range tiedCommits() {
+			summary.add(commit, "repo")
+		}
+		summary.Generator = "git-wrapped test"
+		summary.Finish()
+
+		output, err := Render("json", summary, RenderOptions{Top: 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		document := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(output), &document); err != nil {
+			t.Fatal(err)
+		}
+		root := parseSchema(t, reportSchema)
+		checkDocument(t, root, root, "report", document)
+	}
+}
@@ -0,0 +1,157 @@
+package wrapped
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"path"
+)
+
+// RepoOpenError is returned when a repository can't be opened.
+type RepoOpenError struct {
+	path string
+	err  error
+}
+
+func (e *RepoOpenError) Error() string {
+	return fmt.Sprintf("unable to open the repository at %s: %s", e.path, e.err)
+}
+
+func (e *RepoOpenError) Unwrap() error {
+	return e.err
+}
+
+// Selection decides which commits of a repository are analyzed.
+type Selection struct {
+	Window AnalysisWindow
+	// Authors are the emails whose commits are selected, every author's
+	// when nil.
+	Authors map[string]bool
+	// IncludeUnreachable scans the whole object store instead of walking
+	// history from the refs.
+	IncludeUnreachable bool
+	// ExcludeMerges leaves merge commits out of the analysis entirely.
+	ExcludeMerges bool
+	// ExcludedAuthors are glob patterns (see path.Match) of the emails whose
+	// commits are left out, like ci@example.com or *+bot@*. An excluded email
+	// is left out even when Authors selects it.
+	ExcludedAuthors []string
+	// Range limits the commits to the ones of a revision range, resolved in
+	// every repository, when set.
+	Range *RevRange
+	// Branch is the branch the code at the end of the window is read from,
+	// like the files owned, and a revision range defaults to. It's any
+	// revision git resolves, the default branch of every repository when
+	// empty.
+	Branch string
+	// Coauthors also selects the commits naming one of the Authors in a
+	// Co-authored-by line when another author made them, CoauthorsSeparate
+	// or CoauthorsFull. They're left out when empty.
+	Coauthors string
+}
+
+// Excludes reports whether the email matches one of the ExcludedAuthors.
+func (s Selection) Excludes(email string) bool {
+	for _, pattern := range s.ExcludedAuthors {
+		if matched, _ := path.Match(pattern, email); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selectionCounts are the number of commits that made it through each stage
+// of the selection.
+type selectionCounts struct {
+	scanned int
+	// inWindow are the scanned commits authored within the window.
+	inWindow int
+	// mergesExcluded are the commits in the window left out as merges.
+	mergesExcluded int
+	// excluded are the commits of the authors left out by ExcludedAuthors.
+	excluded int
+	// matched are the commits selected, authored by one of the authors.
+	matched int
+	// coauthored are the commits co-authored by one of the authors rather
+	// than authored, only selected when they count fully.
+	coauthored int
+}
+
+func (c selectionCounts) String() string {
+	counts := fmt.Sprintf("%s commits scanned, %s in window", FormatCount(c.scanned), FormatCount(c.inWindow))
+	if c.mergesExcluded > 0 {
+		counts += fmt.Sprintf(", %s merges excluded", FormatCount(c.mergesExcluded))
+	}
+
+	if c.excluded > 0 {
+		counts += fmt.Sprintf(", %s by excluded authors left out", FormatCount(c.excluded))
+	}
+
+	counts += fmt.Sprintf(", %s matched identities", FormatCount(c.matched))
+	if c.coauthored > 0 {
+		counts += fmt.Sprintf(", %s co-authored", FormatCount(c.coauthored))
+	}
+
+	return counts
+}
+
+// findRelevantCommits calls fn with every commit in the window authored by
+// one of the Authors, or by anyone when Authors is nil, as the history is
+// walked. Only commits reachable from a ref are considered, unless
+// IncludeUnreachable is set, or from the end of the Range but not its start.
+// The commits co-authored by one of the Authors are only passed to fn when
+// they count fully, see Selection.Coauthors. It returns how many commits made
+// it through each stage, even when the walk stopped early. The commits that
+// can't be read are passed to corrupt, see walkCommits.
+func findRelevantCommits(ctx context.Context, repo *git.Repository, selection Selection, logger Logger, corrupt func(CommitError) error, fn func(*object.Commit) error) (selectionCounts, error) {
+	return selectCommits(selection, func(visit func(*object.Commit) error) error {
+		switch {
+		case selection.IncludeUnreachable:
+			return scanCommitObjects(ctx, repo, logger, visit)
+		case selection.Range != nil:
+			return walkRange(ctx, repo, *selection.Range, selection.Branch, selection.Window.Start, logger, corrupt, visit)
+		default:
+			return walkCommits(ctx, repo, selection.Window.Start, logger, corrupt, visit)
+		}
+	}, fn)
+}
+
+// selectCommits is findRelevantCommits over the commits of the walk.
+func selectCommits(selection Selection, walk func(visit func(*object.Commit) error) error, fn func(*object.Commit) error) (selectionCounts, error) {
+	counts := selectionCounts{}
+	err := walk(func(commit *object.Commit) error {
+		counts.scanned++
+		authorSig := commit.Author
+		if !selection.Window.contains(authorSig.When) {
+			return nil
+		}
+		counts.inWindow++
+
+		if selection.ExcludeMerges && commit.NumParents() > 1 {
+			counts.mergesExcluded++
+			return nil
+		}
+
+		if _, ok := selection.Authors[authorSig.Email]; ok || selection.Authors == nil {
+			if selection.Excludes(authorSig.Email) {
+				counts.excluded++
+				return nil
+			}
+			counts.matched++
+			return fn(commit)
+		}
+
+		if coauthor := coauthoredBy(commit, selection.Authors); selection.Coauthors != "" && coauthor != "" && !selection.Excludes(coauthor) {
+			counts.coauthored++
+			if selection.Coauthors == CoauthorsFull {
+				return fn(commit)
+			}
+		}
+
+		return nil
+	})
+
+	return counts, err
+}
@@ -0,0 +1,132 @@
+package wrapped
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"time"
+)
+
+// unshallowDepth is the depth git fetch --unshallow asks for, the history of
+// the remote being shallower than that.
+const unshallowDepth = 1<<31 - 1
+
+// ShallowBoundary returns where the history of a shallow clone is cut off,
+// the latest committer date of the commits whose parents weren't fetched, and
+// false for a repository with its whole history. Commits older than the
+// boundary may be missing, like all of them but the tip on a CI checkout with
+// --depth 1.
+func ShallowBoundary(path string) (time.Time, bool, error) {
+	_, repo, err := openRepo(path)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	shallows, err := repo.Storer.Shallow()
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("unable to read the shallow commits of %s. [err=%s]", path, err.Error())
+	}
+
+	var boundary time.Time
+	for _, hash := range shallows {
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("unable to read the shallow commit %s. [err=%s]", hash, err.Error())
+		}
+		if commit.Committer.When.After(boundary) {
+			boundary = commit.Committer.When
+		}
+	}
+
+	return boundary, len(shallows) > 0, nil
+}
+
+// errShallowCommit is returned for the parent of a commit a shallow clone
+// didn't fetch.
+var errShallowCommit = errors.New("the parent wasn't fetched, the repository is a shallow clone")
+
+// firstParent returns the first parent of the commit, failing with
+// errShallowCommit when the clone is cut off at the commit.
+func firstParent(commit *object.Commit) (*object.Commit, error) {
+	parent, err := commit.Parent(0)
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, errShallowCommit
+	}
+
+	return parent, err
+}
+
+// shallowCommits returns the commits of a shallow clone whose parents weren't
+// fetched, none for a repository with its whole history.
+func shallowCommits(repo *git.Repository) (map[plumbing.Hash]bool, error) {
+	hashes, err := repo.Storer.Shallow()
+	if err != nil {
+		return nil, err
+	}
+
+	shallows := make(map[plumbing.Hash]bool, len(hashes))
+	for _, hash := range hashes {
+		shallows[hash] = true
+	}
+
+	return shallows, nil
+}
+
+// Unshallow fetches the history a shallow clone is missing from its origin,
+// or its only remote when it has no origin, like git fetch --unshallow.
+func Unshallow(ctx context.Context, path string, logger Logger) error {
+	_, repo, err := openRepo(path)
+	if err != nil {
+		return err
+	}
+
+	remotes, err := repo.Remotes()
+	if err != nil {
+		return fmt.Errorf("unable to read the remotes of %s. [err=%s]", path, err.Error())
+	}
+	name := ""
+	for _, remote := range remotes {
+		if name == "" || remote.Config().Name == git.DefaultRemoteName {
+			name = remote.Config().Name
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("unable to fetch the missing history of %s, it has no remote", path)
+	}
+
+	logger.Logf(LevelVerbose, "Fetching the missing history of %s from %s", path, name)
+	err = repo.FetchContext(ctx, &git.FetchOptions{RemoteName: name, Depth: unshallowDepth, Tags: git.AllTags})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("unable to fetch the missing history of %s from %s. [err=%s]", path, name, err.Error())
+	}
+
+	return pruneShallows(repo)
+}
+
+// pruneShallows drops the commits whose parents were fetched from the shallow
+// commits, go-git only ever adds to them.
+func pruneShallows(repo *git.Repository) error {
+	shallows, err := repo.Storer.Shallow()
+	if err != nil {
+		return err
+	}
+
+	kept := make([]plumbing.Hash, 0, len(shallows))
+	for _, hash := range shallows {
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return err
+		}
+		for _, parent := range commit.ParentHashes {
+			if repo.Storer.HasEncodedObject(parent) != nil {
+				kept = append(kept, hash)
+				break
+			}
+		}
+	}
+
+	return repo.Storer.SetShallow(kept)
+}
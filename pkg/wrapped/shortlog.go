@@ -0,0 +1,85 @@
+package wrapped
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FormatShortlog is the format mimicking git shortlog -sne, which is built
+// from the listed commits.
+const FormatShortlog = "shortlog"
+
+// FormatNeedsCommits reports whether the format renders Summary.Commits, so
+// they have to be collected with Options.ListCommits.
+func FormatNeedsCommits(format string) bool {
+	return format == FormatShortlog
+}
+
+type shortlogEntry struct {
+	identity string
+	count    int
+}
+
+// buildShortlog counts the commits per name and email like
+// git shortlog -sne, most commits first and ties by identity. A trailing
+// comment line gives the git command to compare against and what it can't
+// reproduce.
+func buildShortlog(summary *Summary, _ RenderOptions) (string, error) {
+	if !summary.has(fieldCommitList) {
+		return "", errors.New("the shortlog format needs the commits collected with Options.ListCommits")
+	}
+
+	counts := make(map[string]int)
+	for _, commit := range summary.Commits {
+		counts[fmt.Sprintf("%s <%s>", commit.Name, commit.Email)]++
+	}
+	entries := make([]shortlogEntry, 0, len(counts))
+	for identity, count := range counts {
+		entries = append(entries, shortlogEntry{identity: identity, count: count})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].count != entries[j].count {
+			return entries[i].count > entries[j].count
+		}
+		return entries[i].identity < entries[j].identity
+	})
+
+	builder := strings.Builder{}
+	for _, entry := range entries {
+		builder.WriteString(fmt.Sprintf("%6d\t%s\n", entry.count, entry.identity))
+	}
+	builder.WriteString(shortlogNote(summary))
+
+	return builder.String(), nil
+}
+
+// shortlogNote is the comment line following the shortlog.
+func shortlogNote(summary *Summary) string {
+	selection := summary.Selection
+	command := []string{"git shortlog -sne"}
+	if !selection.IncludeUnreachable {
+		command = append(command, "--all")
+	}
+	if selection.ExcludeMerges {
+		command = append(command, "--no-merges")
+	}
+	authors := make([]string, 0, len(selection.Authors))
+	for email := range selection.Authors {
+		authors = append(authors, fmt.Sprintf("--author='<%s>'", email))
+	}
+	sort.Strings(authors)
+	command = append(command, authors...)
+	command = append(command,
+		"--since="+summary.Window.Start.Format(time.RFC3339),
+		"--until="+summary.Window.End.Add(-time.Second).Format(time.RFC3339))
+
+	notes := []string{"commits are selected by author date where git filters on the committer date", "notes refs are skipped", ".mailmap isn't applied"}
+	if selection.IncludeUnreachable {
+		notes = append(notes, "unreachable commits are counted while git only walks refs")
+	}
+
+	return fmt.Sprintf("# compare with: %s; %s", strings.Join(command, " "), strings.Join(notes, ", "))
+}
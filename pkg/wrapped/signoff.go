@@ -0,0 +1,101 @@
+package wrapped
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SignedOffBy is the trailer certifying the Developer Certificate of Origin.
+const SignedOffBy = "Signed-off-by"
+
+// shownUnsigned is how many commits without a sign-off the reports list.
+const shownUnsigned = 3
+
+// SignoffStats is how many commits of the author were signed off by them.
+type SignoffStats struct {
+	// Commits is the number of commits checked, merges left out.
+	Commits int
+	// SignedOff is the number of commits signed off by one of the author's
+	// emails.
+	SignedOff int
+	// Unsigned lists the hashes of the other commits, oldest first.
+	Unsigned []string
+}
+
+// CountSignoffs checks every listed commit but merges for a Signed-off-by
+// trailer naming one of the author's emails, ignoring case. The commits must
+// have been collected with Options.ListCommits.
+func (s *Summary) CountSignoffs() error {
+	if !s.has(fieldCommitList) {
+		return errors.New("sign-offs need the commits collected with Options.ListCommits")
+	}
+
+	emails := make(map[string]bool, len(s.Selection.Authors))
+	for email := range s.Selection.Authors {
+		emails[strings.ToLower(email)] = true
+	}
+
+	stats := &SignoffStats{Unsigned: make([]string, 0)}
+	for _, commit := range s.Commits {
+		if commit.Merge {
+			continue
+		}
+		stats.Commits++
+		if signedOff(commit.Message, emails) {
+			stats.SignedOff++
+		} else {
+			stats.Unsigned = append(stats.Unsigned, commit.Hash)
+		}
+	}
+	s.Signoffs = stats
+
+	return nil
+}
+
+// signedOff reports whether the message carries a Signed-off-by trailer with
+// one of the emails, e.g. Signed-off-by: Jane Doe <jane@example.com>.
+func signedOff(message string, emails map[string]bool) bool {
+	for _, trailer := range messageTrailers(message) {
+		if !strings.EqualFold(trailer.key, SignedOffBy) {
+			continue
+		}
+		_, email, found := strings.Cut(trailer.value, "<")
+		email, closed := strings.CutSuffix(strings.TrimSpace(email), ">")
+		if found && closed && emails[strings.ToLower(strings.TrimSpace(email))] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Share returns the percentage of the commits signed off.
+func (s *SignoffStats) Share() float64 {
+	return percent(s.SignedOff, s.Commits)
+}
+
+// sentence describes the sign-offs, e.g. "37 of 40 commits (92.5%) by the
+// author, merges left out, missing on 1a2b3c4, 5d6e7f8 and 1 more". The
+// denominator is labeled as it differs from the Signed-off-by trailer count's,
+// which keeps the merges and any signer.
+func (s *SignoffStats) sentence() string {
+	sentence := fmt.Sprintf("%d of %s (%.1f%%) by the author, merges left out", s.SignedOff, commitCount(s.Commits), roundHalfUp(s.Share(), 1))
+	if len(s.Unsigned) == 0 {
+		return sentence
+	}
+
+	hashes := make([]string, 0, shownUnsigned)
+	for _, hash := range s.Unsigned {
+		if len(hashes) == shownUnsigned {
+			break
+		}
+		hashes = append(hashes, hash[:shortHashLength])
+	}
+	sentence += ", missing on " + strings.Join(hashes, ", ")
+	if more := len(s.Unsigned) - len(hashes); more > 0 {
+		sentence += fmt.Sprintf(" and %d more", more)
+	}
+
+	return sentence
+}
@@ -0,0 +1,29 @@
+package wrapped
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestSignoffsAndTrailerDenominators checks the sign-off stat and the
+// Signed-off-by trailer count label their denominators, which differ by the
+// merges.
+func TestSignoffsAndTrailerDenominators(t *testing.T) {
+	signed := "change\n\nSigned-off-by: Dev <dev@example.com>"
+	summary := listedSummary([]string{signed, "change", "change", "change", "change", "Merge branch 'topic'"}, 1)
+	if err := summary.CountSignoffs(); err != nil {
+		t.Fatal(err)
+	}
+	if err := summary.CountTrailers([]string{SignedOffBy}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	unsigned := summary.Commits[1:4]
+	want := fmt.Sprintf("1 of 5 commits (20.0%%) by the author, merges left out, missing on %s, %s, %s and 1 more", unsigned[0].Hash[:shortHashLength], unsigned[1].Hash[:shortHashLength], unsigned[2].Hash[:shortHashLength])
+	if got := summary.Signoffs.sentence(); got != want {
+		t.Errorf("sign-offs = %q, want %q", got, want)
+	}
+	if got, want := summary.Trailers.Trailers[0].sentence(summary.Trailers.Commits), "1 of 6 commits (16.7%), merges included"; got != want {
+		t.Errorf("Signed-off-by trailers = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,118 @@
+package wrapped
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sizeBucket is a range of lines changed by a commit, additions and deletions
+// together.
+type sizeBucket struct {
+	Label string
+	// Max is the most lines a commit of the bucket changes, the last bucket
+	// has no limit.
+	Max int64
+}
+
+// sizeBuckets are the buckets of the commit size histogram, smallest first.
+// Every report and the json output share them, so changing the table changes
+// them all.
+var sizeBuckets = []sizeBucket{
+	{Label: "0", Max: 0},
+	{Label: "1–10", Max: 10},
+	{Label: "11–50", Max: 50},
+	{Label: "51–200", Max: 200},
+	{Label: "201–1000", Max: 1000},
+	{Label: "1000+", Max: -1},
+}
+
+// sizeBarWidth is the width of the largest bar of the histogram.
+const sizeBarWidth = 20
+
+// sizeBucketOf returns the index of the bucket the size falls into.
+func sizeBucketOf(size int64) int {
+	for i, bucket := range sizeBuckets {
+		if bucket.Max < 0 || size <= bucket.Max {
+			return i
+		}
+	}
+
+	return len(sizeBuckets) - 1
+}
+
+// addSize counts a commit changing size lines towards the histogram.
+func (s *Summary) addSize(size int64) {
+	if s.CommitSizes == nil {
+		s.CommitSizes = make([]int, len(sizeBuckets))
+	}
+	s.CommitSizes[sizeBucketOf(size)]++
+}
+
+// mergeSizes folds the histogram of another summary into this one.
+func (s *Summary) mergeSizes(sizes []int) {
+	if len(sizes) == 0 {
+		return
+	}
+	if s.CommitSizes == nil {
+		s.CommitSizes = make([]int, len(sizeBuckets))
+	}
+	for i, count := range sizes {
+		s.CommitSizes[i] += count
+	}
+}
+
+// sizeCommits returns the commits of the histogram and its largest bucket.
+func (s *Summary) sizeCommits() (total int, most int) {
+	for i, count := range s.CommitSizes {
+		total += count
+		if count > s.CommitSizes[most] {
+			most = i
+		}
+	}
+
+	return total, most
+}
+
+// sizeSentence names the bucket holding the most commits, e.g. "you're a
+// 1–10 line committer at heart". Ties go to the smaller bucket.
+func (s *Summary) sizeSentence() string {
+	if total, most := s.sizeCommits(); total > 0 {
+		return fmt.Sprintf("you're a %s line committer at heart", sizeBuckets[most].Label)
+	}
+
+	return ""
+}
+
+// SizeHistogram draws the commit size histogram as horizontal bars, one line
+// per bucket with its commits and their share, e.g.
+//
+//	1–10     ████████████ 23 (45.1%)
+func (s *Summary) SizeHistogram() string {
+	total, most := s.sizeCommits()
+	if total == 0 {
+		return ""
+	}
+
+	width := 0
+	for _, bucket := range sizeBuckets {
+		if length := len([]rune(bucket.Label)); length > width {
+			width = length
+		}
+	}
+	lines := make([]string, 0, len(sizeBuckets))
+	for i, bucket := range sizeBuckets {
+		count := s.CommitSizes[i]
+		// Buckets too small for a full block still get a sliver.
+		bar := strings.Repeat("█", count*sizeBarWidth/s.CommitSizes[most])
+		if bar == "" && count > 0 {
+			bar = "▏"
+		}
+		if bar != "" {
+			bar += " "
+		}
+		padding := strings.Repeat(" ", width-len([]rune(bucket.Label)))
+		lines = append(lines, fmt.Sprintf("%s%s %s%d (%.1f%%)", bucket.Label, padding, bar, count, roundHalfUp(percent(count, total), 1)))
+	}
+
+	return strings.Join(lines, "\n")
+}
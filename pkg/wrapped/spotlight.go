@@ -0,0 +1,161 @@
+package wrapped
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"time"
+)
+
+// Spotlight is the file the author changed in the most commits of the window.
+type Spotlight struct {
+	Path      string
+	Commits   int
+	Additions int64
+	Deletions int64
+	// Month is the first day of the month the author changed the file in
+	// the most commits, MonthCommits.
+	Month        time.Time
+	MonthCommits int
+	// Others is the number of other people changing the file in the window,
+	// bots left out.
+	Others int
+}
+
+// FindSpotlight picks the file the author changed in the most commits, ties
+// going to the first path, and walks every commit of the window for it. A
+// commit changed the file when its change record in the summary's index says
+// so, or for the commits that weren't diffed when its blob differs from the
+// first parent's, which is cheap enough for a single path. Renames aren't
+// followed by the walk, the commits under the file's other path still count
+// towards Commits and the line stats. nil is returned without changed files.
+func FindSpotlight(ctx context.Context, paths []string, selection Selection, summary *Summary) (*Spotlight, error) {
+	if !summary.has(fieldLineStats) {
+		return nil, errors.New("the file spotlight needs the line stats")
+	}
+
+	spotlight := &Spotlight{}
+	for name, commits := range summary.touchedFiles() {
+		if commits > spotlight.Commits || (commits == spotlight.Commits && name < spotlight.Path) {
+			spotlight.Path, spotlight.Commits = name, commits
+		}
+	}
+	if spotlight.Path == "" {
+		return nil, nil
+	}
+	for name, file := range summary.files {
+		for _, side := range renameSides(name) {
+			if side == spotlight.Path {
+				spotlight.Additions += file.Additions
+				spotlight.Deletions += file.Deletions
+			}
+		}
+	}
+
+	location := summary.Window.Location
+	changes := summary.changeIndex()
+	months := make(map[time.Time]int)
+	others := make(map[string]bool)
+	authors := selection.Authors
+	selection.Authors = nil
+	for _, path := range paths {
+		_, repo, err := openRepo(path)
+		if err != nil {
+			continue
+		}
+
+		_, err = findRelevantCommits(ctx, repo, selection, nopLogger{}, skipCorrupt, func(commit *object.Commit) error {
+			if commit.NumParents() > 1 {
+				return nil
+			}
+			changed, err := changes.changesPath(commit, spotlight.Path)
+			if errors.Is(err, errShallowCommit) {
+				return nil
+			}
+			if err != nil || !changed {
+				return err
+			}
+
+			if authors[commit.Author.Email] {
+				when := commit.Author.When.In(location)
+				months[time.Date(when.Year(), when.Month(), 1, 0, 0, 0, 0, location)]++
+			} else if !isBot(commit.Author.Name, commit.Author.Email) {
+				others[commit.Author.Email] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to walk the commits of %s. [err=%s]", path, err.Error())
+		}
+	}
+
+	for month, commits := range months {
+		if commits > spotlight.MonthCommits || (commits == spotlight.MonthCommits && month.Before(spotlight.Month)) {
+			spotlight.Month, spotlight.MonthCommits = month, commits
+		}
+	}
+	spotlight.Others = len(others)
+
+	return spotlight, nil
+}
+
+// changesPath reports whether the commit changed the file at path compared to
+// its first parent, or added it for root commits.
+func changesPath(commit *object.Commit, path string) (bool, error) {
+	hash, err := pathHash(commit, path)
+	if err != nil {
+		return false, err
+	}
+	if commit.NumParents() == 0 {
+		return !hash.IsZero(), nil
+	}
+	parent, err := firstParent(commit)
+	if err != nil {
+		return false, err
+	}
+	parentHash, err := pathHash(parent, path)
+	if err != nil {
+		return false, err
+	}
+
+	return hash != parentHash, nil
+}
+
+// pathHash returns the hash of the file at path in the commit's tree, the
+// zero hash when there's none.
+func pathHash(commit *object.Commit, path string) (plumbing.Hash, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	entry, err := tree.FindEntry(path)
+	if errors.Is(err, object.ErrEntryNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+		return plumbing.ZeroHash, nil
+	}
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return entry.Hash, nil
+}
+
+// sentence is the spotlight paragraph, e.g. "Your nemesis this year was
+// pkg/parser/parser.go: 42 of your commits touched it for +1,200/-800, you
+// worked on it hardest in March with 12 commits and 4 other people touched
+// it too."
+func (s *Spotlight) sentence() string {
+	sentence := fmt.Sprintf("Your nemesis this year was %s: %d of your commits touched it for +%s/-%s", s.Path, s.Commits, FormatCount(int(s.Additions)), FormatCount(int(s.Deletions)))
+	if s.MonthCommits > 0 {
+		sentence += fmt.Sprintf(", you worked on it hardest in %s with %s", s.Month.Format("January"), commitCount(s.MonthCommits))
+	}
+	switch s.Others {
+	case 0:
+		return sentence + " and nobody else touched it."
+	case 1:
+		return sentence + " and 1 other person touched it too."
+	default:
+		return sentence + fmt.Sprintf(" and %d other people touched it too.", s.Others)
+	}
+}
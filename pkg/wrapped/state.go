@@ -0,0 +1,390 @@
+package wrapped
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// stateVersion is the version of the state file, bumped whenever its shape or
+// the meaning of its records changes. A state file of another version is
+// ignored, the next run starting over.
+const stateVersion = 1
+
+// State remembers the commits of the last run of every repository, so the
+// next run only walks the history the refs gained since and adds the commits
+// it finds to the ones of the last run. A repository starts over when the
+// settings deciding its commits and their line stats changed, like the path
+// filters, or when one of its refs was deleted or rewritten. The state is only
+// a shortcut: deleting its file at any time makes the next run analyze
+// everything again. It's safe to share between repositories analyzed at once.
+type State struct {
+	mu    sync.Mutex
+	path  string
+	repos map[string]*repoState
+}
+
+// stateFile is the on-disk representation of the State.
+type stateFile struct {
+	Version int                   `json:"version"`
+	Repos   map[string]*repoState `json:"repos"`
+}
+
+// repoState is what the last run of a repository found.
+type repoState struct {
+	// Key identifies the settings the commits were selected and their line
+	// stats computed with, see stateKey.
+	Key string `json:"key"`
+	// Refs are the commits every ref pointed at, keyed by the ref's name.
+	Refs map[string]string `json:"refs"`
+	// Walked are every commit the walks went through, matched or not, which
+	// the next walk stops at.
+	Walked []string `json:"walked"`
+	// Commits are the matched commits with their line stats.
+	Commits []stateCommit `json:"commits"`
+	// CoAuthored is the number of the co-authored commits counted apart.
+	CoAuthored int `json:"co_authored,omitempty"`
+}
+
+// stateCommit is a matched commit of the last run, as much of it as the
+// summary needs.
+type stateCommit struct {
+	Hash        string   `json:"hash"`
+	Parents     []string `json:"parents"`
+	AuthorName  string   `json:"author_name"`
+	AuthorEmail string   `json:"author_email"`
+	// AuthorTime is RFC 3339 with the offset the commit was made at.
+	AuthorTime string      `json:"author_time"`
+	Message    string      `json:"message"`
+	Additions  int64       `json:"additions"`
+	Deletions  int64       `json:"deletions"`
+	Files      []fileStats `json:"files"`
+	Merge      bool        `json:"merge"`
+	SkipLines  bool        `json:"skip_lines"`
+	StatsErr   string      `json:"stats_err,omitempty"`
+	Oversized  string      `json:"oversized,omitempty"`
+}
+
+// LoadState reads the state file at path. A missing file is an empty state,
+// and so is a file of another version or one that can't be parsed, which the
+// logger is told about.
+func LoadState(path string, logger Logger) (*State, error) {
+	logger = orNop(logger)
+	state := &State{path: path, repos: make(map[string]*repoState)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		logger.Logf(LevelVerbose, "No state at %s yet, analyzing every commit", path)
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the state %s. [err=%s]", path, err.Error())
+	}
+
+	file := stateFile{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		logger.Logf(LevelVerbose, "Ignoring the state %s, it can't be parsed: %s", path, err.Error())
+		return state, nil
+	}
+	if file.Version != stateVersion {
+		logger.Logf(LevelVerbose, "Ignoring the state %s of version %d, expected %d", path, file.Version, stateVersion)
+		return state, nil
+	}
+	if file.Repos != nil {
+		state.repos = file.Repos
+	}
+
+	return state, nil
+}
+
+// Save writes the state back to its file through a rename, so an interrupted
+// save leaves the last one in place.
+func (s *State) Save() error {
+	s.mu.Lock()
+	data, err := json.Marshal(stateFile{Version: stateVersion, Repos: s.repos})
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".state-*")
+	if err != nil {
+		return fmt.Errorf("unable to save the state %s. [err=%s]", s.path, err.Error())
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to save the state %s. [err=%s]", s.path, err.Error())
+	}
+
+	return nil
+}
+
+// stateKey identifies the settings deciding which commits of a repository
+// are matched and what their line stats are. A change to any of them makes
+// the commits of the last run meaningless.
+func stateKey(selection Selection, opts Options) string {
+	authors := make([]string, 0, len(selection.Authors))
+	for email := range selection.Authors {
+		authors = append(authors, email)
+	}
+	sort.Strings(authors)
+
+	key, _ := json.Marshal(struct {
+		Start, End      time.Time
+		Location        string
+		Everyone        bool
+		Authors         []string
+		ExcludedAuthors []string
+		ExcludeMerges   bool
+		Coauthors       string
+		Filter          string
+		Fast            bool
+		MergeStats      string
+		Limits          CommitLimits
+	}{
+		selection.Window.Start, selection.Window.End, selection.Window.Location.String(),
+		selection.Authors == nil, authors, selection.ExcludedAuthors, selection.ExcludeMerges, selection.Coauthors,
+		opts.Filter.key(), opts.Fast, opts.MergeStats, opts.Limits,
+	})
+	sum := sha256.Sum256(key)
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// stateRun is the run of a single repository against the state, walking the
+// history its refs gained since the last run, or all of it when the last run
+// can't be built upon.
+type stateRun struct {
+	root  string
+	key   string
+	prior *repoState
+	// refs are the commits the refs point at now, and tips the ones the walk
+	// starts from.
+	refs   map[string]string
+	tips   []*object.Commit
+	hidden map[plumbing.Hash]bool
+	// walked and commits are what this run adds to the prior run.
+	walked  []string
+	commits []stateCommit
+}
+
+// begin starts the run of the repository at root, building on its last run
+// when it had the same key and its refs only moved forward since.
+func (s *State) begin(ctx context.Context, repo *git.Repository, root string, key string, since time.Time, logger Logger) (*stateRun, error) {
+	named, err := namedRefTips(repo, logger)
+	if err != nil {
+		return nil, err
+	}
+	run := &stateRun{root: root, key: key, refs: make(map[string]string, len(named))}
+	for _, tip := range named {
+		run.refs[tip.name] = tip.commit.Hash.String()
+	}
+
+	s.mu.Lock()
+	prior := s.repos[root]
+	s.mu.Unlock()
+	switch {
+	case prior == nil:
+		logger.Logf(LevelVerbose, "%s: no state of the last run, walking every ref", root)
+	case prior.Key != key:
+		logger.Logf(LevelVerbose, "%s: the settings changed since the last run, walking every ref", root)
+		prior = nil
+	default:
+		run.hidden = make(map[plumbing.Hash]bool, len(prior.Walked))
+		for _, hash := range prior.Walked {
+			run.hidden[plumbing.NewHash(hash)] = true
+		}
+		if reason := run.rewritten(ctx, repo, prior, named, since); reason != "" {
+			logger.Logf(LevelVerbose, "%s: %s since the last run, walking every ref", root, reason)
+			prior, run.hidden = nil, nil
+		}
+	}
+	run.prior = prior
+
+	for _, tip := range named {
+		if prior == nil || prior.Refs[tip.name] != tip.commit.Hash.String() {
+			run.tips = append(run.tips, tip.commit)
+		}
+	}
+	if prior != nil {
+		logger.Logf(LevelVerbose, "%s: %s commits of the last run, walking the %s refs that moved since", root, FormatCount(len(prior.Commits)), FormatCount(len(run.tips)))
+	}
+
+	return run, nil
+}
+
+// rewritten returns why the refs can't be walked from where the last run left
+// them, like a deleted ref, empty when every ref only moved forward.
+func (r *stateRun) rewritten(ctx context.Context, repo *git.Repository, prior *repoState, named []namedTip, since time.Time) string {
+	tips := make(map[string]*object.Commit, len(named))
+	for _, tip := range named {
+		tips[tip.name] = tip.commit
+	}
+
+	names := make([]string, 0, len(prior.Refs))
+	for name := range prior.Refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tip, ok := tips[name]
+		if !ok {
+			return name + " was deleted"
+		}
+		if tip.Hash.String() != prior.Refs[name] && !r.fastForward(ctx, repo, tip, plumbing.NewHash(prior.Refs[name]), since) {
+			return name + " was rewritten"
+		}
+	}
+
+	return ""
+}
+
+// fastForward reports whether the new commits of the tip lead to where the
+// ref was, walking no further than the commits already walked. It errs on the
+// side of a rewrite, like for a ref that went back to an older commit.
+func (r *stateRun) fastForward(ctx context.Context, repo *git.Repository, tip *object.Commit, previous plumbing.Hash, since time.Time) bool {
+	horizon := since.Add(-walkSlack)
+	seen := map[plumbing.Hash]bool{tip.Hash: true}
+	queue := []*object.Commit{tip}
+	for len(queue) > 0 {
+		if ctx.Err() != nil {
+			return false
+		}
+		commit := queue[0]
+		queue = queue[1:]
+		if r.hidden[commit.Hash] || commit.Committer.When.Before(horizon) {
+			continue
+		}
+		for _, parentHash := range commit.ParentHashes {
+			if parentHash == previous {
+				return true
+			}
+			if seen[parentHash] {
+				continue
+			}
+			seen[parentHash] = true
+			parent, err := repo.CommitObject(parentHash)
+			if err != nil {
+				return false
+			}
+			queue = append(queue, parent)
+		}
+	}
+
+	return false
+}
+
+// walk is walkCommits over the history the refs gained since the last run,
+// remembering every commit it goes through.
+func (r *stateRun) walk(ctx context.Context, repo *git.Repository, since time.Time, logger Logger, corrupt func(CommitError) error) func(visit func(*object.Commit) error) error {
+	return func(visit func(*object.Commit) error) error {
+		return walkFrom(ctx, repo, r.tips, r.hidden, since, logger, corrupt, func(commit *object.Commit) error {
+			r.walked = append(r.walked, commit.Hash.String())
+			return visit(commit)
+		})
+	}
+}
+
+// record remembers a matched commit of this run.
+func (r *stateRun) record(result commitStats) {
+	commit := stateCommit{
+		Hash:        result.commit.Hash.String(),
+		AuthorName:  result.commit.Author.Name,
+		AuthorEmail: result.commit.Author.Email,
+		AuthorTime:  result.commit.Author.When.Format(time.RFC3339),
+		Message:     result.commit.Message,
+		Additions:   result.additions,
+		Deletions:   result.deletions,
+		Files:       result.files,
+		Merge:       result.merge,
+		SkipLines:   result.skipLines,
+		Oversized:   result.oversized,
+	}
+	for _, parent := range result.commit.ParentHashes {
+		commit.Parents = append(commit.Parents, parent.String())
+	}
+	if result.statsErr != nil {
+		commit.StatsErr = result.statsErr.Error()
+	}
+	r.commits = append(r.commits, commit)
+}
+
+// restored returns the matched commits of the last run, to be added to the
+// summary like the ones this run finds.
+func (r *stateRun) restored() ([]commitStats, error) {
+	if r.prior == nil {
+		return nil, nil
+	}
+
+	results := make([]commitStats, 0, len(r.prior.Commits))
+	for _, commit := range r.prior.Commits {
+		when, err := time.Parse(time.RFC3339, commit.AuthorTime)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read commit %s of the state. [err=%s]", commit.Hash, err.Error())
+		}
+		signature := object.Signature{Name: commit.AuthorName, Email: commit.AuthorEmail, When: when}
+		result := commitStats{
+			commit: &object.Commit{Hash: plumbing.NewHash(commit.Hash), Author: signature, Committer: signature, Message: commit.Message},
+			changeRecord: changeRecord{
+				additions: commit.Additions,
+				deletions: commit.Deletions,
+				files:     commit.Files,
+			},
+			merge:     commit.Merge,
+			skipLines: commit.SkipLines,
+			oversized: commit.Oversized,
+		}
+		for _, parent := range commit.Parents {
+			result.commit.ParentHashes = append(result.commit.ParentHashes, plumbing.NewHash(parent))
+		}
+		if commit.StatsErr != "" {
+			result.statsErr = errors.New(commit.StatsErr)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// priorCoAuthored returns the co-authored commits of the last run counted
+// apart.
+func (r *stateRun) priorCoAuthored() int {
+	if r.prior == nil {
+		return 0
+	}
+
+	return r.prior.CoAuthored
+}
+
+// finish stores the run into the state, once the repository was analyzed
+// in full.
+func (s *State) finish(run *stateRun, coauthored int) {
+	entry := &repoState{Key: run.key, Refs: run.refs, Walked: run.walked, Commits: run.commits, CoAuthored: coauthored}
+	if run.prior != nil {
+		entry.Walked = append(append([]string{}, run.prior.Walked...), run.walked...)
+		entry.Commits = append(append([]stateCommit{}, run.prior.Commits...), run.commits...)
+		entry.CoAuthored += run.prior.CoAuthored
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.repos[run.root] = entry
+}
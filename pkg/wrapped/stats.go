@@ -0,0 +1,192 @@
+package wrapped
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Stat is a stat of the report that can be enabled or disabled on its own,
+// or with the others of its group. A disabled stat is neither computed nor
+// rendered.
+type Stat struct {
+	ID    string
+	Group string
+	// Description tells what the stat is in a line.
+	Description string
+	// Needs are what the stat is derived from on top of the metadata of the
+	// commits. The analysis computes no more than the enabled stats need.
+	Needs Capability
+	// Default is set for the stats enabled unless disabled. The others are
+	// slower or need more settings, and are enabled on request.
+	Default bool
+}
+
+// Capability is something the analysis computes for the commits on top of
+// their metadata, the stats needing it setting its flag.
+type Capability uint
+
+const (
+	// CapChanges is the change record of every commit of the author, the
+	// files it changed and their lines, computed once from its diff for every
+	// stat derived from them.
+	CapChanges Capability = 1 << iota
+	// CapChangeIndex keeps the change records by commit for the stats walking
+	// the window again, which read them rather than diffing the author's
+	// commits again, and share the diffs of the other authors' commits.
+	CapChangeIndex
+)
+
+// The groups of the stats.
+const (
+	StatGroupTiming   = "timing"
+	StatGroupSize     = "size"
+	StatGroupMessages = "messages"
+	StatGroupFun      = "fun"
+)
+
+// The IDs of the stats.
+const (
+	StatKickoff      = "kickoff"
+	StatEarlyLate    = "early-late"
+	StatActiveDays   = "active-days"
+	StatCadence      = "cadence"
+	StatWorkPattern  = "work-pattern"
+	StatHistory      = "history"
+	StatLines        = "lines"
+	StatFocus        = "focus"
+	StatNetLines     = "net-lines"
+	StatCommitSizes  = "commit-sizes"
+	StatFiles        = "files"
+	StatMerges       = "merges"
+	StatOwnership    = "ownership"
+	StatSurvival     = "survival"
+	StatTickets      = "tickets"
+	StatTrailers     = "trailers"
+	StatFixes        = "fixes"
+	StatSignoffs     = "signoffs"
+	StatHotStreak    = "hot-streak"
+	StatSpotlight    = "spotlight"
+	StatNeighbors    = "neighbors"
+	StatReleases     = "releases"
+	StatAchievements = "achievements"
+)
+
+// stats are the stats of the report, group by group. The total
+// commits, the sample, the co-authored commits, the plugin lines, the forge
+// reviews and the team and repository breakdowns aren't among them, they're
+// always reported when there's something to report.
+var stats = []Stat{
+	{ID: StatKickoff, Group: StatGroupTiming, Description: "The first and the last commit of the year", Default: true},
+	{ID: StatEarlyLate, Group: StatGroupTiming, Description: "The commits made the earliest and the latest in the day", Default: true},
+	{ID: StatActiveDays, Group: StatGroupTiming, Description: "The days with commits and the busiest of them", Default: true},
+	{ID: StatCadence, Group: StatGroupTiming, Description: "The commits of every week and how consistent they are", Default: true},
+	{ID: StatWorkPattern, Group: StatGroupTiming, Description: "The commits made in and after the work hours"},
+	{ID: StatHistory, Group: StatGroupTiming, Description: "The rank of the year among the years of the history"},
+	{ID: StatLines, Group: StatGroupSize, Description: "The lines added and deleted, the empty and the oversized commits", Needs: CapChanges, Default: true},
+	{ID: StatFocus, Group: StatGroupSize, Description: "The commits changing a single file and the commit changing the most", Needs: CapChanges, Default: true},
+	{ID: StatNetLines, Group: StatGroupSize, Description: "The lines added net of the deleted ones, month by month", Needs: CapChanges, Default: true},
+	{ID: StatCommitSizes, Group: StatGroupSize, Description: "The commits by the lines they changed", Needs: CapChanges, Default: true},
+	{ID: StatFiles, Group: StatGroupSize, Description: "The most changed files", Needs: CapChanges, Default: true},
+	{ID: StatMerges, Group: StatGroupSize, Description: "The merge commits", Default: true},
+	{ID: StatOwnership, Group: StatGroupSize, Description: "The files the author is the top committer of", Needs: CapChanges | CapChangeIndex},
+	{ID: StatSurvival, Group: StatGroupSize, Description: "The added lines still alive at the end of the window", Needs: CapChanges},
+	{ID: StatTickets, Group: StatGroupMessages, Description: "The tickets the commit messages reference, by the ticket patterns", Default: true},
+	{ID: StatTrailers, Group: StatGroupMessages, Description: "The commits carrying the trailers asked for", Default: true},
+	{ID: StatFixes, Group: StatGroupMessages, Description: "The fix commits against the feature commits"},
+	{ID: StatSignoffs, Group: StatGroupMessages, Description: "The commits signed off by the author"},
+	{ID: StatHotStreak, Group: StatGroupFun, Description: "The weeks with the most commits against the usual pace", Default: true},
+	{ID: StatSpotlight, Group: StatGroupFun, Description: "The file the author changed the most, the nemesis", Needs: CapChanges | CapChangeIndex, Default: true},
+	{ID: StatNeighbors, Group: StatGroupFun, Description: "The other authors changing the same files", Needs: CapChanges | CapChangeIndex},
+	{ID: StatReleases, Group: StatGroupFun, Description: "The tags shipping the author's commits"},
+	{ID: StatAchievements, Group: StatGroupFun, Description: "The badges unlocked by stats reaching their thresholds", Default: true},
+}
+
+// Stats returns the stats of the report, group by group.
+func Stats() []Stat {
+	return append([]Stat{}, stats...)
+}
+
+// StatGroups returns the groups of the stats.
+func StatGroups() []string {
+	return []string{StatGroupTiming, StatGroupSize, StatGroupMessages, StatGroupFun}
+}
+
+// StatSet is the set of the enabled stats, keyed by their ID. A nil StatSet
+// enables every stat.
+type StatSet map[string]bool
+
+// DefaultStats returns the stats enabled unless disabled.
+func DefaultStats() StatSet {
+	set := StatSet{}
+	for _, stat := range stats {
+		if stat.Default {
+			set[stat.ID] = true
+		}
+	}
+
+	return set
+}
+
+// Set enables or disables the stat with the ID, or every stat of the group
+// with the name.
+func (s StatSet) Set(name string, enabled bool) error {
+	found := false
+	for _, stat := range stats {
+		if stat.ID != name && stat.Group != name {
+			continue
+		}
+		found = true
+		if enabled {
+			s[stat.ID] = true
+		} else {
+			delete(s, stat.ID)
+		}
+	}
+	if !found {
+		return fmt.Errorf("unknown stat or group %q", name)
+	}
+
+	return nil
+}
+
+// Has reports whether the stat with the ID is enabled.
+func (s StatSet) Has(id string) bool {
+	return s == nil || s[id]
+}
+
+// Needs returns what the enabled stats need the analysis to compute.
+func (s StatSet) Needs() Capability {
+	needs := Capability(0)
+	for _, stat := range stats {
+		if s.Has(stat.ID) {
+			needs |= stat.Needs
+		}
+	}
+
+	return needs
+}
+
+// Deep reports whether one of the enabled stats is derived from the diffs of
+// the commits.
+func (s StatSet) Deep() bool {
+	return s.Needs()&CapChanges != 0
+}
+
+// IDs returns the IDs of the enabled stats, sorted.
+func (s StatSet) IDs() []string {
+	ids := make([]string, 0, len(s))
+	for _, stat := range stats {
+		if s.Has(stat.ID) {
+			ids = append(ids, stat.ID)
+		}
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+// shows reports whether the report shows the stat with the ID, see
+// Summary.Stats.
+func (s *Summary) shows(id string) bool {
+	return s.Stats.Has(id)
+}
@@ -0,0 +1,136 @@
+package wrapped
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+)
+
+// Survival is how many of the lines the author added in the window still
+// exist at its end.
+type Survival struct {
+	Added int64
+	Alive int64
+}
+
+// Share returns the percentage of the added lines still alive.
+func (s *Survival) Share() float64 {
+	if s.Added == 0 {
+		return 0
+	}
+
+	return float64(s.Alive) * 100 / float64(s.Added)
+}
+
+// sentence describes the survival, e.g. "68% of the code you wrote this year
+// is still alive".
+func (s *Survival) sentence() string {
+	return fmt.Sprintf("%.0f%% of the code you wrote this year is still alive", roundHalfUp(s.Share(), 0))
+}
+
+// FindSurvival blames the files the author changed as they were at the end of
+// the window, on the first parent chain of HEAD, and counts the lines still
+// credited to the author's commits of the window against the lines they added
+// to them. Renames found by the line stats are followed to the file's last
+// path. go-git's blame doesn't follow renames itself, so lines moved by a
+// rename are credited to the renaming commit, which is why a file's alive
+// lines are capped at its additions. Deleted files keep their additions with
+// no lines alive, and binary files are left out. nil is returned when the
+// author added no lines.
+func FindSurvival(ctx context.Context, paths []string, selection Selection, summary *Summary) (*Survival, error) {
+	if !summary.has(fieldLineStats) {
+		return nil, errors.New("code survival needs the line stats")
+	}
+
+	renames := make(map[string]string)
+	for name := range summary.files {
+		if sides := renameSides(name); len(sides) == 2 {
+			renames[sides[0]] = sides[1]
+		}
+	}
+	added := make(map[string]int64, len(summary.files))
+	for name, file := range summary.files {
+		sides := renameSides(name)
+		added[lastPath(renames, sides[len(sides)-1])] += file.Additions
+	}
+
+	alive := make(map[string]int64, len(added))
+	for _, path := range paths {
+		_, repo, err := openRepo(path)
+		if err != nil {
+			continue
+		}
+		// Blame can't tell who wrote the lines older than a shallow clone's
+		// history.
+		shallows, err := shallowCommits(repo)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the shallow commits of %s. [err=%s]", path, err.Error())
+		}
+		if len(shallows) > 0 {
+			continue
+		}
+		end, err := windowEnd(repo, selection.Branch, selection.Window)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find the end of the window in %s. [err=%s]", path, err.Error())
+		}
+		if end == nil {
+			continue
+		}
+		tree, err := end.Tree()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the tree of %s. [err=%s]", end.Hash, err.Error())
+		}
+
+		for name := range added {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if _, ok := fileLines(tree, name); !ok {
+				continue
+			}
+			blame, err := git.Blame(end, name)
+			// The history the analysis couldn't read can't be blamed either,
+			// the files reaching into it are left out.
+			if err != nil && len(summary.CorruptSkipped) > 0 {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("unable to blame %s in %s. [err=%s]", name, path, err.Error())
+			}
+			for _, line := range blame.Lines {
+				if selection.Authors[line.Author] && !selection.Excludes(line.Author) && selection.Window.contains(line.Date) {
+					alive[name]++
+				}
+			}
+		}
+	}
+
+	survival := &Survival{}
+	for name, lines := range added {
+		survival.Added += lines
+		if alive[name] < lines {
+			survival.Alive += alive[name]
+		} else {
+			survival.Alive += lines
+		}
+	}
+	if survival.Added == 0 {
+		return nil, nil
+	}
+
+	return survival, nil
+}
+
+// lastPath follows the renames from the path to the one the file ended up at.
+func lastPath(renames map[string]string, path string) string {
+	seen := map[string]bool{path: true}
+	for {
+		next, ok := renames[path]
+		if !ok || seen[next] {
+			return path
+		}
+		seen[next] = true
+		path = next
+	}
+}
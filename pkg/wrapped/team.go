@@ -0,0 +1,110 @@
+package wrapped
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"time"
+)
+
+// SectionNewContributors is the ranked list of the new contributors of a
+// team wrapped.
+const SectionNewContributors = "new-contributors"
+
+// TeamStats describe who contributed to a team wrapped, where the commits of
+// every author are analyzed together. Authors are told apart by their email,
+// so someone committing with two counts twice.
+type TeamStats struct {
+	// Contributors is the number of emails that committed in the window.
+	Contributors int
+	// NewContributors are the emails whose first commit in any of the
+	// repositories is in the window, most commits first.
+	NewContributors []IdentityCount
+}
+
+// errWalkDone stops a walk once everything it looked for was found.
+var errWalkDone = errors.New("walk done")
+
+// FindTeam counts the contributors of the window and tells the new ones
+// apart by whether they committed before it, which walks the history before
+// the window too. The selection's Authors are ignored. Repositories that
+// can't be opened are skipped, unless none of them can.
+func FindTeam(ctx context.Context, paths []string, selection Selection) (*TeamStats, error) {
+	identities, err := CountIdentities(ctx, paths, selection)
+	if err != nil {
+		return nil, err
+	}
+
+	// Walking stops as soon as every contributor is known from before.
+	unseen := make(map[string]bool, len(identities))
+	for _, identity := range identities {
+		unseen[identity.Email] = true
+	}
+	for _, path := range paths {
+		if len(unseen) == 0 {
+			break
+		}
+		_, repo, err := openRepo(path)
+		if err != nil {
+			continue
+		}
+
+		visit := func(commit *object.Commit) error {
+			if commit.Author.When.Before(selection.Window.Start) && unseen[commit.Author.Email] {
+				delete(unseen, commit.Author.Email)
+				if len(unseen) == 0 {
+					return errWalkDone
+				}
+			}
+			return nil
+		}
+		if selection.IncludeUnreachable {
+			err = scanCommitObjects(ctx, repo, nopLogger{}, visit)
+		} else {
+			err = walkCommits(ctx, repo, time.Time{}, nopLogger{}, skipCorrupt, visit)
+		}
+		if err != nil && !errors.Is(err, errWalkDone) {
+			return nil, err
+		}
+	}
+
+	// The identities are ranked already, so the new contributors are too.
+	team := &TeamStats{Contributors: len(identities), NewContributors: make([]IdentityCount, 0, len(unseen))}
+	for _, identity := range identities {
+		if unseen[identity.Email] {
+			team.NewContributors = append(team.NewContributors, identity)
+		}
+	}
+
+	return team, nil
+}
+
+// sentence describes the contributors, e.g. "12, 3 of them new".
+func (t *TeamStats) sentence() string {
+	return fmt.Sprintf("%d, %d of them new", t.Contributors, len(t.NewContributors))
+}
+
+// lineCount returns the number of lines with the noun agreeing.
+func lineCount(lines int) string {
+	if lines == 1 {
+		return "1 line"
+	}
+
+	return FormatCount(lines) + " lines"
+}
+
+// shownNewContributors returns the new contributors the report lists.
+func shownNewContributors(summary *Summary, opts RenderOptions) []IdentityCount {
+	top := opts.Limit(SectionNewContributors)
+	if summary.Team == nil || top <= 0 {
+		return nil
+	}
+
+	contributors := summary.Team.NewContributors
+	if len(contributors) > top {
+		contributors = contributors[:top]
+	}
+
+	return contributors
+}
@@ -0,0 +1,254 @@
+package wrapped
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Theme is the palette of the colored outputs: the ANSI colors of the text
+// report in a terminal, the image cards and the HTML report. The other colors
+// they need, like the muted text and the cells of the heatmap, are blended
+// from these.
+type Theme struct {
+	Background color.RGBA
+	// Accent highlights the titles, the banner and the busiest days.
+	Accent   color.RGBA
+	Addition color.RGBA
+	Deletion color.RGBA
+	Text     color.RGBA
+
+	// mutedContrast is the contrast the muted text is kept at, 0 for
+	// themeMutedContrast.
+	mutedContrast float64
+}
+
+// The built-in themes, see Themes.
+const (
+	ThemeDefault        = "default"
+	ThemeDracula        = "dracula"
+	ThemeSolarizedLight = "solarized-light"
+	// ThemeHighContrast keeps every color at a contrast of at least 7:1
+	// against its background, WCAG's AAA level.
+	ThemeHighContrast = "high-contrast"
+)
+
+// themes are the built-in themes by their name.
+var themes = map[string]Theme{
+	ThemeDefault: {
+		Background: color.RGBA{13, 17, 23, 255},
+		Accent:     color.RGBA{57, 211, 83, 255},
+		Addition:   color.RGBA{63, 185, 80, 255},
+		Deletion:   color.RGBA{248, 81, 73, 255},
+		Text:       color.RGBA{240, 246, 252, 255},
+	},
+	ThemeDracula: {
+		Background: color.RGBA{40, 42, 54, 255},
+		Accent:     color.RGBA{189, 147, 249, 255},
+		Addition:   color.RGBA{80, 250, 123, 255},
+		Deletion:   color.RGBA{255, 85, 85, 255},
+		Text:       color.RGBA{248, 248, 242, 255},
+	},
+	ThemeSolarizedLight: {
+		Background: color.RGBA{253, 246, 227, 255},
+		Accent:     color.RGBA{38, 139, 210, 255},
+		Addition:   color.RGBA{133, 153, 0, 255},
+		Deletion:   color.RGBA{220, 50, 47, 255},
+		Text:       color.RGBA{88, 110, 117, 255},
+	},
+	ThemeHighContrast: {
+		Background: color.RGBA{0, 0, 0, 255},
+		Accent:     color.RGBA{255, 214, 0, 255},
+		Addition:   color.RGBA{0, 230, 118, 255},
+		Deletion:   color.RGBA{255, 138, 128, 255},
+		Text:       color.RGBA{255, 255, 255, 255},
+		// The muted text isn't dimmed past AAA either.
+		mutedContrast: 7,
+	},
+}
+
+// Themes returns the names of the built-in themes, sorted.
+func Themes() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// NamedTheme returns the built-in theme of the name.
+func NamedTheme(name string) (Theme, bool) {
+	theme, ok := themes[name]
+	return theme, ok
+}
+
+// DefaultTheme returns the theme the cards are drawn with when none is set.
+func DefaultTheme() Theme {
+	return themes[ThemeDefault]
+}
+
+// LoadTheme reads a theme from a JSON file of #rrggbb colors by their name,
+// e.g. {"background": "#282a36", "accent": "#bd93f9"}. The colors it leaves
+// out are the default theme's.
+func LoadTheme(path string) (Theme, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, err
+	}
+
+	var colors struct {
+		Background *string `json:"background"`
+		Accent     *string `json:"accent"`
+		Addition   *string `json:"addition"`
+		Deletion   *string `json:"deletion"`
+		Text       *string `json:"text"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&colors); err != nil {
+		return Theme{}, fmt.Errorf("invalid theme %s. [err=%s]", path, err.Error())
+	}
+
+	theme := DefaultTheme()
+	fields := []struct {
+		name  string
+		value *string
+		color *color.RGBA
+	}{
+		{"background", colors.Background, &theme.Background},
+		{"accent", colors.Accent, &theme.Accent},
+		{"addition", colors.Addition, &theme.Addition},
+		{"deletion", colors.Deletion, &theme.Deletion},
+		{"text", colors.Text, &theme.Text},
+	}
+	for _, field := range fields {
+		if field.value == nil {
+			continue
+		}
+		parsed, err := parseHexColor(*field.value)
+		if err != nil {
+			return Theme{}, fmt.Errorf("invalid %s color %q in the theme %s. [err=%s]", field.name, *field.value, path, err.Error())
+		}
+		*field.color = parsed
+	}
+
+	return theme, nil
+}
+
+// parseHexColor parses a #rrggbb or #rgb color.
+func parseHexColor(hex string) (color.RGBA, error) {
+	digits, ok := strings.CutPrefix(hex, "#")
+	if !ok || (len(digits) != 3 && len(digits) != 6) {
+		return color.RGBA{}, errors.New("expected #rrggbb")
+	}
+	if len(digits) == 3 {
+		digits = string([]byte{digits[0], digits[0], digits[1], digits[1], digits[2], digits[2]})
+	}
+	value, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return color.RGBA{}, errors.New("expected #rrggbb")
+	}
+
+	return color.RGBA{uint8(value >> 16), uint8(value >> 8), uint8(value), 255}, nil
+}
+
+// themeMutedShare is how much of the text color the muted text is blended
+// from, and themeMutedContrast the contrast it's raised to at least, WCAG's
+// AA level.
+const (
+	themeMutedShare    = 0.55
+	themeMutedContrast = 4.5
+)
+
+// muted returns the color of the secondary text, the text blended into the
+// background but kept readable.
+func (t Theme) muted() color.RGBA {
+	minimum := t.mutedContrast
+	if minimum == 0 {
+		minimum = themeMutedContrast
+	}
+	for share := themeMutedShare; share < 1; share += 0.05 {
+		muted := blend(t.Background, t.Text, share)
+		if contrastRatio(muted, t.Background) >= minimum {
+			return muted
+		}
+	}
+
+	return t.Text
+}
+
+// heat returns the cells of the heatmap from no commits to the most, blended
+// from the background to the accent.
+func (t Theme) heat() []color.RGBA {
+	return []color.RGBA{
+		blend(t.Background, t.Text, 0.04),
+		blend(t.Background, t.Accent, 0.25),
+		blend(t.Background, t.Accent, 0.5),
+		blend(t.Background, t.Accent, 0.75),
+		t.Accent,
+	}
+}
+
+// Highlight returns the text in the background color on the accent, e.g. for
+// the selected tab of the terminal UI.
+func (t Theme) Highlight(text string) string {
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%d;48;2;%d;%d;%dm%s\x1b[0m",
+		t.Background.R, t.Background.G, t.Background.B, t.Accent.R, t.Accent.G, t.Accent.B, text)
+}
+
+// css returns the style sheet the HTML report is themed with.
+func (t Theme) css() string {
+	return fmt.Sprintf(`body { background: %s; color: %s; }
+h1, h2, a { color: %s; }
+.muted { color: %s; }
+.additions { color: %s; }
+.deletions { color: %s; }
+`, hexColor(t.Background), hexColor(t.Text), hexColor(t.Accent), hexColor(t.muted()), hexColor(t.Addition), hexColor(t.Deletion))
+}
+
+// hexColor returns the #rrggbb notation of the color.
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// blend mixes share of to into from.
+func blend(from color.RGBA, to color.RGBA, share float64) color.RGBA {
+	mix := func(a uint8, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + (float64(b)-float64(a))*share))
+	}
+
+	return color.RGBA{mix(from.R, to.R), mix(from.G, to.G), mix(from.B, to.B), 255}
+}
+
+// contrastRatio returns the WCAG contrast ratio of the colors, from 1:1 for
+// the same color to 21:1 for black on white.
+func contrastRatio(a color.RGBA, b color.RGBA) float64 {
+	lighter, darker := luminance(a), luminance(b)
+	if darker > lighter {
+		lighter, darker = darker, lighter
+	}
+
+	return (lighter + 0.05) / (darker + 0.05)
+}
+
+// luminance returns the relative luminance of the color as WCAG defines it.
+func luminance(c color.RGBA) float64 {
+	channel := func(value uint8) float64 {
+		v := float64(value) / 255
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+
+	return 0.2126*channel(c.R) + 0.7152*channel(c.G) + 0.0722*channel(c.B)
+}
@@ -0,0 +1,144 @@
+package wrapped
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SectionTickets is the ranked list of the tickets referenced by the most
+// commits.
+const SectionTickets = "tickets"
+
+// TicketStats are the tickets the commit messages reference, like the Jira
+// keys PAY-1234.
+type TicketStats struct {
+	// Tickets are the referenced tickets, most commits first.
+	Tickets []TicketCount
+	// Commits is the number of commits the references were looked for in.
+	Commits int
+	// Ticketless is the number of commits referencing no ticket.
+	Ticketless int
+}
+
+// TicketCount is the number of commits referencing a ticket.
+type TicketCount struct {
+	Key     string
+	Commits int
+}
+
+// CountTickets finds the references of the patterns in the messages of the
+// listed commits. A commit mentioning a ticket more than once counts once.
+// The commits must have been collected with Options.ListCommits.
+func (s *Summary) CountTickets(patterns []*regexp.Regexp) error {
+	if len(patterns) == 0 {
+		return nil
+	}
+	if !s.has(fieldCommitList) {
+		return errors.New("ticket references need the commits collected with Options.ListCommits")
+	}
+
+	counter := newTicketCounter(patterns)
+	for _, commit := range s.Commits {
+		counter.observe(commit.Message)
+	}
+	s.Tickets = counter.finish()
+
+	return nil
+}
+
+// ticketCounter counts the tickets the commit messages reference, for
+// CountTickets and the tickets stat plugin.
+type ticketCounter struct {
+	patterns []*regexp.Regexp
+	counts   map[string]int
+	commits  int
+	// ticketless is the number of messages referencing no ticket.
+	ticketless int
+}
+
+func newTicketCounter(patterns []*regexp.Regexp) *ticketCounter {
+	return &ticketCounter{patterns: patterns, counts: make(map[string]int)}
+}
+
+// observe counts the tickets the message references, once each.
+func (c *ticketCounter) observe(message string) {
+	c.commits++
+	referenced := make(map[string]bool)
+	for _, pattern := range c.patterns {
+		for _, key := range pattern.FindAllString(message, -1) {
+			referenced[key] = true
+		}
+	}
+	if len(referenced) == 0 {
+		c.ticketless++
+	}
+	for key := range referenced {
+		c.counts[key]++
+	}
+}
+
+// finish returns the stats of the observed messages, the tickets with the
+// most commits first.
+func (c *ticketCounter) finish() *TicketStats {
+	tickets := &TicketStats{Commits: c.commits, Ticketless: c.ticketless, Tickets: make([]TicketCount, 0, len(c.counts))}
+	for key, commits := range c.counts {
+		tickets.Tickets = append(tickets.Tickets, TicketCount{Key: key, Commits: commits})
+	}
+	sort.Slice(tickets.Tickets, func(i, j int) bool {
+		if tickets.Tickets[i].Commits != tickets.Tickets[j].Commits {
+			return tickets.Tickets[i].Commits > tickets.Tickets[j].Commits
+		}
+		return tickets.Tickets[i].Key < tickets.Tickets[j].Key
+	})
+
+	return tickets
+}
+
+// TicketlessShare returns the percentage of commits referencing no ticket.
+func (t *TicketStats) TicketlessShare() float64 {
+	return percent(t.Ticketless, t.Commits)
+}
+
+// sentence describes the referenced tickets, e.g. "31 distinct, PAY-12 the
+// most with 4 commits".
+func (t *TicketStats) sentence() string {
+	if len(t.Tickets) == 0 {
+		return "none referenced"
+	}
+
+	return fmt.Sprintf("%d distinct, %s the most with %s", len(t.Tickets), t.Tickets[0].Key, commitCount(t.Tickets[0].Commits))
+}
+
+// ticketlessSentence describes the commits referencing no ticket, e.g. "34%
+// of your commits were ticket-less rebels".
+func (t *TicketStats) ticketlessSentence() string {
+	return fmt.Sprintf("%.0f%% of your commits were ticket-less rebels", roundHalfUp(t.TicketlessShare(), 0))
+}
+
+// shownTickets returns the tickets the report lists.
+func shownTickets(summary *Summary, opts RenderOptions) []TicketCount {
+	top := opts.Limit(SectionTickets)
+	if summary.Tickets == nil || top <= 0 {
+		return nil
+	}
+
+	tickets := summary.Tickets.Tickets
+	if len(tickets) > top {
+		tickets = tickets[:top]
+	}
+
+	return tickets
+}
+
+// ticketURL returns the link to the ticket on the Jira at base, empty
+// without a base.
+func ticketURL(base string, key string) string {
+	if base == "" {
+		return ""
+	}
+
+	return strings.TrimSuffix(base, "/") + "/browse/" + key
+}
@@ -0,0 +1,40 @@
+package wrapped
+
+import (
+	"regexp"
+	"testing"
+)
+
+// TestTicketsStatMatchesCountTickets checks the tickets plugin and
+// --ticket-pattern count the same commits and tickets.
+func TestTicketsStatMatchesCountTickets(t *testing.T) {
+	summary := listedSummary([]string{
+		"PAY-12: fix the rounding",
+		"PAY-12 PAY-12 and PAY-7",
+		"Refactor the ledger",
+		"Mention XPAY-3 and PAY-",
+		"Follow up on PAY-7",
+	}, 0)
+	if err := summary.CountTickets([]*regexp.Regexp{regexp.MustCompile(`\bPAY-\d+\b`)}); err != nil {
+		t.Fatal(err)
+	}
+	plugin, err := NewBuiltinStat("tickets=PAY-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := summary.RunPlugins([]StatPlugin{plugin}); err != nil {
+		t.Fatal(err)
+	}
+
+	tickets := summary.Tickets
+	if tickets.Commits != 5 || tickets.Ticketless != 2 || len(tickets.Tickets) != 2 {
+		t.Fatalf("tickets = %+v, want 2 tickets in 3 of 5 commits", tickets)
+	}
+	if want := (TicketCount{Key: "PAY-12", Commits: 2}); tickets.Tickets[0] != want {
+		t.Errorf("top ticket = %+v, want %+v", tickets.Tickets[0], want)
+	}
+	want := StatLine{Label: "🎫 Commits mentioning PAY-", Value: "3 (60.0%), 2 distinct tickets"}
+	if len(summary.StatLines) != 1 || summary.StatLines[0] != want {
+		t.Errorf("stat lines = %+v, want %+v", summary.StatLines, want)
+	}
+}
@@ -0,0 +1,236 @@
+package wrapped
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ReviewedBy is the trailer naming who reviewed a commit, its values rank the
+// reviewers.
+const ReviewedBy = "Reviewed-by"
+
+// TrailerStats are the counts of the trailers asked for, like Reviewed-by and
+// Signed-off-by, found in the commit messages or their notes.
+type TrailerStats struct {
+	// Trailers are the counted trailers, in the order they were asked for.
+	Trailers []TrailerCount
+	// Commits is the number of commits the trailers were looked for in,
+	// merges included.
+	Commits int
+	// Reviewers are the values of the Reviewed-by trailers, most commits
+	// first. They're only counted when Reviewed-by is.
+	Reviewers []TrailerValue
+}
+
+// TrailerCount is the number of commits carrying a trailer.
+type TrailerCount struct {
+	Key     string
+	Commits int
+}
+
+// TrailerValue is the number of commits carrying a trailer with the value.
+type TrailerValue struct {
+	Value   string
+	Commits int
+}
+
+// trailer is a single Key: value line of a trailer block.
+type trailer struct {
+	key   string
+	value string
+}
+
+// trailerLine matches a trailer, its key made of letters, digits and dashes.
+var trailerLine = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*)[ \t]*:[ \t]*(.*)$`)
+
+// parseTrailers returns the trailers of the last paragraph of the text. Like
+// git interpret-trailers, but without its leniency for other lines, the
+// paragraph is only a trailer block when every line is a trailer or
+// continues the one above it with leading whitespace.
+func parseTrailers(text string) []trailer {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n")), "\n")
+	start := len(lines)
+	for start > 0 && strings.TrimSpace(lines[start-1]) != "" {
+		start--
+	}
+
+	trailers := make([]trailer, 0)
+	for _, line := range lines[start:] {
+		if line[0] == ' ' || line[0] == '\t' {
+			if len(trailers) == 0 {
+				return nil
+			}
+			trailers[len(trailers)-1].value += " " + strings.TrimSpace(line)
+			continue
+		}
+		match := trailerLine.FindStringSubmatch(line)
+		if match == nil {
+			return nil
+		}
+		trailers = append(trailers, trailer{key: match[1], value: strings.TrimSpace(match[2])})
+	}
+
+	return trailers
+}
+
+// messageTrailers returns the trailers of a commit message, whose subject is
+// never a trailer block even when it looks like one.
+func messageTrailers(message string) []trailer {
+	_, body, found := strings.Cut(strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n")), "\n\n")
+	if !found {
+		return nil
+	}
+
+	return parseTrailers(body)
+}
+
+// NotesRef returns the full name of the notes ref, accepting the short
+// names git notes --ref does, e.g. review for refs/notes/review.
+func NotesRef(ref string) string {
+	if strings.HasPrefix(ref, "refs/") {
+		return ref
+	}
+	if strings.HasPrefix(ref, "notes/") {
+		return "refs/" + ref
+	}
+
+	return "refs/notes/" + ref
+}
+
+// ReadNotes returns the notes under the ref of every repository, keyed by
+// the hash of the commit they annotate. Repositories without the ref or that
+// can't be opened are skipped.
+func ReadNotes(ctx context.Context, paths []string, ref string) (map[string]string, error) {
+	notes := make(map[string]string)
+	for _, path := range paths {
+		_, repo, err := openRepo(path)
+		if err != nil {
+			continue
+		}
+		reference, err := repo.Reference(plumbing.ReferenceName(NotesRef(ref)), true)
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve the notes ref %s of %s. [err=%s]", ref, path, err.Error())
+		}
+		commit, err := repo.CommitObject(reference.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the notes ref %s of %s. [err=%s]", ref, path, err.Error())
+		}
+		tree, err := commit.Tree()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the notes ref %s of %s. [err=%s]", ref, path, err.Error())
+		}
+
+		err = tree.Files().ForEach(func(file *object.File) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			// Notes are fanned out into directories as they grow, e.g. ab/cdef...
+			hash := strings.ReplaceAll(file.Name, "/", "")
+			if !plumbing.IsHash(hash) {
+				return nil
+			}
+			contents, err := file.Contents()
+			if err != nil {
+				return err
+			}
+			notes[hash] = contents
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the notes ref %s of %s. [err=%s]", ref, path, err.Error())
+		}
+	}
+
+	return notes, nil
+}
+
+// CountTrailers counts the listed commits carrying each of the trailers,
+// looking at their messages and their notes, keyed by commit hash. Keys are
+// matched ignoring case and a commit carrying a trailer more than once counts
+// once. The commits must have been collected with Options.ListCommits.
+func (s *Summary) CountTrailers(keys []string, notes map[string]string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if !s.has(fieldCommitList) {
+		return errors.New("trailers need the commits collected with Options.ListCommits")
+	}
+
+	stats := &TrailerStats{Commits: len(s.Commits), Trailers: make([]TrailerCount, 0, len(keys))}
+	counted := make(map[string]int, len(keys))
+	for _, key := range keys {
+		if _, ok := counted[strings.ToLower(key)]; ok {
+			continue
+		}
+		counted[strings.ToLower(key)] = len(stats.Trailers)
+		stats.Trailers = append(stats.Trailers, TrailerCount{Key: key})
+	}
+	_, countReviewers := counted[strings.ToLower(ReviewedBy)]
+
+	reviewers := make(map[string]int)
+	for _, commit := range s.Commits {
+		trailers := messageTrailers(commit.Message)
+		if note, ok := notes[commit.Hash]; ok {
+			trailers = append(trailers, parseTrailers(note)...)
+		}
+
+		carried := make(map[int]bool)
+		reviewed := make(map[string]bool)
+		for _, trailer := range trailers {
+			key := strings.ToLower(trailer.key)
+			if i, ok := counted[key]; ok {
+				carried[i] = true
+			}
+			if countReviewers && key == strings.ToLower(ReviewedBy) && trailer.value != "" {
+				reviewed[trailer.value] = true
+			}
+		}
+		for i := range carried {
+			stats.Trailers[i].Commits++
+		}
+		for reviewer := range reviewed {
+			reviewers[reviewer]++
+		}
+	}
+
+	if countReviewers {
+		stats.Reviewers = make([]TrailerValue, 0, len(reviewers))
+		for reviewer, commits := range reviewers {
+			stats.Reviewers = append(stats.Reviewers, TrailerValue{Value: reviewer, Commits: commits})
+		}
+		sort.Slice(stats.Reviewers, func(i, j int) bool {
+			if stats.Reviewers[i].Commits != stats.Reviewers[j].Commits {
+				return stats.Reviewers[i].Commits > stats.Reviewers[j].Commits
+			}
+			return stats.Reviewers[i].Value < stats.Reviewers[j].Value
+		})
+	}
+	s.Trailers = stats
+
+	return nil
+}
+
+// sentence describes how many commits carried the trailer, e.g. "12 of 40
+// commits (30.0%), merges included".
+func (t TrailerCount) sentence(commits int) string {
+	return fmt.Sprintf("%d of %s (%.1f%%), merges included", t.Commits, commitCount(commits), roundHalfUp(percent(t.Commits, commits), 1))
+}
+
+// topReviewerSentence describes who reviewed the most commits, e.g. "Bob
+// <bob@example.com> on 8 commits", empty when nobody did.
+func (t *TrailerStats) topReviewerSentence() string {
+	if len(t.Reviewers) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%s on %s", t.Reviewers[0].Value, commitCount(t.Reviewers[0].Commits))
+}
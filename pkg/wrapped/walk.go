@@ -0,0 +1,231 @@
+package wrapped
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"strings"
+	"time"
+)
+
+// walkSlack is how far past the start of the window the walk keeps descending.
+// Committer dates are mostly monotonic along history, but rebases and skewed
+// clocks produce parents that are a little newer than their children.
+const walkSlack = 24 * time.Hour
+
+// commitQueue is a max-heap of commits ordered by committer time, so the walk
+// visits history newest first regardless of how many refs it started from.
+type commitQueue []*object.Commit
+
+func (q commitQueue) Len() int { return len(q) }
+func (q commitQueue) Less(i, j int) bool {
+	return q[i].Committer.When.After(q[j].Committer.When)
+}
+func (q commitQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *commitQueue) Push(x interface{}) { *q = append(*q, x.(*object.Commit)) }
+func (q *commitQueue) Pop() interface{} {
+	old := *q
+	commit := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return commit
+}
+
+// walkCommits calls fn for every commit reachable from the repository refs,
+// newest first, and stops descending into history once commits are older than
+// since (minus walkSlack). This keeps a single year on a long-lived repository
+// from reading every commit object ever written. The parents a shallow clone
+// didn't fetch are left out. A commit that can't be read, a corrupt or missing
+// object, is passed to corrupt and left out with the history only it reaches,
+// the walk ending with corrupt's error when it returns one. The walk ends
+// early with the context's error once it's cancelled.
+func walkCommits(ctx context.Context, repo *git.Repository, since time.Time, logger Logger, corrupt func(CommitError) error, fn func(*object.Commit) error) error {
+	tips, err := refTips(repo, logger)
+	if err != nil {
+		return err
+	}
+
+	return walkFrom(ctx, repo, tips, nil, since, logger, corrupt, fn)
+}
+
+// walkFrom is walkCommits from the tips rather than the refs, leaving out the
+// hidden commits and the history only they reach.
+func walkFrom(ctx context.Context, repo *git.Repository, tips []*object.Commit, hidden map[plumbing.Hash]bool, since time.Time, logger Logger, corrupt func(CommitError) error, fn func(*object.Commit) error) error {
+	shallows, err := shallowCommits(repo)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[plumbing.Hash]bool)
+	queue := &commitQueue{}
+	for _, tip := range tips {
+		if seen[tip.Hash] || hidden[tip.Hash] {
+			continue
+		}
+		seen[tip.Hash] = true
+		heap.Push(queue, tip)
+	}
+
+	horizon := since.Add(-walkSlack)
+	for queue.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		commit := heap.Pop(queue).(*object.Commit)
+		if err := fn(commit); err != nil {
+			return err
+		}
+
+		if commit.Committer.When.Before(horizon) || shallows[commit.Hash] {
+			continue
+		}
+
+		for _, parentHash := range commit.ParentHashes {
+			if seen[parentHash] || hidden[parentHash] {
+				continue
+			}
+			seen[parentHash] = true
+
+			parent, err := repo.CommitObject(parentHash)
+			if err != nil {
+				logger.Logf(LevelVerbose, "Unable to read commit %s, a parent of %s: %s", parentHash, commit.Hash, err.Error())
+				if err := corrupt(CommitError{Hash: parentHash.String(), Err: err}); err != nil {
+					return err
+				}
+				continue
+			}
+			heap.Push(queue, parent)
+		}
+	}
+
+	return nil
+}
+
+// skipCorrupt leaves the commits that can't be read out of a walk, for the
+// walks following the analysis which already reported them.
+func skipCorrupt(CommitError) error {
+	return nil
+}
+
+// scanCommitObjects calls fn for every commit object in the repository
+// storage, including commits no ref can reach anymore like rebased away or
+// amended predecessors.
+func scanCommitObjects(ctx context.Context, repo *git.Repository, logger Logger, fn func(*object.Commit) error) error {
+	logger.Logf(LevelDebug, "Scanning every commit object in the repository")
+	commits, err := repo.CommitObjects()
+	if err != nil {
+		return err
+	}
+	defer commits.Close()
+
+	return commits.ForEach(func(commit *object.Commit) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fn(commit)
+	})
+}
+
+// refTips resolves every branch, remote branch, tag and HEAD to the commit it
+// points at. Notes refs are skipped since their history isn't code.
+func refTips(repo *git.Repository, logger Logger) ([]*object.Commit, error) {
+	named, err := namedRefTips(repo, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	tips := make([]*object.Commit, 0, len(named))
+	for _, tip := range named {
+		tips = append(tips, tip.commit)
+	}
+
+	return tips, nil
+}
+
+// namedTip is the commit a ref points at.
+type namedTip struct {
+	name   string
+	commit *object.Commit
+}
+
+// namedRefTips is refTips keeping the name of every ref.
+func namedRefTips(repo *git.Repository, logger Logger) ([]namedTip, error) {
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+	defer refs.Close()
+
+	tips := make([]namedTip, 0)
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference || strings.HasPrefix(ref.Name().String(), "refs/notes/") {
+			logger.Logf(LevelDebug, "Skipping ref %s", ref.Name())
+			return nil
+		}
+
+		commit, err := peelToCommit(repo, ref.Hash())
+		if err != nil {
+			return err
+		}
+		if commit != nil {
+			logger.Logf(LevelDebug, "Walking from ref %s at %s", ref.Name(), commit.Hash)
+			tips = append(tips, namedTip{name: ref.Name().String(), commit: commit})
+		}
+
+		return nil
+	})
+
+	return tips, err
+}
+
+// peelToCommit follows annotated tags down to the commit they point at. A nil
+// commit is returned for refs pointing at trees or blobs.
+func peelToCommit(repo *git.Repository, hash plumbing.Hash) (*object.Commit, error) {
+	for {
+		obj, err := repo.Object(plumbing.AnyObject, hash)
+		if err != nil {
+			if errors.Is(err, plumbing.ErrObjectNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		switch o := obj.(type) {
+		case *object.Commit:
+			return o, nil
+		case *object.Tag:
+			hash = o.Target
+		default:
+			return nil, nil
+		}
+	}
+}
+
+// windowEnd returns the commit the branch was at when the window ended, the
+// newest on its first parent chain committed before the end, see
+// defaultBranch. nil is returned when the branch has no history before then,
+// or a shallow clone didn't fetch it.
+func windowEnd(repo *git.Repository, branch string, window AnalysisWindow) (*object.Commit, error) {
+	_, commit, err := defaultBranch(repo, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	for !commit.Committer.When.Before(window.End) {
+		if commit.NumParents() == 0 {
+			return nil, nil
+		}
+		commit, err = firstParent(commit)
+		if errors.Is(err, errShallowCommit) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return commit, nil
+}
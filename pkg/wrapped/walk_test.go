@@ -0,0 +1,129 @@
+package wrapped
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// benchmarkHistory is ten years of a commit a day, the last of them 2023.
+func benchmarkHistory(b *testing.B) *fixture {
+	repo := newFixture(b)
+	repo.history("dev@example.com", time.Date(2014, time.January, 1, 12, 0, 0, 0, time.UTC), 24*time.Hour, 3652)
+
+	return repo
+}
+
+// BenchmarkWalkCommits walks the history of 2023 from the refs, stopping at
+// the start of the year, next to scanning every commit object like the
+// analysis used to.
+func BenchmarkWalkCommits(b *testing.B) {
+	repo := benchmarkHistory(b)
+	since := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	b.Run("walk", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			err := walkCommits(context.Background(), repo.repo, since, nopLogger{}, skipCorrupt, func(*object.Commit) error {
+				return nil
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			err := scanCommitObjects(context.Background(), repo.repo, nopLogger{}, func(*object.Commit) error {
+				return nil
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestWalkCommitsStopsAtWindow(t *testing.T) {
+	repo := newFixture(t)
+	repo.history("dev@example.com", time.Date(2021, time.January, 1, 12, 0, 0, 0, time.UTC), 24*time.Hour, 3*365)
+	since := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	walked, inWindow := 0, 0
+	err := walkCommits(context.Background(), repo.repo, since, nopLogger{}, skipCorrupt, func(commit *object.Commit) error {
+		walked++
+		if !commit.Author.When.Before(since) {
+			inWindow++
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inWindow != 365 {
+		t.Errorf("walked %d commits of 2023, want 365", inWindow)
+	}
+	if limit := 365 + int(walkSlack/(24*time.Hour)) + 1; walked > limit {
+		t.Errorf("walked %d commits, want at most %d stopping at the window", walked, limit)
+	}
+}
+
+// liveHeap returns the bytes of the heap still reachable.
+func liveHeap() int64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	return int64(stats.HeapAlloc)
+}
+
+// BenchmarkWalkFrom reduces every commit of the history into a summary as
+// it's walked, next to collecting the commits first like the analysis used
+// to, which keeps every commit object alive until the end. live-B/op is the
+// heap still in use once the history was walked.
+func BenchmarkWalkFrom(b *testing.B) {
+	repo := benchmarkHistory(b)
+	window := AnalysisWindow{Start: time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), Location: time.UTC}
+
+	b.Run("stream", func(b *testing.B) {
+		b.ReportAllocs()
+		baseline, live := liveHeap(), int64(0)
+		for i := 0; i < b.N; i++ {
+			summary := NewSummary(true, window)
+			err := walkCommits(context.Background(), repo.repo, window.Start, nopLogger{}, skipCorrupt, func(commit *object.Commit) error {
+				summary.add(commitStats{commit: commit}, repo.dir)
+				return nil
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+			live += liveHeap() - baseline
+			runtime.KeepAlive(summary)
+		}
+		b.ReportMetric(float64(live)/float64(b.N), "live-B/op")
+	})
+	b.Run("collect", func(b *testing.B) {
+		b.ReportAllocs()
+		baseline, live := liveHeap(), int64(0)
+		for i := 0; i < b.N; i++ {
+			summary := NewSummary(true, window)
+			commits := make([]*object.Commit, 0)
+			err := walkCommits(context.Background(), repo.repo, window.Start, nopLogger{}, skipCorrupt, func(commit *object.Commit) error {
+				commits = append(commits, commit)
+				return nil
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+			live += liveHeap() - baseline
+			for _, commit := range commits {
+				summary.add(commitStats{commit: commit}, repo.dir)
+			}
+		}
+		b.ReportMetric(float64(live)/float64(b.N), "live-B/op")
+	})
+}
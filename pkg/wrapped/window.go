@@ -0,0 +1,111 @@
+package wrapped
+
+import (
+	"fmt"
+	"time"
+)
+
+// AnalysisWindow is the half-open interval [Start, End) of author times that
+// is analyzed. Commit times are normalized into Location before they're
+// bucketed into days, so Start and End are always midnight in Location.
+type AnalysisWindow struct {
+	Start    time.Time
+	End      time.Time
+	Location *time.Location
+}
+
+// NewYearWindow returns the window from the start of the year to the start of
+// the next one.
+func NewYearWindow(year int, location *time.Location) AnalysisWindow {
+	return AnalysisWindow{
+		Start:    time.Date(year, 1, 1, 0, 0, 0, 0, location),
+		End:      time.Date(year+1, 1, 1, 0, 0, 0, 0, location),
+		Location: location,
+	}
+}
+
+// The periods a window can span, see NewPeriodWindow.
+const (
+	PeriodYear  = "year"
+	PeriodMonth = "month"
+	PeriodWeek  = "week"
+)
+
+// Periods returns the periods of NewPeriodWindow.
+func Periods() []string {
+	return []string{PeriodYear, PeriodMonth, PeriodWeek}
+}
+
+// NewPeriodWindow returns the window of the year, the month or the week
+// containing the day in the location. Weeks start on Monday, like the rows
+// of the heatmap.
+func NewPeriodWindow(period string, day time.Time, location *time.Location) (AnalysisWindow, error) {
+	day = day.In(location)
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, location)
+	window := AnalysisWindow{Location: location}
+	switch period {
+	case PeriodYear:
+		return NewYearWindow(day.Year(), location), nil
+	case PeriodMonth:
+		window.Start = start.AddDate(0, 0, 1-day.Day())
+		window.End = window.Start.AddDate(0, 1, 0)
+	case PeriodWeek:
+		window.Start = start.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		window.End = window.Start.AddDate(0, 0, 7)
+	default:
+		return AnalysisWindow{}, fmt.Errorf("unknown period %q", period)
+	}
+
+	return window, nil
+}
+
+// LastCompleteWindow returns the window of the most recent period ended by
+// now, e.g. last week for a week.
+func LastCompleteWindow(period string, now time.Time, location *time.Location) (AnalysisWindow, error) {
+	current, err := NewPeriodWindow(period, now, location)
+	if err != nil {
+		return AnalysisWindow{}, err
+	}
+
+	return current.Previous(period), nil
+}
+
+// Previous returns the window of the period right before this one, e.g. the
+// week before for a week.
+func (w AnalysisWindow) Previous(period string) AnalysisWindow {
+	// The window is one of the period's, so the day before it is too.
+	previous, _ := NewPeriodWindow(period, w.Start.AddDate(0, 0, -1), w.Location)
+	return previous
+}
+
+// contains reports whether t falls inside the window.
+func (w AnalysisWindow) contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// LastDay returns the last day inside the window.
+func (w AnalysisWindow) LastDay() time.Time {
+	return w.End.AddDate(0, 0, -1)
+}
+
+// days returns the number of calendar days in the window. It's counted on the
+// dates rather than the duration so days lengthened or shortened by a DST
+// change still count as one.
+func (w AnalysisWindow) days() int {
+	days := 0
+	for day := w.Start; day.Before(w.End); day = day.AddDate(0, 0, 1) {
+		days++
+	}
+
+	return days
+}
+
+// dayKey returns the date t falls on in the window's time zone, which is
+// what commits are bucketed into days by.
+func (w AnalysisWindow) dayKey(t time.Time) string {
+	return t.In(w.Location).Format(time.DateOnly)
+}
+
+func (w AnalysisWindow) String() string {
+	return fmt.Sprintf("%s to %s (%s)", w.Start.Format(time.DateOnly), w.LastDay().Format(time.DateOnly), w.Location)
+}
@@ -0,0 +1,56 @@
+package wrapped
+
+import (
+	"testing"
+	"time"
+)
+
+// yearBoundaries are the commit times at the edges of the 2023 window.
+var yearBoundaries = []struct {
+	when time.Time
+	in   bool
+}{
+	{when: time.Date(2022, time.December, 31, 23, 59, 59, 0, time.UTC), in: false},
+	{when: time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC), in: true},
+	{when: time.Date(2023, time.December, 31, 23, 59, 59, 0, time.UTC), in: true},
+	{when: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), in: false},
+}
+
+func TestYearWindowContains(t *testing.T) {
+	window := NewYearWindow(2023, time.UTC)
+	for _, tt := range yearBoundaries {
+		if got := window.contains(tt.when); got != tt.in {
+			t.Errorf("contains(%s) = %t, want %t", tt.when.Format(time.RFC3339), got, tt.in)
+		}
+	}
+}
+
+func TestAnalyzeYearBoundaries(t *testing.T) {
+	repo := newFixture(t)
+	for _, tt := range yearBoundaries {
+		repo.commit("dev@example.com", tt.when, map[string]string{"file.txt": tt.when.Format(time.RFC3339) + "\n"})
+	}
+
+	summary, err := repo.analyze(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.TotalCommits != 2 {
+		t.Fatalf("got %d commits, want the 2 inside the window", summary.TotalCommits)
+	}
+	for _, tt := range yearBoundaries {
+		want := 0
+		if tt.in {
+			want = 1
+		}
+		if got := summary.CommitsOn(tt.when); got != want {
+			t.Errorf("CommitsOn(%s) = %d, want %d", tt.when.Format(time.DateOnly), got, want)
+		}
+	}
+	if got := summary.FirstOfYear.Author.When; !got.Equal(yearBoundaries[1].when) {
+		t.Errorf("got the first commit at %s, want %s", got, yearBoundaries[1].when)
+	}
+	if got := summary.LastOfYear.Author.When; !got.Equal(yearBoundaries[2].when) {
+		t.Errorf("got the last commit at %s, want %s", got, yearBoundaries[2].when)
+	}
+}
@@ -0,0 +1,263 @@
+package wrapped
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// lateNightHour is the hour from which commits count as late at night.
+const lateNightHour = 22
+
+// WorkHours are the hours and weekdays counted as work time.
+type WorkHours struct {
+	// Start and End are the minutes of the day work starts and ends at, End
+	// excluded.
+	Start int
+	End   int
+	// Days are the working weekdays, indexed by time.Weekday.
+	Days [7]bool
+}
+
+// DefaultWorkHours are Monday to Friday from 09:00 to 18:00.
+var DefaultWorkHours = WorkHours{
+	Start: 9 * 60,
+	End:   18 * 60,
+	Days:  [7]bool{time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true},
+}
+
+// weekdayNames are the names weekdays are parsed from, indexed by
+// time.Weekday.
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseHours parses the hours of the day work spans, e.g. 09:00-18:00.
+func (w *WorkHours) ParseHours(hours string) error {
+	startText, endText, found := strings.Cut(hours, "-")
+	if !found {
+		return fmt.Errorf("expected a range like 09:00-18:00, got %q", hours)
+	}
+	start, err := parseClock(startText)
+	if err != nil {
+		return err
+	}
+	end, err := parseClock(endText)
+	if err != nil {
+		return err
+	}
+	if end <= start {
+		return fmt.Errorf("the work hours %q end before they start", hours)
+	}
+	w.Start, w.End = start, end
+
+	return nil
+}
+
+// parseClock returns the minutes of the day of a time like 09:30.
+func parseClock(clock string) (int, error) {
+	parsed, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		if strings.TrimSpace(clock) == "24:00" {
+			return 24 * 60, nil
+		}
+		return 0, fmt.Errorf("expected a time like 09:00, got %q", clock)
+	}
+
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+// ParseDays parses the working weekdays, a comma separated list of days or
+// ranges of them, e.g. mon-fri or mon,wed,fri.
+func (w *WorkHours) ParseDays(days string) error {
+	parsed := [7]bool{}
+	for _, part := range strings.Split(days, ",") {
+		from, to, isRange := strings.Cut(strings.ToLower(strings.TrimSpace(part)), "-")
+		first, err := parseWeekday(from)
+		if err != nil {
+			return err
+		}
+		last := first
+		if isRange {
+			last, err = parseWeekday(to)
+			if err != nil {
+				return err
+			}
+		}
+		// Ranges wrap around the week, e.g. sat-sun.
+		for day := first; ; day = (day + 1) % 7 {
+			parsed[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+	w.Days = parsed
+
+	return nil
+}
+
+// parseWeekday returns the weekday of a name like mon or Monday.
+func parseWeekday(name string) (time.Weekday, error) {
+	name = strings.TrimSpace(name)
+	for day, weekday := range weekdayNames {
+		if len(name) >= 3 && strings.HasPrefix(strings.ToLower(time.Weekday(day).String()), name) {
+			return time.Weekday(day), nil
+		}
+		if name == weekday {
+			return time.Weekday(day), nil
+		}
+	}
+
+	return 0, fmt.Errorf("expected a weekday like mon, got %q", name)
+}
+
+// contains reports whether the time is work time.
+func (w WorkHours) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	return w.Days[t.Weekday()] && minute >= w.Start && minute < w.End
+}
+
+// String describes the work hours, e.g. Mon–Fri 09:00–18:00.
+func (w WorkHours) String() string {
+	return fmt.Sprintf("%s %s–%s", strings.Join(w.dayRanges(), ","), formatClock(w.Start), formatClock(w.End))
+}
+
+// DayNames returns the names of the working weekdays, Monday first.
+func (w WorkHours) DayNames() []string {
+	names := make([]string, 0, 7)
+	for i := 1; i <= 7; i++ {
+		if w.Days[i%7] {
+			names = append(names, weekdayNames[i%7])
+		}
+	}
+
+	return names
+}
+
+// dayRanges returns the working weekdays as ranges, Monday first, e.g.
+// Mon–Fri.
+func (w WorkHours) dayRanges() []string {
+	ranges := make([]string, 0)
+	for i := 1; i <= 7; {
+		if !w.Days[i%7] {
+			i++
+			continue
+		}
+		j := i
+		for j+1 <= 7 && w.Days[(j+1)%7] {
+			j++
+		}
+		name := time.Weekday(i % 7).String()[:3]
+		if j > i {
+			name += "–" + time.Weekday(j % 7).String()[:3]
+		}
+		ranges = append(ranges, name)
+		i = j + 1
+	}
+
+	return ranges
+}
+
+// formatClock formats minutes of the day like 09:00.
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// WorkPattern is when the author committed relative to their work hours.
+type WorkPattern struct {
+	Hours WorkHours
+	// Location is the time zone the commits were placed in, nil for the
+	// author's own time zone of every commit.
+	Location *time.Location
+	Commits  int
+	// Inside is the number of commits made in work hours, the others are
+	// after hours.
+	Inside int
+	// LateNight is the number of commits made from 22:00 to midnight.
+	LateNight int
+	// LongestRun is the most consecutive days with after-hours commits,
+	// starting on RunStart.
+	LongestRun int
+	RunStart   time.Time
+}
+
+// CountWorkPattern places every listed commit in or out of the work hours,
+// in the location or, when it's nil, in the time zone the commit was made
+// in. After-hours days are calendar days in the same time zone, so a commit
+// at 23:00 and one at 01:00 the next night make a run of two. The commits
+// must have been collected with Options.ListCommits.
+func (s *Summary) CountWorkPattern(hours WorkHours, location *time.Location) error {
+	if !s.has(fieldCommitList) {
+		return errors.New("the work pattern needs the commits collected with Options.ListCommits")
+	}
+
+	pattern := &WorkPattern{Hours: hours, Location: location}
+	afterHours := make(map[time.Time]bool)
+	for _, commit := range s.Commits {
+		when := commit.AuthorWhen
+		if location != nil {
+			when = when.In(location)
+		}
+		pattern.Commits++
+		if when.Hour() >= lateNightHour {
+			pattern.LateNight++
+		}
+		if hours.contains(when) {
+			pattern.Inside++
+			continue
+		}
+		afterHours[time.Date(when.Year(), when.Month(), when.Day(), 0, 0, 0, 0, time.UTC)] = true
+	}
+
+	for day := range afterHours {
+		// Only count runs from their first day.
+		if afterHours[day.AddDate(0, 0, -1)] {
+			continue
+		}
+		run := 1
+		for afterHours[day.AddDate(0, 0, run)] {
+			run++
+		}
+		if run > pattern.LongestRun || (run == pattern.LongestRun && day.Before(pattern.RunStart)) {
+			pattern.LongestRun, pattern.RunStart = run, day
+		}
+	}
+	s.WorkPattern = pattern
+
+	return nil
+}
+
+// InsideShare returns the percentage of the commits made in work hours.
+func (p *WorkPattern) InsideShare() float64 {
+	return percent(p.Inside, p.Commits)
+}
+
+// zone names the time zone of the pattern.
+func (p *WorkPattern) zone() string {
+	if p.Location == nil {
+		return "your own time zone"
+	}
+
+	return p.Location.String()
+}
+
+// insideSentence describes the commits in work hours, e.g. "72% of commits
+// inside Mon–Fri 09:00–18:00 (your own time zone)".
+func (p *WorkPattern) insideSentence() string {
+	return fmt.Sprintf("%.0f%% of commits inside %s (%s)", roundHalfUp(p.InsideShare(), 0), p.Hours, p.zone())
+}
+
+// afterHoursSentence counts the commits after hours, e.g. "12 commits, 3
+// after 22:00".
+func (p *WorkPattern) afterHoursSentence() string {
+	return fmt.Sprintf("%s, %d after %02d:00", commitCount(p.Commits-p.Inside), p.LateNight, lateNightHour)
+}
+
+// runSentence describes the longest after-hours run, e.g. "5 days from Mar 3".
+func (p *WorkPattern) runSentence() string {
+	if p.LongestRun == 1 {
+		return "1 day, on " + p.RunStart.Format(yearDayLayout)
+	}
+
+	return fmt.Sprintf("%d days from %s", p.LongestRun, p.RunStart.Format(yearDayLayout))
+}